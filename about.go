@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Версия и коммит задаются при сборке:
+// go build -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
+// Время запуска бота для подсчета аптайма
+var startTime = time.Now()
+
+// Аптайм в виде "3 д 4 ч 15 мин"
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%d д", days))
+	}
+	if days > 0 || hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d ч", hours))
+	}
+	parts = append(parts, fmt.Sprintf("%d мин", minutes))
+	return strings.Join(parts, " ")
+}
+
+// Информация о сборке и источниках данных
+func getAboutInfo() string {
+	var providers []string
+	for _, provider := range providerOrder {
+		providers = append(providers, providerTitles[provider])
+	}
+	if mockWeatherMode() {
+		providers = []string{providerTitles[providerMock]}
+	}
+
+	return fmt.Sprintf(
+		"🤖 Бот погоды\n\n"+
+			"Версия: %s (коммит %s)\n"+
+			"Работает: %s\n"+
+			"Источник погоды: %s\n\n"+
+			"Данные:\n"+
+			"• Погода и прогнозы — OpenWeatherMap (openweathermap.org)\n"+
+			"• Климат, горы, высоты, море, осадки по минутам — Open-Meteo (open-meteo.com), CC BY 4.0\n"+
+			"• Геомагнитная активность — NOAA Space Weather Prediction Center\n"+
+			"• METAR и TAF аэропортов — NOAA Aviation Weather Center\n"+
+			"• Землетрясения — Геологическая служба США (USGS)\n"+
+			"• Спутниковые снимки пожаров — NASA GIBS\n"+
+			"• Расход воды в реках — GloFAS (Copernicus) через Open-Meteo\n"+
+			"• Карта в панели — © участники OpenStreetMap",
+		version,
+		commit,
+		formatUptime(time.Since(startTime)),
+		strings.Join(providers, ", "),
+	)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Чаты администраторов из ADMIN_CHAT_IDS
+func adminChatIDs() []int64 {
+	return config().AdminChatIDs
+}
+
+func isAdmin(chatID int64) bool {
+	for _, id := range adminChatIDs() {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// Сообщение всем администраторам
+func notifyAdmins(bot *Bot, text string) {
+	for _, id := range adminChatIDs() {
+		if _, err := bot.Send(tgbotapi.NewMessage(id, text)); err != nil {
+			log.Printf("Ошибка отправки сообщения администратору %d: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import "strings"
+
+// Справочник аэропортов для /flight: коды IATA и ICAO, название и
+// координаты. Только крупные аэропорты, куда чаще всего летают из России;
+// остальные точки ищем геокодированием по названию города
+
+// Аэропорт из справочника
+type Airport struct {
+	IATA, ICAO string
+	Name       string // название аэропорта, если оно отличается от города
+	City       string
+	Lat, Lon   float64
+}
+
+// Название для ответа: "Шереметьево (SVO), Москва" или "Сочи (AER)"
+func (a Airport) Title() string {
+	if a.Name == "" || a.Name == a.City {
+		return a.City + " (" + a.IATA + ")"
+	}
+	return a.Name + " (" + a.IATA + "), " + a.City
+}
+
+var airports = []Airport{
+	// Россия и соседние страны
+	{"SVO", "UUEE", "Шереметьево", "Москва", 55.9726, 37.4146},
+	{"DME", "UUDD", "Домодедово", "Москва", 55.4088, 37.9063},
+	{"VKO", "UUWW", "Внуково", "Москва", 55.5915, 37.2615},
+	{"ZIA", "UUBW", "Жуковский", "Москва", 55.5533, 38.1500},
+	{"LED", "ULLI", "Пулково", "Санкт-Петербург", 59.8003, 30.2625},
+	{"AER", "URSS", "", "Сочи", 43.4499, 39.9566},
+	{"KZN", "UWKD", "", "Казань", 55.6062, 49.2787},
+	{"SVX", "USSS", "Кольцово", "Екатеринбург", 56.7431, 60.8027},
+	{"OVB", "UNNT", "Толмачево", "Новосибирск", 55.0126, 82.6507},
+	{"KRR", "URKK", "Пашковский", "Краснодар", 45.0347, 39.1705},
+	{"ROV", "URRP", "Платов", "Ростов-на-Дону", 47.4939, 39.9247},
+	{"UFA", "UWUU", "", "Уфа", 54.5575, 55.8744},
+	{"KUF", "UWWW", "Курумоч", "Самара", 53.5049, 50.1643},
+	{"MRV", "URMM", "", "Минеральные Воды", 44.2251, 43.0819},
+	{"KGD", "UMKK", "Храброво", "Калининград", 54.8900, 20.5926},
+	{"GOJ", "UWGG", "Стригино", "Нижний Новгород", 56.2301, 43.7840},
+	{"KJA", "UNKL", "Емельяново", "Красноярск", 56.1729, 92.4933},
+	{"IKT", "UIII", "", "Иркутск", 52.2680, 104.3890},
+	{"VVO", "UHWW", "Кневичи", "Владивосток", 43.3990, 132.1480},
+	{"MMK", "ULMM", "", "Мурманск", 68.7817, 32.7508},
+	{"MSQ", "UMMS", "", "Минск", 53.8825, 28.0307},
+	{"EVN", "UDYZ", "Звартноц", "Ереван", 40.1473, 44.3959},
+	{"TBS", "UGTB", "", "Тбилиси", 41.6692, 44.9547},
+	{"GYD", "UBBB", "Гейдар Алиев", "Баку", 40.4675, 50.0467},
+	{"ALA", "UAAA", "", "Алматы", 43.3521, 77.0405},
+	{"NQZ", "UACC", "", "Астана", 51.0222, 71.4669},
+	{"TAS", "UTTT", "", "Ташкент", 41.2579, 69.2812},
+
+	// Курорты и пересадочные узлы
+	{"IST", "LTFM", "", "Стамбул", 41.2753, 28.7519},
+	{"SAW", "LTFJ", "Сабиха Гёкчен", "Стамбул", 40.8986, 29.3092},
+	{"AYT", "LTAI", "", "Анталья", 36.8987, 30.8005},
+	{"DXB", "OMDB", "", "Дубай", 25.2528, 55.3644},
+	{"AUH", "OMAA", "", "Абу-Даби", 24.4330, 54.6511},
+	{"DOH", "OTHH", "Хамад", "Доха", 25.2731, 51.6081},
+	{"TLV", "LLBG", "Бен-Гурион", "Тель-Авив", 32.0114, 34.8867},
+	{"CAI", "HECA", "", "Каир", 30.1219, 31.4056},
+	{"HRG", "HEGN", "", "Хургада", 27.1783, 33.7994},
+	{"SSH", "HESH", "", "Шарм-эш-Шейх", 27.9773, 34.3950},
+	{"BKK", "VTBS", "Суварнабхуми", "Бангкок", 13.6900, 100.7501},
+	{"HKT", "VTSP", "", "Пхукет", 8.1132, 98.3169},
+	{"DEL", "VIDP", "Индира Ганди", "Дели", 28.5562, 77.1000},
+	{"GOI", "VOGO", "Даболим", "Гоа", 15.3808, 73.8314},
+	{"MLE", "VRMM", "Велана", "Мале", 4.1918, 73.5290},
+	{"PEK", "ZBAA", "Шоуду", "Пекин", 40.0799, 116.6031},
+	{"PVG", "ZSPD", "Пудун", "Шанхай", 31.1443, 121.8083},
+	{"HKG", "VHHH", "", "Гонконг", 22.3080, 113.9185},
+	{"SIN", "WSSS", "Чанги", "Сингапур", 1.3644, 103.9915},
+	{"NRT", "RJAA", "Нарита", "Токио", 35.7720, 140.3929},
+	{"HND", "RJTT", "Ханеда", "Токио", 35.5494, 139.7798},
+	{"ICN", "RKSI", "Инчхон", "Сеул", 37.4602, 126.4407},
+
+	// Европа и Америка
+	{"LHR", "EGLL", "Хитроу", "Лондон", 51.4700, -0.4543},
+	{"LGW", "EGKK", "Гатвик", "Лондон", 51.1537, -0.1821},
+	{"CDG", "LFPG", "Шарль-де-Голль", "Париж", 49.0097, 2.5479},
+	{"FRA", "EDDF", "", "Франкфурт-на-Майне", 50.0379, 8.5622},
+	{"MUC", "EDDM", "", "Мюнхен", 48.3537, 11.7750},
+	{"BER", "EDDB", "Бранденбург", "Берлин", 52.3667, 13.5033},
+	{"AMS", "EHAM", "Схипхол", "Амстердам", 52.3105, 4.7683},
+	{"MAD", "LEMD", "Барахас", "Мадрид", 40.4983, -3.5676},
+	{"BCN", "LEBL", "Эль-Прат", "Барселона", 41.2974, 2.0833},
+	{"FCO", "LIRF", "Фьюмичино", "Рим", 41.8003, 12.2389},
+	{"MXP", "LIMC", "Мальпенса", "Милан", 45.6306, 8.7281},
+	{"VIE", "LOWW", "", "Вена", 48.1103, 16.5697},
+	{"ZRH", "LSZH", "", "Цюрих", 47.4582, 8.5555},
+	{"PRG", "LKPR", "", "Прага", 50.1008, 14.2600},
+	{"HEL", "EFHK", "Вантаа", "Хельсинки", 60.3172, 24.9633},
+	{"BEG", "LYBE", "", "Белград", 44.8184, 20.3091},
+	{"ATH", "LGAV", "", "Афины", 37.9364, 23.9445},
+	{"JFK", "KJFK", "Кеннеди", "Нью-Йорк", 40.6413, -73.7781},
+	{"LAX", "KLAX", "", "Лос-Анджелес", 33.9416, -118.4085},
+}
+
+// Аэропорт по коду IATA (SVO) или ICAO (UUEE)
+func findAirport(code string) (Airport, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, airport := range airports {
+		if airport.IATA == code || airport.ICAO == code {
+			return airport, true
+		}
+	}
+	return Airport{}, false
+}
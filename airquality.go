@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Структура для парсинга прогноза качества воздуха OWM
+type AirPollutionResponse struct {
+	List []airPollutionItem `json:"list"`
+}
+
+// Качество воздуха на один час
+type airPollutionItem struct {
+	Dt   int64 `json:"dt"`
+	Main struct {
+		AQI int `json:"aqi"` // 1 - хорошо ... 5 - очень плохо
+	} `json:"main"`
+	Components struct {
+		PM25 float64 `json:"pm2_5"`
+		PM10 float64 `json:"pm10"`
+		CO   float64 `json:"co"`
+	} `json:"components"`
+}
+
+// Запрос почасового прогноза качества воздуха по координатам
+func fetchAirPollutionForecast(lat, lon float64) (*AirPollutionResponse, error) {
+	if mockWeatherMode() {
+		return mockAirPollution(lat, lon), nil
+	}
+
+	params := url.Values{
+		"lat": {fmt.Sprintf("%.6f", lat)},
+		"lon": {fmt.Sprintf("%.6f", lon)},
+	}
+
+	var data AirPollutionResponse
+	if err := fetchOWM("/data/2.5/air_pollution/forecast", params, "ошибка получения данных о качестве воздуха", &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// Индекс качества воздуха на ближайший к dt час (0, если данных нет)
+func (a *AirPollutionResponse) AQIAt(dt int64) int {
+	aqi := 0
+	for _, item := range a.List {
+		if item.Dt > dt {
+			break
+		}
+		aqi = item.Main.AQI
+	}
+	return aqi
+}
+
+// Описание индекса качества воздуха
+func aqiDescription(aqi int) string {
+	switch aqi {
+	case 1:
+		return "хорошее"
+	case 2:
+		return "удовлетворительное"
+	case 3:
+		return "умеренное"
+	case 4:
+		return "плохое"
+	case 5:
+		return "очень плохое"
+	default:
+		return "нет данных"
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Как часто проверяем условия для подписок на оповещения
+const alertCheckInterval = 30 * time.Minute
+
+// Максимальная длина подписи к фото в Telegram
+const telegramCaptionLimit = 1024
+
+// Подписка чата на оповещения определенного типа
+type AlertSubscription struct {
+	ChatID    int64     `json:"chat_id"`
+	Kind      string    `json:"kind"`
+	City      string    `json:"city"`
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Hour      int       `json:"hour,omitempty"`
+	LeaveHome int       `json:"leave_home,omitempty"` // выход из дома для /commute, минуты от полуночи
+	LeaveWork int       `json:"leave_work,omitempty"` // выход с работы для /commute
+	Weekdays  int       `json:"weekdays,omitempty"`   // время утренней сводки в будни, минуты от полуночи
+	Weekends  int       `json:"weekends,omitempty"`   // и в выходные; 0 — по Hour
+	Blocks    []string  `json:"blocks,omitempty"`     // блоки утренней сводки, пустой — обычный набор
+	LastFired time.Time `json:"last_fired,omitempty"`
+}
+
+// Проверка условий подписки с настройками ее чата: возвращает текст
+// оповещения, если оно должно сработать
+type alertChecker func(sub *AlertSubscription, prefs UserPreferences) (string, bool, error)
+
+// Описание типа оповещений
+type alertKind struct {
+	title string
+	check alertChecker
+	// Минимальный интервал между повторными оповещениями
+	cooldown time.Duration
+	// Необязательный снимок к оповещению: пустой снимок или ошибка — отправляем текст
+	snapshot func(sub *AlertSubscription) ([]byte, error)
+}
+
+// Зарегистрированные типы оповещений
+var alertKinds = map[string]alertKind{}
+
+func subscriptionKey(chatID int64, kind string) string {
+	return fmt.Sprintf("%d:%s", chatID, kind)
+}
+
+// Добавление или обновление подписки. Без премиума число подписок ограничено
+func (s *Store) Subscribe(sub AlertSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := subscriptionKey(sub.ChatID, sub.Kind)
+	if _, exists := s.data.Subscriptions[key]; !exists && !time.Now().Before(s.data.Premium[sub.ChatID]) {
+		count := 0
+		for _, existing := range s.data.Subscriptions {
+			if existing.ChatID == sub.ChatID {
+				count++
+			}
+		}
+		if count >= freeSubscriptionLimit {
+			return fmt.Errorf("без премиума доступно не больше %d подписок, отключите лишние в /settings или оформите /premium", freeSubscriptionLimit)
+		}
+	}
+
+	s.data.Subscriptions[key] = &sub
+	return s.save()
+}
+
+// Удаление подписки. Возвращает false, если подписки не было
+func (s *Store) Unsubscribe(chatID int64, kind string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := subscriptionKey(chatID, kind)
+	if _, exists := s.data.Subscriptions[key]; !exists {
+		return false, nil
+	}
+	delete(s.data.Subscriptions, key)
+	return true, s.save()
+}
+
+// Копии всех подписок, упорядоченные по чату и типу
+func (s *Store) Subscriptions() []AlertSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]AlertSubscription, 0, len(s.data.Subscriptions))
+	for _, sub := range s.data.Subscriptions {
+		subs = append(subs, *sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].ChatID != subs[j].ChatID {
+			return subs[i].ChatID < subs[j].ChatID
+		}
+		return subs[i].Kind < subs[j].Kind
+	})
+	return subs
+}
+
+// Подписки одного чата
+func (s *Store) ChatSubscriptions(chatID int64) []AlertSubscription {
+	var subs []AlertSubscription
+	for _, sub := range s.Subscriptions() {
+		if sub.ChatID == chatID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// Отметка о срабатывании оповещения
+func (s *Store) MarkFired(chatID int64, kind string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.data.Subscriptions[subscriptionKey(chatID, kind)]
+	if !exists {
+		return nil
+	}
+	sub.LastFired = at
+	return s.save()
+}
+
+// Фоновая проверка подписок на оповещения
+func runAlertChecker(bot *Bot) {
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		runScheduledPass("проверка подписок", alertCheckInterval, func() {
+			checkAlerts(bot, bot.store)
+			bot.live.Expire(clockNow())
+		})
+		<-ticker.C
+	}
+}
+
+func checkAlerts(bot messageSender, store *Store) {
+	for _, sub := range store.Subscriptions() {
+		kind, ok := alertKinds[sub.Kind]
+		if !ok {
+			continue
+		}
+		if !sub.LastFired.IsZero() && clockNow().Sub(sub.LastFired) < kind.cooldown {
+			continue
+		}
+
+		text, fire, err := kind.check(&sub, store.Preferences(sub.ChatID))
+		if err != nil {
+			log.Printf("Ошибка проверки оповещения %s для чата %d: %v", sub.Kind, sub.ChatID, err)
+			continue
+		}
+		if !fire {
+			continue
+		}
+
+		// Недоставленное оповещение сохраняется для /missed и тоже
+		// считается сработавшим, иначе оно повторялось бы каждые полчаса
+		if err := deliver(bot, store, sub.ChatID, kind.title, alertMessage(sub, kind, text), text); err != nil {
+			log.Printf("Ошибка отправки оповещения %s: %v", sub.Kind, err)
+		}
+		if err := store.MarkFired(sub.ChatID, sub.Kind, clockNow()); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		publishMQTTAlert(sub)
+		sendAlertWebhooks(store, sub, text)
+	}
+
+	checkEvents(bot, store)
+}
+
+// Оповещение со снимком, если он есть и текст помещается в подпись
+func alertMessage(sub AlertSubscription, kind alertKind, text string) tgbotapi.Chattable {
+	if kind.snapshot == nil || utf8.RuneCountInString(text) > telegramCaptionLimit {
+		return tgbotapi.NewMessage(sub.ChatID, text)
+	}
+	image, err := kind.snapshot(&sub)
+	if err != nil {
+		log.Printf("Ошибка получения снимка для оповещения %s: %v", sub.Kind, err)
+	}
+	if len(image) == 0 {
+		return tgbotapi.NewMessage(sub.ChatID, text)
+	}
+	photo := tgbotapi.NewPhoto(sub.ChatID, tgbotapi.FileBytes{Name: sub.Kind + ".jpg", Bytes: image})
+	photo.Caption = text
+	return photo
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP API для других домашних сервисов: та же погода, что видит бот,
+// из того же кэша и с той же квотой OWM. Значения метрические
+
+// Текущая погода в ответе API
+type apiWeather struct {
+	City        string  `json:"city"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Time        string  `json:"time"`
+	Temp        float64 `json:"temp"`
+	FeelsLike   float64 `json:"feels_like"`
+	Humidity    int     `json:"humidity"`
+	Pressure    float64 `json:"pressure"`
+	WindSpeed   float64 `json:"wind_speed"`
+	WindGust    float64 `json:"wind_gust"`
+	WindDeg     int     `json:"wind_deg"`
+	Condition   int     `json:"condition"`
+	Description string  `json:"description"`
+	Daytime     bool    `json:"daytime"`
+	Clouds      int     `json:"clouds"`
+	Rain        float64 `json:"rain"`
+	Snow        float64 `json:"snow"`
+	Visibility  int     `json:"visibility"`
+	Sunrise     string  `json:"sunrise,omitempty"`
+	Sunset      string  `json:"sunset,omitempty"`
+	Units       string  `json:"units"`
+}
+
+func newAPIWeather(data *CurrentWeather) apiWeather {
+	weather := apiWeather{
+		City:        data.City,
+		Lat:         data.Lat,
+		Lon:         data.Lon,
+		Time:        data.Time.Format(time.RFC3339),
+		Temp:        data.Temp,
+		FeelsLike:   data.FeelsLike,
+		Humidity:    data.Humidity,
+		Pressure:    data.Pressure,
+		WindSpeed:   data.WindSpeed,
+		WindGust:    data.WindGust,
+		WindDeg:     data.WindDeg,
+		Condition:   data.Condition,
+		Description: data.Description,
+		Daytime:     data.Daytime,
+		Clouds:      data.Clouds,
+		Rain:        data.Rain,
+		Snow:        data.Snow,
+		Visibility:  data.Visibility,
+		Units:       unitsMetric,
+	}
+	if !data.Sunrise.IsZero() {
+		weather.Sunrise = data.Sunrise.Format(time.RFC3339)
+		weather.Sunset = data.Sunset.Format(time.RFC3339)
+	}
+	return weather
+}
+
+// Точка из запроса: ?city=... или ?lat=...&lon=..., плюс язык описаний (?lang=)
+type apiQuery struct {
+	city     string
+	lat, lon float64
+	lang     string
+}
+
+func parseAPIQuery(r *http.Request) (apiQuery, error) {
+	values := r.URL.Query()
+	q := apiQuery{city: strings.TrimSpace(values.Get("city")), lang: langRU}
+
+	if lang := values.Get("lang"); lang != "" {
+		if _, ok := langTitles[lang]; !ok {
+			return q, fmt.Errorf("неизвестный язык: %s", lang)
+		}
+		q.lang = lang
+	}
+	if q.city != "" {
+		return q, nil
+	}
+
+	lat, latErr := strconv.ParseFloat(values.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(values.Get("lon"), 64)
+	if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return q, fmt.Errorf("укажите city или lat и lon")
+	}
+	q.lat, q.lon = lat, lon
+	return q, nil
+}
+
+// Проверка токена клиента (Authorization: Bearer ...). Токены читаются
+// из текущих настроек, поэтому их можно менять через /reload
+func apiAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		allowed := false
+		for _, token := range config().APITokens {
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				allowed = true
+			}
+		}
+		if got == "" || !allowed {
+			writeJSONError(w, http.StatusUnauthorized, fmt.Errorf("нужен токен API"))
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("поддерживается только GET"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// GET /api/weather — текущая погода
+func handleAPIWeather(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAPIQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var data *CurrentWeather
+	if q.city != "" {
+		data, err = cachedWeather(q.city, q.lang)
+	} else {
+		data, err = cachedLocationWeather(q.lat, q.lon, q.lang)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAPIWeather(data))
+}
+
+// GET /api/forecast — прогноз на 5 дней в том же виде, что и /export json
+func handleAPIForecast(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAPIQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var forecast *Forecast
+	if q.city != "" {
+		forecast, err = fetchForecastLang(q.city, q.lang)
+	} else {
+		forecast, err = fetchForecastByCoordsLang(q.lat, q.lon, q.lang)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newExportForecast(forecast))
+}
+
+func newAPIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/weather", apiAuth(handleAPIWeather))
+	mux.HandleFunc("/api/forecast", apiAuth(handleAPIForecast))
+	return mux
+}
+
+// HTTP-сервер API (если задан API_ADDR)
+func runAPIServer(addr string) {
+	log.Printf("HTTP API слушает %s", addr)
+	if err := http.ListenAndServe(addr, newAPIHandler()); err != nil {
+		log.Printf("Ошибка сервера HTTP API: %v", err)
+	}
+}
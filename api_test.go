@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPI(t *testing.T) {
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerMock
+	c.APITokens = []string{"home-assistant-token"}
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	server := httptest.NewServer(newAPIHandler())
+	t.Cleanup(server.Close)
+
+	get := func(path, token string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp, body
+	}
+
+	if resp, _ := get("/api/weather?city=Тула", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("без токена статус %d", resp.StatusCode)
+	}
+	if resp, _ := get("/api/weather?city=Тула", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("с чужим токеном статус %d", resp.StatusCode)
+	}
+	if resp, _ := get("/api/weather", "home-assistant-token"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("без города статус %d", resp.StatusCode)
+	}
+	if resp, _ := get("/api/weather?city=Тула&lang=de", "home-assistant-token"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("с неизвестным языком статус %d", resp.StatusCode)
+	}
+
+	resp, body := get("/api/weather?city=Тула", "home-assistant-token")
+	if resp.StatusCode != http.StatusOK || body["city"] != "Тула" || body["units"] != unitsMetric {
+		t.Errorf("погода: статус %d, ответ %v", resp.StatusCode, body)
+	}
+	if _, ok := body["temp"].(float64); !ok {
+		t.Errorf("нет температуры: %v", body)
+	}
+
+	resp, body = get("/api/forecast?lat=54.2&lon=37.6&lang=en", "home-assistant-token")
+	items, _ := body["items"].([]interface{})
+	if resp.StatusCode != http.StatusOK || len(items) == 0 {
+		t.Errorf("прогноз: статус %d, ответ %v", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Тип подписки на оповещения о полярном сиянии
+const alertAurora = "aurora"
+
+// Сколько часов вперед ищем ночь с сиянием
+const auroraHorizon = 12 * time.Hour
+
+// Максимальная облачность, при которой сияние можно увидеть, %
+const auroraMaxClouds = 30
+
+// Южнее этой широты сияние практически не видно
+const auroraMinLatitude = 50.0
+
+func init() {
+	alertKinds[alertAurora] = alertKind{title: "Полярное сияние", check: checkAurora, cooldown: 12 * time.Hour}
+}
+
+// Минимальный Kp-индекс, при котором сияние видно на данной широте
+func auroraKpThreshold(lat float64) float64 {
+	if lat < 0 {
+		lat = -lat
+	}
+	switch {
+	case lat >= 65:
+		return 3
+	case lat >= 62:
+		return 4
+	case lat >= 59:
+		return 5
+	case lat >= 56:
+		return 6
+	case lat >= 53:
+		return 7
+	default:
+		return 8
+	}
+}
+
+// Проверка подписки на полярное сияние: высокий Kp ночью при ясном небе
+func checkAurora(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	entries, err := fetchKpForecast()
+	if err != nil {
+		return "", false, err
+	}
+	forecast, err := fetchForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	threshold := auroraKpThreshold(sub.Lat)
+	now := clockNow()
+	for _, item := range forecast.Items {
+		slot := item.Time
+		if slot.Sub(now) > auroraHorizon {
+			break
+		}
+		if item.Daytime || item.Clouds > auroraMaxClouds {
+			continue
+		}
+
+		kp, ok := kpAt(entries, slot.UTC())
+		if !ok || kp < threshold {
+			continue
+		}
+
+		return fmt.Sprintf(
+			"🌌 Возможно полярное сияние в %s!\n"+
+				"Около %s ожидается Kp %.1f, облачность %d%%.\n"+
+				"Ищите сияние на севере, подальше от городской засветки.",
+			sub.City,
+			forecast.LocalTime(item).Format("15:04"),
+			kp,
+			item.Clouds,
+		), true, nil
+	}
+
+	return "", false, nil
+}
+
+// Подписка чата на оповещения о полярном сиянии
+func subscribeAurora(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	lat := point.Lat
+	if lat < 0 {
+		lat = -lat
+	}
+	if lat < auroraMinLatitude {
+		return fmt.Sprintf("😔 %s слишком далеко от полярных широт — сияние там почти не бывает видно.", point.DisplayName()), nil
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertAurora,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🌌 Подписка оформлена! Сообщу, когда в %s ночью будет Kp от %.0f и ясное небо.\n"+
+			"Отписаться: /aurora off",
+		point.DisplayName(),
+		auroraKpThreshold(point.Lat),
+	), nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Резервные копии называются state-20060102-150405.json, поэтому
+// сортировка по имени совпадает с сортировкой по времени
+const (
+	backupPrefix     = "state-"
+	backupTimeLayout = "20060102-150405"
+)
+
+// Копия состояния в JSON
+func (s *Store) Snapshot() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации состояния: %v", err)
+	}
+	return raw, nil
+}
+
+// Замена состояния содержимым резервной копии. Старые копии
+// проходят те же миграции, что и файл состояния при запуске
+func (s *Store) Restore(raw []byte) error {
+	data, _, err := decodeState(raw)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = data
+	return s.save()
+}
+
+// Хранилище копий из настроек (nil, если S3 не настроен)
+func backupS3() *s3Client {
+	c := config()
+	if c.BackupS3Endpoint == "" {
+		return nil
+	}
+	return &s3Client{
+		endpoint:  c.BackupS3Endpoint,
+		bucket:    c.BackupS3Bucket,
+		region:    c.BackupS3Region,
+		accessKey: c.BackupS3AccessKey,
+		secretKey: c.BackupS3SecretKey,
+	}
+}
+
+func backupsEnabled() bool {
+	return config().BackupDir != "" || config().BackupS3Endpoint != ""
+}
+
+// Каталог локальных копий бота: копии дополнительных ботов лежат
+// в подкаталоге с ID бота (пустая строка, если BACKUP_DIR не задан)
+func backupDir(bot *Bot) string {
+	dir := config().BackupDir
+	if dir == "" || bot.backupScope == "" {
+		return dir
+	}
+	return filepath.Join(dir, bot.backupScope)
+}
+
+// Ключ копии бота в S3, с тем же префиксом, что и каталог
+func backupKey(bot *Bot, name string) string {
+	if bot.backupScope == "" {
+		return name
+	}
+	return bot.backupScope + "/" + name
+}
+
+// Резервная копия во все настроенные места. Возвращает имя копии
+func backupState(bot *Bot, now time.Time) (string, error) {
+	raw, err := bot.store.Snapshot()
+	if err != nil {
+		return "", err
+	}
+	name := backupPrefix + now.UTC().Format(backupTimeLayout) + ".json"
+
+	if dir := backupDir(bot); dir != "" {
+		if err := writeLocalBackup(dir, name, raw); err != nil {
+			return "", err
+		}
+		if err := pruneLocalBackups(dir, config().BackupKeep); err != nil {
+			log.Printf("Ошибка удаления старых резервных копий: %v", err)
+		}
+	}
+	if s3 := backupS3(); s3 != nil {
+		if err := s3.Put(backupKey(bot, name), raw); err != nil {
+			return "", fmt.Errorf("ошибка загрузки копии в S3: %v", err)
+		}
+	}
+	return name, nil
+}
+
+// Запись копии через временный файл, как и основного файла состояния
+func writeLocalBackup(dir, name string, raw []byte) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("ошибка создания каталога копий: %v", err)
+	}
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("ошибка записи резервной копии: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("ошибка записи резервной копии: %v", err)
+	}
+	return nil
+}
+
+// Локальные копии от старых к новым
+func localBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения каталога копий: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, ".json") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Оставляем только keep последних локальных копий. В S3 сроком хранения
+// управляют правила жизненного цикла бакета
+func pruneLocalBackups(dir string, keep int) error {
+	names, err := localBackups(dir)
+	if err != nil || keep <= 0 || len(names) <= keep {
+		return err
+	}
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Все доступные копии, новые первыми
+func listBackups(bot *Bot) ([]string, error) {
+	seen := make(map[string]bool)
+	if dir := backupDir(bot); dir != "" {
+		names, err := localBackups(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			seen[name] = true
+		}
+	}
+	if s3 := backupS3(); s3 != nil {
+		names, err := s3.List(backupKey(bot, backupPrefix))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			seen[strings.TrimPrefix(name, backupKey(bot, ""))] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// Чтение копии: сначала из локального каталога, затем из S3
+func readBackup(bot *Bot, name string) ([]byte, error) {
+	if !strings.HasPrefix(name, backupPrefix) || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("неизвестная копия %q", name)
+	}
+	if dir := backupDir(bot); dir != "" {
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return raw, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("ошибка чтения резервной копии: %v", err)
+		}
+	}
+	if s3 := backupS3(); s3 != nil {
+		raw, err := s3.Get(backupKey(bot, name))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки копии из S3: %v", err)
+		}
+		return raw, nil
+	}
+	return nil, fmt.Errorf("копия %q не найдена", name)
+}
+
+// Восстановление из копии. Текущее состояние перед этим тоже сохраняется
+// в копию, чтобы ошибочное восстановление можно было откатить
+func restoreBackup(bot *Bot, name string, now time.Time) (string, error) {
+	raw, err := readBackup(bot, name)
+	if err != nil {
+		return "", err
+	}
+	safety, err := backupState(bot, now)
+	if err != nil {
+		return "", fmt.Errorf("не удалось сохранить текущее состояние перед восстановлением: %v", err)
+	}
+	if err := bot.store.Restore(raw); err != nil {
+		return "", err
+	}
+	return safety, nil
+}
+
+// Резервное копирование бота по расписанию (BACKUP_INTERVAL)
+func runBackups(bot *Bot) {
+	ticker := time.NewTicker(config().BackupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !backupsEnabled() {
+			continue
+		}
+		name, err := backupState(bot, time.Now())
+		if err != nil {
+			log.Printf("Ошибка резервного копирования: %v", err)
+			reportError(errorLevelError, "ошибка резервного копирования: "+err.Error(), nil, "")
+			continue
+		}
+		log.Printf("Резервная копия состояния: %s", name)
+	}
+}
+
+// /backup — копия прямо сейчас
+func handleBackupCommand(c *commandContext) {
+	if !backupsEnabled() {
+		c.msg.Text = "Резервное копирование не настроено: задайте BACKUP_DIR или BACKUP_S3_ENDPOINT."
+		return
+	}
+	name, err := backupState(c.bot, time.Now())
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	c.msg.Text = "💾 Резервная копия сохранена: " + name
+}
+
+// /restore — список копий, /restore <имя> — восстановление
+func handleRestoreCommand(c *commandContext) {
+	if !backupsEnabled() {
+		c.msg.Text = "Резервное копирование не настроено: задайте BACKUP_DIR или BACKUP_S3_ENDPOINT."
+		return
+	}
+
+	name := strings.TrimSpace(c.args)
+	if name == "" {
+		names, err := listBackups(c.bot)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		if len(names) == 0 {
+			c.msg.Text = "Резервных копий пока нет. Сделать копию: /backup"
+			return
+		}
+		if len(names) > 10 {
+			names = names[:10]
+		}
+		c.msg.Text = "💾 Последние резервные копии:\n" + strings.Join(names, "\n") +
+			"\n\nВосстановить: /restore <имя>"
+		return
+	}
+
+	safety, err := restoreBackup(c.bot, name, time.Now())
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	c.msg.Text = fmt.Sprintf("✅ Состояние восстановлено из %s.\nПрежнее состояние сохранено в %s.", name, safety)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	bot := useTestBot(t)
+
+	previous := config()
+	c := *previous
+	c.BackupDir = filepath.Join(dir, "backups")
+	c.BackupKeep = 2
+	c.BackupS3Endpoint = ""
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	setHome := func(city string) {
+		t.Helper()
+		if err := store.UpdatePreferences(1, func(prefs *UserPreferences) { prefs.HomeCity = city }); err != nil {
+			t.Fatalf("UpdatePreferences: %v", err)
+		}
+	}
+
+	start := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	setHome("Пермь")
+	name, err := backupState(bot, start)
+	if err != nil {
+		t.Fatalf("backupState: %v", err)
+	}
+	if name != "state-20260101-030000.json" {
+		t.Errorf("имя копии %s", name)
+	}
+
+	setHome("Самара")
+	safety, err := restoreBackup(bot, name, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("restoreBackup: %v", err)
+	}
+	if home := store.Preferences(1).HomeCity; home != "Пермь" {
+		t.Errorf("после восстановления домашний город %q, ожидалась Пермь", home)
+	}
+
+	// Состояние до восстановления тоже сохранено
+	raw, err := readBackup(bot, safety)
+	if err != nil {
+		t.Fatalf("readBackup: %v", err)
+	}
+	data, _, err := decodeState(raw)
+	if err != nil || data.Preferences[1].HomeCity != "Самара" {
+		t.Errorf("в страховочной копии нет прежнего состояния: %v", err)
+	}
+
+	// Хранится не больше BACKUP_KEEP копий, новые первыми
+	if _, err := backupState(bot, start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("backupState: %v", err)
+	}
+	names, err := listBackups(bot)
+	if err != nil {
+		t.Fatalf("listBackups: %v", err)
+	}
+	if len(names) != 2 || names[0] != "state-20260101-050000.json" {
+		t.Errorf("копии после очистки: %v", names)
+	}
+
+	if _, err := readBackup(bot, "../state.json"); err == nil {
+		t.Error("чтение файла вне каталога копий должно быть запрещено")
+	}
+}
+
+func TestBackupsPerBot(t *testing.T) {
+	dir := t.TempDir()
+	primary := useTestBot(t)
+	other, err := newBot(&tgbotapi.BotAPI{Token: "654321:OTHER"}, filepath.Join(dir, "state.654321.json"), "654321")
+	if err != nil {
+		t.Fatalf("newBot: %v", err)
+	}
+
+	previous := config()
+	c := *previous
+	c.BackupDir = filepath.Join(dir, "backups")
+	c.BackupS3Endpoint = ""
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	now := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	if err := other.store.UpdatePreferences(1, func(prefs *UserPreferences) { prefs.HomeCity = "Тула" }); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+	for _, bot := range []*Bot{primary, other} {
+		if _, err := backupState(bot, now); err != nil {
+			t.Fatalf("backupState: %v", err)
+		}
+	}
+
+	// Копии дополнительного бота лежат в подкаталоге и не смешиваются с основными
+	if _, err := os.Stat(filepath.Join(dir, "backups", "654321", "state-20260101-030000.json")); err != nil {
+		t.Errorf("нет копии дополнительного бота: %v", err)
+	}
+	names, err := listBackups(primary)
+	if err != nil || len(names) != 1 {
+		t.Errorf("копии основного бота: %v, %v", names, err)
+	}
+	raw, err := readBackup(other, "state-20260101-030000.json")
+	if err != nil {
+		t.Fatalf("readBackup: %v", err)
+	}
+	if data, _, err := decodeState(raw); err != nil || data.Preferences[1].HomeCity != "Тула" {
+		t.Errorf("в копии дополнительного бота чужое состояние: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Блокировка пользователя администратором
+type Ban struct {
+	Reason   string    `json:"reason,omitempty"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+func (s *Store) IsBanned(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, banned := s.data.Banned[userID]
+	return banned
+}
+
+func (s *Store) Ban(userID int64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Banned[userID] = &Ban{Reason: reason, BannedAt: time.Now()}
+	return s.save()
+}
+
+// Снятие блокировки. Возвращает false, если пользователь не был заблокирован
+func (s *Store) Unban(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, banned := s.data.Banned[userID]; !banned {
+		return false, nil
+	}
+	delete(s.data.Banned, userID)
+	return true, s.save()
+}
+
+// Разбор аргументов /ban: ID пользователя и необязательная причина
+func parseBanArgs(args string) (int64, string, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return 0, "", fmt.Errorf("укажите ID пользователя, например: /ban 123456789 спам")
+	}
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("%q не похоже на ID пользователя", fields[0])
+	}
+	return userID, strings.Join(fields[1:], " "), nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Комфортный диапазон температур для пляжа и пикника
+const (
+	beachIdealTempMin = 22.0
+	beachIdealTempMax = 28.0
+)
+
+// Дневные часы, по которым оцениваем день отдыха
+const (
+	beachDayStartHour = 9
+	beachDayEndHour   = 21
+)
+
+// Оценка интервала прогноза для отдыха на улице по шкале 0..10
+func beachScore(item ForecastItem) float64 {
+	score := 10.0
+
+	temp := item.Temp
+	switch {
+	case temp < beachIdealTempMin:
+		score -= (beachIdealTempMin - temp) * 0.5
+	case temp > beachIdealTempMax:
+		score -= (temp - beachIdealTempMax) * 0.5
+	}
+
+	score -= float64(item.Clouds) / 100 * 3
+	score -= item.Pop * 4
+	score -= math.Min((item.Rain+item.Snow)*2, 4)
+	if item.WindSpeed > 5 {
+		score -= (item.WindSpeed - 5) * 0.6
+	}
+
+	return math.Max(0, math.Min(10, score))
+}
+
+// Итоги одного дня выходных
+type beachDay struct {
+	date    time.Time
+	score   float64
+	slots   int
+	maxTemp float64
+	maxPop  float64
+	maxWind float64
+}
+
+// Функция для оценки погоды на выходных для пляжа или шашлыков
+func getBeachDay(city string) (string, error) {
+	forecast, err := fetchForecast(city)
+	if err != nil {
+		return "", err
+	}
+
+	var days []*beachDay
+	byDate := make(map[string]*beachDay)
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		if local.Weekday() != time.Saturday && local.Weekday() != time.Sunday {
+			continue
+		}
+		if local.Hour() < beachDayStartHour || local.Hour() >= beachDayEndHour {
+			continue
+		}
+
+		key := local.Format("2006-01-02")
+		day, ok := byDate[key]
+		if !ok {
+			day = &beachDay{date: local}
+			byDate[key] = day
+			days = append(days, day)
+		}
+		day.score += beachScore(item)
+		day.slots++
+		day.maxTemp = math.Max(day.maxTemp, item.Temp)
+		day.maxPop = math.Max(day.maxPop, item.Pop)
+		day.maxWind = math.Max(day.maxWind, item.WindSpeed)
+	}
+
+	if len(days) == 0 {
+		return "", fmt.Errorf("прогноз на выходные пока недоступен, попробуйте ближе к субботе")
+	}
+
+	beachMsg := fmt.Sprintf("🏖 Выходные в %s:\n\n", forecast.City)
+
+	var best *beachDay
+	for _, day := range days {
+		day.score /= float64(day.slots)
+		if best == nil || day.score > best.score {
+			best = day
+		}
+
+		beachMsg += fmt.Sprintf("%s %s %s: %.0f/10 — до %.0f°C, осадки до %.0f%%, ветер до %.0f м/с\n",
+			scoreEmoji(day.score),
+			weekdayName(day.date.Weekday()),
+			day.date.Format("02.01"),
+			day.score,
+			day.maxTemp,
+			day.maxPop*100,
+			day.maxWind,
+		)
+	}
+
+	switch {
+	case best.score < 5:
+		beachMsg += "\n🌧 Погода на выходных не располагает к отдыху на природе."
+	case len(days) == 1:
+		beachMsg += fmt.Sprintf("\n🔥 %s — подходящий день для отдыха на улице.", weekdayName(best.date.Weekday()))
+	default:
+		beachMsg += fmt.Sprintf("\n🔥 Лучше выбрать %s.", weekdayAccusative(best.date.Weekday()))
+	}
+
+	return beachMsg, nil
+}
+
+// Название дня недели по-русски
+func weekdayName(day time.Weekday) string {
+	return [...]string{"Воскресенье", "Понедельник", "Вторник", "Среда", "Четверг", "Пятница", "Суббота"}[day]
+}
+
+// Краткое название дня недели ("пн")
+func weekdayShortName(day time.Weekday) string {
+	return [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}[day]
+}
+
+// Название дня недели в винительном падеже ("выбрать субботу")
+func weekdayAccusative(day time.Weekday) string {
+	return [...]string{"воскресенье", "понедельник", "вторник", "среду", "четверг", "пятницу", "субботу"}[day]
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Несколько ботов в одном процессе: TELEGRAM_TOKEN — основной бот,
+// TELEGRAM_TOKENS — дополнительные (например, отдельные боты для городов).
+// У каждого бота свое хранилище, журнал обновлений, последние города,
+// трансляции геопозиции, оповещения и публикации. Кэш погоды, ключи OWM
+// с их квотой и настройки общие
+
+// Бот одного токена
+type Bot struct {
+	*tgbotapi.BotAPI
+	store     *Store
+	updateLog *UpdateLog
+	// Последние запрошенные города. Обновления бота обрабатываются по
+	// одному, поэтому без мьютекса
+	lastCity map[int64]string
+	live     *LiveTracker
+	// Подкаталог резервных копий: у основного бота пустой, у дополнительных
+	// ID бота
+	backupScope string
+}
+
+// Запущенные боты, первый — основной. Задаются в main
+var bots []*Bot
+
+// ID бота — часть токена до двоеточия
+func botID(token string) string {
+	id, _, _ := strings.Cut(token, ":")
+	return id
+}
+
+// Файл состояния дополнительного бота рядом с основным: bot_state.123456.json
+func botStateFile(statePath, token string) string {
+	return strings.TrimSuffix(statePath, ".json") + "." + botID(token) + ".json"
+}
+
+// Бот с хранилищем statePath и журналом обновлений рядом с ним
+func newBot(api *tgbotapi.BotAPI, statePath, backupScope string) (*Bot, error) {
+	s, err := openStore(statePath)
+	if err != nil {
+		return nil, err
+	}
+	updates, err := openUpdateLog(updateLogPath(statePath))
+	if err != nil {
+		return nil, err
+	}
+	return &Bot{
+		BotAPI:      api,
+		store:       s,
+		updateLog:   updates,
+		lastCity:    make(map[int64]string),
+		live:        newLiveTracker(),
+		backupScope: backupScope,
+	}, nil
+}
+
+// Подключение всех ботов из настроек: основного и дополнительных
+func openBots(c *Config) ([]*Bot, error) {
+	var opened []*Bot
+	for i, token := range append([]string{c.TelegramToken}, c.TelegramTokens...) {
+		statePath, backupScope := c.StateFile, ""
+		if i > 0 {
+			statePath, backupScope = botStateFile(c.StateFile, token), botID(token)
+		}
+
+		api, err := newBotAPI(c, token)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка инициализации бота %s: %v", botID(token), err)
+		}
+		api.Debug = c.Debug
+
+		bot, err := newBot(api, statePath, backupScope)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка открытия хранилища бота @%s: %v", api.Self.UserName, err)
+		}
+		opened = append(opened, bot)
+	}
+	return opened, nil
+}
+
+// Бот, которому принадлежит токен (nil, если такого нет)
+func botByToken(token string) *Bot {
+	for _, bot := range bots {
+		if bot.Token == token {
+			return bot
+		}
+	}
+	return nil
+}
+
+// Получение и обработка обновлений бота до закрытия stop
+func (b *Bot) run(stop <-chan struct{}) {
+	u := tgbotapi.NewUpdate(b.startUpdateOffset())
+	u.Timeout = 60
+	updates := pollUpdates(b, u, stop)
+
+	handler := newUpdatePipeline()
+	for update := range updates {
+		handler(b, update)
+	}
+	if err := b.updateLog.Flush(); err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBotStateFile(t *testing.T) {
+	path := botStateFile("data/bot_state.json", "654321:SECRET")
+	if path != "data/bot_state.654321.json" {
+		t.Errorf("файл состояния %s", path)
+	}
+	if log := updateLogPath(path); log != "data/bot_state.654321.updates.json" {
+		t.Errorf("журнал обновлений %s", log)
+	}
+}
+
+// Данные запуска мини-приложения, подписанные токеном бота
+func signedInitData(token string, userID int64) string {
+	values := url.Values{
+		"auth_date": {fmt.Sprint(time.Now().Unix())},
+		"user":      {fmt.Sprintf(`{"id":%d}`, userID)},
+	}
+	pairs := []string{"auth_date=" + values.Get("auth_date"), "user=" + values.Get("user")}
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(token))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	values.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return values.Encode()
+}
+
+func TestBotsSeparateState(t *testing.T) {
+	first := useMockReports(t)
+	// Долгота 37.6 — в демо-режиме это UTC+3, 7:30 по местному времени
+	useManualClock(t, time.Date(2026, 10, 16, 4, 30, 0, 0, time.UTC))
+	previousCoords := coordsCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { coordsCache = previousCoords })
+	const otherToken = "654321:OTHER"
+	second := newFakeTelegramBot(t, otherToken)
+	bots = []*Bot{first.bot, second.bot}
+	const chatID = 4081
+
+	first.send(textUpdate(chatID, "/daily Москва"))
+	if len(first.bot.store.ChatSubscriptions(chatID)) != 1 {
+		t.Fatal("подписка не сохранилась у первого бота")
+	}
+	if subs := second.bot.store.ChatSubscriptions(chatID); len(subs) != 0 {
+		t.Errorf("подписка первого бота видна второму: %+v", subs)
+	}
+	if _, ok := second.bot.lastCity[chatID]; ok {
+		t.Error("последний город первого бота виден второму")
+	}
+
+	// Сводку отправляет только бот, у которого есть подписка
+	sub := AlertSubscription{ChatID: chatID + 1, Kind: alertDaily, City: "Москва", Lat: 55.75, Lon: 37.62}
+	if err := first.bot.store.Subscribe(sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	first.reset()
+	checkAlerts(first.bot, first.bot.store)
+	checkAlerts(second.bot, second.bot.store)
+	if len(first.sent("sendMessage")) != 1 || len(second.sent("sendMessage")) != 0 {
+		t.Errorf("сводок от первого бота %d, от второго %d", len(first.sent("sendMessage")), len(second.sent("sendMessage")))
+	}
+
+	// Мини-приложение меняет настройки в хранилище бота, которым подписаны данные
+	r := httptest.NewRequest(http.MethodPost, "/api/settings", strings.NewReader(`{"home_city": "Тула"}`))
+	r.Header.Set("X-Telegram-Init-Data", signedInitData(otherToken, chatID))
+	w := httptest.NewRecorder()
+	webAppAuth(handleWebAppSettings)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("код ответа %d: %s", w.Code, w.Body)
+	}
+	if home := second.bot.store.Preferences(chatID).HomeCity; home != "Тула" {
+		t.Errorf("домашний город у второго бота %q", home)
+	}
+	if home := first.bot.store.Preferences(chatID).HomeCity; home != "" {
+		t.Errorf("настройки второго бота попали первому: %q", home)
+	}
+}
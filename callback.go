@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Действия кнопок
+const (
+	actionForecast      = "forecast"
+	actionWeather       = "weather"
+	actionSettings      = "set"
+	actionOnboarding    = "onb"
+	actionDonate        = "donate"
+	actionFeedbackReply = "fbreply"
+	actionRecent        = "recent"
+	actionForgetMe      = "forget"
+	actionNearby        = "near"
+	actionSkyFlag       = "skyflag"
+	actionSkyDelete     = "skydel"
+	actionSkyBan        = "skyban"
+	actionRefresh       = "refresh"
+	actionUnits         = "units"
+	actionDigest        = "digest"
+	actionPause         = "pause"
+)
+
+// Сколько хранятся данные кнопок. Нажатие на более старую кнопку
+// попросит повторить запрос
+const callbackTTL = 30 * 24 * time.Hour
+
+// Данные кнопки. В callback_data Telegram помещается только 64 байта,
+// поэтому туда пишем короткий идентификатор, а сами данные храним в файле состояния
+type CallbackPayload struct {
+	Action    string    `json:"action"`
+	Value     string    `json:"value,omitempty"`
+	City      string    `json:"city,omitempty"`
+	Units     string    `json:"units,omitempty"`
+	Lang      string    `json:"lang,omitempty"`
+	Day       int       `json:"day,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Идентификатор зависит только от содержимого кнопки, поэтому одна и та же
+// кнопка в разных сообщениях не плодит новых записей
+func callbackID(payload CallbackPayload) string {
+	key := strings.Join([]string{
+		payload.Action,
+		payload.Value,
+		payload.City,
+		payload.Units,
+		payload.Lang,
+		strconv.Itoa(payload.Day),
+	}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
+
+// Сохранение данных кнопки. Устаревшие записи заодно удаляются
+func (s *Store) SaveCallback(id string, payload CallbackPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if saved, exists := s.data.Callbacks[id]; exists && time.Since(saved.CreatedAt) < callbackTTL/2 {
+		return nil
+	}
+
+	for key, saved := range s.data.Callbacks {
+		if time.Since(saved.CreatedAt) > callbackTTL {
+			delete(s.data.Callbacks, key)
+		}
+	}
+	payload.CreatedAt = time.Now()
+	s.data.Callbacks[id] = &payload
+	return s.save()
+}
+
+// Данные кнопки по идентификатору
+func (s *Store) Callback(id string) (CallbackPayload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payload, exists := s.data.Callbacks[id]
+	if !exists || time.Since(payload.CreatedAt) > callbackTTL {
+		return CallbackPayload{}, false
+	}
+	return *payload, true
+}
+
+// callback_data для кнопки
+func encodeCallback(store *Store, payload CallbackPayload) string {
+	id := callbackID(payload)
+	if err := store.SaveCallback(id, payload); err != nil {
+		log.Printf("Ошибка сохранения данных кнопки: %v", err)
+	}
+	return id
+}
+
+// Данные нажатой кнопки. Кнопки, отправленные до перехода на короткие
+// идентификаторы, имеют вид "действие:значение" и разбираются напрямую
+func decodeCallback(store *Store, data string) (CallbackPayload, bool) {
+	if payload, ok := store.Callback(data); ok {
+		return payload, true
+	}
+
+	action, value, found := strings.Cut(data, ":")
+	if !found {
+		return CallbackPayload{}, false
+	}
+	payload := CallbackPayload{Action: action, Value: value}
+	if action == actionForecast {
+		payload.City = value
+	}
+	return payload, true
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Проверка названия города перед запросом к источнику погоды. Сами
+// параметры запроса кодируются через url.Values, а здесь отсекаем то, что
+// названием города быть не может: перевод строки, служебные символы URL,
+// эмодзи, слишком длинный текст
+
+// Самое длинное название населенного пункта короче, а запрос OWM дальше
+// все равно не найдет
+const maxCityLength = 100
+
+// Символы, которые встречаются в названиях кроме букв, цифр и пробелов:
+// "Ростов-на-Дону", "Кызыл-Орда", "St. John's", "Moscow,RU", "Фрязино (Московская обл.)"
+const cityPunctuation = "-'’.,()"
+
+// Нормализованное название: пробелы по краям убраны, переводы строк
+// и повторные пробелы заменены одним пробелом
+func normalizeCity(input string) (string, error) {
+	city := strings.Join(strings.Fields(input), " ")
+	if city == "" {
+		return "", fmt.Errorf("укажите название города, например: Москва")
+	}
+	if n := utf8.RuneCountInString(city); n > maxCityLength {
+		return "", fmt.Errorf("слишком длинное название города: %d символов, допустимо до %d", n, maxCityLength)
+	}
+
+	for _, r := range city {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || strings.ContainsRune(cityPunctuation, r) {
+			continue
+		}
+		return "", fmt.Errorf("в названии города не может быть символа «%c», напишите только название, например: Санкт-Петербург", r)
+	}
+	return city, nil
+}
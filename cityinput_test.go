@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCity(t *testing.T) {
+	valid := map[string]string{
+		"Москва":                 "Москва",
+		"  Нижний   Новгород \n": "Нижний Новгород",
+		"Ростов-на-Дону":         "Ростов-на-Дону",
+		"St. John's":             "St. John's",
+		"Moscow,RU":              "Moscow,RU",
+		"Фрязино (Московская обл.)": "Фрязино (Московская обл.)",
+		"Санкт-\nПетербург":         "Санкт- Петербург",
+		"São Paulo":                 "São Paulo",
+		"東京":                        "東京",
+		"Сочи\t2014":                "Сочи 2014",
+	}
+	for input, want := range valid {
+		if got, err := normalizeCity(input); err != nil || got != want {
+			t.Errorf("normalizeCity(%q) = %q, %v, ожидалось %q", input, got, err, want)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                       "укажите название",
+		" \n ":                   "укажите название",
+		"Москва&appid=123":       "«&»",
+		"Москва#1":               "«#»",
+		"Москва?":                "«?»",
+		"Казань 🌧":               "«🌧»",
+		"<b>Тула</b>":            "«<»",
+		strings.Repeat("Я", 101): "слишком длинное",
+	}
+	for input, want := range invalid {
+		if _, err := normalizeCity(input); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("normalizeCity(%q): ошибка %v, ожидалась с %q", input, err, want)
+		}
+	}
+}
+
+func TestFetchWeatherEncodesCity(t *testing.T) {
+	request := fakeOWM(t, http.StatusOK, owmWeatherFixture)
+
+	if _, err := fetchWeatherLang(" St. John's,\nCA ", langEN); err != nil {
+		t.Fatalf("fetchWeatherLang: %v", err)
+	}
+	if got := request.URL.Query().Get("q"); got != "St. John's, CA" {
+		t.Errorf("параметр q = %q", got)
+	}
+	if strings.ContainsAny(request.URL.RawQuery, " '\n") {
+		t.Errorf("параметры не закодированы: %s", request.URL.RawQuery)
+	}
+
+	if _, err := fetchWeatherLang("Москва&units=imperial", langRU); err == nil {
+		t.Error("запрос с «&» в названии города")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Статистика по городам из собственных наблюдений бота: по записям
+// наблюдений (см. recorder.go) считаем по дням минимум, максимум и осадки
+// за последнюю неделю
+
+// Наблюдения за один день по местному времени города
+type CityDay struct {
+	Date  string // 2006-01-02
+	Min   float64
+	Max   float64
+	Rain  float64 // оценка осадков за день, мм
+	Count int
+	// Сколько наблюдений пришлось на дождь, снег или грозу
+	Wet int
+}
+
+// Осадки или гроза по коду условий OWM
+func isWetCondition(condition int) bool {
+	switch condition / 100 {
+	case 2, 3, 5, 6:
+		return true
+	}
+	return false
+}
+
+// Дни по наблюдениям. Осадки за последний час пересчитываем на время с
+// прошлого наблюдения, но не больше чем на час
+func cityDays(samples []ObservationSample) []*CityDay {
+	var days []*CityDay
+	var previous time.Time
+	for _, sample := range samples {
+		date := sample.Time.Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, &CityDay{Date: date, Min: sample.Temp, Max: sample.Temp})
+		}
+		day := days[len(days)-1]
+
+		day.Min = math.Min(day.Min, sample.Temp)
+		day.Max = math.Max(day.Max, sample.Temp)
+		day.Count++
+		if isWetCondition(sample.Condition) {
+			day.Wet++
+		}
+		hours := 1.0
+		if !previous.IsZero() {
+			hours = math.Min(1, sample.Time.Sub(previous).Hours())
+		}
+		day.Rain += (sample.Rain + sample.Snow) * hours
+		previous = sample.Time
+	}
+	return days
+}
+
+// Сводка по городу за неделю
+func formatCityStats(city string, days []*CityDay, units string) string {
+	if len(days) > 7 {
+		days = days[len(days)-7:]
+	}
+
+	coldest, warmest, rainiest := days[0], days[0], days[0]
+	wetDays := 0
+	for _, day := range days {
+		if day.Min < coldest.Min {
+			coldest = day
+		}
+		if day.Max > warmest.Max {
+			warmest = day
+		}
+		if day.Rain > rainiest.Rain || (day.Rain == rainiest.Rain && day.Wet > rainiest.Wet) {
+			rainiest = day
+		}
+		if day.Wet > 0 || day.Rain >= 0.1 {
+			wetDays++
+		}
+	}
+
+	text := fmt.Sprintf("📊 %s за неделю (%s–%s), по наблюдениям бота:\n",
+		city, cityDayLabel(days[0]), cityDayLabel(days[len(days)-1]))
+	text += fmt.Sprintf("🌡 Минимум %s (%s), максимум %s (%s)\n",
+		formatTemp(coldest.Min, units), cityDayLabel(coldest), formatTemp(warmest.Max, units), cityDayLabel(warmest))
+	if wetDays == 0 {
+		text += fmt.Sprintf("☀️ Осадков не было ни разу за %d %s\n", len(days), pluralDays(len(days)))
+	} else {
+		text += fmt.Sprintf("🌧 Осадки были %d %s из %d, больше всего — %s", wetDays, pluralDays(wetDays), len(days), cityDayLabel(rainiest))
+		if rainiest.Rain >= 0.1 {
+			text += fmt.Sprintf(" (≈ %.1f мм)", rainiest.Rain)
+		}
+		text += "\n"
+	}
+
+	text += "\n📅 По дням:\n"
+	for _, day := range days {
+		line := fmt.Sprintf("• %s: %s…%s", cityDayLabel(day), formatTemp(day.Min, units), formatTemp(day.Max, units))
+		switch {
+		case day.Rain >= 0.1:
+			line += fmt.Sprintf(", ☔ %.1f мм", day.Rain)
+		case day.Wet > 0:
+			line += ", ☔ слабые осадки"
+		}
+		text += line + "\n"
+	}
+	return text + "\nНаблюдения записываются, пока на город есть подписки; осадки — оценка по часовым сводкам."
+}
+
+// Подпись дня: "пн 13.10"
+func cityDayLabel(day *CityDay) string {
+	date, err := time.Parse("2006-01-02", day.Date)
+	if err != nil {
+		return day.Date
+	}
+	return weekdayShortName(date.Weekday()) + " " + date.Format("02.01")
+}
+
+// Статистика по городу для /citystats
+func getCityStats(city, units string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+	observations, ok := botsObservations(point.Lat, point.Lon)
+	if !ok || len(observations.Samples) == 0 {
+		return fmt.Sprintf("📊 %s: у бота пока нет наблюдений — статистика собирается только для городов с подписками (/subscribe).",
+			point.DisplayName()), nil
+	}
+	return formatCityStats(observations.City, cityDays(observations.Samples), units), nil
+}
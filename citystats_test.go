@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCityDays(t *testing.T) {
+	local := time.FixedZone("", 3*3600)
+	day := time.Date(2026, 10, 15, 0, 0, 0, 0, local)
+	samples := []ObservationSample{
+		{Time: day.Add(-time.Hour), Temp: 4},
+		{Time: day.Add(8 * time.Hour), Temp: 3, Condition: 800},
+		// Дождь 2 мм/ч: через полчаса после прошлого наблюдения считаем за полчаса
+		{Time: day.Add(8*time.Hour + 30*time.Minute), Temp: 12, Rain: 2, Condition: 500},
+		{Time: day.Add(12 * time.Hour), Temp: -1, Rain: 2, Condition: 501},
+	}
+
+	days := cityDays(samples)
+	if len(days) != 2 || days[0].Date != "2026-10-14" || days[1].Date != "2026-10-15" {
+		t.Fatalf("дни %+v", days)
+	}
+	if last := days[1]; last.Min != -1 || last.Max != 12 || last.Count != 3 || last.Wet != 2 || last.Rain != 3 {
+		t.Errorf("последний день %+v", *last)
+	}
+}
+
+func TestFormatCityStats(t *testing.T) {
+	var days []*CityDay
+	for _, day := range []CityDay{
+		{Date: "2026-10-09", Min: 1, Max: 7, Rain: 0.6, Wet: 2},
+		{Date: "2026-10-10", Min: 4, Max: 12},
+		{Date: "2026-10-11", Min: 2, Max: 8, Rain: 5.4, Wet: 6},
+		{Date: "2026-10-12", Min: -2, Max: 5},
+		{Date: "2026-10-13", Min: 0, Max: 6, Wet: 1},
+		{Date: "2026-10-14", Min: 1, Max: 10},
+		{Date: "2026-10-15", Min: 3, Max: 11},
+	} {
+		day := day
+		days = append(days, &day)
+	}
+
+	text := formatCityStats("Казань, RU", days, unitsMetric)
+	for _, want := range []string{
+		"📊 Казань, RU за неделю (пт 09.10–чт 15.10), по наблюдениям бота:",
+		"🌡 Минимум -2°C (пн 12.10), максимум 12°C (сб 10.10)",
+		"🌧 Осадки были 3 дня из 7, больше всего — вс 11.10 (≈ 5.4 мм)",
+		"• вт 13.10: 0°C…6°C, ☔ слабые осадки",
+		"• пт 09.10: 1°C…7°C, ☔ 0.6 мм",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в сводке %q нет %q", text, want)
+		}
+	}
+}
+
+func TestCityStatsCommand(t *testing.T) {
+	f := newFakeTelegram(t)
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+	clock := useManualClock(t, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+
+	f.send(textUpdate(4281, "/citystats Казань"))
+	if reply := f.reply(t, 4281); !strings.Contains(reply, "пока нет наблюдений") {
+		t.Errorf("ответ без наблюдений: %q", reply)
+	}
+
+	point, err := geocodeCity("Казань")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Subscribe(AlertSubscription{ChatID: 4281, Kind: alertThunder, City: point.DisplayName(), Lat: point.Lat, Lon: point.Lon}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		recordObservations(store)
+		clock.Advance(2 * time.Hour)
+	}
+
+	f.reset()
+	f.send(textUpdate(4281, "/citystats Казань"))
+	reply := f.reply(t, 4281)
+	if !strings.HasPrefix(reply, "📊 "+point.DisplayName()+" за неделю") || !strings.Contains(reply, "📅 По дням:") {
+		t.Errorf("сводка %q", reply)
+	}
+}
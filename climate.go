@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Базовый период климатической нормы и модель Open-Meteo
+const (
+	climateNormalStart = "1991-01-01"
+	climateNormalEnd   = "2020-12-31"
+	climateModel       = "EC_Earth3P_HR"
+	// Сглаживание нормы: среднее по ±climateWindowDays вокруг даты
+	climateWindowDays = 3
+)
+
+// Ответ климатического API Open-Meteo
+type ClimateResponse struct {
+	Daily struct {
+		Time            []string   `json:"time"`
+		TemperatureMean []*float64 `json:"temperature_2m_mean"`
+	} `json:"daily"`
+}
+
+// Структура для кэширования климатических норм по координатам.
+// Нормы не меняются, поэтому храним их без срока годности
+type ClimateCache struct {
+	data map[string][365]float64
+	mu   sync.RWMutex
+}
+
+var climateCache = &ClimateCache{
+	data: make(map[string][365]float64),
+}
+
+var climateClient = &http.Client{Timeout: 15 * time.Second}
+
+// Ключ кэша: координаты с точностью до 0.1° (~10 км)
+func climateKey(lat, lon float64) string {
+	return fmt.Sprintf("%.1f,%.1f", lat, lon)
+}
+
+// Получение сглаженных среднесуточных норм по дням года
+func getClimateNormals(lat, lon float64) ([365]float64, error) {
+	key := climateKey(lat, lon)
+
+	climateCache.mu.RLock()
+	normals, ok := climateCache.data[key]
+	climateCache.mu.RUnlock()
+	if ok {
+		return normals, nil
+	}
+
+	url := fmt.Sprintf(
+		"https://climate-api.open-meteo.com/v1/climate?latitude=%.1f&longitude=%.1f&start_date=%s&end_date=%s&models=%s&daily=temperature_2m_mean",
+		lat,
+		lon,
+		climateNormalStart,
+		climateNormalEnd,
+		climateModel,
+	)
+
+	resp, err := climateClient.Get(url)
+	if err != nil {
+		return normals, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return normals, fmt.Errorf("ошибка климатического API: статус %d", resp.StatusCode)
+	}
+
+	var data ClimateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return normals, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	// Суммируем значения по дню года (29 февраля считаем вместе с 28-м)
+	var sums [365]float64
+	var counts [365]int
+	for i, day := range data.Daily.Time {
+		if i >= len(data.Daily.TemperatureMean) || data.Daily.TemperatureMean[i] == nil {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		idx := dayOfYearIndex(t)
+		sums[idx] += *data.Daily.TemperatureMean[i]
+		counts[idx]++
+	}
+
+	// Сглаживаем по окну вокруг каждой даты, чтобы норма не скакала день ото дня
+	for idx := range normals {
+		var sum float64
+		var count int
+		for offset := -climateWindowDays; offset <= climateWindowDays; offset++ {
+			j := (idx + offset + 365) % 365
+			sum += sums[j]
+			count += counts[j]
+		}
+		if count == 0 {
+			return normals, fmt.Errorf("нет климатических данных для этих координат")
+		}
+		normals[idx] = sum / float64(count)
+	}
+
+	climateCache.mu.Lock()
+	climateCache.data[key] = normals
+	climateCache.mu.Unlock()
+
+	return normals, nil
+}
+
+// Индекс дня года без учета високосного дня (0..364)
+func dayOfYearIndex(t time.Time) int {
+	day := t.YearDay() - 1
+	if isLeapYear(t.Year()) && day >= 59 {
+		day--
+	}
+	return day
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// Строка сравнения текущей температуры с климатической нормой для даты
+// (шаблон "climate.note")
+func climateComparison(lat, lon, temp float64, date time.Time, prefs UserPreferences) (string, error) {
+	normals, err := getClimateNormals(lat, lon)
+	if err != nil {
+		return "", err
+	}
+
+	return renderTemplate("climate.note", prefs, compareTemp(temp, normals[dayOfYearIndex(date)]))
+}
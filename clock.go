@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Источник текущего времени. Кэши и планировщик оповещений берут время
+// отсюда, а не из time.Now, чтобы тесты и пробный прогон (-dry-run)
+// могли управлять им сами
+type Clock interface {
+	Now() time.Time
+}
+
+// Системные часы
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Часы, которые стоят на месте, пока их не переведут
+type manualClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{now: now}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Перевод часов вперед на d
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Перевод часов на момент now
+func (c *manualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Текущие часы; меняются через setClock
+var (
+	clock   Clock = systemClock{}
+	clockMu sync.RWMutex
+)
+
+func setClock(c Clock) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	clock = c
+}
+
+// Текущее время по текущим часам
+func clockNow() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock.Now()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Подмена часов на время теста
+func useManualClock(t *testing.T, now time.Time) *manualClock {
+	t.Helper()
+	c := newManualClock(now)
+	setClock(c)
+	t.Cleanup(func() { setClock(systemClock{}) })
+	return c
+}
+
+func TestWeatherCacheExpiry(t *testing.T) {
+	c := useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	cache := &WeatherCache{data: make(map[string]CacheItem)}
+
+	cache.Set("Москва|ru", &CurrentWeather{City: "Москва"})
+	c.Advance(config().CacheTTL)
+	if _, ok := cache.Get("москва|ru"); !ok {
+		t.Error("запись устарела раньше CACHE_TTL")
+	}
+	c.Advance(time.Second)
+	if _, ok := cache.Get("москва|ru"); ok {
+		t.Error("запись не устарела после CACHE_TTL")
+	}
+}
+
+func TestSimulateSchedule(t *testing.T) {
+	previousStore := store
+	var err error
+	store, err = openStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	t.Cleanup(func() { store = previousStore })
+
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	// Долгота 37.6 — в демо-режиме это UTC+3
+	if err := store.Subscribe(AlertSubscription{ChatID: 1, Kind: alertDaily, City: "Москва", Lat: 55.75, Lon: 37.62}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	post := GroupPost{City: "Москва", Lat: 55.75, Lon: 37.62, Hour: 8, Minute: 30, TZOffset: 3 * 3600}
+	if err := store.SetGroupPost(-100, post); err != nil {
+		t.Fatalf("SetGroupPost: %v", err)
+	}
+
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.FixedZone("", 3*3600))
+	simClock := useManualClock(t, start)
+	sender := &dryRunSender{}
+	simulateSchedule(sender, store, simClock, start, 48*time.Hour)
+
+	// За двое суток — по утренней сводке и по публикации в группе в день
+	if sender.sent != 4 {
+		t.Errorf("сообщений %d, ожидалось 4", sender.sent)
+	}
+
+	sub := store.ChatSubscriptions(1)[0]
+	if got := sub.LastFired.In(start.Location()); got.Day() != 18 || got.Hour() != 7 {
+		t.Errorf("последняя сводка в %s, ожидалось 18-го в 7 часов", got)
+	}
+	last, _ := store.GroupPost(-100)
+	if got := last.LastPosted.In(start.Location()); got.Day() != 18 || got.Format("15:04") != "08:30" {
+		t.Errorf("последняя публикация в %s, ожидалось 18-го в 08:30", got)
+	}
+	if !strings.HasPrefix(simClock.Now().Format(time.RFC3339), "2026-10-18T23:59") {
+		t.Errorf("прогон закончился в %s", simClock.Now())
+	}
+}
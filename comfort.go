@@ -0,0 +1,86 @@
+package main
+
+import "math"
+
+// Коэффициенты формулы Магнуса для точки росы
+const (
+	magnusA = 17.27
+	magnusB = 237.7
+)
+
+// Точка росы по температуре (°C) и относительной влажности (%)
+func dewPoint(temp float64, humidity int) float64 {
+	if humidity <= 0 {
+		humidity = 1
+	}
+	gamma := magnusA*temp/(magnusB+temp) + math.Log(float64(humidity)/100)
+	return magnusB * gamma / (magnusA - gamma)
+}
+
+// Категории ощущения влажности
+const (
+	comfortMuggy = "muggy"
+	comfortHumid = "humid"
+	comfortRaw   = "raw"
+	comfortDry   = "dry"
+	comfortOK    = "ok"
+)
+
+var comfortTitles = map[string]map[string]string{
+	langRU: {
+		comfortMuggy: "мерзко-влажно, душно",
+		comfortHumid: "влажно",
+		comfortRaw:   "мерзко-влажно, промозгло",
+		comfortDry:   "сухо",
+		comfortOK:    "комфортно",
+	},
+	langEN: {
+		comfortMuggy: "sticky and muggy",
+		comfortHumid: "humid",
+		comfortRaw:   "damp and raw",
+		comfortDry:   "dry",
+		comfortOK:    "comfortable",
+	},
+}
+
+// Категория ощущения влажности: точка росы показывает ее лучше,
+// чем относительная влажность
+func humidityComfortLevel(temp float64, humidity int) string {
+	dew := dewPoint(temp, humidity)
+	switch {
+	case dew >= 21:
+		return comfortMuggy
+	case dew >= 16:
+		return comfortHumid
+	case temp <= 5 && humidity >= 85:
+		return comfortRaw
+	case humidity < 30:
+		return comfortDry
+	default:
+		return comfortOK
+	}
+}
+
+// Ощущение влажности словами на языке пользователя
+func humidityComfort(temp float64, humidity int, lang string) string {
+	titles, ok := comfortTitles[lang]
+	if !ok {
+		titles = comfortTitles[langRU]
+	}
+	return titles[humidityComfortLevel(temp, humidity)]
+}
+
+// Humidex — канадский индекс ощущаемой жары по температуре и точке росы, °C
+func humidex(temp float64, humidity int) float64 {
+	dew := dewPoint(temp, humidity) + 273.15
+	vapor := 6.11 * math.Exp(5417.7530*(1/273.16-1/dew))
+	return temp + 0.5555*(vapor-10)
+}
+
+// Упрощенный WBGT (температура влажного термометра с шаровым термометром)
+// по формуле Австралийского бюро метеорологии: только температура и
+// влажность, для тени и умеренного ветра; на открытом солнце выше на 2–3°
+func wbgt(temp float64, humidity int) float64 {
+	vapor := float64(humidity) / 100 * 6.105 * math.Exp(magnusA*temp/(magnusB+temp))
+	return 0.567*temp + 0.393*vapor + 3.94
+}
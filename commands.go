@@ -0,0 +1,1021 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func init() {
+	commands.Handle("/start", handleStartCommand)
+	commands.Handle("/help", handleHelpCommand)
+	commands.Handle("/forecast", handleForecastCommand, "/f")
+	commands.Handle("/recent", handleRecentCommand)
+	commands.Handle("/ical", handleICalCommand)
+	commands.Handle("/export", handleExportCommand)
+	commands.Handle("/settings", groupAdminOnly(handleSettingsCommand), "/prefs")
+	commands.Handle("/subscribe", groupAdminOnly(handleSubscribeCommand), "/alerts")
+	commands.Handle("/cancel", handleCancelCommand)
+	commands.Handle("/dashboard", handleDashboardCommand)
+	commands.Handle("/premium", handlePremiumCommand)
+	commands.Handle("/nowcast", handleNowcastCommand)
+	commands.Handle("/donate", handleDonateCommand)
+	commands.Handle("/invite", handleInviteCommand)
+	commands.Handle("/feedback", handleFeedbackCommand, "/bug")
+	commands.Handle("/about", handleAboutCommand, "/version")
+	commands.Handle("/mydata", handleMyDataCommand)
+	commands.Handle("/forgetme", groupAdminOnly(handleForgetMeCommand))
+	commands.Handle("/daily", groupAdminOnly(handleDailyCommand))
+	commands.Handle("/commute", groupAdminOnly(handleCommuteCommand))
+	commands.Handle("/grouppost", groupAdminOnly(handleGroupPostCommand))
+	commands.Handle("/webhook", groupAdminOnly(handleWebhookCommand), "/webhooks")
+	commands.Handle("/place", handlePlaceCommand, "/places")
+	commands.Handle("/event", groupAdminOnly(handleEventCommand), "/events")
+	commands.Handle("/route", handleRouteCommand)
+	commands.Handle("/run", handleRunCommand, "/bike")
+	commands.Handle("/hike", handleHikeCommand)
+	commands.Handle("/laundry", handleLaundryCommand)
+	commands.Handle("/beachday", handleBeachdayCommand, "/beach")
+	commands.Handle("/drone", handleDroneCommand)
+	commands.Handle("/aurora", groupAdminOnly(handleAuroraCommand))
+	commands.Handle("/thunder", groupAdminOnly(handleThunderCommand))
+	commands.Handle("/sunset", groupAdminOnly(handleSunsetCommand))
+	commands.Handle("/voice", handleVoiceCommand)
+	commands.Handle("/heatwave", groupAdminOnly(handleHeatwaveCommand))
+	commands.Handle("/heatstress", groupAdminOnly(handleHeatStressCommand))
+	commands.Handle("/hazards", groupAdminOnly(handleHazardsCommand))
+	commands.Handle("/smoke", groupAdminOnly(handleSmokeCommand))
+	commands.Handle("/flood", groupAdminOnly(handleFloodCommand))
+	commands.Handle("/ski", handleSkiCommand)
+	commands.Handle("/metar", handleMetarCommand, "/taf")
+	commands.Handle("/flight", handleFlightCommand)
+	commands.Handle("/sea", handleSeaCommand)
+	commands.Handle("/fishing", handleFishingCommand)
+	commands.Handle("/pressure", groupAdminOnly(handlePressureCommand))
+	commands.Handle("/solar", handleSolarCommand)
+	commands.Handle("/citystats", handleCityStatsCommand)
+	commands.Handle("/report", handleReportCommand)
+	commands.Handle("/sky", handleSkyCommand)
+	commands.Handle("/missed", handleMissedCommand)
+
+	// Команды администраторов
+	commands.Handle("/reload", adminOnly(handleReloadCommand))
+	commands.Handle("/features", adminOnly(handleFeaturesCommand))
+	commands.Handle("/ban", adminOnly(handleBanCommand))
+	commands.Handle("/unban", adminOnly(handleUnbanCommand))
+	commands.Handle("/skymod", adminOnly(handleSkyModCommand))
+	commands.Handle("/stats", adminOnly(handleStatsCommand))
+	commands.Handle("/donations", adminOnly(handleDonationsCommand))
+	commands.Handle("/backup", adminOnly(handleBackupCommand))
+	commands.Handle("/restore", adminOnly(handleRestoreCommand))
+
+	commands.HandleText(handleTextMessage)
+	commands.NotFound(handleUnknownCommand)
+}
+
+// /start: ссылки с параметром, знакомство с ботом для новых пользователей, затем справка
+func handleStartCommand(c *commandContext) {
+	// Ссылки вида t.me/bot?start=sub_daily сразу ведут к оформлению подписки
+	reply, handled, err := handleStartSubscription(
+		c.bot.store,
+		c.args,
+		c.message.Chat.ID,
+		c.bot.lastCity[c.message.Chat.ID],
+	)
+	if handled {
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+		return
+	}
+
+	// Пригласительная ссылка t.me/bot?start=ref_<id>: засчитываем приглашение
+	// и дальше обрабатываем как обычный /start
+	payload := c.args
+	if referrer, ok := startPayloadReferrer(payload); ok {
+		payload = ""
+		credited, err := c.bot.store.AddReferral(c.message.Chat.ID, referrer)
+		if err != nil {
+			log.Printf("Ошибка сохранения приглашения: %v", err)
+		}
+		if credited {
+			notice := tgbotapi.NewMessage(referrer, "🤝 По вашей ссылке пришел новый пользователь. Спасибо!")
+			if _, err := c.bot.Send(notice); err != nil {
+				log.Printf("Ошибка отправки сообщения: %v", err)
+			}
+		}
+	}
+
+	// Нового пользователя проводим через короткую настройку
+	if payload == "" && !c.bot.store.HasPreferences(c.message.Chat.ID) {
+		reply, err := startOnboarding(c.bot.store, c.message.Chat.ID)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg = reply
+		}
+		return
+	}
+
+	handleHelpCommand(c)
+}
+
+// /help
+func handleHelpCommand(c *commandContext) {
+	c.msg.Text = "Привет! Я бот погоды. 🌤\n\n" +
+		"Вы можете:\n" +
+		"• Написать название города для получения текущей погоды\n" +
+		"• Нажать кнопку 'Прогноз на 5 дней' для получения прогноза\n" +
+		"• Отправить своё местоположение для погоды в вашей точке\n" +
+		"• Надиктовать запрос голосовым сообщением\n\n" +
+		"Команды:\n" +
+		"/start - Информация о боте\n" +
+		"/help - Показать эту справку\n" +
+		"/forecast - Прогноз на 5 дней для последнего запрошенного города\n" +
+		"/recent - Недавние города с кнопками для повторного запроса\n" +
+		"/voice [город|on|off] - Погода голосовым сообщением\n" +
+		"/ical [город] - Прогноз файлом для календаря\n" +
+		"/export [город] csv|json - Прогноз файлом с данными по интервалам\n" +
+		"/settings - Единицы, язык, домашний город и уведомления\n" +
+		"/subscribe - Пошаговая настройка оповещений\n" +
+		"/dashboard - Панель с графиком прогноза и картой\n" +
+		"/nowcast [город] - Осадки на 2 часа по 15 минут (премиум)\n" +
+		"/premium - Премиум за звезды Telegram\n" +
+		"/donate [сумма] - Поддержать бота звездами Telegram\n" +
+		"/invite - Пригласительная ссылка и рейтинг приглашений\n" +
+		"/feedback [текст] - Сообщить об ошибке или попросить добавить город\n" +
+		"/about - Версия бота и источники данных\n" +
+		"/mydata - Скачать все, что бот о вас хранит (/forgetme - удалить)\n" +
+		"/daily [город|blocks|time|off] - Утренняя сводка погоды, blocks — выбрать ее блоки, time 7:00 9:30 — время в будни и выходные\n" +
+		"/commute [город] [8:15 18:30|off] - Советы для дороги на работу: велосипед или автобус, зонт\n" +
+		"/grouppost 8:30 [город] - Ежедневная сводка в группе (для администраторов группы)\n" +
+		"/webhook [add <адрес>|del N|test] - JSON на ваш адрес при каждом оповещении (IFTTT, Home Assistant)\n" +
+		"/place [add|del] Дача - Погода в сохраненных местах по названию или кнопкой\n" +
+		"/event 2025-07-12 18:00 Казань - Прогноз к событию за неделю, 3 дня, сутки и 3 часа\n" +
+		"/route Москва - Воронеж - Погода по маршруту между городами\n" +
+		"/run [город] - Когда лучше выйти на пробежку в ближайшие 12 часов\n" +
+		"/hike [город] - Лучшее сухое светлое окно для похода в ближайшие 48 часов\n" +
+		"/laundry [город] - Быстро ли высохнет белье на улице\n" +
+		"/beachday [город] - Какой день выходных лучше для пляжа или шашлыков\n" +
+		"/drone [город] - Можно ли сегодня запускать дрон\n" +
+		"/aurora [город|off] - Подписка на оповещения о полярном сиянии\n" +
+		"/thunder [город|off] - Предупреждения о грозе в ближайшие часы\n" +
+		"/sunset [город|off] - Оповещение за 40 минут до красивого заката\n" +
+		"/heatwave [город|off] - Предупреждения о затяжной жаре и сильных морозах\n" +
+		"/heatstress [город] [уровень|off] - Тепловой стресс (WBGT) для работы и тренировок на улице\n" +
+		"/hazards [город|off] - Оповещения о значимых землетрясениях рядом с городом\n" +
+		"/smoke [город|off] - Предупреждения о дыме от лесных пожаров\n" +
+		"/flood [город] [%|off] - Предупреждения о подъеме воды в реке у города\n" +
+		"/ski <курорт> - Снег, температура и ветер на горнолыжном курорте\n" +
+		"/metar <ICAO> - Авиационная погода аэропорта (METAR и TAF с расшифровкой)\n" +
+		"/flight SVO LHR 2025-06-02 [14:30] - Погода в аэропортах вылета и прилета в день перелета\n" +
+		"/sea [город] - Температура воды, волны и ветер у моря\n" +
+		"/fishing [город] - Прогноз клева на ближайшие дни\n" +
+		"/pressure [город] [гПа|off] - Оповещения о резких перепадах давления\n" +
+		"/solar [город] - Выработка солнечных панелей сегодня и завтра (/solar on|off - утренние оценки)\n" +
+		"/citystats [город] - Погода в городе за неделю по наблюдениям бота\n" +
+		"/report дождь [город] - Сообщить, какая погода за окном (можно с фото); /report - что сообщают другие\n" +
+		"/sky [город] - Последние фото неба от пользователей\n" +
+		"/missed - Оповещения, которые бот не смог доставить"
+
+	// Добавляем кнопку для отправки геолокации
+	locationButton := tgbotapi.NewKeyboardButtonLocation("📍 Отправить местоположение")
+	c.msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(locationButton),
+	)
+}
+
+// /forecast
+func handleForecastCommand(c *commandContext) {
+	// Проверяем, был ли у пользователя последний запрос города
+	city, exists := commandCity(c.bot, c.message)
+	if !exists {
+		c.msg.Text = "Пожалуйста, сначала запросите погоду для какого-либо города."
+	} else {
+		prefs := c.bot.store.Preferences(c.message.Chat.ID)
+		forecast, err := getForecast(city, prefs)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = forecast
+			c.msg.ParseMode = replyParseMode(prefs)
+		}
+	}
+}
+
+// /settings
+func handleSettingsCommand(c *commandContext) {
+	text, markup := settingsView(c.bot.store, c.message.Chat.ID, settingsMenu, c.bot.lastCity[c.message.Chat.ID])
+	c.msg.Text = text
+	c.msg.ReplyMarkup = markup
+}
+
+// /premium
+func handlePremiumCommand(c *commandContext) {
+	c.msg.Text, c.invoice = premiumOffer(c.bot.store, c.message.Chat.ID)
+}
+
+// /donate
+func handleDonateCommand(c *commandContext) {
+	amount, err := parseDonateAmount(c.args)
+	switch {
+	case err != nil:
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	case amount == 0:
+		c.msg.Text = "💙 Бот бесплатный, но запросы к API погоды стоят денег. " +
+			"Если хотите поддержать проект, выберите сумму в звездах или укажите свою: /donate 250"
+		c.msg.ReplyMarkup = donateKeyboard(c.bot.store)
+	default:
+		c.msg.Text = fmt.Sprintf("Спасибо! Счет на %d ⭐️ ниже.", amount)
+		donation := donateInvoice(c.message.Chat.ID, amount)
+		c.invoice = &donation
+	}
+}
+
+// /invite
+func handleInviteCommand(c *commandContext) {
+	if err := c.bot.store.SetReferrerName(c.message.Chat.ID, payerName(c.message.From)); err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+	c.msg.Text = getInviteInfo(c.bot.store, c.message.Chat.ID, c.bot.Self.UserName)
+	c.msg.DisableWebPagePreview = true
+}
+
+// /feedback
+func handleFeedbackCommand(c *commandContext) {
+	text := strings.TrimSpace(c.args)
+	if text == "" {
+		reply, err := startDialog(c.bot.store, c.message.Chat.ID, flowFeedback, map[string]string{
+			"name": payerName(c.message.From),
+		})
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg = reply
+		}
+		return
+	}
+
+	reply, err := submitFeedback(c.bot, c.message.Chat.ID, payerName(c.message.From), text)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		c.msg.Text = reply
+	}
+}
+
+// /about
+func handleAboutCommand(c *commandContext) {
+	c.msg.Text = getAboutInfo()
+	c.msg.DisableWebPagePreview = true
+}
+
+// /reload
+func handleReloadCommand(c *commandContext) {
+	if err := reloadConfig(os.Args[1:]); err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		c.msg.Text = "✅ Настройки перечитаны."
+	}
+}
+
+// /features
+func handleFeaturesCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	if args := strings.TrimSpace(c.args); args != "" {
+		id, err := strconv.ParseInt(args, 10, 64)
+		if err != nil {
+			c.msg.Text = "Укажите ID чата, например: /features 123456789"
+			return
+		}
+		chatID = id
+	}
+	c.msg.Text = featuresReport(chatID)
+}
+
+// /ban
+func handleBanCommand(c *commandContext) {
+	userID, reason, err := parseBanArgs(c.args)
+	switch {
+	case err != nil:
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	case isAdmin(userID):
+		c.msg.Text = "Администратора заблокировать нельзя."
+	default:
+		if err := c.bot.store.Ban(userID, reason); err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = fmt.Sprintf("🚫 Пользователь %d заблокирован.", userID)
+		}
+	}
+}
+
+// /unban
+func handleUnbanCommand(c *commandContext) {
+	userID, _, err := parseBanArgs(c.args)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	removed, err := c.bot.store.Unban(userID)
+	switch {
+	case err != nil:
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	case removed:
+		c.msg.Text = fmt.Sprintf("✅ Пользователь %d разблокирован.", userID)
+	default:
+		c.msg.Text = fmt.Sprintf("Пользователь %d не заблокирован.", userID)
+	}
+}
+
+// /stats
+func handleStatsCommand(c *commandContext) {
+	c.msg.Text = botMetrics.Report()
+}
+
+// /donations
+func handleDonationsCommand(c *commandContext) {
+	c.msg.Text = donationsReport(c.bot.store)
+}
+
+// /nowcast
+func handleNowcastCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /nowcast Москва"
+	} else {
+		nowcast, err := getNowcast(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = nowcast
+		}
+	}
+}
+
+// /dashboard
+func handleDashboardCommand(c *commandContext) {
+	markup, ok := webAppKeyboard()
+	if !ok || !featureEnabled(featureDashboard, c.message.Chat.ID) {
+		c.msg.Text = "Панель погоды не настроена."
+	} else {
+		c.msg.Text = "📊 Панель с графиком прогноза, картой и настройками:"
+		c.msg.ReplyMarkup = markup
+	}
+}
+
+// /subscribe
+func handleSubscribeCommand(c *commandContext) {
+	reply, err := startDialog(c.bot.store, c.message.Chat.ID, flowSubscribe, map[string]string{
+		"last_city": c.bot.lastCity[c.message.Chat.ID],
+	})
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		c.msg = reply
+	}
+}
+
+// /cancel
+func handleCancelCommand(c *commandContext) {
+	if c.dialogCancelled {
+		c.msg.Text = "Хорошо, отменил."
+	} else {
+		c.msg.Text = "Сейчас нечего отменять."
+	}
+	c.msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+}
+
+// /daily
+func handleDailyCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "blocks" {
+		showDigestBlocks(c)
+		return
+	}
+	if fields := strings.Fields(c.args); len(fields) > 0 && fields[0] == "time" {
+		reply, err := setDigestTime(c.bot.store, c.message.Chat.ID, fields[1:])
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+		return
+	}
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertDaily)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Утренняя сводка отключена."
+		default:
+			c.msg.Text = "Вы не подписаны на утреннюю сводку."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /daily Москва"
+	} else {
+		reply, err := subscribeDaily(c.bot.store, c.message.Chat.ID, city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /commute
+func handleCommuteCommand(c *commandContext) {
+	args := strings.TrimSpace(c.args)
+	if args == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertCommute)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Сводка для дороги на работу отключена."
+		default:
+			c.msg.Text = "Вы не подписаны на сводку для дороги на работу."
+		}
+		return
+	}
+
+	city, leaveHome, leaveWork, err := parseCommuteArgs(args)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+	if city == "" {
+		c.msg.Text = "Укажите город и время выхода из дома и с работы, например: /commute Москва 8:15 18:30"
+	} else {
+		reply, err := subscribeCommute(c.bot.store, c.message.Chat.ID, city, leaveHome, leaveWork)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /route
+func handleRouteCommand(c *commandContext) {
+	origin, destination, ok := parseRouteArgs(c.args)
+	if !ok {
+		c.msg.Text = "Укажите начало и конец маршрута, например: /route Москва - Воронеж"
+	} else {
+		routeWeather, err := getRouteWeather(origin, destination)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = routeWeather
+		}
+	}
+}
+
+// /run
+func handleRunCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /run Москва"
+	} else {
+		runConditions, err := getRunConditions(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = runConditions
+		}
+	}
+}
+
+// /hike
+func handleHikeCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /hike Шерегеш"
+	} else {
+		hikeWindow, err := getHikeWindow(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = hikeWindow
+		}
+	}
+}
+
+// /laundry
+func handleLaundryCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /laundry Москва"
+	} else {
+		laundry, err := getLaundryIndex(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = laundry
+		}
+	}
+}
+
+// /beachday
+func handleBeachdayCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /beachday Сочи"
+	} else {
+		beachDay, err := getBeachDay(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = beachDay
+		}
+	}
+}
+
+// /drone
+func handleDroneCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /drone Москва"
+	} else {
+		drone, err := getDroneConditions(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = drone
+		}
+	}
+}
+
+// /aurora
+func handleAuroraCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertAurora)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на полярное сияние отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на полярное сияние."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /aurora Мурманск"
+	} else {
+		reply, err := subscribeAurora(c.bot.store, c.message.Chat.ID, city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /thunder
+func handleThunderCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertThunder)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на предупреждения о грозах отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на предупреждения о грозах."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /thunder Краснодар"
+	} else {
+		reply, err := subscribeThunder(c.bot.store, c.message.Chat.ID, city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /heatwave
+func handleHeatwaveCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertHeatwave)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на предупреждения о жаре и морозах отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на предупреждения о жаре и морозах."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /heatwave Волгоград"
+	} else {
+		reply, err := subscribeHeatwave(c.bot.store, c.message.Chat.ID, city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /hazards
+func handleHazardsCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertHazards)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на природные опасности отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на природные опасности."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /hazards Петропавловск-Камчатский"
+	} else {
+		reply, err := subscribeHazards(c.bot.store, c.message.Chat.ID, city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /smoke
+func handleSmokeCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertSmoke)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на предупреждения о дыме отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на предупреждения о дыме."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /smoke Красноярск"
+	} else {
+		reply, err := subscribeSmoke(c.bot.store, c.message.Chat.ID, city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /flood
+func handleFloodCommand(c *commandContext) {
+	args := strings.TrimSpace(c.args)
+	if args == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertFlood)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на подъем воды отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на подъем воды."
+		}
+		return
+	}
+
+	city, threshold := parseFloodArgs(args)
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+	if city == "" {
+		c.msg.Text = "Укажите город и, при желании, порог роста в процентах, например: /flood Барнаул 80"
+	} else {
+		reply, err := subscribeFlood(c.bot.store, c.message.Chat.ID, city, threshold)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /ski
+func handleSkiCommand(c *commandContext) {
+	resort := strings.TrimSpace(c.args)
+	if resort == "" {
+		c.msg.Text = "Укажите курорт, например: /ski Шерегеш"
+	} else {
+		ski, err := getSkiConditions(resort)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = ski
+		}
+	}
+}
+
+// /metar
+func handleMetarCommand(c *commandContext) {
+	code := strings.TrimSpace(c.args)
+	if code == "" {
+		c.msg.Text = "Укажите код аэропорта ICAO, например: /metar UUEE"
+	} else {
+		airport, err := getAirportWeather(code)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = airport
+		}
+	}
+}
+
+// /flight
+func handleFlightCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "" {
+		c.msg.Text = "Укажите аэропорты вылета и прилета и дату, например: /flight SVO LHR 2025-06-02 (можно добавить время вылета: 14:30)"
+	} else {
+		flight, err := getFlightWeather(c.args, c.bot.store.Preferences(c.message.Chat.ID).Units)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = flight
+		}
+	}
+}
+
+// /citystats
+func handleCityStatsCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /citystats Москва"
+	} else {
+		stats, err := getCityStats(city, c.bot.store.Preferences(c.message.Chat.ID).Units)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = stats
+		}
+	}
+}
+
+// /sea
+func handleSeaCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /sea Сочи"
+	} else {
+		sea, err := getSeaConditions(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = sea
+		}
+	}
+}
+
+// /fishing
+func handleFishingCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /fishing Астрахань"
+	} else {
+		fishing, err := getFishingIndex(city)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = fishing
+		}
+	}
+}
+
+// /pressure
+func handlePressureCommand(c *commandContext) {
+	args := strings.TrimSpace(c.args)
+	if args == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertPressure)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на перепады давления отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на перепады давления."
+		}
+		return
+	}
+
+	city, threshold := parsePressureArgs(args)
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+	if city == "" {
+		c.msg.Text = "Укажите город и, при желании, порог в гПа, например: /pressure Москва 6"
+	} else {
+		reply, err := subscribePressure(c.bot.store, c.message.Chat.ID, city, threshold)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /heatstress
+func handleHeatStressCommand(c *commandContext) {
+	args := strings.TrimSpace(c.args)
+	if args == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertHeatStress)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на предупреждения о тепловом стрессе отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на предупреждения о тепловом стрессе."
+		}
+		return
+	}
+
+	city, level := parseHeatStressArgs(args)
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+	if city == "" {
+		c.msg.Text = "Укажите город и, при желании, уровень риска (умеренный, высокий, опасный, экстремальный), например: /heatstress Краснодар опасный"
+	} else {
+		reply, err := subscribeHeatStress(c.bot.store, c.message.Chat.ID, city, level)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		} else {
+			c.msg.Text = reply
+		}
+	}
+}
+
+// /solar
+func handleSolarCommand(c *commandContext) {
+	args := strings.Fields(c.args)
+
+	// Оценку может запросить любой, а подписку в группе меняют администраторы
+	if len(args) > 0 && (args[0] == "on" || args[0] == "off") && !canManageChatMessage(c.bot, c.message) {
+		c.msg.Text = groupAdminOnlyText
+		return
+	}
+
+	if len(args) > 0 && args[0] == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertSolar)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Утренние оценки выработки отключены."
+		default:
+			c.msg.Text = "Вы не подписаны на утренние оценки выработки."
+		}
+		return
+	}
+
+	subscribe := len(args) > 0 && args[0] == "on"
+	if subscribe {
+		args = args[1:]
+	}
+
+	city := strings.Join(args, " ")
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+
+	var reply string
+	var err error
+	switch {
+	case city == "":
+		reply = "Укажите город, например: /solar Краснодар"
+	case subscribe:
+		reply, err = subscribeSolar(c.bot.store, c.message.Chat.ID, city)
+	default:
+		reply, err = getSolarEstimate(city)
+	}
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		c.msg.Text = reply
+	}
+}
+
+// Обычный текст: запрос обычными словами или название города
+func handleTextMessage(c *commandContext) {
+	if strings.TrimSpace(c.message.Text) == "" {
+		return
+	}
+
+	// Название сохраненного места (в том числе с клавиатуры /place)
+	if replySavedPlace(c) {
+		return
+	}
+
+	// Фразы вроде "погода в Питере завтра вечером" разбираем как запрос
+	query, ok := parseWeatherQuery(c.message.Text, clockNow())
+	if ok && featureEnabled(featureNLQuery, c.message.Chat.ID) {
+		point, err := resolveQueryCity(query.City)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		c.bot.lastCity[c.message.Chat.ID] = point.Name
+
+		// Про текущую погоду отвечаем обычной карточкой
+		if query.DayOffset <= 0 && query.TimeOfDay == "" && query.Metric == "" {
+			c.message.Text = point.Name
+		} else {
+			answer, err := answerWeatherQuery(query, point, c.bot.store.Preferences(c.message.Chat.ID))
+			if err != nil {
+				c.msg.Text = "❌ Ошибка: " + err.Error()
+			} else {
+				c.msg.Text = answer
+			}
+			return
+		}
+	}
+
+	city, err := normalizeCity(c.message.Text)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	prefs := c.bot.store.Preferences(c.message.Chat.ID)
+	data, fetched, pending, err := budgetedWeather(city, prefs.Language)
+	var weatherInfo string
+	if err == nil {
+		weatherInfo, err = weatherCard(city, data, prefs)
+	}
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		// Сохраняем последний запрошенный город и историю для /recent
+		c.bot.lastCity[c.message.Chat.ID] = city
+		if err := c.bot.store.AddRecentCity(c.message.Chat.ID, city); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		c.stickerCity = city
+		if prefs.VoiceReplies && ttsConfigured() {
+			if text, err := speechText(data, prefs); err != nil {
+				log.Printf("Ошибка подготовки голосового ответа: %v", err)
+			} else {
+				c.voiceText = text
+			}
+		}
+
+		c.msg.Text = weatherInfo
+		c.msg.ParseMode = replyParseMode(prefs)
+
+		// Добавляем кнопку для прогноза
+		c.msg.ReplyMarkup = weatherKeyboard(c.bot.store, city, prefs)
+
+		// Источник не успел ответить: показываем данные из кэша и обновляем
+		// сообщение, когда придут свежие
+		if pending != nil {
+			c.msg.Text = staleWeatherNote(fetched) + "\n\n" + weatherInfo
+			chatID := c.message.Chat.ID
+			c.afterSend = func(sent tgbotapi.Message) {
+				go refreshStaleWeather(c.bot, chatID, sent.MessageID, city, weatherInfo, fetched, prefs, pending)
+			}
+		}
+	}
+}
+
+// Неизвестная команда
+func handleUnknownCommand(c *commandContext) {
+	c.msg.Text = "Не знаю команду /" + c.command + ". Список команд: /help"
+}
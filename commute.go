@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Сводка для дороги на работу: погода в момент выхода из дома и с работы
+// и конкретные советы — велосипед или автобус, брать ли зонт, выходить ли
+// раньше из-за снега
+
+// Тип подписки на сводку для дороги на работу
+const alertCommute = "commute"
+
+// Время выхода по умолчанию: из дома и с работы
+const (
+	defaultLeaveHome = 8 * 60
+	defaultLeaveWork = 18 * 60
+)
+
+// Сводка приходит не раньше чем за час и не позже чем за 15 минут до
+// выхода из дома: оповещения проверяются раз в полчаса, окно шире
+const (
+	commuteLeadMax = 60 * time.Minute
+	commuteLeadMin = 15 * time.Minute
+)
+
+// Ниже этой оценки по шкале /run велосипед не советуем
+const commuteBikeMinScore = 6.0
+
+func init() {
+	alertKinds[alertCommute] = alertKind{title: "Дорога на работу", check: checkCommute, cooldown: 20 * time.Hour}
+}
+
+// Время дня из минут от полуночи: "08:15"
+func formatDayMinutes(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// Момент сегодняшнего дня (по местному времени now) из минут от полуночи
+func atDayMinutes(now time.Time, minutes int) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+}
+
+// Интервал прогноза, в который попадает момент t
+func forecastItemAt(forecast *Forecast, t time.Time) (ForecastItem, bool) {
+	for _, item := range forecast.Items {
+		start := forecast.LocalTime(item)
+		if !t.Before(start) && t.Before(start.Add(forecastStep)) {
+			return item, true
+		}
+	}
+	return ForecastItem{}, false
+}
+
+// Проверка подписки: пора ли присылать сводку перед выходом из дома
+func checkCommute(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := cachedForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	now := forecast.Now()
+	leaveHome := atDayMinutes(now, sub.LeaveHome)
+	if lead := leaveHome.Sub(now); lead > commuteLeadMax || lead < commuteLeadMin {
+		return "", false, nil
+	}
+
+	// Ночная смена: с работы уходят уже на следующий день
+	leaveWork := atDayMinutes(now, sub.LeaveWork)
+	if !leaveWork.After(leaveHome) {
+		leaveWork = leaveWork.AddDate(0, 0, 1)
+	}
+
+	text, ok := formatCommute(sub.City, forecast, leaveHome, leaveWork)
+	return text, ok, nil
+}
+
+// Сводка для дороги на работу или false, если в прогнозе нет нужных часов
+func formatCommute(city string, forecast *Forecast, leaveHome, leaveWork time.Time) (string, bool) {
+	morning, ok := forecastItemAt(forecast, leaveHome)
+	if !ok {
+		return "", false
+	}
+	evening, ok := forecastItemAt(forecast, leaveWork)
+	if !ok {
+		return "", false
+	}
+
+	text := fmt.Sprintf("🚦 Дорога на работу в %s, %s:\n", city, leaveHome.Format("02.01"))
+	text += "🏠 Из дома в " + leaveHome.Format("15:04") + ": " + commuteLegLine(morning) + "\n"
+	text += "🏢 С работы в " + leaveWork.Format("15:04") + ": " + commuteLegLine(evening) + "\n\n"
+
+	text += commuteTransportAdvice(morning, evening) + "\n"
+	text += commuteUmbrellaAdvice(morning, evening)
+	if advice := commuteEarlyAdvice(morning); advice != "" {
+		text += "\n" + advice
+	}
+	return text, true
+}
+
+func commuteLegLine(item ForecastItem) string {
+	line := fmt.Sprintf("%+.0f°C", item.Temp)
+	if math.Round(item.FeelsLike) != math.Round(item.Temp) {
+		line += fmt.Sprintf(" (ощущается как %+.0f°C)", item.FeelsLike)
+	}
+	line += fmt.Sprintf(", %s, ветер %.0f м/с", item.Description, item.WindSpeed)
+	if item.Pop >= 0.2 {
+		line += fmt.Sprintf(", осадки %.0f%%", item.Pop*100)
+	}
+	return line
+}
+
+// Скользко ли на дороге: осадки около нуля
+func commuteIcy(item ForecastItem) bool {
+	return item.Temp > -3 && item.Temp < 1 && item.Rain+item.Snow > 0
+}
+
+// Велосипед или общественный транспорт: оценка как в /run для обеих поездок,
+// а в снег, гололед и грозу — всегда автобус
+func commuteTransportAdvice(legs ...ForecastItem) string {
+	var reasons []string
+	seen := make(map[string]bool)
+	addReason := func(reason string) {
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+
+	bike := true
+	for _, item := range legs {
+		score, why := runScore(item, 0)
+		if score < commuteBikeMinScore {
+			bike = false
+			for _, reason := range why {
+				addReason(reason)
+			}
+		}
+		switch {
+		case isThunderstorm(item.Condition):
+			bike = false
+			addReason("гроза")
+		case commuteIcy(item):
+			bike = false
+			addReason("гололед")
+		case item.Snow > 0 || item.Condition/100 == 6:
+			bike = false
+			addReason("снег")
+		}
+	}
+
+	if bike {
+		return "🚲 Можно на велосипеде: сухо и ветер умеренный в обе стороны."
+	}
+	if len(reasons) == 0 {
+		return "🚌 Сегодня лучше автобус или метро."
+	}
+	return "🚌 Сегодня лучше автобус или метро: " + strings.Join(reasons, ", ") + "."
+}
+
+// Зонт нужен, если в одну из поездок вероятен дождь, а не снег
+func commuteUmbrellaAdvice(morning, evening ForecastItem) string {
+	rainy := func(item ForecastItem) bool {
+		return item.Condition/100 != 6 && item.Temp > 0 && (item.Pop >= 0.4 || item.Rain >= 0.2)
+	}
+	switch {
+	case rainy(morning) && rainy(evening):
+		return fmt.Sprintf("☂️ Возьмите зонт: дождь и утром (%.0f%%), и вечером (%.0f%%).", morning.Pop*100, evening.Pop*100)
+	case rainy(morning):
+		return fmt.Sprintf("☂️ Возьмите зонт: утром вероятность дождя %.0f%%.", morning.Pop*100)
+	case rainy(evening):
+		return fmt.Sprintf("☂️ Возьмите зонт: вечером вероятность дождя %.0f%%.", evening.Pop*100)
+	}
+	return "🌂 Зонт не понадобится."
+}
+
+// Совет выйти раньше: в снегопад и гололед дорога занимает больше времени
+func commuteEarlyAdvice(morning ForecastItem) string {
+	switch {
+	case morning.Snow >= 2:
+		return "⏰ Выходите на 20 минут раньше: сильный снегопад, дороги будут стоять."
+	case morning.Snow > 0 || morning.Condition/100 == 6:
+		return "⏰ Выходите на 10 минут раньше из-за снега."
+	case commuteIcy(morning):
+		return "⏰ Выходите на 10 минут раньше: возможен гололед."
+	case morning.Rain >= 4:
+		return "⏰ Выходите на 10 минут раньше: ливень, на дорогах будет медленнее."
+	}
+	return ""
+}
+
+// Разбор аргументов вида "Москва 8:15 18:30": город и необязательное время
+// выхода из дома и с работы в минутах от полуночи
+func parseCommuteArgs(args string) (string, int, int, error) {
+	fields := strings.Fields(args)
+	var times []int
+	for len(fields) > 0 && len(times) < 2 && strings.Contains(fields[len(fields)-1], ":") {
+		hour, minute, err := parsePostTime(fields[len(fields)-1])
+		if err != nil {
+			return "", 0, 0, err
+		}
+		times = append([]int{hour*60 + minute}, times...)
+		fields = fields[:len(fields)-1]
+	}
+
+	city := strings.Join(fields, " ")
+	switch len(times) {
+	case 0:
+		return city, defaultLeaveHome, defaultLeaveWork, nil
+	case 1:
+		return "", 0, 0, fmt.Errorf("укажите два времени: выход из дома и с работы, например 8:15 18:30")
+	}
+	if times[0] == times[1] {
+		return "", 0, 0, fmt.Errorf("время выхода из дома и с работы совпадает")
+	}
+	return city, times[0], times[1], nil
+}
+
+// Подписка чата на сводку для дороги на работу
+func subscribeCommute(store *Store, chatID int64, city string, leaveHome, leaveWork int) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID:    chatID,
+		Kind:      alertCommute,
+		City:      point.DisplayName(),
+		Lat:       point.Lat,
+		Lon:       point.Lon,
+		LeaveHome: leaveHome,
+		LeaveWork: leaveWork,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🚦 Подписка оформлена! Выход из дома в %s, с работы в %s (%s): примерно за час до выхода пришлю, "+
+			"ехать ли на велосипеде, брать ли зонт и стоит ли выйти пораньше.\n"+
+			"Отписаться: /commute off",
+		formatDayMinutes(leaveHome),
+		formatDayMinutes(leaveWork),
+		point.DisplayName(),
+	), nil
+}
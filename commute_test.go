@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommuteArgs(t *testing.T) {
+	city, home, work, err := parseCommuteArgs("Нижний Новгород 7:40 17:15")
+	if err != nil || city != "Нижний Новгород" || home != 7*60+40 || work != 17*60+15 {
+		t.Errorf("parseCommuteArgs = %q, %d, %d, %v", city, home, work, err)
+	}
+	if city, home, work, err := parseCommuteArgs("Казань"); err != nil || city != "Казань" || home != defaultLeaveHome || work != defaultLeaveWork {
+		t.Errorf("без времени: %q, %d, %d, %v", city, home, work, err)
+	}
+	for _, args := range []string{"Москва 8:15", "Москва 8:15 8:15", "Москва 25:00 18:00"} {
+		if _, _, _, err := parseCommuteArgs(args); err == nil {
+			t.Errorf("parseCommuteArgs(%q) без ошибки", args)
+		}
+	}
+}
+
+func TestFormatCommute(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	day := time.Date(2026, 12, 3, 0, 0, 0, 0, moscow)
+	forecast := &Forecast{City: "Москва", Location: moscow, Items: []ForecastItem{
+		{Time: day.Add(6 * time.Hour), Temp: -4, FeelsLike: -9, Description: "снег", Condition: 601, Snow: 1.2, Pop: 0.9, WindSpeed: 4},
+		{Time: day.Add(18 * time.Hour), Temp: 2, FeelsLike: 0, Description: "небольшой дождь", Condition: 500, Rain: 0.5, Pop: 0.7, WindSpeed: 5},
+	}}
+
+	text, ok := formatCommute("Москва", forecast, day.Add(8*time.Hour+15*time.Minute), day.Add(18*time.Hour+30*time.Minute))
+	if !ok {
+		t.Fatal("нет сводки")
+	}
+	for _, want := range []string{
+		"🏠 Из дома в 08:15: -4°C (ощущается как -9°C), снег",
+		"🏢 С работы в 18:30: +2°C",
+		"🚌 Сегодня лучше автобус или метро",
+		"снег",
+		"☂️ Возьмите зонт: вечером вероятность дождя 70%",
+		"⏰ Выходите на 10 минут раньше из-за снега",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в сводке %q нет %q", text, want)
+		}
+	}
+
+	// Сухо и тепло: на велосипеде, без зонта и без спешки
+	dry := ForecastItem{Temp: 15, FeelsLike: 15, Description: "ясно", Condition: 800, WindSpeed: 3, Humidity: 50}
+	if got := commuteTransportAdvice(dry, dry); !strings.HasPrefix(got, "🚲") {
+		t.Errorf("совет в сухую погоду %q", got)
+	}
+	if got := commuteUmbrellaAdvice(dry, dry); got != "🌂 Зонт не понадобится." {
+		t.Errorf("зонт в сухую погоду %q", got)
+	}
+	if got := commuteEarlyAdvice(dry); got != "" {
+		t.Errorf("совет выйти раньше в сухую погоду %q", got)
+	}
+
+	// Время выхода с работы за пределами прогноза
+	if _, ok := formatCommute("Москва", forecast, day.Add(8*time.Hour), day.Add(30*time.Hour)); ok {
+		t.Error("сводка без прогноза на вечер")
+	}
+}
@@ -0,0 +1,661 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Настройки бота
+type Config struct {
+	TelegramToken string
+	// Токены дополнительных ботов (TELEGRAM_TOKENS), см. bots.go
+	TelegramTokens []string
+	// Адрес Bot API (локальный сервер или поддельный в тестах)
+	TelegramAPIURL string
+	// Прокси (http, https или socks5) для Telegram и для источников погоды
+	TelegramProxy string
+	WeatherProxy  string
+
+	// Ключи OWM, запросы распределяются между ними по кругу
+	OWMAPIKeys []string
+	// Адрес API OpenWeatherMap (для прокси и тестов)
+	OWMAPIURL string
+	StateFile string
+	Debug     bool
+	// Пробный прогон оповещений и публикаций (-dry-run): начало модельного
+	// времени (по умолчанию сейчас) и продолжительность
+	DryRun      bool
+	DryRunStart time.Time
+	DryRunFor   time.Duration
+	// Сколько хранится карточка погоды в кэше
+	CacheTTL time.Duration
+
+	STTAPIKey string
+	STTAPIURL string
+	STTModel  string
+
+	// Синтез речи для голосовых ответов (/voice)
+	TTSAPIKey string
+	TTSAPIURL string
+	TTSModel  string
+	TTSVoice  string
+
+	OperatorWebhookURL string
+
+	// Сборщики ошибок: Sentry и/или произвольный вебхук с JSON
+	SentryDSN       string
+	ErrorWebhookURL string
+
+	// Стикеры по темам погоды (file_id или ссылка на .gif/.mp4)
+	Stickers map[string]string
+
+	WebAppAddr string
+	WebAppURL  string
+
+	// Отладочный сервер pprof: адрес и токен доступа
+	DebugAddr  string
+	DebugToken string
+
+	// HTTP API с погодой для других сервисов: адрес и токены клиентов
+	APIAddr   string
+	APITokens []string
+
+	// Публикация погоды в MQTT: брокер, города, префикс топиков и период
+	MQTTURL         string
+	MQTTCities      []string
+	MQTTTopicPrefix string
+	MQTTInterval    time.Duration
+
+	// Источник погоды для тех, кто не выбрал его в настройках
+	DefaultProvider string
+
+	// Флаги функций (FEATURES)
+	Features map[string]featureRule
+
+	PremiumPriceStars int
+	AdminChatIDs      []int64
+
+	// Резервные копии состояния: локальный каталог и/или S3-совместимое хранилище
+	BackupDir         string
+	BackupInterval    time.Duration
+	BackupKeep        int
+	BackupS3Endpoint  string
+	BackupS3Bucket    string
+	BackupS3Region    string
+	BackupS3AccessKey string
+	BackupS3SecretKey string
+
+	// Запись наблюдений для городов с подписками: период и срок хранения
+	ObservationInterval  time.Duration
+	ObservationRetention time.Duration
+}
+
+// Глобальные настройки, загружаются в main и могут перечитываться на ходу
+var (
+	activeConfig   = defaultConfig()
+	activeConfigMu sync.RWMutex
+)
+
+// Текущие настройки
+func config() *Config {
+	activeConfigMu.RLock()
+	defer activeConfigMu.RUnlock()
+
+	return activeConfig
+}
+
+func setConfig(c *Config) {
+	activeConfigMu.Lock()
+	defer activeConfigMu.Unlock()
+
+	activeConfig = c
+}
+
+// Значения по умолчанию
+func defaultConfig() *Config {
+	return &Config{
+		StateFile:         defaultStateFile,
+		TelegramAPIURL:    defaultTelegramURL,
+		OWMAPIURL:         defaultOWMURL,
+		CacheTTL:          30 * time.Minute,
+		STTAPIURL:         defaultSTTURL,
+		STTModel:          "whisper-1",
+		TTSAPIURL:         defaultTTSURL,
+		TTSModel:          "tts-1",
+		TTSVoice:          "alloy",
+		Stickers:          map[string]string{},
+		PremiumPriceStars: defaultPremiumPrice,
+		DefaultProvider:   providerOWM,
+		MQTTTopicPrefix:   "weather",
+		MQTTInterval:      10 * time.Minute,
+		BackupInterval:    24 * time.Hour,
+		BackupKeep:        7,
+		BackupS3Region:    "us-east-1",
+
+		ObservationInterval:  time.Hour,
+		ObservationRetention: 30 * 24 * time.Hour,
+	}
+}
+
+// Ключи настроек. В переменных окружения они записываются как есть,
+// в YAML-файле — в нижнем регистре (telegram_token: ...)
+var configKeys = []string{
+	"TELEGRAM_TOKEN", "TELEGRAM_TOKENS", "TELEGRAM_API_URL", "OWM_API_KEY", "OWM_API_URL",
+	"TELEGRAM_PROXY", "WEATHER_PROXY", "STATE_FILE", "BOT_DEBUG", "CACHE_TTL",
+	"STT_API_KEY", "STT_API_URL", "STT_MODEL",
+	"TTS_API_KEY", "TTS_API_URL", "TTS_MODEL", "TTS_VOICE",
+	"OPERATOR_WEBHOOK_URL", "SENTRY_DSN", "ERROR_WEBHOOK_URL",
+	"WEBAPP_ADDR", "WEBAPP_URL",
+	"DEBUG_ADDR", "DEBUG_TOKEN", "API_ADDR", "API_TOKENS",
+	"MQTT_URL", "MQTT_CITIES", "MQTT_TOPIC_PREFIX", "MQTT_INTERVAL",
+	"PREMIUM_PRICE_STARS", "ADMIN_CHAT_IDS", "WEATHER_PROVIDER", "FEATURES",
+	"BACKUP_DIR", "BACKUP_INTERVAL", "BACKUP_KEEP",
+	"BACKUP_S3_ENDPOINT", "BACKUP_S3_BUCKET", "BACKUP_S3_REGION", "BACKUP_S3_ACCESS_KEY", "BACKUP_S3_SECRET_KEY",
+	"OBSERVATION_INTERVAL", "OBSERVATION_RETENTION",
+}
+
+func init() {
+	for _, theme := range stickerThemes {
+		configKeys = append(configKeys, "STICKER_"+theme)
+	}
+}
+
+// Чтение плоского YAML-файла вида "ключ: значение". Вложенные структуры
+// не нужны, поэтому обходимся без сторонней библиотеки
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла настроек: %v", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: ожидается строка вида \"ключ: значение\"", path, lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			// В кавычках "#" не комментарий, а после закрывающей кавычки
+			// может идти только комментарий
+			end := strings.IndexByte(value[1:], value[0]) + 1
+			if end == 0 {
+				return nil, fmt.Errorf("%s:%d: нет закрывающей кавычки", path, lineNumber)
+			}
+			if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("%s:%d: после закрывающей кавычки лишнее: %q", path, lineNumber, rest)
+			}
+			value = value[1:end]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		values[strings.ToUpper(strings.TrimSpace(key))] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла настроек: %v", err)
+	}
+
+	return values, nil
+}
+
+// Загрузка настроек: значения по умолчанию, затем YAML-файл (-config или
+// CONFIG_FILE), затем переменные окружения, затем флаги командной строки
+func loadConfig(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("bot", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML-файл с настройками")
+	stateFile := flags.String("state", "", "файл состояния")
+	webAppAddr := flags.String("webapp-addr", "", "адрес HTTP-сервера мини-приложения")
+	debug := flags.Bool("debug", false, "логировать запросы к Telegram")
+	dryRun := flags.Bool("dry-run", false, "прогнать оповещения и публикации на модельных часах, ничего не отправляя")
+	dryRunStart := flags.String("dry-run-start", "", "начало пробного прогона (RFC 3339, по умолчанию сейчас)")
+	dryRunFor := flags.Duration("dry-run-for", 24*time.Hour, "продолжительность пробного прогона")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]string)
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		known := make(map[string]bool)
+		for _, key := range configKeys {
+			known[key] = true
+		}
+		for key, value := range values {
+			if !known[key] {
+				return nil, fmt.Errorf("%s: неизвестный параметр %s", *configFile, strings.ToLower(key))
+			}
+			raw[key] = value
+		}
+	}
+	for _, key := range configKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			raw[key] = value
+		}
+	}
+	if *stateFile != "" {
+		raw["STATE_FILE"] = *stateFile
+	}
+	if *webAppAddr != "" {
+		raw["WEBAPP_ADDR"] = *webAppAddr
+	}
+	if *debug {
+		raw["BOT_DEBUG"] = "true"
+	}
+
+	c, err := parseConfig(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	c.DryRun = *dryRun
+	c.DryRunFor = *dryRunFor
+	if c.DryRunFor < time.Minute || c.DryRunFor > 31*24*time.Hour {
+		return nil, fmt.Errorf("-dry-run-for: должен быть от 1m до 744h, получено %s", c.DryRunFor)
+	}
+	if *dryRunStart != "" {
+		start, err := time.Parse(time.RFC3339, *dryRunStart)
+		if err != nil {
+			return nil, fmt.Errorf("-dry-run-start: ожидается время вида 2026-10-17T07:00:00+03:00, получено %q", *dryRunStart)
+		}
+		c.DryRunStart = start
+	}
+	return c, nil
+}
+
+// Разбор и проверка значений. Ошибки собираются все сразу, чтобы
+// не исправлять конфигурацию по одному полю за запуск
+func parseConfig(raw map[string]string) (*Config, error) {
+	c := defaultConfig()
+	var problems []string
+	invalid := func(key, format string, args ...interface{}) {
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	c.TelegramToken = raw["TELEGRAM_TOKEN"]
+	if c.TelegramToken == "" {
+		invalid("TELEGRAM_TOKEN", "не задан")
+	} else if !strings.Contains(c.TelegramToken, ":") {
+		invalid("TELEGRAM_TOKEN", "ожидается токен вида 123456:ABC..., выданный @BotFather")
+	}
+	seenBots := map[string]bool{botID(c.TelegramToken): true}
+	for _, token := range strings.Split(raw["TELEGRAM_TOKENS"], ",") {
+		token = strings.TrimSpace(token)
+		switch {
+		case token == "":
+		case !strings.Contains(token, ":"):
+			invalid("TELEGRAM_TOKENS", "ожидается список токенов вида 123456:ABC... через запятую")
+		case seenBots[botID(token)]:
+			invalid("TELEGRAM_TOKENS", "бот %s указан дважды", botID(token))
+		default:
+			seenBots[botID(token)] = true
+			c.TelegramTokens = append(c.TelegramTokens, token)
+		}
+	}
+
+	if value := raw["TELEGRAM_API_URL"]; value != "" {
+		c.TelegramAPIURL = strings.TrimRight(value, "/")
+	}
+
+	seenKeys := make(map[string]bool)
+	for _, key := range strings.Split(raw["OWM_API_KEY"], ",") {
+		key = strings.TrimSpace(key)
+		if key != "" && !seenKeys[key] {
+			seenKeys[key] = true
+			c.OWMAPIKeys = append(c.OWMAPIKeys, key)
+		}
+	}
+	if len(c.OWMAPIKeys) == 0 && raw["WEATHER_PROVIDER"] != providerMock {
+		invalid("OWM_API_KEY", "не задан")
+	}
+
+	if value := raw["OWM_API_URL"]; value != "" {
+		c.OWMAPIURL = strings.TrimRight(value, "/")
+	}
+
+	c.TelegramProxy = raw["TELEGRAM_PROXY"]
+	c.WeatherProxy = raw["WEATHER_PROXY"]
+	for _, key := range []string{"TELEGRAM_PROXY", "WEATHER_PROXY"} {
+		if value := raw[key]; value != "" {
+			if _, err := parseProxyURL(value); err != nil {
+				invalid(key, "%v", err)
+			}
+		}
+	}
+
+	if value := raw["STATE_FILE"]; value != "" {
+		c.StateFile = value
+	}
+
+	if value := raw["BOT_DEBUG"]; value != "" {
+		debug, err := strconv.ParseBool(value)
+		if err != nil {
+			invalid("BOT_DEBUG", "ожидается true или false, получено %q", value)
+		}
+		c.Debug = debug
+	}
+
+	if value := raw["CACHE_TTL"]; value != "" {
+		ttl, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			invalid("CACHE_TTL", "ожидается длительность вроде 30m или 1h, получено %q", value)
+		case ttl < time.Minute || ttl > 24*time.Hour:
+			invalid("CACHE_TTL", "должен быть от 1m до 24h, получено %s", ttl)
+		default:
+			c.CacheTTL = ttl
+		}
+	}
+
+	c.STTAPIKey = raw["STT_API_KEY"]
+	if value := raw["STT_API_URL"]; value != "" {
+		c.STTAPIURL = value
+	}
+	if value := raw["STT_MODEL"]; value != "" {
+		c.STTModel = value
+	}
+
+	c.TTSAPIKey = raw["TTS_API_KEY"]
+	if value := raw["TTS_API_URL"]; value != "" {
+		c.TTSAPIURL = value
+	}
+	if value := raw["TTS_MODEL"]; value != "" {
+		c.TTSModel = value
+	}
+	if value := raw["TTS_VOICE"]; value != "" {
+		c.TTSVoice = value
+	}
+
+	c.OperatorWebhookURL = raw["OPERATOR_WEBHOOK_URL"]
+	c.ErrorWebhookURL = raw["ERROR_WEBHOOK_URL"]
+	for _, key := range []string{"TELEGRAM_API_URL", "OWM_API_URL", "STT_API_URL", "TTS_API_URL", "OPERATOR_WEBHOOK_URL", "ERROR_WEBHOOK_URL"} {
+		if value := raw[key]; value != "" && !isHTTPURL(value) {
+			invalid(key, "ожидается адрес http(s)://..., получено %q", value)
+		}
+	}
+
+	c.SentryDSN = raw["SENTRY_DSN"]
+	if c.SentryDSN != "" {
+		if _, _, err := parseSentryDSN(c.SentryDSN); err != nil {
+			invalid("SENTRY_DSN", "%v", err)
+		}
+	}
+
+	for _, theme := range stickerThemes {
+		if value := strings.TrimSpace(raw["STICKER_"+theme]); value != "" {
+			c.Stickers[theme] = value
+		}
+	}
+
+	c.WebAppAddr = raw["WEBAPP_ADDR"]
+	if c.WebAppAddr != "" {
+		_, port, err := net.SplitHostPort(c.WebAppAddr)
+		number, convErr := strconv.Atoi(port)
+		if err != nil || convErr != nil || number < 1 || number > 65535 {
+			invalid("WEBAPP_ADDR", "ожидается адрес вида :8080 или 127.0.0.1:8080 с портом от 1 до 65535, получено %q", c.WebAppAddr)
+		}
+	}
+	c.WebAppURL = raw["WEBAPP_URL"]
+	if c.WebAppURL != "" && !strings.HasPrefix(c.WebAppURL, "https://") {
+		invalid("WEBAPP_URL", "Telegram открывает мини-приложения только по https://, получено %q", c.WebAppURL)
+	}
+
+	if value := raw["PREMIUM_PRICE_STARS"]; value != "" {
+		price, err := strconv.Atoi(value)
+		if err != nil || price < 1 || price > 10000 {
+			invalid("PREMIUM_PRICE_STARS", "ожидается целое число от 1 до 10000, получено %q", value)
+		} else {
+			c.PremiumPriceStars = price
+		}
+	}
+
+	if value := raw["WEATHER_PROVIDER"]; value != "" {
+		if _, ok := providerTitles[value]; !ok {
+			invalid("WEATHER_PROVIDER", "неизвестный источник %q", value)
+		} else {
+			c.DefaultProvider = value
+		}
+	}
+
+	features, err := parseFeatureRules(raw["FEATURES"])
+	if err != nil {
+		invalid("FEATURES", "%v", err)
+	}
+	c.Features = features
+
+	for _, field := range strings.Split(raw["ADMIN_CHAT_IDS"], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			invalid("ADMIN_CHAT_IDS", "%q не похоже на ID чата", field)
+			continue
+		}
+		c.AdminChatIDs = append(c.AdminChatIDs, id)
+	}
+
+	c.DebugAddr = raw["DEBUG_ADDR"]
+	c.DebugToken = raw["DEBUG_TOKEN"]
+	if c.DebugAddr != "" {
+		_, port, err := net.SplitHostPort(c.DebugAddr)
+		number, convErr := strconv.Atoi(port)
+		switch {
+		case err != nil || convErr != nil || number < 1 || number > 65535:
+			invalid("DEBUG_ADDR", "ожидается адрес вида 127.0.0.1:6060 с портом от 1 до 65535, получено %q", c.DebugAddr)
+		case c.DebugToken == "" && !isLoopbackAddr(c.DebugAddr):
+			invalid("DEBUG_ADDR", "профили доступны всем, кто достучится до %s: слушайте 127.0.0.1 или задайте DEBUG_TOKEN", c.DebugAddr)
+		}
+	}
+
+	c.APIAddr = raw["API_ADDR"]
+	for _, token := range strings.Split(raw["API_TOKENS"], ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			c.APITokens = append(c.APITokens, token)
+		}
+	}
+	if c.APIAddr != "" {
+		_, port, err := net.SplitHostPort(c.APIAddr)
+		number, convErr := strconv.Atoi(port)
+		switch {
+		case err != nil || convErr != nil || number < 1 || number > 65535:
+			invalid("API_ADDR", "ожидается адрес вида :8090 или 127.0.0.1:8090 с портом от 1 до 65535, получено %q", c.APIAddr)
+		case len(c.APITokens) == 0:
+			invalid("API_TOKENS", "не задан, а без токенов API доступен всем и расходует квоту OWM")
+		}
+	}
+
+	c.MQTTURL = raw["MQTT_URL"]
+	if c.MQTTURL != "" {
+		if _, err := parseMQTTURL(c.MQTTURL); err != nil {
+			invalid("MQTT_URL", "%v", err)
+		}
+	}
+	for _, city := range strings.Split(raw["MQTT_CITIES"], ",") {
+		if city = strings.TrimSpace(city); city != "" {
+			c.MQTTCities = append(c.MQTTCities, city)
+		}
+	}
+	if c.MQTTURL != "" && len(c.MQTTCities) == 0 {
+		invalid("MQTT_CITIES", "не задан, а без городов в MQTT нечего публиковать")
+	}
+	if value := raw["MQTT_TOPIC_PREFIX"]; value != "" {
+		if strings.ContainsAny(value, "+#") || strings.HasSuffix(value, "/") {
+			invalid("MQTT_TOPIC_PREFIX", "не должен содержать + и # и заканчиваться на /, получено %q", value)
+		} else {
+			c.MQTTTopicPrefix = value
+		}
+	}
+	if value := raw["MQTT_INTERVAL"]; value != "" {
+		interval, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			invalid("MQTT_INTERVAL", "ожидается длительность вроде 5m или 1h, получено %q", value)
+		case interval < time.Minute || interval > 24*time.Hour:
+			invalid("MQTT_INTERVAL", "должен быть от 1m до 24h, получено %s", interval)
+		default:
+			c.MQTTInterval = interval
+		}
+	}
+
+	c.BackupDir = raw["BACKUP_DIR"]
+	if value := raw["BACKUP_INTERVAL"]; value != "" {
+		interval, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			invalid("BACKUP_INTERVAL", "ожидается длительность вроде 6h или 24h, получено %q", value)
+		case interval < 10*time.Minute || interval > 7*24*time.Hour:
+			invalid("BACKUP_INTERVAL", "должен быть от 10m до 168h, получено %s", interval)
+		default:
+			c.BackupInterval = interval
+		}
+	}
+	if value := raw["BACKUP_KEEP"]; value != "" {
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 1 || keep > 1000 {
+			invalid("BACKUP_KEEP", "ожидается целое число от 1 до 1000, получено %q", value)
+		} else {
+			c.BackupKeep = keep
+		}
+	}
+	c.BackupS3Endpoint = strings.TrimRight(raw["BACKUP_S3_ENDPOINT"], "/")
+	c.BackupS3Bucket = raw["BACKUP_S3_BUCKET"]
+	c.BackupS3AccessKey = raw["BACKUP_S3_ACCESS_KEY"]
+	c.BackupS3SecretKey = raw["BACKUP_S3_SECRET_KEY"]
+	if value := raw["BACKUP_S3_REGION"]; value != "" {
+		c.BackupS3Region = value
+	}
+
+	if value := raw["OBSERVATION_INTERVAL"]; value != "" {
+		interval, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			invalid("OBSERVATION_INTERVAL", "ожидается длительность вроде 1h или 3h, получено %q", value)
+		case interval < 10*time.Minute || interval > 24*time.Hour:
+			invalid("OBSERVATION_INTERVAL", "должен быть от 10m до 24h, получено %s", interval)
+		default:
+			c.ObservationInterval = interval
+		}
+	}
+	if value := raw["OBSERVATION_RETENTION"]; value != "" {
+		retention, err := time.ParseDuration(value)
+		switch {
+		case err != nil:
+			invalid("OBSERVATION_RETENTION", "ожидается длительность вроде 168h или 720h, получено %q", value)
+		case retention < 7*24*time.Hour || retention > 366*24*time.Hour:
+			invalid("OBSERVATION_RETENTION", "должен быть от 168h до 8784h, получено %s", retention)
+		default:
+			c.ObservationRetention = retention
+		}
+	}
+	if c.BackupS3Endpoint != "" {
+		if !isHTTPURL(c.BackupS3Endpoint) {
+			invalid("BACKUP_S3_ENDPOINT", "ожидается адрес http(s)://..., получено %q", c.BackupS3Endpoint)
+		}
+		for _, key := range []string{"BACKUP_S3_BUCKET", "BACKUP_S3_ACCESS_KEY", "BACKUP_S3_SECRET_KEY"} {
+			if raw[key] == "" {
+				invalid(key, "не задан, а без него копии в S3 не сохранить")
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("ошибки в настройках:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return c, nil
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// Переменные, заданные в окружении процесса до чтения .env. При перезагрузке
+// их не трогаем, а значения из .env перечитываем
+var processEnv = make(map[string]bool)
+
+func rememberProcessEnv() {
+	for _, key := range configKeys {
+		if _, ok := os.LookupEnv(key); ok {
+			processEnv[key] = true
+		}
+	}
+}
+
+// Перечитывание .env: новые значения применяются, удаленные из файла сбрасываются
+func reloadDotEnv() error {
+	values, err := godotenv.Read()
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("ошибка чтения .env: %v", err)
+	}
+	for _, key := range configKeys {
+		if processEnv[key] {
+			continue
+		}
+		if value, ok := values[key]; ok {
+			os.Setenv(key, value)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+	return nil
+}
+
+// Перечитывание настроек без перезапуска (SIGHUP или /reload). Секреты,
+// файл состояния, адреса серверов мини-приложения и отладки и режим
+// отладки применяются только при запуске
+func reloadConfig(args []string) error {
+	if err := reloadDotEnv(); err != nil {
+		return err
+	}
+	loaded, err := loadConfig(args)
+	if err != nil {
+		return err
+	}
+
+	current := config()
+	loaded.TelegramToken = current.TelegramToken
+	loaded.TelegramTokens = current.TelegramTokens
+	loaded.TelegramAPIURL = current.TelegramAPIURL
+	loaded.TelegramProxy = current.TelegramProxy
+	loaded.WeatherProxy = current.WeatherProxy
+	loaded.OWMAPIKeys = current.OWMAPIKeys
+	loaded.STTAPIKey = current.STTAPIKey
+	loaded.TTSAPIKey = current.TTSAPIKey
+	loaded.SentryDSN = current.SentryDSN
+	loaded.BackupS3SecretKey = current.BackupS3SecretKey
+	loaded.BackupInterval = current.BackupInterval
+	loaded.MQTTInterval = current.MQTTInterval
+	loaded.ObservationInterval = current.ObservationInterval
+	loaded.StateFile = current.StateFile
+	loaded.WebAppAddr = current.WebAppAddr
+	loaded.DebugAddr = current.DebugAddr
+	loaded.DebugToken = current.DebugToken
+	loaded.APIAddr = current.APIAddr
+	loaded.Debug = current.Debug
+
+	setConfig(loaded)
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "ключи приводятся к верхнему регистру",
+			content: "telegram_token: 123:abc\nCache_TTL:   45m  \n",
+			want:    map[string]string{"TELEGRAM_TOKEN": "123:abc", "CACHE_TTL": "45m"},
+		},
+		{
+			name:    "комментарии и пустые строки",
+			content: "# настройки бота\n\n  # отступ\nstate_file: state.json # рядом с ботом\n",
+			want:    map[string]string{"STATE_FILE": "state.json"},
+		},
+		{
+			name:    "решетка без пробела остается в значении",
+			content: "sticker_rain: CAAC#x\n",
+			want:    map[string]string{"STICKER_RAIN": "CAAC#x"},
+		},
+		{
+			name:    "кавычки",
+			content: "mqtt_cities: \"Москва, Нижний Новгород\"\nbackup_dir: '/var/backups # бот'\ndebug_token: \"s3cr#t\" # токен\n",
+			want: map[string]string{
+				"MQTT_CITIES": "Москва, Нижний Новгород",
+				"BACKUP_DIR":  "/var/backups # бот",
+				"DEBUG_TOKEN": "s3cr#t",
+			},
+		},
+		{
+			name:    "пустое значение и значение с двоеточием",
+			content: "webapp_url:\ntelegram_api_url: http://localhost:8081\n",
+			want:    map[string]string{"WEBAPP_URL": "", "TELEGRAM_API_URL": "http://localhost:8081"},
+		},
+		{
+			name:    "строка без двоеточия",
+			content: "cache_ttl: 1h\n- Москва\n",
+			wantErr: ":2: ожидается строка",
+		},
+		{
+			name:    "незакрытая кавычка",
+			content: "backup_dir: \"/var/backups\n",
+			wantErr: ":1: нет закрывающей кавычки",
+		},
+		{
+			name:    "текст после кавычки",
+			content: "backup_dir: \"/var\"/backups\n",
+			wantErr: ":1: после закрывающей кавычки лишнее",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := readConfigFile(writeConfigFile(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась с %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readConfigFile: %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("значения %v, ожидались %v", values, tt.want)
+			}
+		})
+	}
+
+	if _, err := readConfigFile(filepath.Join(t.TempDir(), "нет.yaml")); err == nil {
+		t.Error("нет ошибки для отсутствующего файла")
+	}
+}
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	path := writeConfigFile(t, "telegram_token: 123:abc\nowm_api_key: key\ncahce_ttl: 1h\n")
+	_, err := loadConfig([]string{"-config", path})
+	if err == nil || !strings.Contains(err.Error(), "неизвестный параметр cahce_ttl") {
+		t.Fatalf("ошибка %v, ожидалась про cahce_ttl", err)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	base := func(extra map[string]string) map[string]string {
+		raw := map[string]string{"TELEGRAM_TOKEN": "123:abc", "OWM_API_KEY": "key"}
+		for key, value := range extra {
+			raw[key] = value
+		}
+		return raw
+	}
+
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		c, err := parseConfig(base(nil))
+		if err != nil {
+			t.Fatalf("parseConfig: %v", err)
+		}
+		if defaults := defaultConfig(); c.CacheTTL != defaults.CacheTTL || c.StateFile != defaults.StateFile {
+			t.Errorf("значения по умолчанию не применились: %+v", c)
+		}
+	})
+
+	t.Run("списки", func(t *testing.T) {
+		c, err := parseConfig(base(map[string]string{
+			"OWM_API_KEY":     " first, second,,first ",
+			"TELEGRAM_TOKENS": " 456:def, ,789:ghi",
+			"ADMIN_CHAT_IDS":  "42, -100123",
+			"MQTT_URL":        "mqtt://localhost:1883",
+			"MQTT_CITIES":     "Москва, ,Тула",
+			"API_ADDR":        ":8090",
+			"API_TOKENS":      "a,b",
+			"CACHE_TTL":       "45m",
+		}))
+		if err != nil {
+			t.Fatalf("parseConfig: %v", err)
+		}
+		if !reflect.DeepEqual(c.OWMAPIKeys, []string{"first", "second"}) {
+			t.Errorf("ключи OWM %q", c.OWMAPIKeys)
+		}
+		if !reflect.DeepEqual(c.TelegramTokens, []string{"456:def", "789:ghi"}) {
+			t.Errorf("токены ботов %q", c.TelegramTokens)
+		}
+		if !reflect.DeepEqual(c.AdminChatIDs, []int64{42, -100123}) {
+			t.Errorf("администраторы %v", c.AdminChatIDs)
+		}
+		if !reflect.DeepEqual(c.MQTTCities, []string{"Москва", "Тула"}) {
+			t.Errorf("города MQTT %q", c.MQTTCities)
+		}
+		if !reflect.DeepEqual(c.APITokens, []string{"a", "b"}) || c.CacheTTL != 45*time.Minute {
+			t.Errorf("токены API %q, CACHE_TTL %s", c.APITokens, c.CacheTTL)
+		}
+	})
+
+	t.Run("все ошибки сразу", func(t *testing.T) {
+		_, err := parseConfig(map[string]string{
+			"CACHE_TTL":       "forever",
+			"TELEGRAM_TOKENS": "456:def,456:xyz,broken",
+			"ADMIN_CHAT_IDS":  "42, admin",
+			"STT_API_URL":     "ftp://stt.example",
+			"TTS_API_URL":     "tts.example",
+			"MQTT_URL":        "mqtt://localhost:1883",
+		})
+		if err == nil {
+			t.Fatal("нет ошибки")
+		}
+		for _, want := range []string{
+			"TELEGRAM_TOKEN: не задан",
+			"OWM_API_KEY: не задан",
+			"TELEGRAM_TOKENS: бот 456 указан дважды",
+			"TELEGRAM_TOKENS: ожидается список токенов",
+			"CACHE_TTL: ожидается длительность",
+			`ADMIN_CHAT_IDS: "admin"`,
+			"STT_API_URL: ожидается адрес",
+			"TTS_API_URL: ожидается адрес",
+			"MQTT_CITIES: не задан",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("в ошибке нет %q:\n%v", want, err)
+			}
+		}
+	})
+
+	t.Run("мок-источник без ключа OWM", func(t *testing.T) {
+		if _, err := parseConfig(map[string]string{"TELEGRAM_TOKEN": "123:abc", "WEATHER_PROVIDER": providerMock}); err != nil {
+			t.Errorf("parseConfig: %v", err)
+		}
+	})
+}
@@ -0,0 +1,609 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Обратный отсчет до события: /event 2025-07-12 18:00 Казань. За неделю,
+// за 3 дня, за сутки и за 3 часа до начала бот присылает прогноз на час
+// события и показывает, как этот прогноз менялся. Прогноз OWM покрывает
+// только 5 дней, поэтому почасовой прогноз на 16 дней берется из Open-Meteo
+
+// Сколько событий можно завести в одном чате
+const maxEvents = 5
+
+// Насколько вперед можно завести событие
+const maxEventAhead = 365 * 24 * time.Hour
+
+// Сколько дней почасового прогноза запрашиваем у Open-Meteo
+const eventForecastDays = 16
+
+// Этап обратного отсчета: за сколько до события присылаем прогноз
+type eventStage struct {
+	name   string
+	before time.Duration
+	// Заголовок сообщения и подпись в истории прогноза
+	title, label string
+}
+
+// Этапы от самого раннего к самому позднему
+var eventStages = []eventStage{
+	{name: "7d", before: 7 * 24 * time.Hour, title: "Через неделю", label: "за неделю"},
+	{name: "3d", before: 3 * 24 * time.Hour, title: "Через 3 дня", label: "за 3 дня"},
+	{name: "1d", before: 24 * time.Hour, title: "Уже завтра", label: "за сутки"},
+	{name: "3h", before: 3 * time.Hour, title: "Через 3 часа", label: "за 3 часа"},
+}
+
+// Подпись прогноза, полученного при добавлении события
+const eventStageAdded = "added"
+
+// Прогноз на час события в момент одного из этапов
+type EventSnapshot struct {
+	Stage       string    `json:"stage"`
+	At          time.Time `json:"at"`
+	Temp        float64   `json:"temp"`
+	Pop         float64   `json:"pop"` // вероятность осадков, 0..1
+	Precip      float64   `json:"precip"`
+	WindSpeed   float64   `json:"wind_speed"`
+	Description string    `json:"description,omitempty"`
+}
+
+// Событие с обратным отсчетом. Time хранится со смещением часового пояса города
+type WeatherEvent struct {
+	ID        string          `json:"id"`
+	ChatID    int64           `json:"chat_id"`
+	City      string          `json:"city"`
+	Lat       float64         `json:"lat"`
+	Lon       float64         `json:"lon"`
+	Time      time.Time       `json:"time"`
+	Created   time.Time       `json:"created"`
+	Sent      []string        `json:"sent,omitempty"`
+	Snapshots []EventSnapshot `json:"snapshots,omitempty"`
+}
+
+// Этапы, время которых наступило, но прогноз по ним еще не отправлен
+func (e WeatherEvent) dueStages(now time.Time) []eventStage {
+	if !now.Before(e.Time) {
+		return nil
+	}
+	sent := make(map[string]bool)
+	for _, name := range e.Sent {
+		sent[name] = true
+	}
+
+	var due []eventStage
+	for _, stage := range eventStages {
+		if !sent[stage.name] && !now.Before(e.Time.Add(-stage.before)) {
+			due = append(due, stage)
+		}
+	}
+	return due
+}
+
+func newEventID() string {
+	id := make([]byte, 4)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Добавление события
+func (s *Store) AddEvent(event WeatherEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.data.Events[event.ChatID]
+	if len(events) >= maxEvents {
+		return fmt.Errorf("в чате может быть не больше %d событий, удалите лишнее: /event del N", maxEvents)
+	}
+	s.data.Events[event.ChatID] = append(events, &event)
+	return s.save()
+}
+
+// Копии событий чата, начиная с ближайшего
+func (s *Store) Events(chatID int64) []WeatherEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]WeatherEvent, 0, len(s.data.Events[chatID]))
+	for _, event := range s.data.Events[chatID] {
+		events = append(events, *event)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// Копии событий всех чатов, упорядоченные по чату
+func (s *Store) AllEvents() []WeatherEvent {
+	s.mu.Lock()
+	chatIDs := make([]int64, 0, len(s.data.Events))
+	for chatID := range s.data.Events {
+		chatIDs = append(chatIDs, chatID)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(chatIDs, func(i, j int) bool { return chatIDs[i] < chatIDs[j] })
+	var events []WeatherEvent
+	for _, chatID := range chatIDs {
+		events = append(events, s.Events(chatID)...)
+	}
+	return events
+}
+
+// Удаление события по номеру из списка /event (с 1). Возвращает false, если такого нет
+func (s *Store) DeleteEvent(chatID int64, n int) (bool, error) {
+	events := s.Events(chatID)
+	if n < 1 || n > len(events) {
+		return false, nil
+	}
+	return s.removeEvents(chatID, func(event *WeatherEvent) bool { return event.ID == events[n-1].ID })
+}
+
+// Удаление событий, которые уже начались
+func (s *Store) DeleteStartedEvents(now time.Time) error {
+	s.mu.Lock()
+	chatIDs := make([]int64, 0, len(s.data.Events))
+	for chatID := range s.data.Events {
+		chatIDs = append(chatIDs, chatID)
+	}
+	s.mu.Unlock()
+
+	for _, chatID := range chatIDs {
+		if _, err := s.removeEvents(chatID, func(event *WeatherEvent) bool { return !now.Before(event.Time) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) removeEvents(chatID int64, match func(event *WeatherEvent) bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var kept []*WeatherEvent
+	for _, event := range s.data.Events[chatID] {
+		if !match(event) {
+			kept = append(kept, event)
+		}
+	}
+	if len(kept) == len(s.data.Events[chatID]) {
+		return false, nil
+	}
+	if len(kept) == 0 {
+		delete(s.data.Events, chatID)
+	} else {
+		s.data.Events[chatID] = kept
+	}
+	return true, s.save()
+}
+
+// Отметка об отправленных этапах и новый прогноз в истории события
+func (s *Store) RecordEventStages(chatID int64, id string, stages []eventStage, snapshot EventSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range s.data.Events[chatID] {
+		if event.ID != id {
+			continue
+		}
+		for _, stage := range stages {
+			event.Sent = append(event.Sent, stage.name)
+		}
+		event.Snapshots = append(event.Snapshots, snapshot)
+		return s.save()
+	}
+	return nil
+}
+
+// Почасовой прогноз Open-Meteo на 16 дней
+type eventHourlyResponse struct {
+	UTCOffset int `json:"utc_offset_seconds"`
+	Hourly    struct {
+		Time                     []int64    `json:"time"`
+		Temperature              []*float64 `json:"temperature_2m"`
+		PrecipitationProbability []*float64 `json:"precipitation_probability"`
+		Precipitation            []*float64 `json:"precipitation"`
+		WindSpeed                []*float64 `json:"wind_speed_10m"`
+		WeatherCode              []*float64 `json:"weather_code"`
+	} `json:"hourly"`
+}
+
+// Адрес прогноза Open-Meteo для событий; в тестах подменяется
+var eventForecastURL = "https://api.open-meteo.com/v1/forecast"
+
+func fetchEventForecast(lat, lon float64) (*eventHourlyResponse, error) {
+	if mockWeatherMode() {
+		return mockEventForecast(lat, lon), nil
+	}
+
+	reqURL := fmt.Sprintf(
+		"%s?latitude=%.4f&longitude=%.4f"+
+			"&hourly=temperature_2m,precipitation_probability,precipitation,wind_speed_10m,weather_code"+
+			"&wind_speed_unit=ms&forecast_days=%d&timeformat=unixtime&timezone=auto",
+		eventForecastURL,
+		lat,
+		lon,
+		eventForecastDays,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения данных Open-Meteo: статус %d", resp.StatusCode)
+	}
+
+	var data eventHourlyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	return &data, nil
+}
+
+// Почасовой прогноз демо-режима на те же 16 дней
+func mockEventForecast(lat, lon float64) *eventHourlyResponse {
+	location := mockLocation(lon)
+	_, offset := clockNow().In(location).Zone()
+	data := &eventHourlyResponse{UTCOffset: offset}
+
+	key := mockPlaceName(lat, lon)
+	start := clockNow().Truncate(time.Hour)
+	for i := 0; i < eventForecastDays*24; i++ {
+		item := mockSample(key, lat, start.Add(time.Duration(i)*time.Hour).In(location))
+		pop := math.Min(1, (item.Rain+item.Snow)/2)
+		precip := item.Rain + item.Snow
+		code := float64(mockWMOCode(item.Condition))
+		data.Hourly.Time = append(data.Hourly.Time, item.Time.Unix())
+		data.Hourly.Temperature = append(data.Hourly.Temperature, &item.Temp)
+		data.Hourly.PrecipitationProbability = append(data.Hourly.PrecipitationProbability, floatPtr(pop*100))
+		data.Hourly.Precipitation = append(data.Hourly.Precipitation, &precip)
+		data.Hourly.WindSpeed = append(data.Hourly.WindSpeed, &item.WindSpeed)
+		data.Hourly.WeatherCode = append(data.Hourly.WeatherCode, &code)
+	}
+	return data
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// Код погоды WMO по группе условий OWM для демо-режима
+func mockWMOCode(condition int) int {
+	switch condition / 100 {
+	case 2:
+		return 95
+	case 3:
+		return 51
+	case 5:
+		return 61
+	case 6:
+		return 71
+	case 7:
+		return 45
+	}
+	if condition == 800 {
+		return 0
+	}
+	return 3
+}
+
+// Описание погоды по коду WMO, который отдает Open-Meteo
+func wmoDescription(code int) string {
+	switch {
+	case code == 0:
+		return "ясно"
+	case code <= 2:
+		return "переменная облачность"
+	case code == 3:
+		return "пасмурно"
+	case code == 45 || code == 48:
+		return "туман"
+	case code >= 51 && code <= 57:
+		return "морось"
+	case code >= 61 && code <= 67:
+		return "дождь"
+	case code >= 71 && code <= 77:
+		return "снег"
+	case code >= 80 && code <= 82:
+		return "ливень"
+	case code == 85 || code == 86:
+		return "снегопад"
+	case code >= 95:
+		return "гроза"
+	}
+	return ""
+}
+
+// Часовой пояс точки по смещению из ответа
+func (r *eventHourlyResponse) zone() *time.Location {
+	return time.FixedZone("", r.UTCOffset)
+}
+
+// Прогноз на час, в который начинается событие. false, если до него
+// прогноз еще не дотягивается. Часы в ответе начинаются по местному
+// времени, поэтому в поясах со смещением в полчаса они не совпадают с UTC
+func (r *eventHourlyResponse) snapshot(at time.Time) (EventSnapshot, bool) {
+	i := r.hourIndex(at)
+	temp, ok := valueAt(r.Hourly.Temperature, i)
+	if !ok {
+		return EventSnapshot{}, false
+	}
+	snapshot := EventSnapshot{At: clockNow(), Temp: temp}
+	if pop, ok := valueAt(r.Hourly.PrecipitationProbability, i); ok {
+		snapshot.Pop = pop / 100
+	}
+	snapshot.Precip, _ = valueAt(r.Hourly.Precipitation, i)
+	snapshot.WindSpeed, _ = valueAt(r.Hourly.WindSpeed, i)
+	if code, ok := valueAt(r.Hourly.WeatherCode, i); ok {
+		snapshot.Description = wmoDescription(int(code))
+	}
+	return snapshot, true
+}
+
+// Номер часа прогноза, в который попадает момент at, или -1
+func (r *eventHourlyResponse) hourIndex(at time.Time) int {
+	for i, ts := range r.Hourly.Time {
+		if offset := at.Unix() - ts; offset >= 0 && offset < 3600 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Краткий прогноз для истории: "+21°C, осадки 40%"
+func (s EventSnapshot) short(units string) string {
+	return fmt.Sprintf("%s, осадки %.0f%%", formatTemp(s.Temp, units), s.Pop*100)
+}
+
+// Подпись прогноза в истории по этапу
+func eventStageLabel(name string) string {
+	for _, stage := range eventStages {
+		if stage.name == name {
+			return stage.label
+		}
+	}
+	return "при добавлении"
+}
+
+// Дата и время события по местному времени: "Суббота 12.07 18:00"
+func formatEventTime(t time.Time) string {
+	return weekdayName(t.Weekday()) + " " + t.Format("02.01 15:04")
+}
+
+// Как изменился прогноз с первого снимка до последнего
+func eventTrend(first, last EventSnapshot, units string) string {
+	var changes []string
+	diff := last.Temp - first.Temp
+	switch {
+	case diff >= 2:
+		changes = append(changes, "теплее на "+formatTempDelta(diff, units))
+	case diff <= -2:
+		changes = append(changes, "холоднее на "+formatTempDelta(-diff, units))
+	}
+	switch popDiff := last.Pop - first.Pop; {
+	case popDiff >= 0.2:
+		changes = append(changes, "шанс осадков вырос")
+	case popDiff <= -0.2:
+		changes = append(changes, "шанс осадков снизился")
+	}
+	if len(changes) == 0 {
+		return "Прогноз почти не меняется."
+	}
+	return "По сравнению с первым прогнозом: " + strings.Join(changes, ", ") + "."
+}
+
+// Сообщение этапа обратного отсчета с историей прогноза
+func formatEventUpdate(event WeatherEvent, stage eventStage, snapshot EventSnapshot, units string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📅 %s событие в %s: %s\n\n", stage.title, event.City, formatEventTime(event.Time))
+	fmt.Fprintf(&b, "🌡 %s", formatTemp(snapshot.Temp, units))
+	if snapshot.Description != "" {
+		b.WriteString(", " + snapshot.Description)
+	}
+	fmt.Fprintf(&b, "\n☔️ Осадки: %.0f%%", snapshot.Pop*100)
+	if snapshot.Precip > 0 {
+		fmt.Fprintf(&b, " (%.1f мм)", snapshot.Precip)
+	}
+	fmt.Fprintf(&b, "\n💨 Ветер %.0f м/с\n", snapshot.WindSpeed)
+
+	if len(event.Snapshots) > 0 {
+		b.WriteString("\n📈 Как менялся прогноз:\n")
+		for _, previous := range event.Snapshots {
+			fmt.Fprintf(&b, "• %s: %s\n", eventStageLabel(previous.Stage), previous.short(units))
+		}
+		fmt.Fprintf(&b, "• сейчас: %s\n", snapshot.short(units))
+		b.WriteString(eventTrend(event.Snapshots[0], snapshot, units))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Рассылка прогнозов по наступившим этапам. Вызывается вместе с проверкой оповещений
+func checkEvents(bot messageSender, store *Store) {
+	now := clockNow()
+	if err := store.DeleteStartedEvents(now); err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+
+	for _, event := range store.AllEvents() {
+		due := event.dueStages(now)
+		if len(due) == 0 {
+			continue
+		}
+
+		forecast, err := fetchEventForecast(event.Lat, event.Lon)
+		if err != nil {
+			log.Printf("Ошибка получения прогноза для события чата %d: %v", event.ChatID, err)
+			continue
+		}
+		snapshot, ok := forecast.snapshot(event.Time)
+		if !ok {
+			log.Printf("Нет прогноза на время события чата %d (%s)", event.ChatID, event.Time.Format(time.RFC3339))
+			continue
+		}
+
+		// Если пропущено несколько этапов, присылаем только последний
+		stage := due[len(due)-1]
+		snapshot.Stage = stage.name
+		text := formatEventUpdate(event, stage, snapshot, store.Preferences(event.ChatID).Units)
+		if err := deliver(bot, store, event.ChatID, "Прогноз к событию", tgbotapi.NewMessage(event.ChatID, text), text); err != nil {
+			log.Printf("Ошибка отправки прогноза к событию: %v", err)
+		}
+		if err := store.RecordEventStages(event.ChatID, event.ID, due, snapshot); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+	}
+}
+
+// Разбор даты события: "2025-07-12" или "12.07.2025"
+func parseEventDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02.01.2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("не понял дату «%s», укажите ее как 2025-07-12", value)
+}
+
+// Добавление события: город ищем сразу, а по прогнозу Open-Meteo узнаем
+// часовой пояс, в котором указано время
+func scheduleEvent(store *Store, chatID int64, date time.Time, hour, minute int, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+	forecast, err := fetchEventForecast(point.Lat, point.Lon)
+	if err != nil {
+		return "", err
+	}
+
+	at := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, forecast.zone())
+	now := clockNow()
+	switch {
+	case !at.After(now):
+		return "", fmt.Errorf("событие уже прошло")
+	case at.Sub(now) > maxEventAhead:
+		return "", fmt.Errorf("событие можно добавить не больше чем за год")
+	}
+
+	event := WeatherEvent{
+		ID:      newEventID(),
+		ChatID:  chatID,
+		City:    point.DisplayName(),
+		Lat:     point.Lat,
+		Lon:     point.Lon,
+		Time:    at,
+		Created: now,
+	}
+	// Наступившие этапы не повторяем: прогноз на них уже в ответе
+	for _, stage := range event.dueStages(now) {
+		event.Sent = append(event.Sent, stage.name)
+	}
+
+	units := store.Preferences(chatID).Units
+	reply := fmt.Sprintf("✅ Событие добавлено: %s, %s.\n", event.City, formatEventTime(event.Time))
+	if snapshot, ok := forecast.snapshot(at); ok {
+		snapshot.Stage = eventStageAdded
+		event.Snapshots = append(event.Snapshots, snapshot)
+		reply += "Прогноз на это время: " + snapshot.short(units)
+		if snapshot.Description != "" {
+			reply += ", " + snapshot.Description
+		}
+		reply += ".\n"
+	} else {
+		reply += "Прогноз на эту дату пока не готов.\n"
+	}
+	if err := store.AddEvent(event); err != nil {
+		return "", err
+	}
+
+	var upcoming []string
+	for _, stage := range eventStages {
+		if at.Add(-stage.before).After(now) {
+			upcoming = append(upcoming, stage.label)
+		}
+	}
+	if len(upcoming) > 0 {
+		reply += "\nПришлю обновленный прогноз " + strings.Join(upcoming, ", ") + " до начала."
+	}
+	return reply, nil
+}
+
+// Список событий для ответа на /event
+func eventListText(events []WeatherEvent) string {
+	if len(events) == 0 {
+		return "📅 Событий нет. Добавьте дату, время и город, и бот пришлет прогноз за неделю, за 3 дня, за сутки и за 3 часа до начала:\n" +
+			"/event 2025-07-12 18:00 Казань"
+	}
+
+	var b strings.Builder
+	b.WriteString("📅 События:\n")
+	for i, event := range events {
+		fmt.Fprintf(&b, "%d. %s, %s\n", i+1, event.City, formatEventTime(event.Time))
+	}
+	b.WriteString("\nУдалить: /event del N")
+	return b.String()
+}
+
+// /event [дата время город|del N] — прогноз к событию с обратным отсчетом
+func handleEventCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	args := strings.Fields(c.args)
+	if len(args) == 0 {
+		c.msg.Text = eventListText(c.bot.store.Events(chatID))
+		return
+	}
+
+	if strings.EqualFold(args[0], "del") {
+		n := 0
+		if len(args) == 2 {
+			n, _ = strconv.Atoi(args[1])
+		}
+		removed, err := c.bot.store.DeleteEvent(chatID, n)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Событие удалено."
+		default:
+			c.msg.Text = "Нет события с таким номером. Список: /event"
+		}
+		return
+	}
+
+	if len(args) < 3 {
+		c.msg.Text = "Укажите дату, время и город, например: /event 2025-07-12 18:00 Казань"
+		return
+	}
+	date, err := parseEventDate(args[0])
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	hour, minute, err := parsePostTime(args[1])
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	reply, err := scheduleEvent(c.bot.store, chatID, date, hour, minute, strings.Join(args[2:], " "))
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	c.msg.Text = reply
+}
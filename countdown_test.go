@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventDueStages(t *testing.T) {
+	at := time.Date(2026, 10, 25, 18, 0, 0, 0, time.UTC)
+	event := WeatherEvent{Time: at}
+
+	if due := event.dueStages(at.Add(-8 * 24 * time.Hour)); len(due) != 0 {
+		t.Errorf("за 8 дней наступили этапы %+v", due)
+	}
+	if due := event.dueStages(at.Add(-2 * 24 * time.Hour)); len(due) != 2 || due[1].name != "3d" {
+		t.Errorf("за 2 дня этапы %+v, ожидались 7d и 3d", due)
+	}
+	event.Sent = []string{"7d", "3d"}
+	if due := event.dueStages(at.Add(-2 * time.Hour)); len(due) != 2 || due[0].name != "1d" || due[1].name != "3h" {
+		t.Errorf("за 2 часа этапы %+v, ожидались 1d и 3h", due)
+	}
+	if due := event.dueStages(at); len(due) != 0 {
+		t.Errorf("после начала этапы %+v", due)
+	}
+}
+
+func TestEventForecastSnapshot(t *testing.T) {
+	useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	// Индия: часы прогноза начинаются в :30 по UTC
+	var data eventHourlyResponse
+	data.UTCOffset = 19800
+	for i, temp := range []float64{20, 21, 22} {
+		data.Hourly.Time = append(data.Hourly.Time, time.Date(2026, 10, 20, 12, 30, 0, 0, time.UTC).Add(time.Duration(i)*time.Hour).Unix())
+		data.Hourly.Temperature = append(data.Hourly.Temperature, floatPtr(temp))
+		data.Hourly.PrecipitationProbability = append(data.Hourly.PrecipitationProbability, floatPtr(40))
+		data.Hourly.WeatherCode = append(data.Hourly.WeatherCode, floatPtr(61))
+	}
+
+	at := time.Date(2026, 10, 20, 19, 0, 0, 0, data.zone())
+	snapshot, ok := data.snapshot(at)
+	if !ok || snapshot.Temp != 21 || snapshot.Pop != 0.4 || snapshot.Description != "дождь" {
+		t.Errorf("snapshot = %+v, %v", snapshot, ok)
+	}
+	if _, ok := data.snapshot(at.Add(24 * time.Hour)); ok {
+		t.Error("прогноз за пределами ответа")
+	}
+}
+
+func TestPipelineEventCountdown(t *testing.T) {
+	f := newFakeTelegram(t)
+	c := *config()
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	simClock := useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	const chatID = 2701
+	f.send(textUpdate(chatID, "/event 2026-10-25 18:00 Казань"))
+	reply := f.reply(t, chatID)
+	for _, want := range []string{"Событие добавлено: Казань, Воскресенье 25.10 18:00", "Прогноз на это время:", "за неделю, за 3 дня, за сутки, за 3 часа до начала"} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("ответ %q не содержит %q", reply, want)
+		}
+	}
+	events := store.Events(chatID)
+	if len(events) != 1 {
+		t.Fatalf("событий %d", len(events))
+	}
+	at := events[0].Time
+
+	// За неделю — первое обновление, повторно не отправляется
+	f.reset()
+	simClock.Set(at.Add(-7 * 24 * time.Hour))
+	checkEvents(f.bot, store)
+	checkEvents(f.bot, store)
+	if got := f.reply(t, chatID); !strings.HasPrefix(got, "📅 Через неделю событие в Казань") {
+		t.Errorf("обновление за неделю %q", got)
+	}
+
+	// Пропущенный этап за 3 дня не отправляется отдельно
+	f.reset()
+	simClock.Set(at.Add(-23 * time.Hour))
+	checkEvents(f.bot, store)
+	got := f.reply(t, chatID)
+	for _, want := range []string{"Уже завтра", "• при добавлении:", "• за неделю:", "• сейчас:"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("обновление за сутки %q не содержит %q", got, want)
+		}
+	}
+	if strings.Contains(got, "за 3 дня:") {
+		t.Errorf("в истории пропущенный этап: %q", got)
+	}
+
+	// После начала событие удаляется
+	f.reset()
+	simClock.Set(at.Add(time.Minute))
+	checkEvents(f.bot, store)
+	if len(f.sent("sendMessage")) != 0 || len(store.Events(chatID)) != 0 {
+		t.Errorf("событие после начала: %+v", store.Events(chatID))
+	}
+}
+
+func TestEventCommandErrors(t *testing.T) {
+	f := newFakeTelegram(t)
+	c := *config()
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		text, want string
+	}{
+		{"/event", "Событий нет"},
+		{"/event 2026-10-25", "Укажите дату, время и город"},
+		{"/event 25/10 18:00 Казань", "не понял дату"},
+		{"/event 2026-10-25 18ч Казань", "не понял время"},
+		{"/event 2026-01-01 18:00 Казань", "событие уже прошло"},
+		{"/event 2028-01-01 18:00 Казань", "не больше чем за год"},
+		{"/event del 3", "Нет события с таким номером"},
+	}
+	for _, tt := range tests {
+		f.reset()
+		f.send(textUpdate(2702, tt.text))
+		if got := f.reply(t, 2702); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: ответ %q не содержит %q", tt.text, got, tt.want)
+		}
+	}
+}
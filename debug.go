@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Адрес отладочного сервера считается закрытым, если он слушает только
+// локальный интерфейс. На остальных адресах нужен DEBUG_TOKEN
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Проверка токена из заголовка Authorization: Bearer ... или параметра token
+// (go tool pprof не умеет передавать заголовки)
+func debugAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if got == "" {
+				got = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "нужен токен отладки", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Состояние процесса и размеры растущих в памяти таблиц
+func runtimeStats() map[string]interface{} {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	weatherCache.mu.RLock()
+	weatherEntries := len(weatherCache.data)
+	weatherCache.mu.RUnlock()
+
+	coordsCache.mu.RLock()
+	coordsEntries := len(coordsCache.data)
+	coordsCache.mu.RUnlock()
+
+	placeCacheMu.Lock()
+	placeEntries := len(placeCache)
+	placeCacheMu.Unlock()
+
+	nearbyCacheMu.Lock()
+	nearbyEntries := len(nearbyCache)
+	nearbyCacheMu.Unlock()
+
+	climateCache.mu.RLock()
+	climateEntries := len(climateCache.data)
+	climateCache.mu.RUnlock()
+
+	observationStore.mu.Lock()
+	observationCities := len(observationStore.data)
+	observationStore.mu.Unlock()
+
+	liveSessions := 0
+	for _, bot := range bots {
+		bot.live.mu.Lock()
+		liveSessions += len(bot.live.sessions)
+		bot.live.mu.Unlock()
+	}
+
+	limiter.mu.Lock()
+	rateBuckets := len(limiter.buckets)
+	limiter.mu.Unlock()
+
+	return map[string]interface{}{
+		"uptime":     time.Since(startTime).Round(time.Second).String(),
+		"goroutines": runtime.NumGoroutine(),
+		"heap_alloc": mem.HeapAlloc,
+		"heap_inuse": mem.HeapInuse,
+		"sys":        mem.Sys,
+		"num_gc":     mem.NumGC,
+		"last_gc":    time.Unix(0, int64(mem.LastGC)).Format(time.RFC3339),
+		"maps": map[string]int{
+			"weather_cache":      weatherEntries,
+			"coords_cache":       coordsEntries,
+			"place_cache":        placeEntries,
+			"nearby_cache":       nearbyEntries,
+			"climate_cache":      climateEntries,
+			"observation_cities": observationCities,
+			"live_sessions":      liveSessions,
+			"rate_limit_buckets": rateBuckets,
+		},
+	}
+}
+
+// Отладочный HTTP-сервер: профили net/http/pprof и /debug/runtime
+func runDebugServer(addr, token string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, runtimeStats())
+	})
+
+	log.Printf("Отладочный сервер слушает %s", addr)
+	if err := http.ListenAndServe(addr, debugAuth(token, mux)); err != nil {
+		log.Printf("Ошибка отладочного сервера: %v", err)
+	}
+}
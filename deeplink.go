@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Префиксы параметра start в ссылках вида t.me/bot?start=city_London
+const (
+	startPrefixCity = "city_"
+	startPrefixSub  = "sub_"
+)
+
+// Город из ссылки t.me/bot?start=city_New_York. Параметр start допускает
+// только латиницу, цифры, "_" и "-", поэтому пробелы кодируются подчеркиванием
+func startPayloadCity(message *tgbotapi.Message) (string, bool) {
+	if message.Command() != "start" {
+		return "", false
+	}
+	payload := message.CommandArguments()
+	if !strings.HasPrefix(payload, startPrefixCity) {
+		return "", false
+	}
+	city := strings.TrimSpace(strings.ReplaceAll(strings.TrimPrefix(payload, startPrefixCity), "_", " "))
+	return city, city != ""
+}
+
+// Подписки, которые можно оформить по ссылке t.me/bot?start=sub_<тип>
+var startSubscriptions = map[string]struct {
+	command     string
+	subscribe   func(store *Store, chatID int64, city string) (string, error)
+	description string
+}{
+	alertDaily:   {command: "/daily", subscribe: subscribeDaily, description: "утренняя сводка погоды"},
+	alertAurora:  {command: "/aurora", subscribe: subscribeAurora, description: "оповещения о полярном сиянии"},
+	alertThunder: {command: "/thunder", subscribe: subscribeThunder, description: "предупреждения о грозах"},
+	alertSolar:   {command: "/solar on", subscribe: subscribeSolar, description: "утренние оценки выработки солнечных панелей"},
+	alertSunset:  {command: "/sunset", subscribe: subscribeSunset, description: "оповещения о красивом закате"},
+}
+
+// Обработка ссылки t.me/bot?start=sub_daily: если город уже известен,
+// сразу оформляем подписку, иначе подсказываем команду
+func handleStartSubscription(store *Store, payload string, chatID int64, lastCity string) (string, bool, error) {
+	if !strings.HasPrefix(payload, startPrefixSub) {
+		return "", false, nil
+	}
+
+	kind := strings.TrimPrefix(payload, startPrefixSub)
+	option, ok := startSubscriptions[kind]
+	if !ok {
+		return "", false, nil
+	}
+
+	if lastCity == "" {
+		return fmt.Sprintf(
+			"Привет! 🌤 Чтобы оформить подписку (%s), отправьте команду с вашим городом, например:\n%s Москва",
+			option.description,
+			option.command,
+		), true, nil
+	}
+
+	reply, err := option.subscribe(store, chatID, lastCity)
+	return reply, true, err
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Доставка оповещений: при временных ошибках Telegram (сеть, 5xx, 429)
+// отправка повторяется с нарастающей паузой. Повторы идут только в
+// фоновых рассылках, ответы на команды их не ждут. Оповещение, которое так и
+// не удалось доставить, сохраняется, и пользователь может получить его
+// командой /missed
+
+// Паузы перед повторами отправки; в тестах короче
+var sendRetryDelays = []time.Duration{500 * time.Millisecond, 2 * time.Second, 5 * time.Second}
+
+// Дольше этого ждать по retry_after не будем: сообщение сохранится в /missed
+const sendRetryAfterMax = 30 * time.Second
+
+// Сколько пропущенных оповещений храним на чат и как долго
+const (
+	missedLimit = 10
+	missedTTL   = 7 * 24 * time.Hour
+)
+
+// Оповещение, которое не удалось доставить
+type MissedMessage struct {
+	Title string    `json:"title"`
+	Text  string    `json:"text"`
+	At    time.Time `json:"at"`
+	// Пользователю уже сказали о нем
+	Noticed bool `json:"noticed,omitempty"`
+}
+
+// Временная ли ошибка отправки и сколько подождать перед повтором.
+// Ошибки без ответа Telegram (сеть, обрыв) считаются временными
+func transientSendError(err error) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return 0, true
+	}
+	switch {
+	case apiErr.Code == 429:
+		wait := time.Duration(apiErr.RetryAfter) * time.Second
+		return wait, wait <= sendRetryAfterMax
+	case apiErr.Code >= 500:
+		return 0, true
+	}
+	return 0, false
+}
+
+// Отправка с повторами при временных ошибках
+func sendWithRetry(bot messageSender, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	for attempt := 0; ; attempt++ {
+		message, err := bot.Send(c)
+		if err == nil {
+			return message, nil
+		}
+		wait, transient := transientSendError(err)
+		if !transient || attempt >= len(sendRetryDelays) {
+			return message, err
+		}
+		if wait < sendRetryDelays[attempt] {
+			wait = sendRetryDelays[attempt]
+		}
+		log.Printf("Ошибка отправки сообщения, повтор через %s: %v", wait, err)
+		time.Sleep(wait)
+	}
+}
+
+// Отправка оповещения. Если Telegram так и не принял сообщение, оно
+// сохраняется для /missed, а ошибка возвращается для лога. Пока оповещения
+// чата на паузе, они не отправляются и в /missed не попадают
+func deliver(bot messageSender, store *Store, chatID int64, title string, c tgbotapi.Chattable, text string) error {
+	if alertsPaused(store, chatID, clockNow()) {
+		return nil
+	}
+
+	var err error
+	c = withPauseButtons(store, c)
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		msg.Text = accessibleText(store, chatID, msg.Text)
+		_, err = sendSplitWithRetry(bot, msg)
+	} else {
+		_, err = sendWithRetry(bot, c)
+	}
+	if err == nil {
+		return nil
+	}
+	if err := store.AddMissed(chatID, MissedMessage{Title: title, Text: text, At: clockNow()}); err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+	return err
+}
+
+// Сохранение пропущенного оповещения. Устаревшие удаляются, из лишних
+// остаются самые новые
+func (s *Store) AddMissed(chatID int64, missed MissedMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var kept []*MissedMessage
+	for _, old := range s.data.Missed[chatID] {
+		if missed.At.Sub(old.At) <= missedTTL {
+			kept = append(kept, old)
+		}
+	}
+	kept = append(kept, &missed)
+	if len(kept) > missedLimit {
+		kept = kept[len(kept)-missedLimit:]
+	}
+	s.data.Missed[chatID] = kept
+	return s.save()
+}
+
+// Пропущенные оповещения чата; они удаляются, потому что пользователь их
+// получил
+func (s *Store) TakeMissed(chatID int64, now time.Time) ([]MissedMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.data.Missed[chatID]
+	if !exists {
+		return nil, nil
+	}
+	var missed []MissedMessage
+	for _, message := range stored {
+		if now.Sub(message.At) <= missedTTL {
+			missed = append(missed, *message)
+		}
+	}
+	delete(s.data.Missed, chatID)
+	return missed, s.save()
+}
+
+// Число пропущенных оповещений, о которых пользователю еще не говорили.
+// Они отмечаются, чтобы не напоминать на каждое сообщение
+func (s *Store) NoticeMissed(chatID int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, message := range s.data.Missed[chatID] {
+		if !message.Noticed {
+			message.Noticed = true
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return count, s.save()
+}
+
+func pluralAlerts(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "оповещение"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "оповещения"
+	}
+	return "оповещений"
+}
+
+// Напоминание о пропущенных оповещениях после ответа на команду
+func missedNotice(store *Store, chatID int64) string {
+	count, err := store.NoticeMissed(chatID)
+	if err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("📭 Бот не смог доставить вам %d %s. Получить их: /missed", count, pluralAlerts(count))
+}
+
+// /missed — оповещения, которые не удалось доставить
+func handleMissedCommand(c *commandContext) {
+	missed, err := c.bot.store.TakeMissed(c.message.Chat.ID, clockNow())
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	if len(missed) == 0 {
+		c.msg.Text = "📭 Пропущенных оповещений нет."
+		return
+	}
+
+	for _, message := range missed {
+		text := fmt.Sprintf("📭 %s, %s:\n\n%s", message.Title, message.At.Format("02.01 15:04"), message.Text)
+		if _, err := sendSplit(c.bot, tgbotapi.NewMessage(c.message.Chat.ID, accessibleText(c.bot.store, c.message.Chat.ID, text))); err != nil {
+			log.Printf("Ошибка отправки пропущенного оповещения: %v", err)
+		}
+	}
+	c.msg.Text = fmt.Sprintf("📭 Это все: %d %s за последнюю неделю.", len(missed), pluralAlerts(len(missed)))
+}
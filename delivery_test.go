@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Отправитель, который отвечает ошибками из очереди, а потом принимает сообщения
+type flakySender struct {
+	errs  []error
+	calls int
+}
+
+func (s *flakySender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return tgbotapi.Message{}, err
+	}
+	return tgbotapi.Message{MessageID: s.calls}, nil
+}
+
+func useFastRetries(t *testing.T) {
+	t.Helper()
+	previous := sendRetryDelays
+	sendRetryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+	t.Cleanup(func() { sendRetryDelays = previous })
+}
+
+func TestTransientSendError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+		wait      time.Duration
+	}{
+		{"сеть", errors.New("connection reset by peer"), true, 0},
+		{"502", &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}, true, 0},
+		{"429", &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3}}, true, 3 * time.Second},
+		{"429 надолго", &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 600}}, false, 600 * time.Second},
+		{"бот заблокирован", &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}, false, 0},
+		{"неверный запрос", &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}, false, 0},
+	}
+	for _, tt := range tests {
+		wait, transient := transientSendError(tt.err)
+		if transient != tt.transient || wait != tt.wait {
+			t.Errorf("%s: transientSendError = %s, %v", tt.name, wait, transient)
+		}
+	}
+}
+
+func TestSendWithRetry(t *testing.T) {
+	useFastRetries(t)
+	gateway := &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}
+
+	sender := &flakySender{errs: []error{gateway, gateway}}
+	if _, err := sendWithRetry(sender, tgbotapi.NewMessage(1, "тест")); err != nil || sender.calls != 3 {
+		t.Errorf("после двух временных ошибок: %v, попыток %d", err, sender.calls)
+	}
+
+	sender = &flakySender{errs: []error{gateway, gateway, gateway}}
+	if _, err := sendWithRetry(sender, tgbotapi.NewMessage(1, "тест")); err == nil || sender.calls != 3 {
+		t.Errorf("повторы не ограничены: %v, попыток %d", err, sender.calls)
+	}
+
+	sender = &flakySender{errs: []error{&tgbotapi.Error{Code: 403, Message: "Forbidden"}}}
+	if _, err := sendWithRetry(sender, tgbotapi.NewMessage(1, "тест")); err == nil || sender.calls != 1 {
+		t.Errorf("повтор после постоянной ошибки: %v, попыток %d", err, sender.calls)
+	}
+	// Ответы на команды не ждут повторов, чтобы не задерживать остальные чаты
+	sender = &flakySender{errs: []error{gateway}}
+	if _, err := sendSplit(sender, tgbotapi.NewMessage(1, "тест")); err == nil || sender.calls != 1 {
+		t.Errorf("повтор ответа на команду: %v, попыток %d", err, sender.calls)
+	}
+	sender = &flakySender{errs: []error{gateway}}
+	if _, err := sendSplitWithRetry(sender, tgbotapi.NewMessage(1, "тест")); err != nil || sender.calls != 2 {
+		t.Errorf("повтор оповещения: %v, попыток %d", err, sender.calls)
+	}
+}
+
+func TestMissedAlerts(t *testing.T) {
+	f := newFakeTelegram(t)
+	useFastRetries(t)
+	useManualClock(t, time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC))
+	const chatID = 4351
+
+	blocked := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	for _, title := range []string{"Гроза", "Утренняя сводка"} {
+		sender := &flakySender{errs: []error{blocked}}
+		if err := deliver(sender, store, chatID, title, tgbotapi.NewMessage(chatID, title+": текст"), title+": текст"); err == nil {
+			t.Fatal("deliver не вернул ошибку")
+		}
+	}
+
+	// Первый же ответ после разблокировки напоминает о пропущенном, но один раз
+	f.send(textUpdate(chatID, "/help"))
+	messages := f.sent("sendMessage")
+	if len(messages) != 2 || messages[1].Params["text"] != "📭 Бот не смог доставить вам 2 оповещения. Получить их: /missed" {
+		t.Errorf("напоминание: %+v", messages)
+	}
+	f.reset()
+	f.send(textUpdate(chatID, "/help"))
+	f.reply(t, chatID)
+
+	f.reset()
+	f.send(textUpdate(chatID, "/missed"))
+	messages = f.sent("sendMessage")
+	if len(messages) != 3 || messages[0].Params["text"] != "📭 Гроза, 16.10 09:30:\n\nГроза: текст" ||
+		messages[2].Params["text"] != "📭 Это все: 2 оповещения за последнюю неделю." {
+		t.Errorf("пропущенные оповещения: %+v", messages)
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/missed"))
+	if reply := f.reply(t, chatID); reply != "📭 Пропущенных оповещений нет." {
+		t.Errorf("повторный /missed: %q", reply)
+	}
+}
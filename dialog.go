@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Через сколько времени без ответа диалог забывается
+const dialogTimeout = 15 * time.Minute
+
+// Кнопка отмены, которая добавляется к каждому вопросу диалога
+const dialogCancel = "✖️ Отмена"
+
+// Состояние многошагового диалога с чатом
+type DialogState struct {
+	Flow      string            `json:"flow"`
+	Step      string            `json:"step"`
+	Data      map[string]string `json:"data,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Вопрос пользователю: текст и варианты ответа для клавиатуры
+type dialogPrompt struct {
+	text    string
+	options []string
+	// Кнопка отправки геопозиции над вариантами ответа
+	location bool
+}
+
+// Шаг диалога
+type dialogStep struct {
+	prompt func(state *DialogState) dialogPrompt
+	// Обработка ответа: возвращает следующий шаг или "" и итоговый текст,
+	// если диалог завершен. Ошибка означает, что вопрос нужно задать заново
+	handle func(bot *Bot, chatID int64, state *DialogState, input string) (next string, reply string, err error)
+	// Обработка геопозиции, если шаг ее ждет. Геопозиция в шаге без
+	// этого обработчика показывает погоду как обычно
+	location func(bot *Bot, chatID int64, state *DialogState, lat, lon float64) (next string, reply string, err error)
+}
+
+// Описание диалога: первый шаг и все шаги по именам
+type dialogFlow struct {
+	first string
+	steps map[string]dialogStep
+	// Итоговое сообщение по тексту последнего шага. Если не задано,
+	// отправляется просто текст без клавиатуры
+	finish func(bot *Bot, chatID int64, state *DialogState, reply string) tgbotapi.MessageConfig
+}
+
+// Зарегистрированные диалоги
+var dialogFlows = map[string]dialogFlow{}
+
+// Текущий диалог чата. Диалог, в котором давно не было ответов, удаляется
+func (s *Store) Dialog(chatID int64) (DialogState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, exists := s.data.Dialogs[chatID]
+	if !exists {
+		return DialogState{}, false
+	}
+	if time.Since(state.UpdatedAt) > dialogTimeout {
+		delete(s.data.Dialogs, chatID)
+		if err := s.save(); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		return DialogState{}, false
+	}
+	return *state, true
+}
+
+// Сохранение состояния диалога с отметкой времени последнего ответа
+func (s *Store) SetDialog(chatID int64, state DialogState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.UpdatedAt = time.Now()
+	s.data.Dialogs[chatID] = &state
+	return s.save()
+}
+
+// Завершение диалога. Возвращает false, если диалога не было
+func (s *Store) ClearDialog(chatID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.data.Dialogs[chatID]; !exists {
+		return false, nil
+	}
+	delete(s.data.Dialogs, chatID)
+	return true, s.save()
+}
+
+// Сообщение с вопросом и клавиатурой вариантов ответа
+func promptMessage(chatID int64, prompt dialogPrompt) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, prompt.text)
+
+	var rows [][]tgbotapi.KeyboardButton
+	if prompt.location {
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButtonLocation("📍 Отправить местоположение")))
+	}
+	for _, option := range prompt.options {
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(option)))
+	}
+	rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(dialogCancel)))
+
+	keyboard := tgbotapi.NewReplyKeyboard(rows...)
+	keyboard.OneTimeKeyboard = true
+	msg.ReplyMarkup = keyboard
+	return msg
+}
+
+// Начало диалога: запоминаем состояние и задаем первый вопрос
+func startDialog(store *Store, chatID int64, flowName string, data map[string]string) (tgbotapi.MessageConfig, error) {
+	flow, ok := dialogFlows[flowName]
+	if !ok {
+		return tgbotapi.MessageConfig{}, fmt.Errorf("неизвестный диалог: %s", flowName)
+	}
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	state := DialogState{Flow: flowName, Step: flow.first, Data: data}
+	if err := store.SetDialog(chatID, state); err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	return promptMessage(chatID, flow.steps[flow.first].prompt(&state)), nil
+}
+
+// Ответ на текстовое сообщение или геопозицию внутри диалога. Возвращает
+// false, если диалога нет и сообщение нужно обработать как обычно
+func handleDialogMessage(bot *Bot, message *tgbotapi.Message) (tgbotapi.MessageConfig, bool) {
+	chatID := message.Chat.ID
+	input := strings.TrimSpace(message.Text)
+	if input == "" && message.Location == nil {
+		return tgbotapi.MessageConfig{}, false
+	}
+
+	state, ok := bot.store.Dialog(chatID)
+	if !ok {
+		return tgbotapi.MessageConfig{}, false
+	}
+
+	flow := dialogFlows[state.Flow]
+	step, ok := flow.steps[state.Step]
+	if !ok || input == dialogCancel {
+		if _, err := bot.store.ClearDialog(chatID); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, "Хорошо, отменил.")
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		return msg, true
+	}
+
+	var next, reply string
+	var err error
+	switch {
+	case message.Location != nil && step.location != nil:
+		next, reply, err = step.location(bot, chatID, &state, message.Location.Latitude, message.Location.Longitude)
+	case message.Location != nil:
+		return tgbotapi.MessageConfig{}, false
+	default:
+		next, reply, err = step.handle(bot, chatID, &state, input)
+	}
+	if err != nil {
+		// Переспрашиваем тот же шаг и продлеваем диалог
+		if err := bot.store.SetDialog(chatID, state); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		msg := promptMessage(chatID, step.prompt(&state))
+		msg.Text = "❌ " + err.Error() + "\n\n" + msg.Text
+		return msg, true
+	}
+
+	if next == "" {
+		if _, err := bot.store.ClearDialog(chatID); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		if flow.finish != nil {
+			return flow.finish(bot, chatID, &state, reply), true
+		}
+		msg := tgbotapi.NewMessage(chatID, reply)
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		return msg, true
+	}
+
+	state.Step = next
+	if err := bot.store.SetDialog(chatID, state); err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+	return promptMessage(chatID, flow.steps[next].prompt(&state)), true
+}
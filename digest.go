@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Тип подписки на утреннюю сводку погоды
+const alertDaily = "daily"
+
+// Часы местного времени, в которые отправляем утреннюю сводку
+const (
+	digestMorningFrom = 7
+	digestMorningTo   = 9
+)
+
+// Допустимый час отправки сводки, если пользователь выбрал его сам
+const (
+	digestHourMin = 5
+	digestHourMax = 11
+)
+
+// Сводка приходит раз в местные сутки, поэтому интервала между оповещениями
+// у нее нет: время в будни и выходные может отличаться на несколько часов,
+// и любой фиксированный интервал съел бы окно следующего дня
+func init() {
+	alertKinds[alertDaily] = alertKind{title: "Утренняя сводка", check: checkDaily}
+}
+
+// Отправлена ли сводка уже сегодня по местному времени now
+func digestSentToday(sub *AlertSubscription, now time.Time) bool {
+	return !sub.LastFired.IsZero() && sub.LastFired.In(now.Location()).Format("2006-01-02") == now.Format("2006-01-02")
+}
+
+// Утренняя сводка: текущая погода, прогноз на день и сравнение со вчера
+func checkDaily(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	// Если часовой пояс города уже известен, после сегодняшней сводки
+	// прогноз до завтра не запрашиваем
+	if location, ok := coordsCache.Location(sub.Lat, sub.Lon); ok && digestSentToday(sub, clockNow().In(location)) {
+		return "", false, nil
+	}
+
+	forecast, err := cachedForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	now := forecast.Now()
+	if digestSentToday(sub, now) {
+		return "", false, nil
+	}
+	from, to := digestWindow(sub, now.Weekday())
+	if minutes := now.Hour()*60 + now.Minute(); minutes < from || minutes >= to {
+		return "", false, nil
+	}
+
+	current, err := cachedWeatherByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	digest := formatDigest(sub.City, current, forecast, now, sub.Blocks)
+	if tip := digestTipText(sub, prefs, now); tip != "" {
+		digest = strings.TrimRight(digest, "\n") + "\n\n" + tip
+	}
+	return digest, true, nil
+}
+
+// Когда отправлять сводку в день недели day: начало и конец окна в минутах
+// от полуночи местного времени. Время на будни и выходные задается отдельно,
+// иначе действует выбранный час или обычное окно с 7 до 9
+func digestWindow(sub *AlertSubscription, day time.Weekday) (int, int) {
+	weekend := day == time.Saturday || day == time.Sunday
+	switch {
+	case weekend && sub.Weekends > 0:
+		return sub.Weekends, sub.Weekends + 60
+	case !weekend && sub.Weekdays > 0:
+		return sub.Weekdays, sub.Weekdays + 60
+	case sub.Hour > 0:
+		return sub.Hour * 60, (sub.Hour + 1) * 60
+	}
+	return digestMorningFrom * 60, digestMorningTo * 60
+}
+
+// Расписание сводки для ответа пользователю: "в будни в 07:00, в выходные в 09:30"
+func digestScheduleText(sub *AlertSubscription) string {
+	weekdays, _ := digestWindow(sub, time.Monday)
+	weekends, _ := digestWindow(sub, time.Saturday)
+	switch {
+	case weekdays != weekends:
+		return fmt.Sprintf("в будни в %s, в выходные в %s", formatDayMinutes(weekdays), formatDayMinutes(weekends))
+	case sub.Weekdays > 0:
+		return "в " + formatDayMinutes(weekdays)
+	case sub.Hour > 0:
+		return fmt.Sprintf("в %d:00", sub.Hour)
+	}
+	return fmt.Sprintf("с %d:00 до %d:00", digestMorningFrom, digestMorningTo)
+}
+
+// Текст утренней сводки из выбранных блоков (пустой список — обычный набор)
+func formatDigest(city string, current *CurrentWeather, forecast *Forecast, now time.Time, blocks []string) string {
+	show := digestBlockSet(blocks)
+	digest := fmt.Sprintf("☀️ Доброе утро! Погода в %s на сегодня:\n\n", city)
+
+	if show[digestBlockCurrent] {
+		digest += fmt.Sprintf("🌡 Сейчас %.0f°C (ощущается как %.0f°C), %s\n",
+			current.Temp,
+			current.FeelsLike,
+			current.Description,
+		)
+	}
+
+	minTemp, maxTemp := math.Inf(1), math.Inf(-1)
+	maxPop, maxWind := 0.0, 0.0
+	today := now.Format("2006-01-02")
+	for _, item := range forecast.Items {
+		if forecast.LocalTime(item).Format("2006-01-02") != today {
+			continue
+		}
+		minTemp = math.Min(minTemp, item.Temp)
+		maxTemp = math.Max(maxTemp, item.Temp)
+		maxPop = math.Max(maxPop, item.Pop)
+		maxWind = math.Max(maxWind, item.WindSpeed)
+	}
+	if show[digestBlockDay] && !math.IsInf(minTemp, 0) {
+		digest += fmt.Sprintf("📈 Днем от %.0f°C до %.0f°C, ветер до %.0f м/с\n", minTemp, maxTemp, maxWind)
+		if maxPop >= 0.5 {
+			digest += fmt.Sprintf("☔️ Вероятность осадков до %.0f%% — возьмите зонт\n", maxPop*100)
+		}
+	}
+	if show[digestBlockHourly] {
+		digest += digestHourlyChart(forecast, now)
+	}
+	if show[digestBlockClothing] {
+		digest += "👕 " + simpleWeather(current.FeelsLike, current.WindSpeed, current.Condition, langRU) + "\n"
+	}
+	if show[digestBlockUV] {
+		if uv := uvIndexLine(current, langRU); uv != "" {
+			digest += "🕶 УФ-индекс " + uv + "\n"
+		}
+	}
+	if show[digestBlockAQI] {
+		digest += digestAirQuality(current)
+	}
+	if show[digestBlockSun] && !current.Sunrise.IsZero() {
+		digest += fmt.Sprintf("🌅 Восход в %s, закат в %s\n", current.Sunrise.Format("15:04"), current.Sunset.Format("15:04"))
+	}
+
+	// Сводка приходит примерно в одно и то же время, поэтому вчерашнее наблюдение
+	// обычно находится и сравнение получается "утро к утру". Наблюдение
+	// записываем, даже если сравнение в сводке не показываем
+	comparison := recordAndCompare(city, current, UserPreferences{Units: unitsMetric, Language: langRU})
+	if show[digestBlockYesterday] {
+		digest += comparison
+	}
+
+	return digest
+}
+
+// Подписка чата на утреннюю сводку в обычное время
+func subscribeDaily(store *Store, chatID int64, city string) (string, error) {
+	return subscribeDailyAt(store, chatID, city, 0)
+}
+
+// Подписка чата на утреннюю сводку в выбранный час (0 — в обычное время)
+func subscribeDailyAt(store *Store, chatID int64, city string, hour int) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	// Выбранные блоки сводки сохраняются при смене города, а время — если
+	// новый час не указан
+	previous, _ := dailySubscription(store, chatID)
+	sub := AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertDaily,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+		Hour:   hour,
+		Blocks: previous.Blocks,
+	}
+	if hour == 0 {
+		sub.Hour, sub.Weekdays, sub.Weekends = previous.Hour, previous.Weekdays, previous.Weekends
+	}
+	if err := store.Subscribe(sub); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"☀️ Каждое утро %s пришлю сводку погоды в %s.\n"+
+			"Выбрать, что в ней показывать: /daily blocks\n"+
+			"Другое время в будни и выходные: /daily time 7:00 9:30\n"+
+			"Отписаться: /daily off",
+		digestScheduleText(&sub),
+		point.DisplayName(),
+	), nil
+}
+
+// Сохранение времени сводки на будни и выходные в минутах от полуночи.
+// Возвращает false, если чат не подписан на сводку
+func (s *Store) SetDigestTimes(chatID int64, weekdays, weekends int) (AlertSubscription, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.data.Subscriptions[subscriptionKey(chatID, alertDaily)]
+	if !exists {
+		return AlertSubscription{}, false, nil
+	}
+	sub.Hour, sub.Weekdays, sub.Weekends = 0, weekdays, weekends
+	return *sub, true, s.save()
+}
+
+// Время сводки из /daily time: одно время на всю неделю или два — на будни
+// и на выходные
+func setDigestTime(store *Store, chatID int64, args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		usage := "Укажите время сводки в будни и в выходные, например: /daily time 7:00 9:30. Одно время — на всю неделю."
+		if sub, ok := dailySubscription(store, chatID); ok {
+			usage = fmt.Sprintf("☀️ Сейчас сводка приходит %s.\n%s", digestScheduleText(&sub), usage)
+		}
+		return usage, nil
+	}
+
+	var times []int
+	for _, arg := range args {
+		hour, minute, err := parsePostTime(arg)
+		if err != nil {
+			return "", err
+		}
+		if hour < digestHourMin || hour > digestHourMax {
+			return "", fmt.Errorf("сводку можно получать с %d:00 до %d:59", digestHourMin, digestHourMax)
+		}
+		times = append(times, hour*60+minute)
+	}
+	if len(times) == 1 {
+		times = append(times, times[0])
+	}
+
+	sub, ok, err := store.SetDigestTimes(chatID, times[0], times[1])
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "Вы не подписаны на утреннюю сводку. Подписаться: /daily Москва", nil
+	}
+	return fmt.Sprintf("⏰ Готово: сводка о погоде в %s будет приходить %s.", sub.City, digestScheduleText(&sub)), nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestWindow(t *testing.T) {
+	tests := []struct {
+		sub      AlertSubscription
+		day      time.Weekday
+		from, to int
+	}{
+		{AlertSubscription{}, time.Monday, 7 * 60, 9 * 60},
+		{AlertSubscription{Hour: 6}, time.Sunday, 6 * 60, 7 * 60},
+		{AlertSubscription{Weekdays: 7 * 60, Weekends: 9*60 + 30}, time.Friday, 7 * 60, 8 * 60},
+		{AlertSubscription{Weekdays: 7 * 60, Weekends: 9*60 + 30}, time.Saturday, 9*60 + 30, 10*60 + 30},
+		{AlertSubscription{Hour: 8, Weekends: 10 * 60}, time.Tuesday, 8 * 60, 9 * 60},
+	}
+	for _, tt := range tests {
+		if from, to := digestWindow(&tt.sub, tt.day); from != tt.from || to != tt.to {
+			t.Errorf("digestWindow(%+v, %s) = %d, %d, ожидалось %d, %d", tt.sub, tt.day, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestDailyTimeCommand(t *testing.T) {
+	f := useMockReports(t)
+	previousCoords := coordsCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { coordsCache = previousCoords })
+	clock := useManualClock(t, time.Date(2026, 10, 16, 4, 30, 0, 0, time.UTC))
+	const chatID = 4451
+
+	f.send(textUpdate(chatID, "/daily time 7:00 9:30"))
+	if reply := f.reply(t, chatID); !strings.Contains(reply, "не подписаны") {
+		t.Errorf("без подписки: %q", reply)
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/daily Москва"))
+	f.reset()
+	f.send(textUpdate(chatID, "/daily time 7:00 9:30"))
+	if reply := f.reply(t, chatID); !strings.Contains(reply, "в будни в 07:00, в выходные в 09:30") {
+		t.Errorf("ответ: %q", reply)
+	}
+	f.reset()
+	f.send(textUpdate(chatID, "/daily time 3:00"))
+	if reply := f.reply(t, chatID); !strings.HasPrefix(reply, "❌ Ошибка: сводку можно получать с 5:00") {
+		t.Errorf("слишком рано: %q", reply)
+	}
+
+	// Смена города не сбрасывает расписание
+	f.reset()
+	f.send(textUpdate(chatID, "/daily Тула"))
+	if reply := f.reply(t, chatID); !strings.Contains(reply, "в будни в 07:00, в выходные в 09:30") {
+		t.Errorf("расписание после смены города: %q", reply)
+	}
+
+	// Долгота 37.6 — в демо-режиме это UTC+3
+	stored, _ := dailySubscription(store, chatID)
+	sub := &AlertSubscription{ChatID: chatID, Kind: alertDaily, City: "Москва", Lat: 55.75, Lon: 37.62,
+		Weekdays: stored.Weekdays, Weekends: stored.Weekends}
+	due := func() bool {
+		t.Helper()
+		_, ok, err := checkDaily(sub, store.Preferences(sub.ChatID))
+		if err != nil {
+			t.Fatalf("checkDaily: %v", err)
+		}
+		return ok
+	}
+	if !due() {
+		t.Error("нет сводки в пятницу в 7:30")
+	}
+	clock.Advance(24 * time.Hour)
+	if due() {
+		t.Error("сводка в субботу в 7:30")
+	}
+	clock.Advance(2 * time.Hour)
+	if !due() {
+		t.Error("нет сводки в субботу в 9:30")
+	}
+}
+
+// Время в будни и выходные может отличаться на полдня, и сводка все равно
+// приходит каждый день: с пятницы на субботу (11:30 → 5:00) и с воскресенья
+// на понедельник (11:30 → 5:00)
+func TestDailyDigestEveryDay(t *testing.T) {
+	f := useMockReports(t)
+	previousCoords := coordsCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { coordsCache = previousCoords })
+	// Пятница, 00:00 по Москве (UTC+3 в демо-режиме)
+	start := time.Date(2026, 10, 15, 21, 0, 0, 0, time.UTC)
+	clock := useManualClock(t, start)
+
+	const earlyWeekend, earlyWeekday = 4452, 4453
+	schedules := map[int64]AlertSubscription{
+		earlyWeekend: {Weekdays: 11*60 + 30, Weekends: 5 * 60},
+		earlyWeekday: {Weekdays: 5 * 60, Weekends: 11*60 + 30},
+	}
+	for chatID, schedule := range schedules {
+		schedule.ChatID, schedule.Kind, schedule.City = chatID, alertDaily, "Москва"
+		schedule.Lat, schedule.Lon = 55.75, 37.62
+		if err := store.Subscribe(schedule); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	}
+
+	// С пятницы по понедельник включительно, проверки каждые полчаса
+	sent := make(map[string][]string)
+	for now := start; now.Before(start.Add(4 * 24 * time.Hour)); now = now.Add(alertCheckInterval) {
+		clock.Set(now)
+		f.reset()
+		checkAlerts(f.bot, store)
+		for _, call := range f.sent("sendMessage") {
+			local := now.In(time.FixedZone("UTC+3", 3*60*60)).Format("Mon 15:04")
+			sent[call.Params["chat_id"]] = append(sent[call.Params["chat_id"]], local)
+		}
+	}
+
+	want := map[string][]string{
+		"4452": {"Fri 11:30", "Sat 05:00", "Sun 05:00", "Mon 11:30"},
+		"4453": {"Fri 05:00", "Sat 11:30", "Sun 11:30", "Mon 05:00"},
+	}
+	for chatID, times := range want {
+		if got := strings.Join(sent[chatID], ", "); got != strings.Join(times, ", ") {
+			t.Errorf("сводки чата %s: %s, ожидалось %s", chatID, got, strings.Join(times, ", "))
+		}
+	}
+}
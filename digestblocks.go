@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Состав утренней сводки: пользователь отмечает в /daily blocks, какие
+// блоки в ней показывать. Выбор хранится в подписке, пустой — обычный набор
+
+const (
+	digestBlockCurrent   = "current"
+	digestBlockDay       = "day"
+	digestBlockHourly    = "hourly"
+	digestBlockClothing  = "clothing"
+	digestBlockUV        = "uv"
+	digestBlockAQI       = "aqi"
+	digestBlockSun       = "sun"
+	digestBlockYesterday = "yesterday"
+)
+
+// Блоки в том порядке, в котором они идут в сводке и в списке
+var digestBlockOrder = []string{
+	digestBlockCurrent,
+	digestBlockDay,
+	digestBlockHourly,
+	digestBlockClothing,
+	digestBlockUV,
+	digestBlockAQI,
+	digestBlockSun,
+	digestBlockYesterday,
+}
+
+var digestBlockTitles = map[string]string{
+	digestBlockCurrent:   "Погода сейчас",
+	digestBlockDay:       "Прогноз на день",
+	digestBlockHourly:    "График по часам",
+	digestBlockClothing:  "Совет по одежде",
+	digestBlockUV:        "УФ-индекс",
+	digestBlockAQI:       "Качество воздуха",
+	digestBlockSun:       "Восход и закат",
+	digestBlockYesterday: "Сравнение со вчера",
+}
+
+// Блоки сводки, если пользователь ничего не выбирал
+var defaultDigestBlocks = []string{digestBlockCurrent, digestBlockDay, digestBlockYesterday}
+
+// Сколько интервалов прогноза показываем на графике
+const digestHourlySlots = 6
+
+// Выбранные блоки сводки
+func digestBlockSet(blocks []string) map[string]bool {
+	if len(blocks) == 0 {
+		blocks = defaultDigestBlocks
+	}
+	set := make(map[string]bool, len(blocks))
+	for _, block := range blocks {
+		set[block] = true
+	}
+	return set
+}
+
+// Блоки после нажатия на пункт списка. Последний блок убрать нельзя
+func toggleDigestBlock(blocks []string, block string) ([]string, bool) {
+	set := digestBlockSet(blocks)
+	set[block] = !set[block]
+
+	var next []string
+	for _, name := range digestBlockOrder {
+		if set[name] {
+			next = append(next, name)
+		}
+	}
+	if len(next) == 0 {
+		return blocks, false
+	}
+	return next, true
+}
+
+// Сохранение состава сводки. Возвращает false, если чат не подписан на сводку
+func (s *Store) SetDigestBlocks(chatID int64, blocks []string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.data.Subscriptions[subscriptionKey(chatID, alertDaily)]
+	if !exists {
+		return false, nil
+	}
+	sub.Blocks = blocks
+	return true, s.save()
+}
+
+// Подписка чата на утреннюю сводку
+func dailySubscription(store *Store, chatID int64) (AlertSubscription, bool) {
+	for _, sub := range store.ChatSubscriptions(chatID) {
+		if sub.Kind == alertDaily {
+			return sub, true
+		}
+	}
+	return AlertSubscription{}, false
+}
+
+// Список блоков с отметками: нажатие включает или убирает блок
+func digestBlocksKeyboard(store *Store, blocks []string) tgbotapi.InlineKeyboardMarkup {
+	set := digestBlockSet(blocks)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, block := range digestBlockOrder {
+		mark := "⬜ "
+		if set[block] {
+			mark = "✅ "
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark+digestBlockTitles[block], encodeCallback(store, CallbackPayload{
+				Action: actionDigest,
+				Value:  block,
+			})),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// /daily blocks — выбор блоков утренней сводки
+func showDigestBlocks(c *commandContext) {
+	sub, ok := dailySubscription(c.bot.store, c.message.Chat.ID)
+	if !ok {
+		c.msg.Text = "Вы не подписаны на утреннюю сводку. Подписаться: /daily Москва"
+		return
+	}
+	c.msg.Text = fmt.Sprintf("☀️ Что показывать в утренней сводке (%s)? Нажмите на блок, чтобы включить или убрать его.", sub.City)
+	c.msg.ReplyMarkup = digestBlocksKeyboard(c.bot.store, sub.Blocks)
+}
+
+// Нажатие на блок в списке /daily blocks: список обновляется в том же сообщении
+func handleDigestCallback(bot *Bot, callback *tgbotapi.CallbackQuery, block string) (string, error) {
+	chatID := callback.Message.Chat.ID
+	if _, known := digestBlockTitles[block]; !known {
+		return "", nil
+	}
+	sub, ok := dailySubscription(bot.store, chatID)
+	if !ok {
+		return "Вы не подписаны на утреннюю сводку.", nil
+	}
+
+	blocks, ok := toggleDigestBlock(sub.Blocks, block)
+	if !ok {
+		return "В сводке должен остаться хотя бы один блок", nil
+	}
+	if _, err := bot.store.SetDigestBlocks(chatID, blocks); err != nil {
+		return "", err
+	}
+
+	markup := digestBlocksKeyboard(bot.store, blocks)
+	if _, err := bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, callback.Message.MessageID, markup)); err != nil {
+		return "", err
+	}
+	if digestBlockSet(blocks)[block] {
+		return "✅ Добавлено: " + digestBlockTitles[block], nil
+	}
+	return "Убрано: " + digestBlockTitles[block], nil
+}
+
+// Температура по интервалам прогноза до конца дня: полоска тем длиннее,
+// чем теплее
+func digestHourlyChart(forecast *Forecast, now time.Time) string {
+	var items []ForecastItem
+	today := now.Format("2006-01-02")
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		if local.Format("2006-01-02") != today || local.Add(forecastStep).Before(now) {
+			continue
+		}
+		items = append(items, item)
+		if len(items) == digestHourlySlots {
+			break
+		}
+	}
+	if len(items) < 2 {
+		return ""
+	}
+
+	minTemp, maxTemp := math.Inf(1), math.Inf(-1)
+	for _, item := range items {
+		minTemp = math.Min(minTemp, item.Temp)
+		maxTemp = math.Max(maxTemp, item.Temp)
+	}
+
+	var chart strings.Builder
+	chart.WriteString("📊 По часам:\n")
+	for _, item := range items {
+		bar := 1
+		if maxTemp > minTemp {
+			bar += int(math.Round((item.Temp - minTemp) / (maxTemp - minTemp) * 7))
+		}
+		line := fmt.Sprintf("%s %s %.0f°C", forecast.LocalTime(item).Format("15:04"), strings.Repeat("▇", bar), item.Temp)
+		if item.Pop >= 0.5 {
+			line += " ☔️"
+		}
+		chart.WriteString(line + "\n")
+	}
+	return chart.String()
+}
+
+// Качество воздуха сейчас (пустая строка при ошибке)
+func digestAirQuality(current *CurrentWeather) string {
+	air, err := fetchAirPollutionForecast(current.Lat, current.Lon)
+	if err != nil {
+		log.Printf("Ошибка получения качества воздуха для сводки: %v", err)
+		return ""
+	}
+	aqi := air.AQIAt(current.Time.Unix())
+	if aqi == 0 {
+		return ""
+	}
+	return fmt.Sprintf("🌫 Качество воздуха %s\n", aqiDescription(aqi))
+}
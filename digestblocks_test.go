@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestToggleDigestBlock(t *testing.T) {
+	blocks, ok := toggleDigestBlock(nil, digestBlockHourly)
+	want := []string{digestBlockCurrent, digestBlockDay, digestBlockHourly, digestBlockYesterday}
+	if !ok || strings.Join(blocks, ",") != strings.Join(want, ",") {
+		t.Errorf("добавление к обычному набору: %v, %v", blocks, ok)
+	}
+	if blocks, ok := toggleDigestBlock(blocks, digestBlockDay); !ok || len(blocks) != 3 || digestBlockSet(blocks)[digestBlockDay] {
+		t.Errorf("удаление блока: %v, %v", blocks, ok)
+	}
+	if blocks, ok := toggleDigestBlock([]string{digestBlockUV}, digestBlockUV); ok || len(blocks) != 1 {
+		t.Errorf("удален последний блок: %v, %v", blocks, ok)
+	}
+}
+
+func TestDailyDigestBlocks(t *testing.T) {
+	f := useMockReports(t)
+	// Долгота 37.6 — в демо-режиме это UTC+3, 7:30 по местному времени
+	useManualClock(t, time.Date(2026, 10, 16, 4, 30, 0, 0, time.UTC))
+	const chatID = 4441
+	previousCoords := coordsCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { coordsCache = previousCoords })
+
+	f.send(textUpdate(chatID, "/daily Москва"))
+	if reply := f.reply(t, chatID); !strings.Contains(reply, "/daily blocks") {
+		t.Errorf("ответ на подписку: %q", reply)
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/daily blocks"))
+	if reply := f.reply(t, chatID); !strings.Contains(reply, "Что показывать в утренней сводке") {
+		t.Errorf("список блоков: %q", reply)
+	}
+	markup := f.sent("sendMessage")[0].Params["reply_markup"]
+	if !strings.Contains(markup, "✅ Погода сейчас") || !strings.Contains(markup, "⬜ График по часам") {
+		t.Errorf("отметки блоков: %s", markup)
+	}
+
+	press := func(block string) string {
+		t.Helper()
+		f.reset()
+		f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "1",
+			From:    &tgbotapi.User{ID: chatID},
+			Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}},
+			Data:    encodeCallback(store, CallbackPayload{Action: actionDigest, Value: block}),
+		}})
+		answers := f.sent("answerCallbackQuery")
+		if len(answers) != 1 {
+			t.Fatalf("ответов на колбэк %d", len(answers))
+		}
+		return answers[0].Params["text"]
+	}
+
+	if toast := press(digestBlockHourly); toast != "✅ Добавлено: График по часам" {
+		t.Errorf("подсказка: %q", toast)
+	}
+	edits := f.sent("editMessageReplyMarkup")
+	if len(edits) != 1 || !strings.Contains(edits[0].Params["reply_markup"], "✅ График по часам") {
+		t.Errorf("обновление списка: %+v", edits)
+	}
+	for _, block := range []string{digestBlockCurrent, digestBlockDay, digestBlockYesterday} {
+		if toast := press(block); !strings.HasPrefix(toast, "Убрано: ") {
+			t.Errorf("подсказка для %s: %q", block, toast)
+		}
+	}
+	if toast := press(digestBlockHourly); !strings.Contains(toast, "хотя бы один блок") {
+		t.Errorf("удален последний блок: %q", toast)
+	}
+
+	stored, _ := dailySubscription(store, chatID)
+	sub := &AlertSubscription{ChatID: chatID, Kind: alertDaily, City: "Москва", Lat: 55.75, Lon: 37.62, Blocks: stored.Blocks}
+	digest, ok, err := checkDaily(sub, store.Preferences(sub.ChatID))
+	if err != nil || !ok {
+		t.Fatalf("checkDaily = %v, %v", ok, err)
+	}
+	if !strings.Contains(digest, "📊 По часам:") || strings.Contains(digest, "🌡 Сейчас") || strings.Contains(digest, "📈 Днем") {
+		t.Errorf("сводка не по выбранным блокам: %q", digest)
+	}
+
+	// Смена города не сбрасывает выбор
+	f.reset()
+	f.send(textUpdate(chatID, "/daily Тула"))
+	if sub, _ := dailySubscription(store, chatID); strings.Join(sub.Blocks, ",") != digestBlockHourly {
+		t.Errorf("блоки после смены города: %v", sub.Blocks)
+	}
+}
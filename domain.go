@@ -0,0 +1,84 @@
+package main
+
+import "time"
+
+// Текущая погода в точке. Форматирование, кэш и проверки подписок работают
+// с этой структурой, а не с ответом конкретного источника данных.
+// Температура в °C, скорость ветра в м/с, давление в гПа
+type CurrentWeather struct {
+	City string
+	Lat  float64
+	Lon  float64
+	// Время наблюдения в часовом поясе города
+	Time time.Time
+
+	Temp      float64
+	FeelsLike float64
+	Humidity  int
+	Pressure  float64
+
+	WindSpeed float64
+	WindGust  float64
+	WindDeg   int
+
+	// Код погодных условий в нумерации OWM (2xx гроза, 5xx дождь, 800 ясно...),
+	// другие источники переводят свои коды в нее же
+	Condition   int
+	Description string
+	Daytime     bool
+
+	Clouds int // облачность, %
+	// Осадки за последний час, мм
+	Rain float64
+	Snow float64
+	// Видимость в метрах (0, если неизвестна)
+	Visibility int
+
+	// Восход и закат в часовом поясе города (нулевые, если неизвестны)
+	Sunrise time.Time
+	Sunset  time.Time
+}
+
+// Прогноз по интервалам для точки
+type Forecast struct {
+	City     string
+	Lat      float64
+	Lon      float64
+	Location *time.Location
+	Items    []ForecastItem
+}
+
+// Один интервал прогноза (forecastStep). Единицы те же, что в CurrentWeather
+type ForecastItem struct {
+	// Начало интервала
+	Time time.Time
+
+	Temp      float64
+	FeelsLike float64
+	Humidity  int
+	Pressure  float64
+
+	WindSpeed float64
+	WindGust  float64
+
+	Condition   int
+	Description string
+	Daytime     bool
+
+	Clouds int
+	// Осадки за интервал, мм
+	Rain       float64
+	Snow       float64
+	Visibility int
+	Pop        float64 // вероятность осадков, 0..1
+}
+
+// Местное время интервала прогноза с учетом часового пояса города
+func (f *Forecast) LocalTime(item ForecastItem) time.Time {
+	return item.Time.In(f.Location)
+}
+
+// Текущее время в часовом поясе города
+func (f *Forecast) Now() time.Time {
+	return clockNow().In(f.Location)
+}
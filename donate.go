@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Payload счета на пожертвование
+const donatePayload = "donate"
+
+// Суммы пожертвований в звездах, которые предлагаем кнопками
+var donateAmounts = []int{50, 100, 500}
+
+// Пожертвование на оплату API
+type Donation struct {
+	ChatID   int64     `json:"chat_id"`
+	Name     string    `json:"name"`
+	Amount   int       `json:"amount"`
+	Currency string    `json:"currency"`
+	ChargeID string    `json:"charge_id"`
+	At       time.Time `json:"at"`
+}
+
+func (s *Store) AddDonation(donation Donation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Donations = append(s.data.Donations, donation)
+	return s.save()
+}
+
+// Копия всех пожертвований
+func (s *Store) Donations() []Donation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Donation(nil), s.data.Donations...)
+}
+
+// Счет на пожертвование в звездах
+func donateInvoice(chatID int64, amount int) tgbotapi.InvoiceConfig {
+	return tgbotapi.NewInvoice(
+		chatID,
+		"Поддержать бота",
+		"Пожертвование на оплату API погоды и сервера",
+		donatePayload,
+		"",
+		"",
+		currencyStars,
+		[]tgbotapi.LabeledPrice{{Label: "Пожертвование", Amount: amount}},
+	)
+}
+
+// Кнопки с суммами пожертвования
+func donateKeyboard(store *Store) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, amount := range donateAmounts {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("⭐️ %d", amount),
+			encodeCallback(store, CallbackPayload{Action: actionDonate, Value: strconv.Itoa(amount)}),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// Разбор суммы из аргументов /donate (0, если сумма не указана)
+func parseDonateAmount(args string) (int, error) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return 0, nil
+	}
+	amount, err := strconv.Atoi(args)
+	if err != nil || amount < 1 || amount > 10000 {
+		return 0, fmt.Errorf("сумма должна быть целым числом звезд от 1 до 10000")
+	}
+	return amount, nil
+}
+
+// Имя для отчета о пожертвованиях
+func payerName(from *tgbotapi.User) string {
+	if from == nil {
+		return ""
+	}
+	if from.UserName != "" {
+		return "@" + from.UserName
+	}
+	return strings.TrimSpace(from.FirstName + " " + from.LastName)
+}
+
+// Отчет для администраторов: итоги, лучшие помощники и последние пожертвования
+func donationsReport(store *Store) string {
+	donations := store.Donations()
+	if len(donations) == 0 {
+		return "Пожертвований пока не было."
+	}
+
+	totals := make(map[string]int)
+	byDonor := make(map[string]int)
+	for _, donation := range donations {
+		totals[donation.Currency] += donation.Amount
+		name := donation.Name
+		if name == "" {
+			name = strconv.FormatInt(donation.ChatID, 10)
+		}
+		byDonor[name] += donation.Amount
+	}
+
+	report := fmt.Sprintf("💰 Пожертвований: %d\n", len(donations))
+	for currency, total := range totals {
+		report += fmt.Sprintf("Итого: %d %s\n", total, currency)
+	}
+
+	donors := make([]string, 0, len(byDonor))
+	for name := range byDonor {
+		donors = append(donors, name)
+	}
+	sort.Slice(donors, func(i, j int) bool { return byDonor[donors[i]] > byDonor[donors[j]] })
+	if len(donors) > 5 {
+		donors = donors[:5]
+	}
+	report += "\n🏆 Больше всех помогли:\n"
+	for i, name := range donors {
+		report += fmt.Sprintf("%d. %s — %d\n", i+1, name, byDonor[name])
+	}
+
+	report += "\n🕒 Последние:\n"
+	start := len(donations) - 10
+	if start < 0 {
+		start = 0
+	}
+	for i := len(donations) - 1; i >= start; i-- {
+		donation := donations[i]
+		report += fmt.Sprintf("%s %s — %d %s\n",
+			donation.At.Format("02.01.2006"), donation.Name, donation.Amount, donation.Currency)
+	}
+
+	return strings.TrimRight(report, "\n")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Типичные ограничения бытовых дронов
+const (
+	droneWindCaution  = 7.0  // м/с
+	droneWindNoFly    = 10.0 // м/с
+	droneGustCaution  = 10.0 // м/с
+	droneGustNoFly    = 12.0 // м/с
+	droneVisibilityOK = 3000 // м, полет в пределах прямой видимости
+	droneVisibilityNo = 1000 // м
+	droneKpCaution    = 5.0  // геомагнитная буря может сбивать GPS и компас
+	droneKpNoFly      = 7.0
+	droneColdCaution  = -10.0 // °C, аккумуляторы быстро садятся
+)
+
+// Вердикт для полета дрона
+const (
+	droneFly = iota
+	droneCaution
+	droneNoFly
+)
+
+// Функция для оценки условий для полета дрона
+func getDroneConditions(city string) (string, error) {
+	data, err := fetchWeather(city)
+	if err != nil {
+		return "", err
+	}
+
+	verdict := droneFly
+	var reasons []string
+	raise := func(level int, reason string) {
+		if level > verdict {
+			verdict = level
+		}
+		reasons = append(reasons, reason)
+	}
+
+	switch {
+	case data.WindSpeed >= droneWindNoFly:
+		raise(droneNoFly, fmt.Sprintf("ветер %.0f м/с", data.WindSpeed))
+	case data.WindSpeed >= droneWindCaution:
+		raise(droneCaution, fmt.Sprintf("ветер %.0f м/с", data.WindSpeed))
+	}
+
+	switch {
+	case data.WindGust >= droneGustNoFly:
+		raise(droneNoFly, fmt.Sprintf("порывы до %.0f м/с", data.WindGust))
+	case data.WindGust >= droneGustCaution:
+		raise(droneCaution, fmt.Sprintf("порывы до %.0f м/с", data.WindGust))
+	}
+
+	switch data.Condition / 100 {
+	case 2:
+		raise(droneNoFly, "гроза")
+	case 5, 6:
+		raise(droneNoFly, "осадки")
+	case 3:
+		raise(droneCaution, "морось")
+	}
+
+	switch {
+	case data.Visibility > 0 && data.Visibility < droneVisibilityNo:
+		raise(droneNoFly, fmt.Sprintf("видимость %d м", data.Visibility))
+	case data.Visibility > 0 && data.Visibility < droneVisibilityOK:
+		raise(droneCaution, fmt.Sprintf("видимость %d м", data.Visibility))
+	}
+
+	if data.Temp <= droneColdCaution {
+		raise(droneCaution, fmt.Sprintf("мороз %.0f°C, аккумулятор сядет быстрее", data.Temp))
+	}
+
+	kpLine := "🧲 Kp-индекс: нет данных"
+	if entries, err := fetchKpForecast(); err != nil {
+		log.Printf("Ошибка получения Kp-индекса: %v", err)
+	} else if kp, ok := kpAt(entries, time.Now().UTC()); ok {
+		kpLine = fmt.Sprintf("🧲 Kp-индекс: %.1f", kp)
+		switch {
+		case kp >= droneKpNoFly:
+			raise(droneNoFly, fmt.Sprintf("сильная геомагнитная буря (Kp %.0f)", kp))
+		case kp >= droneKpCaution:
+			raise(droneCaution, fmt.Sprintf("геомагнитная буря (Kp %.0f), возможны сбои GPS", kp))
+		}
+	}
+
+	visibility := "нет данных"
+	if data.Visibility > 0 {
+		visibility = fmt.Sprintf("%.1f км", float64(data.Visibility)/1000)
+	}
+
+	droneMsg := fmt.Sprintf(
+		"🚁 Условия для полета дрона в %s:\n"+
+			"🌬 Ветер: %.0f м/с, порывы до %.0f м/с\n"+
+			"👁 Видимость: %s\n"+
+			"🌡 Температура: %.0f°C\n"+
+			"%s\n\n",
+		data.City,
+		data.WindSpeed,
+		data.WindGust,
+		visibility,
+		data.Temp,
+		kpLine,
+	)
+
+	switch verdict {
+	case droneFly:
+		droneMsg += "✅ Можно летать"
+	case droneCaution:
+		droneMsg += "⚠️ Летать с осторожностью: " + strings.Join(reasons, ", ")
+	default:
+		droneMsg += "⛔️ Лучше не летать: " + strings.Join(reasons, ", ")
+	}
+
+	return droneMsg, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Пробный прогон (-dry-run): оповещения и публикации в группах проверяются
+// на модельных часах с шагом планировщика, а сообщения вместо отправки
+// пишутся в лог. Так можно увидеть, когда и что получат подписчики
+// за сутки, не дожидаясь их. С WEATHER_PROVIDER=mock прогон не ходит в сеть
+
+// Куда отправляются оповещения и публикации: *Bot или заглушка
+// пробного прогона
+type messageSender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// Заглушка, которая пишет сообщения в лог с модельным временем
+type dryRunSender struct {
+	sent int
+}
+
+func (s *dryRunSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	switch msg := c.(type) {
+	case tgbotapi.MessageConfig:
+		s.sent++
+		log.Printf("[%s] чат %d:\n%s", clockNow().Format("2006-01-02 15:04 MST"), msg.ChatID, msg.Text)
+	case tgbotapi.PhotoConfig:
+		s.sent++
+		log.Printf("[%s] чат %d, снимок:\n%s", clockNow().Format("2006-01-02 15:04 MST"), msg.ChatID, msg.Caption)
+	}
+	return tgbotapi.Message{}, nil
+}
+
+// Вебхуки в пробном прогоне тоже не вызываются
+type dryRunTransport struct{}
+
+func (dryRunTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	log.Printf("[%s] вебхук %s", clockNow().Format("2006-01-02 15:04 MST"), r.URL.Host)
+	return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: r}, nil
+}
+
+// Копия файла состояния во временном каталоге: отметки о срабатывании
+// в пробном прогоне не должны попасть в настоящий файл
+func openDryRunStore(path string) (*Store, func(), error) {
+	raw, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("ошибка чтения файла состояния: %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "dry-run-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания временного каталога: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	copyPath := filepath.Join(dir, filepath.Base(path))
+	if raw != nil {
+		if err := os.WriteFile(copyPath, raw, 0600); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("ошибка копирования файла состояния: %v", err)
+		}
+	}
+	s, err := openStore(copyPath)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return s, cleanup, nil
+}
+
+// Прогон планировщика от start в течение period. Оповещения проверяются
+// раз в alertCheckInterval, публикации в группах — раз в groupPostCheckInterval,
+// как в runAlertChecker и runGroupPosts
+func simulateSchedule(sender messageSender, store *Store, simClock *manualClock, start time.Time, period time.Duration) {
+	nextAlerts := start
+	for now := start; now.Before(start.Add(period)); now = now.Add(groupPostCheckInterval) {
+		simClock.Set(now)
+		if !now.Before(nextAlerts) {
+			checkAlerts(sender, store)
+			nextAlerts = nextAlerts.Add(alertCheckInterval)
+		}
+		publishGroupPosts(sender, store, now)
+	}
+}
+
+// Прогон всех ботов по очереди: у каждого свой файл состояния
+func runDryRun(c *Config) error {
+	paths := []string{c.StateFile}
+	for _, token := range c.TelegramTokens {
+		paths = append(paths, botStateFile(c.StateFile, token))
+	}
+
+	// MQTT в пробном прогоне отключен, вебхуки только пишутся в лог
+	dry := *c
+	dry.MQTTURL = ""
+	setConfig(&dry)
+	webhookClient = &http.Client{Transport: dryRunTransport{}}
+
+	start := c.DryRunStart
+	if start.IsZero() {
+		start = time.Now()
+	}
+	simClock := newManualClock(start)
+	setClock(simClock)
+	defer setClock(systemClock{})
+
+	sender := &dryRunSender{}
+	for _, path := range paths {
+		if err := dryRunState(sender, simClock, path, start, c.DryRunFor); err != nil {
+			return err
+		}
+	}
+	webhookPosts.Wait()
+
+	log.Printf("Пробный прогон завершен: сообщений %d", sender.sent)
+	return nil
+}
+
+// Прогон по копии одного файла состояния
+func dryRunState(sender *dryRunSender, simClock *manualClock, path string, start time.Time, period time.Duration) error {
+	store, cleanup, err := openDryRunStore(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	log.Printf("Пробный прогон %s с %s на %s: подписок %d, публикаций в группах %d",
+		filepath.Base(path), start.Format("2006-01-02 15:04 MST"), period, len(store.Subscriptions()), len(store.GroupPosts()))
+	simulateSchedule(sender, store, simClock, start, period)
+	return nil
+}
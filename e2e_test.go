@@ -0,0 +1,533 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Вызов метода Bot API, который получил поддельный сервер
+type telegramCall struct {
+	Method string
+	Params map[string]string
+}
+
+// Поддельный Telegram: принимает запросы бота, запоминает их и отвечает
+// правдоподобными объектами, чтобы прогонять всю цепочку обработки без токена
+type fakeTelegram struct {
+	t     *testing.T
+	bot   *Bot
+	token string
+
+	mu       sync.Mutex
+	calls    []telegramCall
+	updateID int
+
+	// Очередь для getUpdates и число ответов 502 перед ней
+	pending        []tgbotapi.Update
+	failGetUpdates int
+
+	// Администраторы групп для getChatMember
+	chatAdmins map[int64]bool
+}
+
+const fakeTelegramToken = "123456:TEST"
+
+// Хранилище бота последнего поддельного Telegram: в коде бота оно
+// передается явно, а тестам так короче
+var store *Store
+
+// Бот, направленный на поддельный Telegram, с пустым хранилищем во временном каталоге
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	t.Helper()
+	return newFakeTelegramBot(t, fakeTelegramToken)
+}
+
+// То же для бота с другим токеном; он становится единственным в bots
+func newFakeTelegramBot(t *testing.T, token string) *fakeTelegram {
+	t.Helper()
+
+	f := &fakeTelegram{t: t, token: token}
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	previousConfig := config()
+	c := *previousConfig
+	c.TelegramToken = token
+	c.TelegramAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previousConfig) })
+
+	api, err := newBotAPI(config(), token)
+	if err != nil {
+		t.Fatalf("newBotAPI: %v", err)
+	}
+	f.bot, err = newBot(api, filepath.Join(t.TempDir(), "state.json"), "")
+	if err != nil {
+		t.Fatalf("newBot: %v", err)
+	}
+
+	previousStore, previousBots := store, bots
+	store, bots = f.bot.store, []*Bot{f.bot}
+	t.Cleanup(func() { store, bots = previousStore, previousBots })
+
+	f.reset()
+	return f
+}
+
+// Бот без Telegram с пустым хранилищем во временном каталоге: для фоновых
+// задач, которым нужны только хранилища ботов
+func useTestBot(t *testing.T) *Bot {
+	t.Helper()
+	bot, err := newBot(&tgbotapi.BotAPI{Token: fakeTelegramToken}, filepath.Join(t.TempDir(), "state.json"), "")
+	if err != nil {
+		t.Fatalf("newBot: %v", err)
+	}
+	previousStore, previousBots := store, bots
+	store, bots = bot.store, []*Bot{bot}
+	t.Cleanup(func() { store, bots = previousStore, previousBots })
+	return bot
+}
+
+func (f *fakeTelegram) serve(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + f.token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		f.t.Errorf("запрос с неверным токеном: %s", r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, prefix)
+
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		f.t.Errorf("%s: ошибка разбора запроса: %v", method, err)
+	}
+	params := make(map[string]string)
+	for key := range r.Form {
+		params[key] = r.Form.Get(key)
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, telegramCall{Method: method, Params: params})
+	f.mu.Unlock()
+
+	var result interface{} = true
+	switch {
+	case method == "getUpdates":
+		f.mu.Lock()
+		failing := f.failGetUpdates > 0
+		var batch []tgbotapi.Update
+		if failing {
+			f.failGetUpdates--
+		} else {
+			batch, f.pending = f.pending, nil
+		}
+		f.mu.Unlock()
+
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 502, "description": "Bad Gateway"})
+			return
+		}
+		if batch == nil {
+			batch = []tgbotapi.Update{}
+		}
+		result = batch
+	case method == "getChatMember":
+		var userID int64
+		json.Unmarshal([]byte(params["user_id"]), &userID)
+		f.mu.Lock()
+		status := "member"
+		if f.chatAdmins[userID] {
+			status = "administrator"
+		}
+		f.mu.Unlock()
+		result = map[string]interface{}{"user": map[string]interface{}{"id": userID, "first_name": "Тест"}, "status": status}
+	case method == "getMe":
+		result = map[string]interface{}{"id": 1, "is_bot": true, "first_name": "Погода", "username": "test_weather_bot"}
+	case strings.HasPrefix(method, "send"):
+		var chatID int64
+		json.Unmarshal([]byte(params["chat_id"]), &chatID)
+		result = map[string]interface{}{
+			"message_id": len(f.calls),
+			"date":       0,
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+			"text":       params["text"],
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// Прогон обновления через ту же цепочку, что и в main. Обновлениям
+// без номера присваивается следующий по порядку, как это делает Telegram
+func (f *fakeTelegram) send(update tgbotapi.Update) {
+	if update.UpdateID == 0 {
+		f.updateID++
+		update.UpdateID = f.updateID
+	}
+	newUpdatePipeline()(f.bot, update)
+}
+
+// Вызовы метода с момента последнего reset
+func (f *fakeTelegram) sent(method string) []telegramCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []telegramCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (f *fakeTelegram) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+// Единственный ответ sendMessage в чат
+func (f *fakeTelegram) reply(t *testing.T, chatID int64) string {
+	t.Helper()
+
+	messages := f.sent("sendMessage")
+	if len(messages) != 1 {
+		t.Fatalf("ожидался один ответ, отправлено %d: %+v", len(messages), messages)
+	}
+	if got := messages[0].Params["chat_id"]; got != formatChatID(chatID) {
+		t.Errorf("ответ ушел в чат %s, ожидался %d", got, chatID)
+	}
+	return messages[0].Params["text"]
+}
+
+func formatChatID(chatID int64) string {
+	data, _ := json.Marshal(chatID)
+	return string(data)
+}
+
+// Текстовое сообщение из личного чата; команды размечаются как в Telegram
+func textUpdate(chatID int64, text string) tgbotapi.Update {
+	message := &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: chatID, FirstName: "Тест"},
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len([]rune(command))}}
+	}
+	return tgbotapi.Update{Message: message}
+}
+
+// Сообщение участника группы
+func groupTextUpdate(chatID, userID int64, text string) tgbotapi.Update {
+	update := textUpdate(chatID, text)
+	update.Message.From.ID = userID
+	update.Message.Chat.Type = "supergroup"
+	return update
+}
+
+func TestPipelineCommands(t *testing.T) {
+	f := newFakeTelegram(t)
+
+	tests := []struct {
+		name   string
+		chatID int64
+		text   string
+		want   string
+	}{
+		{"справка", 1001, "/help", "/forecast - Прогноз на 5 дней"},
+		{"неизвестная команда", 1002, "/foo", "Не знаю команду /foo"},
+		{"псевдоним", 1003, "/version", "Версия"},
+		{"прогноз без города", 1004, "/forecast", "сначала запросите погоду"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.reset()
+			f.send(textUpdate(tt.chatID, tt.text))
+			if got := f.reply(t, tt.chatID); !strings.Contains(got, tt.want) {
+				t.Errorf("%s: ответ %q не содержит %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineWeatherCard(t *testing.T) {
+	f := newFakeTelegram(t)
+	fakeOWM(t, http.StatusOK, owmWeatherFixture)
+
+	// Климатическая норма для координат фикстуры, чтобы не ходить в Open-Meteo
+	climateCache.mu.Lock()
+	climateCache.data[climateKey(55.75, 37.62)] = [365]float64{}
+	climateCache.mu.Unlock()
+
+	const chatID = 2001
+	f.send(textUpdate(chatID, "Москва"))
+
+	card := f.reply(t, chatID)
+	for _, want := range []string{"Москва", "небольшой снег"} {
+		if !strings.Contains(card, want) {
+			t.Errorf("карточка %q не содержит %q", card, want)
+		}
+	}
+	if f.bot.lastCity[chatID] != "Москва" {
+		t.Errorf("последний город %q, ожидалась Москва", f.bot.lastCity[chatID])
+	}
+
+	// Город попадает в /recent с кнопкой повторного запроса
+	f.reset()
+	f.send(textUpdate(chatID, "/recent"))
+	f.reply(t, chatID)
+	if markup := f.sent("sendMessage")[0].Params["reply_markup"]; !strings.Contains(markup, "Москва") {
+		t.Errorf("в /recent нет кнопки с городом: %s", markup)
+	}
+}
+
+func TestPipelineBannedUser(t *testing.T) {
+	f := newFakeTelegram(t)
+
+	const chatID = 3001
+	if err := store.Ban(chatID, "спам"); err != nil {
+		t.Fatalf("Ban: %v", err)
+	}
+	f.send(textUpdate(chatID, "/help"))
+
+	if calls := f.sent("sendMessage"); len(calls) != 0 {
+		t.Errorf("заблокированному пользователю отправлено %d сообщений", len(calls))
+	}
+}
+
+func TestPipelineDuplicateUpdate(t *testing.T) {
+	f := newFakeTelegram(t)
+
+	const chatID = 4001
+	update := textUpdate(chatID, "/help")
+	update.UpdateID = 500
+	f.send(update)
+	f.send(update)
+	if calls := f.sent("sendMessage"); len(calls) != 1 {
+		t.Fatalf("на повтор обновления отправлено %d ответов, ожидался один", len(calls))
+	}
+
+	// Основной файл состояния на каждое обновление не переписывается
+	if _, err := os.Stat(store.path); !os.IsNotExist(err) {
+		t.Errorf("файл состояния записан при обработке /help: %v", err)
+	}
+
+	// После перезапуска продолжаем со следующего обновления и помним обработанные
+	if err := f.bot.updateLog.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	reopened, err := openUpdateLog(f.bot.updateLog.path)
+	if err != nil {
+		t.Fatalf("openUpdateLog: %v", err)
+	}
+	f.bot.updateLog = reopened
+	if got := f.bot.startUpdateOffset(); got != 501 {
+		t.Errorf("смещение после перезапуска %d, ожидалось 501", got)
+	}
+	f.reset()
+	f.send(update)
+	if calls := f.sent("sendMessage"); len(calls) != 0 {
+		t.Errorf("после перезапуска обновление обработано повторно")
+	}
+}
+
+func TestPollUpdatesReconnects(t *testing.T) {
+	f := newFakeTelegram(t)
+	f.mu.Lock()
+	f.failGetUpdates = 1
+	f.pending = []tgbotapi.Update{textUpdate(1, "/help"), textUpdate(1, "/about")}
+	f.pending[0].UpdateID = 41
+	f.pending[1].UpdateID = 42
+	f.mu.Unlock()
+
+	stop := make(chan struct{})
+	u := tgbotapi.NewUpdate(0)
+	updates := pollUpdates(f.bot, u, stop)
+
+	// После ошибки 502 опрос продолжается и отдает обе записи по порядку
+	for _, want := range []int{41, 42} {
+		select {
+		case update := <-updates:
+			if update.UpdateID != want {
+				t.Errorf("получено обновление %d, ожидалось %d", update.UpdateID, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("не дождались обновления %d", want)
+		}
+	}
+
+	close(stop)
+	for range updates {
+	}
+
+	calls := f.sent("getUpdates")
+	if len(calls) < 3 {
+		t.Fatalf("ожидалось не меньше трех запросов getUpdates, было %d", len(calls))
+	}
+	if offset := calls[2].Params["offset"]; offset != "43" {
+		t.Errorf("смещение после пакета %s, ожидалось 43", offset)
+	}
+}
+
+func TestPollBackoff(t *testing.T) {
+	tests := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 7: time.Minute, 100: time.Minute}
+	for failures, want := range tests {
+		if got := pollBackoff(failures); got != want {
+			t.Errorf("pollBackoff(%d) = %s, ожидалось %s", failures, got, want)
+		}
+	}
+}
+
+func TestPipelineForgetMe(t *testing.T) {
+	f := newFakeTelegram(t)
+
+	const chatID = 5001
+	if err := store.AddRecentCity(chatID, "Тула"); err != nil {
+		t.Fatalf("AddRecentCity: %v", err)
+	}
+	if err := store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.HomeCity = "Тула" }); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+
+	f.send(textUpdate(chatID, "/mydata"))
+	if documents := f.sent("sendDocument"); len(documents) != 1 {
+		t.Fatalf("ожидался один файл с данными, отправлено %d", len(documents))
+	}
+	if export := store.ExportUserData(chatID); export.Preferences == nil || len(export.RecentCities) != 1 {
+		t.Errorf("в выгрузке нет настроек или истории: %+v", export)
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/forgetme"))
+	f.reply(t, chatID)
+
+	f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: chatID},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}},
+		Data:    encodeCallback(store, CallbackPayload{Action: actionForgetMe, Value: forgetConfirm}),
+	}})
+	if edits := f.sent("editMessageText"); len(edits) != 1 {
+		t.Errorf("ожидалась замена вопроса итогом, правок %d", len(edits))
+	}
+	if store.HasPreferences(chatID) || len(store.RecentCities(chatID)) != 0 {
+		t.Errorf("данные не удалены: %+v", store.ExportUserData(chatID))
+	}
+}
+
+func TestPipelineGroupPermissions(t *testing.T) {
+	f := newFakeTelegram(t)
+	f.chatAdmins = map[int64]bool{6002: true}
+
+	const groupID = -1006001
+	const member, admin = 6001, 6002
+
+	f.send(groupTextUpdate(groupID, member, "/daily Тула"))
+	if got := f.reply(t, groupID); got != groupAdminOnlyText {
+		t.Errorf("участник подписал группу на сводку: %q", got)
+	}
+	if calls := f.sent("getChatMember"); len(calls) != 1 || calls[0].Params["user_id"] != "6001" {
+		t.Errorf("права не проверены через getChatMember: %+v", calls)
+	}
+
+	f.reset()
+	f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: member},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: groupID, Type: "supergroup"}},
+		Data:    encodeCallback(store, CallbackPayload{Action: actionSettings, Value: "lang:en"}),
+	}})
+	answers := f.sent("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Params["text"] != groupAdminOnlyText {
+		t.Errorf("нажатие участника на кнопку настроек не отклонено: %+v", answers)
+	}
+	if edits := f.sent("editMessageText"); len(edits) != 0 {
+		t.Errorf("настройки изменены участником: %+v", edits)
+	}
+
+	f.reset()
+	f.send(groupTextUpdate(groupID, admin, "/settings"))
+	if got := f.reply(t, groupID); got == groupAdminOnlyText {
+		t.Error("администратору группы закрыты настройки")
+	}
+
+	// Запрашивать погоду может любой участник
+	f.reset()
+	f.send(groupTextUpdate(groupID, member, "/forecast"))
+	if got := f.reply(t, groupID); got == groupAdminOnlyText {
+		t.Error("участнику группы закрыт прогноз")
+	}
+
+	// В личном чате права не проверяются
+	f.reset()
+	f.send(textUpdate(member, "/settings"))
+	if got := f.reply(t, member); got == groupAdminOnlyText {
+		t.Error("настройки закрыты в личном чате")
+	}
+	if calls := f.sent("getChatMember"); len(calls) != 0 {
+		t.Errorf("лишняя проверка прав в личном чате: %+v", calls)
+	}
+}
+
+func TestPipelineCallbackToasts(t *testing.T) {
+	f := useMockReports(t)
+	const chatID = 6101
+	press := func(payload CallbackPayload) (string, []telegramCall) {
+		t.Helper()
+		f.reset()
+		f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "1",
+			From:    &tgbotapi.User{ID: chatID},
+			Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}},
+			Data:    encodeCallback(store, payload),
+		}})
+		answers := f.sent("answerCallbackQuery")
+		if len(answers) != 1 {
+			t.Fatalf("ответов на колбэк %d", len(answers))
+		}
+		return answers[0].Params["text"], f.sent("editMessageText")
+	}
+
+	toast, edits := press(CallbackPayload{Action: actionRefresh, City: "Тула"})
+	if !strings.HasPrefix(toast, "Обновлено, ") || len(edits) != 1 {
+		t.Errorf("обновление: %q, %+v", toast, edits)
+	}
+
+	// Смена единиц меняет только карточку, а кнопка предлагает вернуть °C
+	toast, edits = press(CallbackPayload{Action: actionUnits, City: "Тула", Units: unitsImperial})
+	if toast != "Температура в °F" || len(edits) != 1 || !strings.Contains(edits[0].Params["text"], "°F") ||
+		!strings.Contains(edits[0].Params["reply_markup"], "В °C") {
+		t.Errorf("смена единиц: %q, %+v", toast, edits)
+	}
+	if store.Preferences(chatID).Units == unitsImperial {
+		t.Error("смена единиц в карточке изменила настройки")
+	}
+
+	// Прогноз — не быстрое действие, подсказки нет
+	if toast, _ = press(CallbackPayload{Action: actionForecast, City: "Тула"}); toast != "" {
+		t.Errorf("подсказка при переходе к прогнозу: %q", toast)
+	}
+
+	if toast, _ = press(CallbackPayload{Action: actionSettings, Value: "wind:kmh"}); toast != "✅ Ветер: км/ч" {
+		t.Errorf("подсказка настроек: %q", toast)
+	}
+	if toast, _ = press(CallbackPayload{Action: actionSettings, Value: settingsWind}); toast != "" {
+		t.Errorf("подсказка при переходе в раздел: %q", toast)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Поправка на высоту для геопозиции в горах. Погода по координатам
+// приходит от станции в ближайшем городе, обычно в долине, а на склоне
+// выше нее холоднее примерно на 6,5° на километр. Если точка заметно
+// выше ближайшего города, под карточкой показываем оценку температуры
+// на высоте точки
+
+// С какой разницы высот показывать поправку, м
+const elevationMinDifference = 500
+
+// Падение температуры с высотой в стандартной атмосфере, °C на метр
+const elevationLapseRate = 0.0065
+
+// Длина геохеша для кэша высот: около 150 м, в горах высота меняется быстро
+const elevationGeohashPrecision = 7
+
+// Ответ Open-Meteo Elevation API: высоты в порядке координат запроса
+type elevationResponse struct {
+	Elevation []float64 `json:"elevation"`
+}
+
+// Кэш высот по ячейке геохеша: рельеф не меняется, поэтому без срока хранения
+var (
+	elevationCache   = make(map[string]float64)
+	elevationCacheMu sync.Mutex
+)
+
+// Адрес Open-Meteo Elevation API; в тестах подменяется
+var elevationAPIURL = "https://api.open-meteo.com/v1/elevation"
+
+// Высоты точек над уровнем моря в метрах одним запросом
+func fetchElevations(points ...[2]float64) ([]float64, error) {
+	elevations := make([]float64, len(points))
+	var missing []int
+	elevationCacheMu.Lock()
+	for i, point := range points {
+		elevation, ok := elevationCache[geohash(point[0], point[1], elevationGeohashPrecision)]
+		if !ok {
+			missing = append(missing, i)
+		}
+		elevations[i] = elevation
+	}
+	elevationCacheMu.Unlock()
+	if len(missing) == 0 {
+		return elevations, nil
+	}
+
+	var lats, lons string
+	for n, i := range missing {
+		if n > 0 {
+			lats += ","
+			lons += ","
+		}
+		lats += fmt.Sprintf("%.4f", points[i][0])
+		lons += fmt.Sprintf("%.4f", points[i][1])
+	}
+
+	resp, err := openMeteoClient.Get(elevationAPIURL + "?latitude=" + lats + "&longitude=" + lons)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения высоты: статус %d", resp.StatusCode)
+	}
+
+	var data elevationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга высоты: %v", err)
+	}
+	if len(data.Elevation) != len(missing) {
+		return nil, fmt.Errorf("нет данных о высоте")
+	}
+
+	elevationCacheMu.Lock()
+	for n, i := range missing {
+		elevations[i] = data.Elevation[n]
+		elevationCache[geohash(points[i][0], points[i][1], elevationGeohashPrecision)] = data.Elevation[n]
+	}
+	elevationCacheMu.Unlock()
+	return elevations, nil
+}
+
+// Поправка температуры при подъеме с высоты from на высоту to, °C
+func elevationAdjustment(from, to float64) float64 {
+	return -(to - from) * elevationLapseRate
+}
+
+// Строка о поправке на высоту для карточки погоды в точке: "⛰ На высоте
+// 1800 м ≈ −8°C к долинной температуре (Красная Поляна, 570 м): около −14°C".
+// Пустая, если точка не выше ближайшего города или данных нет
+func elevationLine(data *CurrentWeather, lat, lon float64, prefs UserPreferences) string {
+	// В демо-режиме сеть не нужна, высоту не запрашиваем
+	if mockWeatherMode() {
+		return ""
+	}
+
+	cities, err := nearbyCities(lat, lon, prefs.Language)
+	if err != nil || len(cities) == 0 {
+		return ""
+	}
+	valley := cities[0]
+
+	elevations, err := fetchElevations([2]float64{lat, lon}, [2]float64{valley.Lat, valley.Lon})
+	if err != nil {
+		log.Printf("Ошибка получения высоты: %v", err)
+		return ""
+	}
+	point, base := elevations[0], elevations[1]
+	if point-base < elevationMinDifference {
+		return ""
+	}
+
+	delta := elevationAdjustment(base, point)
+	if prefs.Language == langEN {
+		return fmt.Sprintf("⛰ At %.0f m ≈ %s vs the valley temperature (%s, %.0f m): about %s",
+			point, formatTempDelta(delta, prefs.Units), valley.Name, base, formatTemp(data.Temp+delta, prefs.Units))
+	}
+	return fmt.Sprintf("⛰ На высоте %.0f м ≈ %s к долинной температуре (%s, %.0f м): около %s",
+		point, formatTempDelta(delta, prefs.Units), valley.Name, base, formatTemp(data.Temp+delta, prefs.Units))
+}
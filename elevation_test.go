@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestElevationAdjustment(t *testing.T) {
+	if got := elevationAdjustment(600, 1800); math.Abs(got+7.8) > 1e-9 {
+		t.Errorf("elevationAdjustment(600, 1800) = %v, ожидалось -7.8", got)
+	}
+	if got := elevationAdjustment(1000, 500); got <= 0 {
+		t.Errorf("при спуске теплеет, а поправка %v", got)
+	}
+}
+
+func TestFetchElevationsCache(t *testing.T) {
+	requests := fakeOWMRoutes(t, map[string]string{"/v1/elevation": `{"elevation": [1800, 600]}`})
+
+	for i := 0; i < 2; i++ {
+		elevations, err := fetchElevations([2]float64{43.68, 40.26}, [2]float64{43.58, 39.72})
+		if err != nil {
+			t.Fatalf("fetchElevations: %v", err)
+		}
+		if elevations[0] != 1800 || elevations[1] != 600 {
+			t.Errorf("высоты %v", elevations)
+		}
+	}
+	if n := requests["/v1/elevation"]; n != 1 {
+		t.Errorf("запросов высоты %d, ожидался 1", n)
+	}
+}
+
+func TestPipelineLocationElevation(t *testing.T) {
+	f := newFakeTelegram(t)
+	routes := map[string]string{
+		"/data/2.5/weather": owmWeatherFixture,
+		"/data/2.5/find":    owmFindFixture,
+		"/geo/1.0/reverse":  owmReverseFixture,
+		"/v1/elevation":     `{"elevation": [1800, 600]}`,
+	}
+	fakeOWMRoutes(t, routes)
+
+	climateCache.mu.Lock()
+	climateCache.data[climateKey(55.75, 37.62)] = [365]float64{}
+	climateCache.mu.Unlock()
+
+	const chatID = 2601
+	update := textUpdate(chatID, "")
+	update.Message.Location = &tgbotapi.Location{Latitude: 55.8891, Longitude: 37.4449}
+	f.send(update)
+
+	want := "⛰ На высоте 1800 м ≈ -8°C к долинной температуре (Химки, 600 м): около -11°C"
+	if card := f.reply(t, chatID); !strings.Contains(card, want) {
+		t.Errorf("в карточке %q нет %q", card, want)
+	}
+
+	// Точка чуть выше города — без поправки
+	routes["/v1/elevation"] = `{"elevation": [200, 150]}`
+	fakeOWMRoutes(t, routes)
+	f.reset()
+	f.send(update)
+	if card := f.reply(t, chatID); strings.Contains(card, "На высоте") {
+		t.Errorf("поправка для равнины: %q", card)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Уровни событий для сборщика ошибок
+const (
+	errorLevelError = "error"
+	errorLevelFatal = "fatal"
+)
+
+// Одинаковые ошибки отправляем не чаще этого интервала, чтобы поток
+// однотипных сбоев не съел квоту Sentry
+const errorReportCooldown = time.Minute
+
+var errorSinkClient = &http.Client{Timeout: 5 * time.Second}
+
+var (
+	errorLastSent   = make(map[string]time.Time)
+	errorLastSentMu sync.Mutex
+)
+
+// Событие об ошибке с контекстом обновления. В таком виде уходит
+// на ERROR_WEBHOOK_URL, для Sentry перекладывается в его формат
+type errorEvent struct {
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+	Release   string `json:"release"`
+	Stack     string `json:"stack,omitempty"`
+
+	UpdateID   int    `json:"update_id,omitempty"`
+	UpdateKind string `json:"update_kind,omitempty"`
+	UserID     int64  `json:"user_id,omitempty"`
+	ChatID     int64  `json:"chat_id,omitempty"`
+	// Текст сообщения или данные кнопки, обрезанные до 200 символов
+	Input string `json:"input,omitempty"`
+}
+
+// Адрес приема событий и ключ из DSN вида https://<ключ>@<хост>/<проект>
+func parseSentryDSN(dsn string) (string, string, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка разбора DSN: %v", err)
+	}
+	project := strings.Trim(parsed.Path, "/")
+	if parsed.User == nil || parsed.User.Username() == "" || project == "" || parsed.Host == "" {
+		return "", "", fmt.Errorf("ожидается DSN вида https://ключ@хост/проект")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", "", fmt.Errorf("ожидается DSN вида https://ключ@хост/проект")
+	}
+
+	// Проект может лежать под префиксом: https://ключ@хост/префикс/42
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, prefix, project)
+	return endpoint, parsed.User.Username(), nil
+}
+
+// Ошибка при обработке обновления: в лог и в сборщик ошибок
+func reportUpdateError(update tgbotapi.Update, context string, err error) {
+	log.Printf("%s: %v", context, err)
+	reportError(errorLevelError, context+": "+err.Error(), &update, "")
+}
+
+// Отправка события в Sentry (SENTRY_DSN) и/или на ERROR_WEBHOOK_URL.
+// Отправка идет в фоне и не задерживает обработку обновлений
+func reportError(level, message string, update *tgbotapi.Update, stack string) {
+	c := config()
+	if c.SentryDSN == "" && c.ErrorWebhookURL == "" {
+		return
+	}
+
+	errorLastSentMu.Lock()
+	if last, ok := errorLastSent[message]; ok && time.Since(last) < errorReportCooldown {
+		errorLastSentMu.Unlock()
+		return
+	}
+	errorLastSent[message] = time.Now()
+	errorLastSentMu.Unlock()
+
+	event := errorEvent{
+		Level:     level,
+		Message:   redactSecrets(message),
+		Timestamp: time.Now().Unix(),
+		Release:   version + "+" + commit,
+		Stack:     redactSecrets(stack),
+	}
+	if update != nil {
+		event.UpdateID = update.UpdateID
+		event.UpdateKind = updateKind(*update)
+		event.UserID = updateUserID(*update)
+		if chat := update.FromChat(); chat != nil {
+			event.ChatID = chat.ID
+		}
+		event.Input = updateInput(*update)
+	}
+
+	go func() {
+		if c.SentryDSN != "" {
+			if err := sendSentryEvent(c.SentryDSN, event); err != nil {
+				log.Printf("Ошибка отправки события в Sentry: %v", err)
+			}
+		}
+		if c.ErrorWebhookURL != "" {
+			if err := postErrorEvent(c.ErrorWebhookURL, nil, event); err != nil {
+				log.Printf("Ошибка отправки события об ошибке: %v", err)
+			}
+		}
+	}()
+}
+
+// Что прислал пользователь: текст сообщения, данные кнопки или инлайн-запрос
+func updateInput(update tgbotapi.Update) string {
+	var input string
+	switch {
+	case update.Message != nil:
+		input = update.Message.Text
+	case update.CallbackQuery != nil:
+		input = update.CallbackQuery.Data
+	case update.InlineQuery != nil:
+		input = update.InlineQuery.Query
+	}
+	if runes := []rune(input); len(runes) > 200 {
+		input = string(runes[:200]) + "…"
+	}
+	return input
+}
+
+// Событие в формате Sentry store API
+func sendSentryEvent(dsn string, event errorEvent) error {
+	endpoint, key, err := parseSentryDSN(dsn)
+	if err != nil {
+		return err
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	payload := map[string]interface{}{
+		"event_id":  hex.EncodeToString(id),
+		"timestamp": time.Unix(event.Timestamp, 0).UTC().Format(time.RFC3339),
+		"level":     event.Level,
+		"platform":  "go",
+		"logger":    "weather-bot",
+		"release":   event.Release,
+		"message":   map[string]string{"formatted": event.Message},
+		"tags":      map[string]string{"update_kind": event.UpdateKind},
+		"extra": map[string]interface{}{
+			"update_id": event.UpdateID,
+			"chat_id":   event.ChatID,
+			"input":     event.Input,
+			"stack":     event.Stack,
+		},
+	}
+	if event.UserID != 0 {
+		payload["user"] = map[string]string{"id": fmt.Sprint(event.UserID)}
+	}
+
+	headers := map[string]string{
+		"X-Sentry-Auth": fmt.Sprintf("Sentry sentry_version=7, sentry_client=weather-bot/%s, sentry_key=%s", version, key),
+	}
+	return postErrorEvent(endpoint, headers, payload)
+}
+
+func postErrorEvent(endpoint string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("ошибка формирования события: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := errorSinkClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("сборщик ошибок ответил статусом %d", resp.StatusCode)
+	}
+	return nil
+}
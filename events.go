@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// События жизненного цикла, о которых сообщаем оператору
+const (
+	eventStarted      = "started"
+	eventShuttingDown = "shutting_down"
+	// Получение обновлений от Telegram раз за разом завершается ошибкой и восстановилось
+	eventPollingFailing   = "polling_failing"
+	eventPollingRecovered = "polling_recovered"
+	// Ключ OWM отключен после 401 или 429; отключен последний ключ
+	// (квота исчерпана или ключи отозваны)
+	eventOWMKeyDisabled   = "owm_key_disabled"
+	eventOWMKeysExhausted = "owm_keys_exhausted"
+	// Проход фоновой проверки длился дольше интервала между проходами
+	eventSchedulerBacklog = "scheduler_backlog"
+)
+
+// Повторные одинаковые события отправляем не чаще этого интервала
+const operatorEventCooldown = 10 * time.Minute
+
+// Клиент для вебхука оператора с ограничением по времени
+var operatorClient = &http.Client{Timeout: 5 * time.Second}
+
+// Время последней отправки каждого типа события
+var (
+	operatorLastSent   = make(map[string]time.Time)
+	operatorLastSentMu sync.Mutex
+)
+
+// Тело запроса совместимо со входящими вебхуками Slack (поле text)
+type operatorEvent struct {
+	Text      string `json:"text"`
+	Event     string `json:"event"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Отправка события на вебхук оператора (OPERATOR_WEBHOOK_URL).
+// Если вебхук не настроен, событие только пишется в лог
+func notifyOperator(event, text string) {
+	log.Printf("Событие %s: %s", event, text)
+
+	webhookURL := config().OperatorWebhookURL
+	if webhookURL == "" {
+		return
+	}
+
+	operatorLastSentMu.Lock()
+	if last, ok := operatorLastSent[event]; ok && time.Since(last) < operatorEventCooldown {
+		operatorLastSentMu.Unlock()
+		return
+	}
+	operatorLastSent[event] = time.Now()
+	operatorLastSentMu.Unlock()
+
+	body, err := json.Marshal(operatorEvent{
+		Text:      fmt.Sprintf("[weather-bot] %s", text),
+		Event:     event,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("Ошибка формирования события для оператора: %v", err)
+		return
+	}
+
+	resp, err := operatorClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Ошибка отправки события оператору: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Вебхук оператора ответил статусом %d", resp.StatusCode)
+	}
+}
+
+// Проход фоновой проверки. Если он длился дольше интервала, тикер
+// пропускает тики и рассылки опаздывают — об этом сообщаем оператору
+func runScheduledPass(name string, interval time.Duration, pass func()) {
+	start := time.Now()
+	pass()
+	elapsed := time.Since(start)
+	if elapsed <= interval {
+		return
+	}
+	go notifyOperator(eventSchedulerBacklog, fmt.Sprintf(
+		"%s: проход занял %s при интервале %s, пропущено тиков: %d",
+		name, elapsed.Round(time.Millisecond), interval, int(elapsed/interval)))
+}
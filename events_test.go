@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Вебхук оператора, который передает полученные события в канал
+func fakeOperatorWebhook(t *testing.T) <-chan operatorEvent {
+	t.Helper()
+	events := make(chan operatorEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event operatorEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("тело события: %v", err)
+		}
+		events <- event
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.OperatorWebhookURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	operatorLastSentMu.Lock()
+	operatorLastSent = make(map[string]time.Time)
+	operatorLastSentMu.Unlock()
+	return events
+}
+
+// События с вебхука по типам: отправки асинхронные, порядок не важен
+func waitOperatorEvents(t *testing.T, events <-chan operatorEvent, count int) map[string]string {
+	t.Helper()
+	got := make(map[string]string)
+	for len(got) < count {
+		select {
+		case event := <-events:
+			got[event.Event] = event.Text
+		case <-time.After(2 * time.Second):
+			t.Fatalf("событий %d из %d: %v", len(got), count, got)
+		}
+	}
+	return got
+}
+
+func TestOWMKeyDisabledEvents(t *testing.T) {
+	events := fakeOperatorWebhook(t)
+	pool := &owmKeyPool{disabled: make(map[string]time.Time)}
+	keys := []string{"key-aaaa", "key-bbbb"}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	pool.Report(keys, "key-aaaa", http.StatusUnauthorized, now)
+	got := waitOperatorEvents(t, events, 1)
+	if !strings.Contains(got[eventOWMKeyDisabled], "ключ OWM …aaaa отключен на 1h0m0s (HTTP 401)") {
+		t.Errorf("отключение ключа: %v", got)
+	}
+
+	// Об отключении последнего ключа приходит одно событие
+	pool.Report(keys, "key-bbbb", http.StatusTooManyRequests, now)
+	got = waitOperatorEvents(t, events, 1)
+	if !strings.Contains(got[eventOWMKeysExhausted], "все ключи OWM (2) отключены, последний — …bbbb на 10m0s (HTTP 429)") {
+		t.Errorf("отключение всех ключей: %v", got)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("лишнее событие: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestScheduledPassBacklog(t *testing.T) {
+	events := fakeOperatorWebhook(t)
+
+	runScheduledPass("быстрая проверка", time.Hour, func() {})
+	runScheduledPass("медленная проверка", 5*time.Millisecond, func() { time.Sleep(12 * time.Millisecond) })
+
+	got := waitOperatorEvents(t, events, 1)
+	text := got[eventSchedulerBacklog]
+	if !strings.Contains(text, "медленная проверка: проход занял") || !strings.Contains(text, "пропущено тиков:") {
+		t.Errorf("событие о задержке: %v", got)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("лишнее событие: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
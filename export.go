@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Форматы выгрузки прогноза
+const (
+	exportCSV  = "csv"
+	exportJSON = "json"
+)
+
+// Интервал прогноза в выгрузке. Значения всегда метрические, как их отдает
+// источник: °C, м/с, гПа, мм за интервал
+type exportItem struct {
+	Time        string  `json:"time"`
+	Temp        float64 `json:"temp"`
+	FeelsLike   float64 `json:"feels_like"`
+	Humidity    int     `json:"humidity"`
+	Pressure    float64 `json:"pressure"`
+	WindSpeed   float64 `json:"wind_speed"`
+	WindGust    float64 `json:"wind_gust"`
+	Clouds      int     `json:"clouds"`
+	Rain        float64 `json:"rain"`
+	Snow        float64 `json:"snow"`
+	Visibility  int     `json:"visibility"`
+	Pop         float64 `json:"pop"`
+	Condition   int     `json:"condition"`
+	Description string  `json:"description"`
+}
+
+type exportForecast struct {
+	City string  `json:"city"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	// Смещение часового пояса города в секундах
+	UTCOffset int          `json:"utc_offset"`
+	Units     string       `json:"units"`
+	Items     []exportItem `json:"items"`
+}
+
+// Столбцы CSV в порядке полей exportItem
+var exportCSVHeader = []string{
+	"time", "temp", "feels_like", "humidity", "pressure", "wind_speed", "wind_gust",
+	"clouds", "rain", "snow", "visibility", "pop", "condition", "description",
+}
+
+func newExportForecast(forecast *Forecast) exportForecast {
+	_, offset := forecast.Now().Zone()
+	export := exportForecast{
+		City:      forecast.City,
+		Lat:       forecast.Lat,
+		Lon:       forecast.Lon,
+		UTCOffset: offset,
+		Units:     unitsMetric,
+		Items:     make([]exportItem, 0, len(forecast.Items)),
+	}
+	for _, item := range forecast.Items {
+		export.Items = append(export.Items, exportItem{
+			Time:        forecast.LocalTime(item).Format(time.RFC3339),
+			Temp:        item.Temp,
+			FeelsLike:   item.FeelsLike,
+			Humidity:    item.Humidity,
+			Pressure:    item.Pressure,
+			WindSpeed:   item.WindSpeed,
+			WindGust:    item.WindGust,
+			Clouds:      item.Clouds,
+			Rain:        item.Rain,
+			Snow:        item.Snow,
+			Visibility:  item.Visibility,
+			Pop:         item.Pop,
+			Condition:   item.Condition,
+			Description: item.Description,
+		})
+	}
+	return export
+}
+
+// Прогноз в CSV: строка заголовков и по строке на интервал
+func forecastCSV(forecast *Forecast) ([]byte, error) {
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(exportCSVHeader)
+	for _, item := range newExportForecast(forecast).Items {
+		w.Write([]string{
+			item.Time,
+			float(item.Temp),
+			float(item.FeelsLike),
+			strconv.Itoa(item.Humidity),
+			float(item.Pressure),
+			float(item.WindSpeed),
+			float(item.WindGust),
+			strconv.Itoa(item.Clouds),
+			float(item.Rain),
+			float(item.Snow),
+			strconv.Itoa(item.Visibility),
+			float(item.Pop),
+			strconv.Itoa(item.Condition),
+			item.Description,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("ошибка записи CSV: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func forecastJSON(forecast *Forecast) ([]byte, error) {
+	data, err := json.MarshalIndent(newExportForecast(forecast), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации прогноза: %v", err)
+	}
+	return data, nil
+}
+
+// Разбор аргументов /export: формат последним словом (по умолчанию CSV), остальное — город
+func parseExportArgs(args string) (string, string) {
+	words := strings.Fields(args)
+	format := exportCSV
+	if n := len(words); n > 0 {
+		switch last := strings.ToLower(words[n-1]); last {
+		case exportCSV, exportJSON:
+			format = last
+			words = words[:n-1]
+		}
+	}
+	return strings.Join(words, " "), format
+}
+
+// /export [город] csv|json — прогноз файлом для таблиц и домашней автоматизации
+func handleExportCommand(c *commandContext) {
+	city, format := parseExportArgs(c.args)
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+	if city == "" {
+		city = c.bot.store.Preferences(c.message.Chat.ID).HomeCity
+	}
+	if city == "" {
+		c.msg.Text = "Укажите город и формат, например: /export Москва csv или /export Москва json"
+		return
+	}
+
+	prefs := c.bot.store.Preferences(c.message.Chat.ID)
+	forecast, err := fetchForecastLang(city, prefs.Language)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	var data []byte
+	if format == exportJSON {
+		data, err = forecastJSON(forecast)
+	} else {
+		data, err = forecastCSV(forecast)
+	}
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	document := tgbotapi.NewDocument(c.message.Chat.ID, tgbotapi.FileBytes{
+		Name:  "forecast." + format,
+		Bytes: data,
+	})
+	document.Caption = fmt.Sprintf("📄 Прогноз для %s по 3 часа: %d интервалов, °C, м/с, гПа, мм.", forecast.City, len(forecast.Items))
+	if _, err := c.bot.Send(document); err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportFixture() *Forecast {
+	zone := time.FixedZone("", 3*3600)
+	return &Forecast{
+		City: "Москва", Lat: 55.75, Lon: 37.62, Location: zone,
+		Items: []ForecastItem{
+			{Time: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), Temp: 7.5, Humidity: 80, Pressure: 1012, WindSpeed: 3.2, Pop: 0.4, Condition: 500, Description: "дождь, местами"},
+			{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), Temp: 9, Condition: 800, Description: "ясно"},
+		},
+	}
+}
+
+func TestForecastCSV(t *testing.T) {
+	data, err := forecastCSV(exportFixture())
+	if err != nil {
+		t.Fatalf("forecastCSV: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV не читается: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != strings.Join(exportCSVHeader, ",") {
+		t.Fatalf("неверные строки: %v", rows)
+	}
+	if rows[1][0] != "2026-10-16T12:00:00+03:00" || rows[1][1] != "7.5" || rows[1][13] != "дождь, местами" {
+		t.Errorf("неверная первая строка: %v", rows[1])
+	}
+}
+
+func TestForecastJSON(t *testing.T) {
+	data, err := forecastJSON(exportFixture())
+	if err != nil {
+		t.Fatalf("forecastJSON: %v", err)
+	}
+	var export exportForecast
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("JSON не читается: %v", err)
+	}
+	if export.City != "Москва" || export.UTCOffset != 10800 || export.Units != unitsMetric || len(export.Items) != 2 {
+		t.Errorf("неверная выгрузка: %+v", export)
+	}
+	if export.Items[1].Time != "2026-10-16T15:00:00+03:00" || export.Items[1].Condition != 800 {
+		t.Errorf("неверный интервал: %+v", export.Items[1])
+	}
+}
+
+func TestParseExportArgs(t *testing.T) {
+	tests := map[string][2]string{
+		"":                     {"", exportCSV},
+		"json":                 {"", exportJSON},
+		"Нижний Новгород JSON": {"Нижний Новгород", exportJSON},
+		"Тула csv":             {"Тула", exportCSV},
+		"Тула":                 {"Тула", exportCSV},
+	}
+	for args, want := range tests {
+		city, format := parseExportArgs(args)
+		if city != want[0] || format != want[1] {
+			t.Errorf("parseExportArgs(%q) = %q, %q, ожидалось %q, %q", args, city, format, want[0], want[1])
+		}
+	}
+}
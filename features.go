@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Флаги функций, которые можно выключить или раскатить на часть чатов
+const (
+	featureNLQuery   = "nlquery"
+	featureVoice     = "voice"
+	featureStickers  = "stickers"
+	featureDashboard = "dashboard"
+	// Советы и факты о погоде в утренней сводке
+	featureDigestTips = "digesttips"
+)
+
+// Состояние флага, если оно не задано в FEATURES
+var featureDefaults = map[string]bool{
+	featureNLQuery:    true,
+	featureVoice:      true,
+	featureStickers:   true,
+	featureDashboard:  true,
+	featureDigestTips: true,
+}
+
+// Правило включения флага: для всех, ни для кого, для доли чатов или для списка чатов
+type featureRule struct {
+	enabled bool
+	percent int
+	chats   map[int64]bool
+}
+
+// Разбор FEATURES вида "nlquery=on,stickers=25%,dashboard=123|456"
+func parseFeatureRules(value string) (map[string]featureRule, error) {
+	rules := make(map[string]featureRule)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, setting, found := strings.Cut(item, "=")
+		name, setting = strings.TrimSpace(name), strings.TrimSpace(setting)
+		if !found {
+			return nil, fmt.Errorf("ожидается флаг=значение, получено %q", item)
+		}
+		if _, ok := featureDefaults[name]; !ok {
+			return nil, fmt.Errorf("неизвестный флаг %q", name)
+		}
+
+		switch {
+		case setting == "on":
+			rules[name] = featureRule{enabled: true}
+		case setting == "off":
+			rules[name] = featureRule{}
+		case strings.HasSuffix(setting, "%"):
+			percent, err := strconv.Atoi(strings.TrimSuffix(setting, "%"))
+			if err != nil || percent < 0 || percent > 100 {
+				return nil, fmt.Errorf("%s: доля должна быть от 0%% до 100%%, получено %q", name, setting)
+			}
+			rules[name] = featureRule{percent: percent}
+		default:
+			chats := make(map[int64]bool)
+			for _, field := range strings.Split(setting, "|") {
+				id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s: ожидается on, off, доля в %% или ID чатов через |, получено %q", name, setting)
+				}
+				chats[id] = true
+			}
+			rules[name] = featureRule{chats: chats}
+		}
+	}
+	return rules, nil
+}
+
+// Описание правила для /features
+func (r featureRule) String() string {
+	switch {
+	case r.enabled:
+		return "on"
+	case r.percent > 0:
+		return fmt.Sprintf("%d%%", r.percent)
+	case len(r.chats) > 0:
+		return fmt.Sprintf("%d чатов", len(r.chats))
+	default:
+		return "off"
+	}
+}
+
+// Включен ли флаг для чата. Доля чатов выбирается по хэшу, поэтому чат
+// не "мигает" между включенным и выключенным состоянием
+func (r featureRule) enabledFor(name string, chatID int64) bool {
+	switch {
+	case r.enabled:
+		return true
+	case r.chats[chatID]:
+		return true
+	case r.percent > 0:
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s:%d", name, chatID)
+		return int(h.Sum32()%100) < r.percent
+	default:
+		return false
+	}
+}
+
+// Включена ли функция для чата
+func featureEnabled(name string, chatID int64) bool {
+	rule, ok := config().Features[name]
+	if !ok {
+		return featureDefaults[name]
+	}
+	return rule.enabledFor(name, chatID)
+}
+
+// Состояние всех флагов для чата
+func featuresReport(chatID int64) string {
+	names := make([]string, 0, len(featureDefaults))
+	for name := range featureDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := fmt.Sprintf("🚩 Флаги для чата %d:\n", chatID)
+	for _, name := range names {
+		state := "❌"
+		if featureEnabled(name, chatID) {
+			state = "✅"
+		}
+		rule := "по умолчанию"
+		if r, ok := config().Features[name]; ok {
+			rule = r.String()
+		}
+		report += fmt.Sprintf("%s %s (%s)\n", state, name, rule)
+	}
+	return strings.TrimRight(report, "\n")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Диалоги: отзыв без текста в команде и ответ администратора на отзыв
+const (
+	flowFeedback      = "feedback"
+	flowFeedbackReply = "feedback_reply"
+)
+
+// Отзыв пользователя
+type Feedback struct {
+	ID      int       `json:"id"`
+	ChatID  int64     `json:"chat_id"`
+	Name    string    `json:"name"`
+	Text    string    `json:"text"`
+	At      time.Time `json:"at"`
+	Replied bool      `json:"replied,omitempty"`
+}
+
+// Сохранение отзыва с очередным номером
+func (s *Store) AddFeedback(feedback Feedback) (Feedback, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	feedback.ID = len(s.data.Feedback) + 1
+	feedback.At = time.Now()
+	s.data.Feedback = append(s.data.Feedback, &feedback)
+	return feedback, s.save()
+}
+
+// Отзыв по номеру
+func (s *Store) Feedback(id int) (Feedback, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > len(s.data.Feedback) {
+		return Feedback{}, false
+	}
+	return *s.data.Feedback[id-1], true
+}
+
+// Отметка, что на отзыв ответили
+func (s *Store) MarkFeedbackReplied(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > len(s.data.Feedback) {
+		return nil
+	}
+	s.data.Feedback[id-1].Replied = true
+	return s.save()
+}
+
+func init() {
+	dialogFlows[flowFeedback] = dialogFlow{
+		first: "text",
+		steps: map[string]dialogStep{
+			"text": {
+				prompt: func(state *DialogState) dialogPrompt {
+					return dialogPrompt{text: "✍️ Напишите, что не так или чего не хватает. Например, неверные данные или город, которого нет."}
+				},
+				handle: func(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+					reply, err := submitFeedback(bot, chatID, state.Data["name"], input)
+					if err != nil {
+						return "", "❌ Ошибка: " + err.Error(), nil
+					}
+					return "", reply, nil
+				},
+			},
+		},
+	}
+
+	dialogFlows[flowFeedbackReply] = dialogFlow{
+		first: "text",
+		steps: map[string]dialogStep{
+			"text": {
+				prompt: func(state *DialogState) dialogPrompt {
+					return dialogPrompt{text: fmt.Sprintf("✉️ Напишите ответ на отзыв #%s.", state.Data["id"])}
+				},
+				handle: func(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+					id, _ := strconv.Atoi(state.Data["id"])
+					if err := replyToFeedback(bot, id, input); err != nil {
+						return "", "❌ Ошибка: " + err.Error(), nil
+					}
+					return "", fmt.Sprintf("✅ Ответ на отзыв #%d отправлен.", id), nil
+				},
+			},
+		},
+	}
+}
+
+// Сохранение отзыва и пересылка администраторам с кнопкой ответа
+func submitFeedback(bot *Bot, chatID int64, name, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("пустой отзыв")
+	}
+
+	feedback, err := bot.store.AddFeedback(Feedback{ChatID: chatID, Name: name, Text: text})
+	if err != nil {
+		return "", err
+	}
+
+	admins := adminChatIDs()
+	if len(admins) == 0 {
+		log.Printf("Отзыв #%d от чата %d сохранен, но администраторы не настроены", feedback.ID, chatID)
+	}
+	for _, adminID := range admins {
+		notice := tgbotapi.NewMessage(adminID, fmt.Sprintf("📝 Отзыв #%d от %s (чат %d):\n\n%s",
+			feedback.ID, name, chatID, text))
+		notice.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✉️ Ответить", encodeCallback(bot.store, CallbackPayload{
+				Action: actionFeedbackReply,
+				Value:  strconv.Itoa(feedback.ID),
+			})),
+		))
+		if _, err := bot.Send(notice); err != nil {
+			log.Printf("Ошибка отправки отзыва администратору %d: %v", adminID, err)
+		}
+	}
+
+	return fmt.Sprintf("🙏 Спасибо! Отзыв #%d передан разработчикам. Если понадобится, вам ответят здесь же.", feedback.ID), nil
+}
+
+// Ответ администратора пользователю
+func replyToFeedback(bot *Bot, id int, text string) error {
+	feedback, ok := bot.store.Feedback(id)
+	if !ok {
+		return fmt.Errorf("отзыв #%d не найден", id)
+	}
+
+	msg := tgbotapi.NewMessage(feedback.ChatID, fmt.Sprintf("💬 Ответ на ваш отзыв «%s»:\n\n%s", feedback.Text, text))
+	if _, err := bot.Send(msg); err != nil {
+		return err
+	}
+	return bot.store.MarkFeedbackReplied(id)
+}
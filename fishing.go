@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Сводка прогноза за один день для оценки клева
+type fishingDay struct {
+	date          time.Time
+	pressure      float64 // среднее давление, гПа
+	maxWind       float64
+	precipitation float64
+	slots         int
+}
+
+// Оценка клева по шкале 0..10. Рыба любит стабильное давление,
+// слабый ветер и фазы Луны около новолуния и полнолуния
+func fishingScore(day fishingDay, pressureChange float64) (float64, []string) {
+	score := 7.0
+	var notes []string
+
+	switch {
+	case pressureChange <= -4:
+		score -= 3
+		notes = append(notes, "давление резко падает")
+	case pressureChange >= 4:
+		score -= 2
+		notes = append(notes, "давление резко растет")
+	case math.Abs(pressureChange) <= 1.5:
+		score += 1.5
+		notes = append(notes, "давление стабильное")
+	}
+
+	switch {
+	case day.maxWind >= 10:
+		score -= 3
+		notes = append(notes, "сильный ветер")
+	case day.maxWind >= 6:
+		score -= 1
+	case day.maxWind <= 4:
+		score += 0.5
+	}
+
+	switch {
+	case day.precipitation >= 10:
+		score -= 2
+		notes = append(notes, "ливни")
+	case day.precipitation > 0 && day.precipitation < 3:
+		// Небольшой теплый дождь часто оживляет клев
+		score += 0.5
+	}
+
+	phase := moonPhase(day.date)
+	if phase < 0.1 || phase > 0.9 || math.Abs(phase-0.5) < 0.1 {
+		score += 1
+		notes = append(notes, moonPhaseName(phase))
+	}
+
+	return math.Max(0, math.Min(10, score)), notes
+}
+
+// Функция для прогноза клева на ближайшие дни
+func getFishingIndex(city string) (string, error) {
+	forecast, err := fetchForecast(city)
+	if err != nil {
+		return "", err
+	}
+
+	var days []*fishingDay
+	byDate := make(map[string]*fishingDay)
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		key := local.Format("2006-01-02")
+		day, ok := byDate[key]
+		if !ok {
+			day = &fishingDay{date: local}
+			byDate[key] = day
+			days = append(days, day)
+		}
+		day.pressure += item.Pressure
+		day.maxWind = math.Max(day.maxWind, item.WindSpeed)
+		day.precipitation += item.Rain + item.Snow
+		day.slots++
+	}
+	if len(days) == 0 {
+		return "", fmt.Errorf("нет данных прогноза")
+	}
+
+	fishingMsg := fmt.Sprintf("🎣 Прогноз клева в %s:\n\n", forecast.City)
+
+	previous := 0.0
+	for i, day := range days {
+		day.pressure /= float64(day.slots)
+		change := 0.0
+		if i > 0 {
+			change = day.pressure - previous
+		}
+		previous = day.pressure
+
+		score, notes := fishingScore(*day, change)
+		fishingMsg += fmt.Sprintf("%s %s %s: %.0f/10 — %.0f гПа (%+.0f), ветер до %.0f м/с",
+			scoreEmoji(score),
+			weekdayName(day.date.Weekday()),
+			day.date.Format("02.01"),
+			score,
+			day.pressure,
+			change,
+			day.maxWind,
+		)
+		for _, note := range notes {
+			fishingMsg += ", " + note
+		}
+		fishingMsg += "\n"
+	}
+
+	fishingMsg += "\nОценка ориентировочная: учитывает давление, ветер, осадки и фазу Луны."
+
+	return fishingMsg, nil
+}
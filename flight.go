@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Погода в день перелета: прогноз в аэропортах вылета и прилета. Аэропорт
+// ищем в справочнике по коду IATA или ICAO, иначе геокодируем как город.
+// Прогноз — почасовой Open-Meteo на 16 дней, как у обратного отсчета /event
+
+// Часы местного времени, на которые показываем прогноз, если время вылета не указано
+var flightDaySlots = []int{6, 12, 18, 23}
+
+// Оценка времени в пути: крейсерская скорость и время на взлет и посадку
+const (
+	flightCruiseSpeed = 800.0 // км/ч
+	flightExtraTime   = 30 * time.Minute
+)
+
+// С какого ветра предупреждаем о возможных задержках, м/с
+const flightMaxWind = 15.0
+
+// Точка вылета или прилета
+type flightPoint struct {
+	title    string // аэропорт с кодом или город с регионом
+	city     string // для предупреждений
+	lat, lon float64
+}
+
+// Аэропорт из справочника или город по геокодированию
+func resolveFlightPoint(code string) (flightPoint, error) {
+	if airport, ok := findAirport(code); ok {
+		return flightPoint{title: airport.Title(), city: airport.City, lat: airport.Lat, lon: airport.Lon}, nil
+	}
+	point, err := geocodeCity(code)
+	if err != nil {
+		return flightPoint{}, fmt.Errorf("не нашел аэропорт или город «%s»: %v", code, err)
+	}
+	return flightPoint{title: point.DisplayName(), city: point.Name, lat: point.Lat, lon: point.Lon}, nil
+}
+
+// Примерное время в пути по расстоянию между точками
+func flightDuration(from, to flightPoint) time.Duration {
+	hours := haversineKm(from.lat, from.lon, to.lat, to.lon) / flightCruiseSpeed
+	return (time.Duration(hours*float64(time.Hour)) + flightExtraTime).Round(5 * time.Minute)
+}
+
+// Погода, из-за которой задерживают рейсы, по коду WMO и ветру
+func flightDelayReason(code int, wind float64) string {
+	switch {
+	case code >= 95:
+		return "гроза"
+	case code == 56 || code == 57 || code == 66 || code == 67:
+		return "ледяной дождь"
+	case (code >= 71 && code <= 77) || code == 85 || code == 86:
+		return "снег"
+	case code == 45 || code == 48:
+		return "туман"
+	case wind >= flightMaxWind:
+		return "сильный ветер"
+	}
+	return ""
+}
+
+// Аргументы /flight: откуда, куда, дата и необязательное время вылета
+type flightRequest struct {
+	from, to     string
+	date         time.Time
+	hour, minute int
+	hasTime      bool
+}
+
+// Разбор аргументов вида "SVO LHR 2025-06-02 14:30"
+func parseFlightArgs(args string) (flightRequest, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 3 || len(fields) > 4 {
+		return flightRequest{}, fmt.Errorf("укажите аэропорты и дату, например: /flight SVO LHR 2025-06-02")
+	}
+	date, err := parseEventDate(fields[2])
+	if err != nil {
+		return flightRequest{}, err
+	}
+	request := flightRequest{from: fields[0], to: fields[1], date: date}
+	if len(fields) == 4 {
+		request.hour, request.minute, err = parsePostTime(fields[3])
+		if err != nil {
+			return flightRequest{}, err
+		}
+		request.hasTime = true
+	}
+	return request, nil
+}
+
+// Функция для получения погоды в аэропортах в день перелета
+func getFlightWeather(args string, units string) (string, error) {
+	request, err := parseFlightArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	from, err := resolveFlightPoint(request.from)
+	if err != nil {
+		return "", err
+	}
+	to, err := resolveFlightPoint(request.to)
+	if err != nil {
+		return "", err
+	}
+	fromForecast, err := fetchEventForecast(from.lat, from.lon)
+	if err != nil {
+		return "", err
+	}
+	toForecast, err := fetchEventForecast(to.lat, to.lon)
+	if err != nil {
+		return "", err
+	}
+
+	day := func(zone *time.Location) time.Time {
+		return time.Date(request.date.Year(), request.date.Month(), request.date.Day(), 0, 0, 0, 0, zone)
+	}
+	if !day(toForecast.zone()).AddDate(0, 0, 1).After(clockNow()) {
+		return "", fmt.Errorf("эта дата уже прошла")
+	}
+
+	flight := flightReport{units: units}
+	fmt.Fprintf(&flight.text, "✈️ Погода в день перелета %s → %s, %s:\n",
+		strings.ToUpper(request.from),
+		strings.ToUpper(request.to),
+		strings.ToLower(weekdayName(request.date.Weekday()))+" "+request.date.Format("02.01"),
+	)
+
+	if request.hasTime {
+		departure := day(fromForecast.zone()).Add(time.Duration(request.hour)*time.Hour + time.Duration(request.minute)*time.Minute)
+		duration := flightDuration(from, to)
+		arrival := departure.Add(duration).In(toForecast.zone())
+
+		fmt.Fprintf(&flight.text, "\n🛫 Вылет в %s, %s\n", departure.Format("15:04"), from.title)
+		flight.slot(fromForecast, from, departure)
+		arrivalTime := arrival.Format("15:04")
+		if arrival.Format("02.01") != departure.Format("02.01") {
+			arrivalTime = arrival.Format("02.01 15:04")
+		}
+		fmt.Fprintf(&flight.text, "\n🛬 Прилет около %s по местному времени (≈ %s в пути), %s\n",
+			arrivalTime, formatHikeDuration(duration), to.title)
+		flight.slot(toForecast, to, arrival)
+	} else {
+		fmt.Fprintf(&flight.text, "\n🛫 %s\n", from.title)
+		for _, hour := range flightDaySlots {
+			flight.slot(fromForecast, from, day(fromForecast.zone()).Add(time.Duration(hour)*time.Hour))
+		}
+		fmt.Fprintf(&flight.text, "\n🛬 %s\n", to.title)
+		for _, hour := range flightDaySlots {
+			flight.slot(toForecast, to, day(toForecast.zone()).Add(time.Duration(hour)*time.Hour))
+		}
+	}
+
+	if flight.missing {
+		fmt.Fprintf(&flight.text, "\nПрогноз дальше %d дней пока не готов — загляните ближе к вылету.\n", eventForecastDays)
+	}
+	if len(flight.warnings) > 0 {
+		flight.text.WriteString("\n⚠️ Возможны задержки рейсов: " + strings.Join(flight.warnings, "; ") + ".")
+	} else if !flight.missing {
+		flight.text.WriteString("\n✅ Погода полетам не мешает.")
+	}
+	return strings.TrimSpace(flight.text.String()), nil
+}
+
+// Ответ на /flight и предупреждения, которые собираются по ходу
+type flightReport struct {
+	text     strings.Builder
+	units    string
+	warnings []string
+	missing  bool
+}
+
+// Строка прогноза на час at в точке и предупреждение о задержках
+func (f *flightReport) slot(forecast *eventHourlyResponse, point flightPoint, at time.Time) {
+	snapshot, ok := forecast.snapshot(at)
+	switch {
+	case !ok && at.Before(clockNow()):
+		fmt.Fprintf(&f.text, "• %s: уже прошло\n", at.Format("15:04"))
+		return
+	case !ok:
+		fmt.Fprintf(&f.text, "• %s: нет прогноза\n", at.Format("15:04"))
+		f.missing = true
+		return
+	}
+
+	line := fmt.Sprintf("• %s: %s", at.Format("15:04"), formatTemp(snapshot.Temp, f.units))
+	if snapshot.Description != "" {
+		line += ", " + snapshot.Description
+	}
+	line += fmt.Sprintf(", ветер %.0f м/с", snapshot.WindSpeed)
+	if snapshot.Pop >= 0.2 {
+		line += fmt.Sprintf(", осадки %.0f%%", snapshot.Pop*100)
+	}
+	f.text.WriteString(line + "\n")
+
+	code, _ := valueAt(forecast.Hourly.WeatherCode, forecast.hourIndex(at))
+	if reason := flightDelayReason(int(code), snapshot.WindSpeed); reason != "" {
+		f.warnings = append(f.warnings, fmt.Sprintf("%s в %s — %s", point.city, at.Format("15:04"), reason))
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindAirport(t *testing.T) {
+	for _, code := range []string{"svo", "UUEE"} {
+		if airport, ok := findAirport(code); !ok || airport.Title() != "Шереметьево (SVO), Москва" {
+			t.Errorf("findAirport(%q) = %+v, %v", code, airport, ok)
+		}
+	}
+	if airport, _ := findAirport("AER"); airport.Title() != "Сочи (AER)" {
+		t.Errorf("название без аэропорта %q", airport.Title())
+	}
+	if _, ok := findAirport("XXX"); ok {
+		t.Error("найден несуществующий аэропорт")
+	}
+}
+
+func TestParseFlightArgs(t *testing.T) {
+	request, err := parseFlightArgs("SVO LHR 02.06.2025 14:30")
+	if err != nil || request.from != "SVO" || request.to != "LHR" || !request.hasTime || request.hour != 14 || request.minute != 30 ||
+		request.date.Format("2006-01-02") != "2025-06-02" {
+		t.Errorf("parseFlightArgs = %+v, %v", request, err)
+	}
+	for _, args := range []string{"SVO LHR", "SVO LHR завтра", "SVO LHR 2025-06-02 обед", "SVO LHR 2025-06-02 14:30 лишнее"} {
+		if _, err := parseFlightArgs(args); err == nil {
+			t.Errorf("parseFlightArgs(%q) без ошибки", args)
+		}
+	}
+}
+
+func TestFlightDelayReason(t *testing.T) {
+	for _, c := range []struct {
+		code int
+		wind float64
+		want string
+	}{
+		{95, 3, "гроза"}, {73, 3, "снег"}, {45, 1, "туман"}, {67, 2, "ледяной дождь"}, {3, 16, "сильный ветер"}, {61, 8, ""},
+	} {
+		if got := flightDelayReason(c.code, c.wind); got != c.want {
+			t.Errorf("flightDelayReason(%d, %.0f) = %q, ожидалось %q", c.code, c.wind, got, c.want)
+		}
+	}
+}
+
+func TestPipelineFlight(t *testing.T) {
+	f := newFakeTelegram(t)
+	c := *config()
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	const chatID = 2801
+	f.send(textUpdate(chatID, "/flight svo lhr 2026-10-18 14:30"))
+	reply := f.reply(t, chatID)
+	for _, want := range []string{
+		"✈️ Погода в день перелета SVO → LHR, воскресенье 18.10",
+		"🛫 Вылет в 14:30, Шереметьево (SVO), Москва\n• 14:30: ",
+		"(≈ 3 ч 40 мин в пути), Хитроу (LHR), Лондон",
+	} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("в ответе %q нет %q", reply, want)
+		}
+	}
+
+	// Без времени — прогноз на несколько часов дня, дальше 16 дней прогноза нет
+	f.reset()
+	f.send(textUpdate(chatID, "/flight SVO AER 2026-11-20"))
+	reply = f.reply(t, chatID)
+	if strings.Count(reply, "• ") != 2*len(flightDaySlots) || !strings.Contains(reply, "Прогноз дальше 16 дней пока не готов") {
+		t.Errorf("ответ на далекую дату %q", reply)
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/flight SVO LHR 2026-10-01"))
+	if got := f.reply(t, chatID); got != "❌ Ошибка: эта дата уже прошла" {
+		t.Errorf("ответ на прошедшую дату %q", got)
+	}
+}
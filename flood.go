@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Предупреждения о подъеме воды в реках. Источник — гидрологическая модель
+// GloFAS (Copernicus) через Flood API Open-Meteo: суточный расход воды в
+// ближайшей к городу точке реки за прошлый месяц и прогноз на две недели.
+// Весной в чатах поселков у рек это самый частый запрос администраторов
+
+// Тип подписки на подъем воды
+const alertFlood = "flood"
+
+// Адрес Flood API Open-Meteo; в тестах подменяется
+var floodAPIURL = "https://flood-api.open-meteo.com/v1/flood"
+
+// Сколько дней истории берем для обычного уровня и на сколько смотрим вперед
+const (
+	floodPastDays    = 30
+	floodHorizonDays = 10
+)
+
+// Рост расхода воды в процентах, о котором предупреждаем по умолчанию
+const defaultFloodThreshold = 50.0
+
+// Реки с меньшим расходом, м³/с, модель описывает плохо: это ручьи
+const floodMinDischarge = 5.0
+
+// Во сколько раз выше обычного за месяц вода грозит выйти из берегов
+const floodDangerRatio = 3.0
+
+// Суточные данные модели обновляются раз в день, кэша на 3 часа хватает
+const floodCacheTTL = 3 * time.Hour
+
+// Длина геохеша для кэша: около 5 км, как ячейка модели
+const floodGeohashPrecision = 5
+
+func init() {
+	alertKinds[alertFlood] = alertKind{title: "Подъем воды в реках", check: checkFlood, cooldown: 72 * time.Hour}
+}
+
+// Ответ Flood API: даты по UTC и расход воды, м³/с
+type floodResponse struct {
+	Daily struct {
+		Time      []string   `json:"time"`
+		Discharge []*float64 `json:"river_discharge"`
+	} `json:"daily"`
+}
+
+type floodCacheEntry struct {
+	data    *floodResponse
+	fetched time.Time
+}
+
+var (
+	floodCache   = make(map[string]floodCacheEntry)
+	floodCacheMu sync.Mutex
+)
+
+func fetchFlood(lat, lon float64) (*floodResponse, error) {
+	if mockWeatherMode() {
+		return mockFlood(lat, lon), nil
+	}
+
+	key := geohash(lat, lon, floodGeohashPrecision)
+	floodCacheMu.Lock()
+	entry, ok := floodCache[key]
+	floodCacheMu.Unlock()
+	if ok && clockNow().Sub(entry.fetched) < floodCacheTTL {
+		return entry.data, nil
+	}
+
+	reqURL := fmt.Sprintf(
+		"%s?latitude=%.4f&longitude=%.4f&daily=river_discharge&past_days=%d&forecast_days=%d",
+		floodAPIURL,
+		lat,
+		lon,
+		floodPastDays,
+		floodHorizonDays+1,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения данных о реках: статус %d", resp.StatusCode)
+	}
+
+	var data floodResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	floodCacheMu.Lock()
+	floodCache[key] = floodCacheEntry{data: &data, fetched: clockNow()}
+	floodCacheMu.Unlock()
+	return &data, nil
+}
+
+// Расход воды демо-режима: весенний подъем в апреле–мае
+func mockFlood(lat, lon float64) *floodResponse {
+	var data floodResponse
+	base := 20 + float64(mockSeed(mockPlaceName(lat, lon))%300)
+	today := clockNow().UTC().Truncate(24 * time.Hour)
+	for d := -floodPastDays; d <= floodHorizonDays; d++ {
+		day := today.AddDate(0, 0, d)
+		season := math.Exp(-math.Pow(float64(day.YearDay()-120)/20, 2))
+		discharge := base * (1 + 3*season)
+		data.Daily.Time = append(data.Daily.Time, day.Format("2006-01-02"))
+		data.Daily.Discharge = append(data.Daily.Discharge, &discharge)
+	}
+	return &data
+}
+
+// Сводка по реке: сегодня, обычный уровень и пик в ближайшие дни
+type floodOutlook struct {
+	current  float64
+	baseline float64 // медиана за прошлый месяц
+	peak     float64
+	peakDay  time.Time
+}
+
+// Рост расхода к пику в процентах от сегодняшнего
+func (o floodOutlook) rise() float64 {
+	return (o.peak - o.current) / o.current * 100
+}
+
+// Сводка по данным модели на сегодня (по UTC). false, если сегодняшнего
+// значения нет — например, точка не на реке
+func floodOutlookFor(data *floodResponse, now time.Time) (floodOutlook, bool) {
+	today := now.UTC().Format("2006-01-02")
+	var outlook floodOutlook
+	var past []float64
+	found := false
+	for i, day := range data.Daily.Time {
+		value, ok := valueAt(data.Daily.Discharge, i)
+		if !ok {
+			continue
+		}
+		switch {
+		case day < today:
+			past = append(past, value)
+		case day == today:
+			outlook.current = value
+			found = true
+		case value > outlook.peak:
+			outlook.peak = value
+			outlook.peakDay, _ = time.Parse("2006-01-02", day)
+		}
+	}
+	if !found {
+		return floodOutlook{}, false
+	}
+
+	sort.Float64s(past)
+	if len(past) > 0 {
+		outlook.baseline = past[len(past)/2]
+	}
+	return outlook, true
+}
+
+// Проверка подписки: расход воды вырастет не меньше чем на порог
+func checkFlood(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	data, err := fetchFlood(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	outlook, ok := floodOutlookFor(data, clockNow())
+	if !ok || outlook.current < floodMinDischarge || outlook.rise() < sub.Threshold {
+		return "", false, nil
+	}
+	return formatFloodAlert(sub.City, outlook), true, nil
+}
+
+func formatFloodAlert(city string, outlook floodOutlook) string {
+	text := fmt.Sprintf(
+		"🌊 Подъем воды у %s: расход реки сейчас %.0f м³/с, к %s ожидается до %.0f м³/с — на %.0f%% больше.\n",
+		city,
+		outlook.current,
+		outlook.peakDay.Format("02.01")+" ("+strings.ToLower(weekdayName(outlook.peakDay.Weekday()))+")",
+		outlook.peak,
+		outlook.rise(),
+	)
+	if outlook.baseline > 0 && outlook.peak >= outlook.baseline*floodDangerRatio {
+		text += fmt.Sprintf(
+			"⚠️ Это в %.1f раза выше обычного за последний месяц — возможен выход воды из берегов, следите за сообщениями МЧС.\n",
+			outlook.peak/outlook.baseline,
+		)
+	}
+	return text + "\nУберите машины и имущество из низин у воды, не выходите на лед и подмытые берега.\n" +
+		"Данные: модель GloFAS (Copernicus) через Open-Meteo — это прогноз, а не замеры гидропоста. Отписаться: /flood off"
+}
+
+// Разбор аргументов вида "Барнаул 80" или "Барнаул 80%": город и необязательный порог
+func parseFloodArgs(args string) (string, float64) {
+	fields := strings.Fields(args)
+	if len(fields) > 1 {
+		if threshold, err := strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "%"), 64); err == nil {
+			return strings.Join(fields[:len(fields)-1], " "), threshold
+		}
+	}
+	return strings.Join(fields, " "), 0
+}
+
+// Подписка чата на предупреждения о подъеме воды. Сразу проверяем,
+// что у города в модели есть река
+func subscribeFlood(store *Store, chatID int64, city string, threshold float64) (string, error) {
+	if threshold == 0 {
+		threshold = defaultFloodThreshold
+	}
+	if threshold < 10 || threshold > 500 || math.IsNaN(threshold) {
+		return "Порог должен быть от 10 до 500%.", nil
+	}
+
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := fetchFlood(point.Lat, point.Lon)
+	if err != nil {
+		return "", err
+	}
+	outlook, ok := floodOutlookFor(data, clockNow())
+	if !ok || outlook.current < floodMinDischarge {
+		return fmt.Sprintf("🏞 Рядом с %s в модели нет реки с заметным расходом воды — предупреждать не о чем.", point.DisplayName()), nil
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID:    chatID,
+		Kind:      alertFlood,
+		City:      point.DisplayName(),
+		Lat:       point.Lat,
+		Lon:       point.Lon,
+		Threshold: threshold,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🌊 Подписка оформлена! Сейчас расход реки у %s — %.0f м³/с. Предупрежу, если в ближайшие %d дней он вырастет на %.0f%% и больше.\n"+
+			"Отписаться: /flood off",
+		point.DisplayName(),
+		outlook.current,
+		floodHorizonDays,
+		threshold,
+	), nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Расход воды по дням с 5 апреля: месяц около 100 м³/с, с 5 мая подъем до 15 мая
+func floodFixture() *floodResponse {
+	var data floodResponse
+	start := time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)
+	for d := 0; d < 41; d++ {
+		value := 100.0 + float64(d%3)
+		if d >= 30 {
+			value = 120 + float64(d-30)*20
+		}
+		if d == 40 {
+			value = 360
+		}
+		data.Daily.Time = append(data.Daily.Time, start.AddDate(0, 0, d).Format("2006-01-02"))
+		data.Daily.Discharge = append(data.Daily.Discharge, floatPtr(value))
+	}
+	return &data
+}
+
+func TestFloodOutlook(t *testing.T) {
+	outlook, ok := floodOutlookFor(floodFixture(), time.Date(2026, 5, 5, 10, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("нет сегодняшнего значения")
+	}
+	if outlook.current != 120 || outlook.baseline != 101 || outlook.peak != 360 || outlook.peakDay.Format("02.01") != "15.05" {
+		t.Errorf("сводка %+v", outlook)
+	}
+	if rise := outlook.rise(); rise != 200 {
+		t.Errorf("рост %.0f%%, ожидалось 200%%", rise)
+	}
+
+	if _, ok := floodOutlookFor(floodFixture(), time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("сводка без сегодняшнего значения")
+	}
+}
+
+func TestParseFloodArgs(t *testing.T) {
+	for args, want := range map[string]struct {
+		city      string
+		threshold float64
+	}{
+		"Барнаул":            {"Барнаул", 0},
+		"Барнаул 80":         {"Барнаул", 80},
+		"Великий Устюг 120%": {"Великий Устюг", 120},
+		"":                   {"", 0},
+	} {
+		city, threshold := parseFloodArgs(args)
+		if city != want.city || threshold != want.threshold {
+			t.Errorf("parseFloodArgs(%q) = %q, %.0f", args, city, threshold)
+		}
+	}
+}
+
+func TestCheckFlood(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("daily") != "river_discharge" {
+			t.Errorf("запрос %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(floodFixture())
+	}))
+	t.Cleanup(server.Close)
+	previousURL := floodAPIURL
+	floodAPIURL = server.URL
+	t.Cleanup(func() { floodAPIURL = previousURL })
+	floodCacheMu.Lock()
+	floodCache = make(map[string]floodCacheEntry)
+	floodCacheMu.Unlock()
+
+	useManualClock(t, time.Date(2026, 5, 5, 10, 0, 0, 0, time.UTC))
+	sub := &AlertSubscription{ChatID: 4271, Kind: alertFlood, City: "Барнаул", Lat: 53.35, Lon: 83.78, Threshold: 250}
+	if text, fire, err := checkFlood(sub, UserPreferences{}); err != nil || fire {
+		t.Errorf("порог 250%% сработал: %q, %v", text, err)
+	}
+
+	sub.Threshold = defaultFloodThreshold
+	text, fire, err := checkFlood(sub, UserPreferences{})
+	if err != nil || !fire {
+		t.Fatalf("checkFlood = %q, %v, %v", text, fire, err)
+	}
+	for _, want := range []string{
+		"🌊 Подъем воды у Барнаул: расход реки сейчас 120 м³/с, к 15.05 (пятница) ожидается до 360 м³/с — на 200% больше.",
+		"⚠️ Это в 3.6 раза выше обычного за последний месяц",
+		"Отписаться: /flood off",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в предупреждении %q нет %q", text, want)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("запросов к Flood API %d, второй должен взяться из кэша", requests)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Оформление ответов
+const (
+	formatPlain   = "plain"
+	formatHTML    = "html"
+	formatCompact = "compact"
+	// Полная карточка: давление, видимость, УФ-индекс, восход и закат
+	formatDetailed = "detailed"
+	// Простыми словами, с советом, что надеть (см. simple.go)
+	formatSimple = "simple"
+)
+
+// Шаблоны ответов по языкам: templates/<язык>.tmpl, в каждом шаблоны
+// "<ответ>.<оформление>", например "weather.html"
+//
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var replyTemplates = loadReplyTemplates()
+
+func loadReplyTemplates() map[string]*template.Template {
+	templates := make(map[string]*template.Template)
+	for _, lang := range langOrder {
+		// Функции здесь только для разбора, при выводе их заменяют templateFuncs
+		tmpl, err := template.New(lang).Funcs(templateFuncs(lang, UserPreferences{Units: unitsMetric})).
+			ParseFS(templateFiles, "templates/"+lang+".tmpl")
+		if err != nil {
+			panic(fmt.Sprintf("ошибка разбора шаблонов %s: %v", lang, err))
+		}
+		templates[lang] = tmpl
+	}
+	return templates
+}
+
+// Функции шаблонов: единицы измерения зависят от пользователя
+func templateFuncs(lang string, prefs UserPreferences) template.FuncMap {
+	return template.FuncMap{
+		"temp": func(celsius float64) string {
+			return formatTemp(celsius, prefs.Units)
+		},
+		"wind": func(ms float64) string {
+			return formatWindSpeed(ms, windUnitFor(prefs), lang)
+		},
+		"beaufort": func(ms float64) string {
+			return beaufortDescription(ms, lang)
+		},
+		"pressure": func(hpa float64) string {
+			return formatPressure(hpa, lang)
+		},
+		"direction": func(deg int) string {
+			return windDirection(deg, lang)
+		},
+		"delta": func(celsius float64) string {
+			return formatTempDelta(celsius, prefs.Units)
+		},
+		"percent": func(share float64) string {
+			return fmt.Sprintf("%.0f%%", share*100)
+		},
+		"clock": func(t time.Time) string {
+			return t.Format("15:04")
+		},
+		"simply": func(feelsLike, wind float64, condition int) string {
+			return simpleWeather(feelsLike, wind, condition, lang)
+		},
+	}
+}
+
+// Оформление из настроек (обычный текст по умолчанию)
+func replyFormat(prefs UserPreferences) string {
+	if _, ok := formatTitles[prefs.Format]; ok {
+		return prefs.Format
+	}
+	return formatPlain
+}
+
+// Режим разметки Telegram для оформления пользователя
+func replyParseMode(prefs UserPreferences) string {
+	if replyFormat(prefs) == formatHTML {
+		return tgbotapi.ModeHTML
+	}
+	return ""
+}
+
+// Вывод ответа name по шаблону для языка и оформления пользователя
+func renderReply(name string, prefs UserPreferences, data interface{}) (string, error) {
+	return renderTemplate(name+"."+replyFormat(prefs), prefs, data)
+}
+
+// Вывод шаблона name на языке пользователя, без выбора оформления
+// (заметки карточки одинаковы во всех оформлениях)
+func renderTemplate(name string, prefs UserPreferences, data interface{}) (string, error) {
+	tmpl, ok := replyTemplates[prefs.Language]
+	if !ok {
+		tmpl = replyTemplates[langRU]
+	}
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("ошибка шаблона: %v", err)
+	}
+	tmpl.Funcs(templateFuncs(prefs.Language, prefs))
+
+	var text strings.Builder
+	if err := tmpl.ExecuteTemplate(&text, name, data); err != nil {
+		return "", fmt.Errorf("ошибка шаблона: %v", err)
+	}
+	return strings.TrimSpace(text.String()), nil
+}
+
+// Сравнение температуры с другой (нормой или вчерашней) для заметок
+// карточки: Delta по модулю, Near — разница меньше градуса
+type tempComparison struct {
+	Near   bool
+	Warmer bool
+	Delta  float64
+}
+
+func compareTemp(temp, other float64) tempComparison {
+	diff := temp - other
+	return tempComparison{Near: math.Abs(diff) < 1, Warmer: diff > 0, Delta: math.Abs(diff)}
+}
+
+// Данные карточки текущей погоды
+type weatherCardData struct {
+	City     string
+	Weather  *CurrentWeather
+	DewPoint float64
+	Comfort  string
+	// Погода по геопозиции, а не по названию города
+	Location bool
+	// Дополнительные строки: сравнение с нормой, со вчерашним днем
+	Notes []string
+	// УФ-индекс с уровнем, только для подробной карточки
+	UV string
+}
+
+func newWeatherCardData(data *CurrentWeather, prefs UserPreferences, notes ...string) weatherCardData {
+	card := weatherCardData{
+		City:     data.City,
+		Weather:  data,
+		DewPoint: dewPoint(data.Temp, data.Humidity),
+		Comfort:  humidityComfort(data.Temp, data.Humidity, prefs.Language),
+	}
+	// Гроза за окном важнее остальных заметок
+	if isThunderstorm(data.Condition) {
+		notes = append([]string{thunderNote(prefs.Language)}, notes...)
+	}
+	for _, note := range notes {
+		if note = strings.TrimSpace(note); note != "" {
+			card.Notes = append(card.Notes, note)
+		}
+	}
+	// УФ-индекс требует отдельного запроса, поэтому только в подробной карточке
+	if replyFormat(prefs) == formatDetailed {
+		card.UV = uvIndexLine(data, prefs.Language)
+	}
+	return card
+}
+
+// Данные прогноза по дням
+type forecastData struct {
+	City string
+	Days []*forecastDay
+}
+
+type forecastDay struct {
+	Date  string
+	Items []forecastLine
+	Min   float64
+	Max   float64
+	// Описание самого теплого интервала дня
+	Description string
+	// Гроза в один из интервалов дня и ее наибольшая вероятность
+	Thunder    bool
+	ThunderPop float64
+	// Наибольший ветер и самое заметное явление погоды за день
+	Wind      float64
+	Condition int
+}
+
+type forecastLine struct {
+	Time        string
+	Temp        float64
+	Description string
+	Pop         float64
+	WindSpeed   float64
+	Thunder     bool
+}
+
+// Группировка интервалов прогноза по дням (не больше limit интервалов).
+// Даты и время по UTC, как в dt_txt OWM
+func newForecastData(forecast *Forecast, limit int) forecastData {
+	data := forecastData{City: forecast.City}
+	var day *forecastDay
+	for i, item := range forecast.Items {
+		if i >= limit {
+			break
+		}
+
+		t := item.Time.UTC()
+		if day == nil || day.Date != t.Format("02.01") {
+			day = &forecastDay{Date: t.Format("02.01"), Min: item.Temp, Max: item.Temp}
+			data.Days = append(data.Days, day)
+		}
+		day.Items = append(day.Items, forecastLine{
+			Time:        t.Format("15:00"),
+			Temp:        item.Temp,
+			Description: item.Description,
+			Pop:         item.Pop,
+			WindSpeed:   item.WindSpeed,
+			Thunder:     isThunderstorm(item.Condition),
+		})
+		if isThunderstorm(item.Condition) {
+			day.Thunder = true
+			day.ThunderPop = math.Max(day.ThunderPop, item.Pop)
+		}
+		day.Wind = math.Max(day.Wind, item.WindSpeed)
+		day.Condition = notableCondition(day.Condition, item.Condition)
+		if item.Temp <= day.Min {
+			day.Min = item.Temp
+		}
+		if item.Temp >= day.Max {
+			day.Max = item.Temp
+			day.Description = item.Description
+		}
+	}
+	return data
+}
+
+// Данные прогноза на сегодня
+type todayData struct {
+	City string
+	// Интервалы на сегодня закончились (поздний вечер), показываем завтра
+	Tomorrow bool
+	Day      *forecastDay
+	// Наибольшие вероятность осадков и ветер за день
+	Pop  float64
+	Wind float64
+}
+
+// Прогноз на ближайший день по местному времени города. false, если в
+// прогнозе нет интервалов
+func newTodayData(forecast *Forecast) (todayData, bool) {
+	if len(forecast.Items) == 0 {
+		return todayData{}, false
+	}
+
+	now := forecast.Now()
+	date := forecast.LocalTime(forecast.Items[0]).Format("2006-01-02")
+	data := todayData{City: forecast.City, Tomorrow: date != now.Format("2006-01-02")}
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		if local.Format("2006-01-02") != date {
+			break
+		}
+		if data.Day == nil {
+			data.Day = &forecastDay{Date: local.Format("02.01"), Min: item.Temp, Max: item.Temp}
+		}
+		day := data.Day
+		day.Items = append(day.Items, forecastLine{
+			Time:        local.Format("15:04"),
+			Temp:        item.Temp,
+			Description: item.Description,
+			Pop:         item.Pop,
+			WindSpeed:   item.WindSpeed,
+			Thunder:     isThunderstorm(item.Condition),
+		})
+		if isThunderstorm(item.Condition) {
+			day.Thunder = true
+			day.ThunderPop = math.Max(day.ThunderPop, item.Pop)
+		}
+		day.Wind = math.Max(day.Wind, item.WindSpeed)
+		day.Condition = notableCondition(day.Condition, item.Condition)
+		if item.Temp <= day.Min {
+			day.Min = item.Temp
+		}
+		if item.Temp >= day.Max {
+			day.Max = item.Temp
+			day.Description = item.Description
+		}
+		data.Pop = math.Max(data.Pop, item.Pop)
+		data.Wind = math.Max(data.Wind, item.WindSpeed)
+	}
+	return data, true
+}
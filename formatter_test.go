@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeatherCardDetailed(t *testing.T) {
+	c := *config()
+	c.DefaultProvider = providerMock
+	previous := config()
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	zone := time.FixedZone("", 3*3600)
+	data := &CurrentWeather{
+		City: "Тула", Time: time.Date(2026, 10, 16, 12, 0, 0, 0, zone),
+		Temp: 3, FeelsLike: 0, Humidity: 80, Pressure: 1012,
+		WindSpeed: 5, WindGust: 9, WindDeg: 200, Clouds: 90, Visibility: 8000,
+		Description: "пасмурно",
+		Sunrise:     time.Date(2026, 10, 16, 7, 12, 0, 0, zone),
+		Sunset:      time.Date(2026, 10, 16, 17, 40, 0, 0, zone),
+	}
+
+	prefs := UserPreferences{Units: unitsMetric, Language: langRU, Format: formatDetailed}
+	text, err := renderReply("weather", prefs, newWeatherCardData(data, prefs))
+	if err != nil {
+		t.Fatalf("renderReply: %v", err)
+	}
+	for _, want := range []string{
+		"Ветер: 5 м/с Ю, слабый ветер, порывы до 9 м/с",
+		"Давление: 1012 гПа (759 мм рт. ст.)",
+		"Видимость: 8000 м",
+		"Восход 07:12, закат 17:40",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в подробной карточке нет %q:\n%s", want, text)
+		}
+	}
+
+	prefs.Format = formatCompact
+	text, err = renderReply("weather", prefs, newWeatherCardData(data, prefs))
+	if err != nil {
+		t.Fatalf("renderReply: %v", err)
+	}
+	if strings.Contains(text, "\n") || !strings.Contains(text, "пасмурно, ветер 5 м/с") {
+		t.Errorf("краткая карточка не в одну строку: %q", text)
+	}
+}
+
+func TestWindDirectionAndUV(t *testing.T) {
+	for deg, want := range map[int]string{0: "С", 22: "С", 23: "СВ", 200: "Ю", 350: "С", 270: "З", -90: "З"} {
+		if got := windDirection(deg, langRU); got != want {
+			t.Errorf("windDirection(%d) = %q, ожидалось %q", deg, got, want)
+		}
+	}
+	for uv, want := range map[float64]string{1: "1, низкий", 3: "3, умеренный", 7: "7, высокий", 9: "9, очень высокий", 12: "12, экстремальный"} {
+		if got := formatUVIndex(uv, langRU); got != want {
+			t.Errorf("formatUVIndex(%v) = %q, ожидалось %q", uv, got, want)
+		}
+	}
+}
+
+func TestCardNotesLanguage(t *testing.T) {
+	yesterday := Observation{Temp: 12, Wind: 2}
+	today := Observation{Temp: 9, Wind: 5}
+	ru := UserPreferences{Units: unitsMetric, Language: langRU}
+	en := UserPreferences{Units: unitsImperial, Language: langEN}
+
+	if got := yesterdayComparison(yesterday, today, ru); got != "📆 На 3°C холоднее, чем вчера, ветер усилился вдвое" {
+		t.Errorf("сравнение со вчера на русском: %q", got)
+	}
+	if got := yesterdayComparison(yesterday, today, en); got != "📆 5°F colder than yesterday, the wind has doubled" {
+		t.Errorf("сравнение со вчера на английском: %q", got)
+	}
+	if got := yesterdayComparison(today, Observation{Temp: 9.5, Wind: 2}, en); got != "📆 About the same as yesterday, the wind has halved" {
+		t.Errorf("сравнение со вчера без перемен: %q", got)
+	}
+
+	for _, tt := range []struct {
+		prefs UserPreferences
+		diff  tempComparison
+		want  string
+	}{
+		{ru, compareTemp(14, 10), "📊 На 4°C теплее нормы для этой даты"},
+		{en, compareTemp(10, 15), "📊 9°F colder than normal for this date"},
+		{en, compareTemp(10, 10.4), "📊 Temperature is close to normal for this date"},
+	} {
+		if got, err := renderTemplate("climate.note", tt.prefs, tt.diff); err != nil || got != tt.want {
+			t.Errorf("сравнение с нормой %q (%v), ожидалось %q", got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "strings"
+
+// Геохеш: точка кодируется строкой, и чем длиннее общий префикс, тем
+// ближе точки. Используется как ключ кэша по координатам, чтобы соседние
+// места попадали в одну ячейку
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Длина геохеша для кэша: 5 символов — ячейка около 4,9 × 4,9 км
+const geohashCachePrecision = 5
+
+// Геохеш точки заданной длины. Биты долготы и широты чередуются,
+// начиная с долготы, и по 5 бит кодируются одним символом
+func geohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var b strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for b.Len() < precision {
+		value, bounds := lat, &latRange
+		if even {
+			value, bounds = lon, &lonRange
+		}
+		mid := (bounds[0] + bounds[1]) / 2
+		ch <<= 1
+		if value >= mid {
+			ch |= 1
+			bounds[0] = mid
+		} else {
+			bounds[1] = mid
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			b.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	// Примеры из описания алгоритма
+	if got := geohash(57.64911, 10.40744, 11); got != "u4pruydqqvj" {
+		t.Errorf("geohash = %q, ожидалось u4pruydqqvj", got)
+	}
+	if got := geohash(42.6, -5.6, 5); got != "ezs42" {
+		t.Errorf("geohash = %q, ожидалось ezs42", got)
+	}
+
+	// Точки в паре сотен метров друг от друга попадают в одну ячейку кэша,
+	// а соседний город — нет
+	kremlin := geohash(55.7520, 37.6175, geohashCachePrecision)
+	if got := geohash(55.7539, 37.6208, geohashCachePrecision); got != kremlin {
+		t.Errorf("соседние точки в разных ячейках: %q и %q", got, kremlin)
+	}
+	if got := geohash(55.9116, 37.7308, geohashCachePrecision); got == kremlin {
+		t.Errorf("Мытищи в одной ячейке с центром Москвы: %q", got)
+	}
+}
+
+func TestCachedLocationWeather(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(owmWeatherFixture))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.OWMAPIKeys = []string{"test-key"}
+	c.OWMAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	previousCache := weatherCache
+	weatherCache = &WeatherCache{data: make(map[string]CacheItem)}
+	t.Cleanup(func() { weatherCache = previousCache })
+
+	for _, point := range [][2]float64{{55.7520, 37.6175}, {55.7539, 37.6208}, {55.7520, 37.6175}} {
+		if _, err := cachedLocationWeather(point[0], point[1], langRU); err != nil {
+			t.Fatalf("cachedLocationWeather: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("запросов к OWM %d для соседних точек, ожидался 1", requests)
+	}
+
+	// Другой язык и другая ячейка запрашиваются отдельно
+	cachedLocationWeather(55.7520, 37.6175, langEN)
+	cachedLocationWeather(55.9116, 37.7308, langRU)
+	if requests != 3 {
+		t.Errorf("запросов к OWM %d, ожидалось 3", requests)
+	}
+}
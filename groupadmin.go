@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// В группе погоду может запрашивать любой участник, а настройки группы
+// (город, язык, подписки и рассылки) меняют только ее администраторы
+
+// Сколько помним результат проверки прав участника группы
+const chatAdminCacheTTL = 5 * time.Minute
+
+const groupAdminOnlyText = "В группе это могут менять только ее администраторы."
+
+// Действия кнопок, которые меняют настройки чата
+var groupSettingsActions = map[string]bool{
+	actionSettings:   true,
+	actionOnboarding: true,
+	actionForgetMe:   true,
+	actionDigest:     true,
+	actionPause:      true,
+}
+
+// Диалоги, ответы в которых меняют настройки чата
+var groupSettingsFlows = map[string]bool{
+	flowSubscribe:      true,
+	flowOnboardingHome: true,
+	flowPlace:          true,
+}
+
+type chatAdminKey struct {
+	chatID int64
+	userID int64
+}
+
+type chatAdminEntry struct {
+	admin     bool
+	checkedAt time.Time
+}
+
+var (
+	chatAdminCache   = make(map[chatAdminKey]chatAdminEntry)
+	chatAdminCacheMu sync.Mutex
+)
+
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// Является ли пользователь создателем или администратором группы (getChatMember)
+func isChatAdmin(bot *Bot, chatID, userID int64) (bool, error) {
+	key := chatAdminKey{chatID: chatID, userID: userID}
+
+	chatAdminCacheMu.Lock()
+	entry, exists := chatAdminCache[key]
+	chatAdminCacheMu.Unlock()
+	if exists && clockNow().Sub(entry.checkedAt) < chatAdminCacheTTL {
+		return entry.admin, nil
+	}
+
+	member, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки прав в группе: %v", err)
+	}
+	admin := member.IsCreator() || member.IsAdministrator()
+
+	chatAdminCacheMu.Lock()
+	defer chatAdminCacheMu.Unlock()
+	for k, e := range chatAdminCache {
+		if clockNow().Sub(e.checkedAt) > chatAdminCacheTTL {
+			delete(chatAdminCache, k)
+		}
+	}
+	chatAdminCache[key] = chatAdminEntry{admin: admin, checkedAt: clockNow()}
+	return admin, nil
+}
+
+// Может ли отправитель менять настройки чата. В личном чате — всегда,
+// в группе — ее администраторы (в том числе анонимные, которые пишут
+// от имени группы) и администраторы бота. Если проверить права не
+// удалось, изменение запрещаем
+func canManageChat(bot *Bot, chat *tgbotapi.Chat, from *tgbotapi.User, senderChat *tgbotapi.Chat) bool {
+	if !isGroupChat(chat) {
+		return true
+	}
+	if senderChat != nil && senderChat.ID == chat.ID {
+		return true
+	}
+	if from == nil {
+		return false
+	}
+	if isAdmin(from.ID) {
+		return true
+	}
+
+	admin, err := isChatAdmin(bot, chat.ID, from.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки прав пользователя %d в чате %d: %v", from.ID, chat.ID, err)
+		return false
+	}
+	return admin
+}
+
+// Можно ли отправителю сообщения менять настройки чата
+func canManageChatMessage(bot *Bot, message *tgbotapi.Message) bool {
+	return canManageChat(bot, message.Chat, message.From, message.SenderChat)
+}
+
+// Можно ли считать сообщение ответом в начатом диалоге. В группе ответы
+// в диалогах настройки принимаются только от администраторов, сообщения
+// остальных участников обрабатываются как обычные запросы погоды
+func dialogInputAllowed(bot *Bot, message *tgbotapi.Message) bool {
+	if !isGroupChat(message.Chat) {
+		return true
+	}
+	state, ok := bot.store.Dialog(message.Chat.ID)
+	if !ok || !groupSettingsFlows[state.Flow] {
+		return true
+	}
+	return canManageChatMessage(bot, message)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Утренние публикации в группах: администраторы выбирают время, и бот
+// каждый день в это время по местному времени города публикует сводку
+
+// Как часто проверяем, не пора ли публиковать. Время задается с точностью
+// до минуты, поэтому общая проверка оповещений раз в полчаса не подходит
+const groupPostCheckInterval = time.Minute
+
+// Сколько после назначенного времени публикацию еще можно отправить
+// (например, если бот был перезапущен)
+const groupPostWindow = time.Hour
+
+// Расписание публикаций группы
+type GroupPost struct {
+	City   string  `json:"city"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Hour   int     `json:"hour"`
+	Minute int     `json:"minute"`
+	// Смещение часового пояса города в секундах, обновляется при каждой публикации
+	TZOffset   int       `json:"tz_offset"`
+	LastPosted time.Time `json:"last_posted,omitempty"`
+}
+
+// Часовой пояс города публикации
+func (p GroupPost) Location() *time.Location {
+	return time.FixedZone("", p.TZOffset)
+}
+
+// Пора ли публиковать: назначенное время сегодня уже наступило, но прошло
+// не больше groupPostWindow, и сегодня еще не публиковали
+func (p GroupPost) Due(now time.Time) bool {
+	local := now.In(p.Location())
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), p.Hour, p.Minute, 0, 0, p.Location())
+	if local.Before(scheduled) || local.Sub(scheduled) >= groupPostWindow {
+		return false
+	}
+	return p.LastPosted.IsZero() || p.LastPosted.In(p.Location()).Format("2006-01-02") != local.Format("2006-01-02")
+}
+
+// Сохранение расписания публикаций группы
+func (s *Store) SetGroupPost(chatID int64, post GroupPost) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.GroupPosts[chatID] = &post
+	return s.save()
+}
+
+// Расписание публикаций группы
+func (s *Store) GroupPost(chatID int64) (GroupPost, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, exists := s.data.GroupPosts[chatID]
+	if !exists {
+		return GroupPost{}, false
+	}
+	return *post, true
+}
+
+// Копии всех расписаний публикаций по чатам
+func (s *Store) GroupPosts() map[int64]GroupPost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	posts := make(map[int64]GroupPost, len(s.data.GroupPosts))
+	for chatID, post := range s.data.GroupPosts {
+		posts[chatID] = *post
+	}
+	return posts
+}
+
+// Отключение публикаций. Возвращает false, если их не было
+func (s *Store) DeleteGroupPost(chatID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.data.GroupPosts[chatID]; !exists {
+		return false, nil
+	}
+	delete(s.data.GroupPosts, chatID)
+	return true, s.save()
+}
+
+// Отметка о публикации с актуальным смещением часового пояса
+func (s *Store) MarkGroupPosted(chatID int64, at time.Time, tzOffset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, exists := s.data.GroupPosts[chatID]
+	if !exists {
+		return nil
+	}
+	post.LastPosted = at
+	post.TZOffset = tzOffset
+	return s.save()
+}
+
+// Разбор времени публикации: "8:30", "08:30" или просто час "8"
+func parsePostTime(value string) (int, int, error) {
+	layout := "15:04"
+	if !strings.Contains(value, ":") {
+		layout = "15"
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("не понял время «%s», укажите его как 8:30", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// Настройка публикаций: город ищем сразу, а по текущей погоде узнаем часовой пояс
+func scheduleGroupPost(store *Store, chatID int64, city string, hour, minute int) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+	current, err := fetchWeatherByCoords(point.Lat, point.Lon)
+	if err != nil {
+		return "", err
+	}
+	_, offset := current.Time.Zone()
+
+	post := GroupPost{
+		City:     point.DisplayName(),
+		Lat:      point.Lat,
+		Lon:      point.Lon,
+		Hour:     hour,
+		Minute:   minute,
+		TZOffset: offset,
+	}
+	if err := store.SetGroupPost(chatID, post); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		"📰 Каждый день в %02d:%02d по местному времени буду публиковать здесь сводку погоды в %s.\n"+
+			"Отключить: /grouppost off",
+		hour, minute, post.City,
+	), nil
+}
+
+// /grouppost — расписание утренней сводки в группе
+func handleGroupPostCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	if !isGroupChat(c.message.Chat) {
+		c.msg.Text = "Публикации по расписанию настраиваются в группах. Для личного чата есть /daily."
+		return
+	}
+
+	args := strings.Fields(c.args)
+	if len(args) == 0 {
+		if post, ok := c.bot.store.GroupPost(chatID); ok {
+			c.msg.Text = fmt.Sprintf("📰 Сводка погоды в %s публикуется каждый день в %02d:%02d.\n"+
+				"Изменить: /grouppost 8:30 [город], отключить: /grouppost off", post.City, post.Hour, post.Minute)
+		} else {
+			c.msg.Text = "Укажите время и, при желании, город, например: /grouppost 8:30 Москва. " +
+				"Без города берется домашний город группы из /settings."
+		}
+		return
+	}
+
+	if args[0] == "off" {
+		removed, err := c.bot.store.DeleteGroupPost(chatID)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Публикации сводки отключены."
+		default:
+			c.msg.Text = "Публикации сводки не были настроены."
+		}
+		return
+	}
+
+	hour, minute, err := parsePostTime(args[0])
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	city := strings.Join(args[1:], " ")
+	if city == "" {
+		city = c.bot.store.Preferences(chatID).HomeCity
+	}
+	if city == "" {
+		city = c.bot.lastCity[chatID]
+	}
+	if city == "" {
+		c.msg.Text = "Укажите город, например: /grouppost 8:30 Москва, или выберите домашний город группы в /settings."
+		return
+	}
+
+	reply, err := scheduleGroupPost(c.bot.store, chatID, city, hour, minute)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		c.msg.Text = reply
+	}
+}
+
+// Фоновая публикация сводок в группах
+func runGroupPosts(bot *Bot) {
+	ticker := time.NewTicker(groupPostCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		runScheduledPass("публикации в группах", groupPostCheckInterval, func() { publishGroupPosts(bot, bot.store, clockNow()) })
+		<-ticker.C
+	}
+}
+
+func publishGroupPosts(bot messageSender, store *Store, now time.Time) {
+	for chatID, post := range store.GroupPosts() {
+		if !post.Due(now) {
+			continue
+		}
+
+		forecast, err := cachedForecastByCoords(post.Lat, post.Lon)
+		if err != nil {
+			log.Printf("Ошибка получения прогноза для публикации в чате %d: %v", chatID, err)
+			continue
+		}
+		current, err := cachedWeatherByCoords(post.Lat, post.Lon)
+		if err != nil {
+			log.Printf("Ошибка получения погоды для публикации в чате %d: %v", chatID, err)
+			continue
+		}
+
+		text := formatDigest(post.City, current, forecast, forecast.Now(), nil)
+		if err := deliver(bot, store, chatID, "Сводка погоды", tgbotapi.NewMessage(chatID, text), text); err != nil {
+			log.Printf("Ошибка публикации сводки в чате %d: %v", chatID, err)
+		}
+
+		_, offset := forecast.Now().Zone()
+		if err := store.MarkGroupPosted(chatID, now, offset); err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGroupPostDue(t *testing.T) {
+	// Город в UTC+3, публикация в 8:30 местного времени
+	post := GroupPost{Hour: 8, Minute: 30, TZOffset: 3 * 3600}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 16, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		last time.Time
+		want bool
+	}{
+		{"до назначенного времени", at(5, 29), time.Time{}, false},
+		{"ровно в назначенное время", at(5, 30), time.Time{}, true},
+		{"с опозданием в пределах часа", at(6, 15), time.Time{}, true},
+		{"окно прошло", at(6, 30), time.Time{}, false},
+		{"сегодня уже публиковали", at(5, 45), at(5, 30), false},
+		{"публиковали вчера", at(5, 30), at(5, 30).AddDate(0, 0, -1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := post
+			p.LastPosted = tt.last
+			if got := p.Due(tt.now); got != tt.want {
+				t.Errorf("Due(%s) = %v, ожидалось %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePostTime(t *testing.T) {
+	tests := map[string][2]int{"8:30": {8, 30}, "08:05": {8, 5}, "7": {7, 0}, "23:59": {23, 59}}
+	for value, want := range tests {
+		hour, minute, err := parsePostTime(value)
+		if err != nil || hour != want[0] || minute != want[1] {
+			t.Errorf("parsePostTime(%q) = %d, %d, %v, ожидалось %d:%02d", value, hour, minute, err, want[0], want[1])
+		}
+	}
+	for _, value := range []string{"", "25:00", "8:61", "утром"} {
+		if _, _, err := parsePostTime(value); err == nil {
+			t.Errorf("parsePostTime(%q) без ошибки", value)
+		}
+	}
+}
+
+func TestPipelineGroupPost(t *testing.T) {
+	f := newFakeTelegram(t)
+	f.chatAdmins = map[int64]bool{7002: true}
+
+	c := *config()
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+
+	const groupID = -1007001
+	f.send(groupTextUpdate(groupID, 7001, "/grouppost 8:30 Тула"))
+	if got := f.reply(t, groupID); got != groupAdminOnlyText {
+		t.Errorf("участник настроил публикации: %q", got)
+	}
+
+	f.reset()
+	f.send(groupTextUpdate(groupID, 7002, "/grouppost 8:30 Тула"))
+	if got := f.reply(t, groupID); !strings.Contains(got, "08:30") {
+		t.Errorf("неожиданный ответ: %q", got)
+	}
+	post, ok := store.GroupPost(groupID)
+	if !ok || post.City != "Тула" {
+		t.Fatalf("расписание не сохранено: %+v", post)
+	}
+
+	local := time.Now().In(post.Location())
+	due := time.Date(local.Year(), local.Month(), local.Day(), 8, 40, 0, 0, post.Location())
+
+	f.reset()
+	publishGroupPosts(f.bot, store, due.Add(-time.Hour))
+	if posted := f.sent("sendMessage"); len(posted) != 0 {
+		t.Fatalf("публикация раньше времени: %+v", posted)
+	}
+
+	publishGroupPosts(f.bot, store, due)
+	publishGroupPosts(f.bot, store, due.Add(time.Minute))
+	if got := f.reply(t, groupID); !strings.Contains(got, "Тула") {
+		t.Errorf("в сводке нет города: %q", got)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Природные опасности рядом с городом подписки. Пока источник один —
+// землетрясения из ленты USGS, но подписка задумана шире погоды: сюда
+// же можно добавить другие ленты предупреждений
+
+// Тип подписки на природные опасности
+const alertHazards = "hazards"
+
+// Лента USGS: землетрясения магнитудой от 2.5 за последние сутки
+var earthquakeFeedURL = "https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/2.5_day.geojson"
+
+// Лента обновляется раз в минуту, но для оповещений хватает и реже
+const earthquakeCacheTTL = 5 * time.Minute
+
+// Насколько старые толчки показываем при первой проверке подписки
+const hazardLookback = 3 * time.Hour
+
+// Лента USGS публикует толчок с задержкой после него: при каждой проверке
+// смотрим немного раньше прошлого оповещения, а повторы отсекаем по номеру
+const hazardPublishLag = time.Hour
+
+// Какие толчки считаем значимыми: чем сильнее, тем дальше
+var earthquakeRadii = []struct {
+	magnitude float64
+	radiusKm  float64
+}{
+	{6, 1000},
+	{5, 500},
+	{4, 250},
+	{3, 80},
+}
+
+func init() {
+	// Землетрясения не ждут: проверяем при каждом обходе подписок
+	alertKinds[alertHazards] = alertKind{title: "Природные опасности", check: checkHazards}
+}
+
+// Землетрясение из ленты USGS
+type Earthquake struct {
+	ID        string
+	Magnitude float64
+	Place     string
+	Time      time.Time
+	Lat, Lon  float64
+	DepthKm   float64
+	Tsunami   bool
+	URL       string
+}
+
+// Ответ ленты USGS в формате GeoJSON
+type usgsFeedResponse struct {
+	Features []struct {
+		ID         string `json:"id"`
+		Properties struct {
+			Mag     *float64 `json:"mag"`
+			Place   string   `json:"place"`
+			Time    int64    `json:"time"` // мс
+			URL     string   `json:"url"`
+			Tsunami int      `json:"tsunami"`
+		} `json:"properties"`
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"` // долгота, широта, глубина
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+var usgsClient = &http.Client{Timeout: 10 * time.Second}
+
+var (
+	earthquakeCache     []Earthquake
+	earthquakeCacheTime time.Time
+	earthquakeCacheMu   sync.Mutex
+)
+
+// Уже отправленные толчки по чатам: ключ "чат|номер события"
+var (
+	hazardSent   = make(map[string]time.Time)
+	hazardSentMu sync.Mutex
+)
+
+// Землетрясения за последние сутки
+func fetchEarthquakes() ([]Earthquake, error) {
+	earthquakeCacheMu.Lock()
+	defer earthquakeCacheMu.Unlock()
+
+	if earthquakeCache != nil && clockNow().Sub(earthquakeCacheTime) < earthquakeCacheTTL {
+		return earthquakeCache, nil
+	}
+
+	resp, err := usgsClient.Get(earthquakeFeedURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса ленты землетрясений: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения ленты землетрясений: статус %d", resp.StatusCode)
+	}
+
+	var data usgsFeedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ленты землетрясений: %v", err)
+	}
+
+	quakes := []Earthquake{}
+	for _, feature := range data.Features {
+		coords := feature.Geometry.Coordinates
+		if feature.Properties.Mag == nil || len(coords) < 2 {
+			continue
+		}
+		quake := Earthquake{
+			ID:        feature.ID,
+			Magnitude: *feature.Properties.Mag,
+			Place:     feature.Properties.Place,
+			Time:      time.UnixMilli(feature.Properties.Time).UTC(),
+			Lat:       coords[1],
+			Lon:       coords[0],
+			Tsunami:   feature.Properties.Tsunami == 1,
+			URL:       feature.Properties.URL,
+		}
+		if len(coords) > 2 {
+			quake.DepthKm = coords[2]
+		}
+		quakes = append(quakes, quake)
+	}
+
+	earthquakeCache = quakes
+	earthquakeCacheTime = clockNow()
+	return quakes, nil
+}
+
+// Значим ли толчок для точки на таком расстоянии
+func earthquakeRelevant(magnitude, distanceKm float64) bool {
+	for _, radius := range earthquakeRadii {
+		if magnitude >= radius.magnitude {
+			return distanceKm <= radius.radiusKm
+		}
+	}
+	return false
+}
+
+// Значимые толчки рядом с точкой после since, еще не отправленные в чат
+func nearbyEarthquakes(chatID int64, lat, lon float64, since time.Time) ([]Earthquake, error) {
+	quakes, err := fetchEarthquakes()
+	if err != nil {
+		return nil, err
+	}
+
+	hazardSentMu.Lock()
+	defer hazardSentMu.Unlock()
+
+	// Старые отметки больше не понадобятся: лента только за сутки
+	for key, at := range hazardSent {
+		if clockNow().Sub(at) > 24*time.Hour {
+			delete(hazardSent, key)
+		}
+	}
+
+	var found []Earthquake
+	for _, quake := range quakes {
+		if !quake.Time.After(since) || !earthquakeRelevant(quake.Magnitude, haversineKm(lat, lon, quake.Lat, quake.Lon)) {
+			continue
+		}
+		if _, sent := hazardSent[fmt.Sprintf("%d|%s", chatID, quake.ID)]; sent {
+			continue
+		}
+		found = append(found, quake)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Time.Before(found[j].Time) })
+	return found, nil
+}
+
+// Отметка, что о толчках уже сообщили в чат
+func markEarthquakesSent(chatID int64, quakes []Earthquake) {
+	hazardSentMu.Lock()
+	defer hazardSentMu.Unlock()
+	for _, quake := range quakes {
+		hazardSent[fmt.Sprintf("%d|%s", chatID, quake.ID)] = quake.Time
+	}
+}
+
+// Проверка подписки: новые значимые землетрясения рядом с городом
+func checkHazards(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	since := clockNow().Add(-hazardLookback)
+	if !sub.LastFired.IsZero() && sub.LastFired.Add(-hazardPublishLag).After(since) {
+		since = sub.LastFired.Add(-hazardPublishLag)
+	}
+
+	quakes, err := nearbyEarthquakes(sub.ChatID, sub.Lat, sub.Lon, since)
+	if err != nil {
+		return "", false, err
+	}
+	if len(quakes) == 0 {
+		return "", false, nil
+	}
+	markEarthquakesSent(sub.ChatID, quakes)
+	return formatEarthquakes(sub.City, sub.Lat, sub.Lon, quakes), true, nil
+}
+
+func formatEarthquakes(city string, lat, lon float64, quakes []Earthquake) string {
+	var lines []string
+	for _, quake := range quakes {
+		line := fmt.Sprintf("🌍 Землетрясение M%.1f в %.0f км от %s\n📍 %s, глубина %.0f км\n🕐 %s UTC",
+			quake.Magnitude,
+			haversineKm(lat, lon, quake.Lat, quake.Lon),
+			city,
+			quake.Place,
+			quake.DepthKm,
+			quake.Time.Format("02.01 15:04"),
+		)
+		if quake.Tsunami {
+			line += "\n🌊 Возможна угроза цунами — следите за сообщениями МЧС и местных служб."
+		}
+		if quake.URL != "" {
+			line += "\n" + quake.URL
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n\n") + "\n\nДанные: Геологическая служба США (USGS). Отписаться: /hazards off"
+}
+
+// Подписка чата на природные опасности рядом с городом
+func subscribeHazards(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertHazards,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🌍 Подписка оформлена! Сообщу о значимых землетрясениях рядом с %s: от M3 в радиусе 80 км до M6 в радиусе 1000 км.\n"+
+			"Отписаться: /hazards off",
+		point.DisplayName(),
+	), nil
+}
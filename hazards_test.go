@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const usgsFeedFixture = `{"features": [
+	{"id": "us7000near", "properties": {"mag": 5.4, "place": "40 km SE of Sochi, Russia", "time": 1792141200000, "url": "https://earthquake.usgs.gov/earthquakes/eventpage/us7000near", "tsunami": 1},
+		"geometry": {"coordinates": [40.1, 43.3, 12.5]}},
+	{"id": "us7000weak", "properties": {"mag": 3.1, "place": "Black Sea", "time": 1792141800000, "url": "", "tsunami": 0},
+		"geometry": {"coordinates": [38.5, 43.0, 10]}},
+	{"id": "us7000old", "properties": {"mag": 6.1, "place": "Turkey", "time": 1792090800000, "url": "", "tsunami": 0},
+		"geometry": {"coordinates": [38.0, 40.0, 20]}}
+]}`
+
+func TestEarthquakeRelevant(t *testing.T) {
+	for _, c := range []struct {
+		magnitude, distance float64
+		want                bool
+	}{
+		{6.5, 900, true}, {5.2, 600, false}, {4.1, 200, true}, {3.2, 100, false}, {2.8, 10, false},
+	} {
+		if got := earthquakeRelevant(c.magnitude, c.distance); got != c.want {
+			t.Errorf("earthquakeRelevant(%.1f, %.0f) = %v", c.magnitude, c.distance, got)
+		}
+	}
+}
+
+func TestCheckHazards(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(usgsFeedFixture))
+	}))
+	t.Cleanup(server.Close)
+	previousURL := earthquakeFeedURL
+	earthquakeFeedURL = server.URL
+	t.Cleanup(func() { earthquakeFeedURL = previousURL })
+	earthquakeCacheMu.Lock()
+	earthquakeCache = nil
+	earthquakeCacheMu.Unlock()
+
+	// Толчки в 09:00 и 09:10 UTC и накануне вечером, проверка в 11:00
+	useManualClock(t, time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC))
+	sub := &AlertSubscription{ChatID: 4251, Kind: alertHazards, City: "Сочи", Lat: 43.6, Lon: 39.73}
+
+	text, fire, err := checkHazards(sub, UserPreferences{})
+	if err != nil || !fire {
+		t.Fatalf("checkHazards = %q, %v, %v", text, fire, err)
+	}
+	for _, want := range []string{"🌍 Землетрясение M5.4 в ", " км от Сочи", "40 km SE of Sochi, Russia, глубина 12 км", "16.10 09:00 UTC", "🌊 Возможна угроза цунами"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в оповещении %q нет %q", text, want)
+		}
+	}
+	// Слабый далекий толчок и толчок старше трех часов не попадают
+	if strings.Contains(text, "Black Sea") || strings.Contains(text, "Turkey") {
+		t.Errorf("лишние толчки в оповещении %q", text)
+	}
+
+	// Отправленный толчок не повторяется, даже если он попадает в окно задержки ленты
+	sub.LastFired = clockNow()
+	if text, fire, _ := checkHazards(sub, UserPreferences{}); fire {
+		t.Errorf("повторное оповещение %q", text)
+	}
+}
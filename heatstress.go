@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Предупреждения о тепловом стрессе для тех, кто работает или тренируется
+// на улице: прорабов, курьеров, тренеров. Риск оцениваем по WBGT, как в
+// рекомендациях по режиму труда и отдыха, и для наглядности показываем humidex
+
+// Тип подписки на предупреждения о тепловом стрессе
+const alertHeatStress = "heatstress"
+
+// На сколько вперед смотрим прогноз
+const heatStressHorizon = 24 * time.Hour
+
+func init() {
+	alertKinds[alertHeatStress] = alertKind{title: "Тепловой стресс", check: checkHeatStress, cooldown: 20 * time.Hour}
+}
+
+// Категория риска теплового стресса
+type heatRiskLevel struct {
+	name    string  // название в команде и в сообщении
+	minWBGT float64 // нижняя граница WBGT, °C
+	emoji   string
+	advice  string
+}
+
+// Категории в порядке роста риска; номер категории хранится в пороге подписки
+var heatRiskLevels = []heatRiskLevel{
+	{name: "низкий", minWBGT: math.Inf(-1), emoji: "🟢", advice: "Обычный режим работы и тренировок."},
+	{name: "умеренный", minWBGT: 25, emoji: "🟡", advice: "Пейте воду каждые 20 минут, отдыхайте в тени, новичкам и непривычным к жаре — полегче."},
+	{name: "высокий", minWBGT: 28, emoji: "🟠", advice: "Режим 45 минут работы и 15 минут отдыха в тени, тяжелую работу и интенсивные тренировки — на утро."},
+	{name: "опасный", minWBGT: 30, emoji: "🔴", advice: "Режим 30/30, тренировки короче и легче, следите друг за другом: головокружение и тошнота — сигнал остановиться."},
+	{name: "экстремальный", minWBGT: 32, emoji: "⚫️", advice: "Тяжелую работу и тренировки на улице лучше отменить, оставить только необходимое с частым отдыхом."},
+}
+
+// Категория по умолчанию для новой подписки
+const defaultHeatRiskLevel = 2
+
+// Номер категории риска по WBGT
+func heatRiskLevelFor(wbgtValue float64) int {
+	level := 0
+	for i, candidate := range heatRiskLevels {
+		if wbgtValue >= candidate.minWBGT {
+			level = i
+		}
+	}
+	return level
+}
+
+// Категория из аргумента команды: номер (1–4) или название
+func parseHeatRiskLevel(text string) (int, bool) {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n, n >= 1 && n < len(heatRiskLevels)
+	}
+	for i, level := range heatRiskLevels[1:] {
+		if strings.EqualFold(text, level.name) {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Разбор аргументов вида "Краснодар высокий": город и необязательная категория
+func parseHeatStressArgs(args string) (string, int) {
+	fields := strings.Fields(args)
+	if len(fields) > 0 {
+		if level, ok := parseHeatRiskLevel(fields[len(fields)-1]); ok {
+			return strings.Join(fields[:len(fields)-1], " "), level
+		}
+	}
+	return strings.Join(fields, " "), 0
+}
+
+// Отрезок прогноза с риском не ниже порога
+type heatStressPeriod struct {
+	start, end time.Time
+	maxWBGT    float64
+	maxHumidex float64
+}
+
+// Отрезки ближайших суток, где категория риска не ниже level
+func heatStressPeriods(forecast *Forecast, now time.Time, level int) []heatStressPeriod {
+	var periods []heatStressPeriod
+	var current *heatStressPeriod
+	for _, item := range forecast.Items {
+		start := forecast.LocalTime(item)
+		end := start.Add(forecastStep)
+		if !end.After(now) || !start.Before(now.Add(heatStressHorizon)) {
+			continue
+		}
+
+		value := wbgt(item.Temp, item.Humidity)
+		if heatRiskLevelFor(value) < level {
+			current = nil
+			continue
+		}
+		if current == nil {
+			periods = append(periods, heatStressPeriod{start: latestTime(start, now), maxWBGT: value})
+			current = &periods[len(periods)-1]
+		}
+		current.end = end
+		current.maxWBGT = math.Max(current.maxWBGT, value)
+		current.maxHumidex = math.Max(current.maxHumidex, humidex(item.Temp, item.Humidity))
+	}
+	return periods
+}
+
+// Проверка подписки: в прогнозе на сутки риск не ниже выбранной категории
+func checkHeatStress(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := fetchForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	level := int(sub.Threshold)
+	if level < 1 || level >= len(heatRiskLevels) {
+		level = defaultHeatRiskLevel
+	}
+	periods := heatStressPeriods(forecast, forecast.Now(), level)
+	if len(periods) == 0 {
+		return "", false, nil
+	}
+	return formatHeatStressAlert(sub.City, level, periods), true, nil
+}
+
+func formatHeatStressAlert(city string, level int, periods []heatStressPeriod) string {
+	text := fmt.Sprintf("👷 Тепловой стресс в %s: риск «%s» и выше в ближайшие сутки.\n", city, heatRiskLevels[level].name)
+	peak := 0.0
+	for _, period := range periods {
+		text += fmt.Sprintf("⏰ %s %s–%s: WBGT до %.0f°C, humidex до %.0f\n",
+			period.start.Format("02.01"),
+			period.start.Format("15:04"),
+			period.end.Format("15:04"),
+			period.maxWBGT,
+			period.maxHumidex,
+		)
+		peak = math.Max(peak, period.maxWBGT)
+	}
+	worst := heatRiskLevels[heatRiskLevelFor(peak)]
+	return text + fmt.Sprintf("\n%s Риск %s. %s\nWBGT рассчитан для тени, на открытом солнце он выше на 2–3°.", worst.emoji, worst.name, worst.advice)
+}
+
+// Подписка чата на предупреждения о тепловом стрессе
+func subscribeHeatStress(store *Store, chatID int64, city string, level int) (string, error) {
+	if level == 0 {
+		level = defaultHeatRiskLevel
+	}
+
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID:    chatID,
+		Kind:      alertHeatStress,
+		City:      point.DisplayName(),
+		Lat:       point.Lat,
+		Lon:       point.Lon,
+		Threshold: float64(level),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"👷 Подписка оформлена! Предупрежу, если в ближайшие сутки в %s риск теплового стресса будет «%s» (WBGT от %.0f°C) или выше.\n"+
+			"Отписаться: /heatstress off",
+		point.DisplayName(),
+		heatRiskLevels[level].name,
+		heatRiskLevels[level].minWBGT,
+	), nil
+}
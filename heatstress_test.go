@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHumidexAndWBGT(t *testing.T) {
+	// Справочные значения: 30°C при 40% — humidex 34, 33°C при 50% — около 42
+	for _, c := range []struct {
+		temp     float64
+		humidity int
+		humidex  float64
+		wbgt     float64
+	}{
+		{30, 40, 34, 27.6},
+		{33, 50, 41.6, 32.5},
+		{25, 60, 30.1, 25.6},
+	} {
+		if got := humidex(c.temp, c.humidity); math.Abs(got-c.humidex) > 0.5 {
+			t.Errorf("humidex(%.0f, %d) = %.1f, ожидалось %.1f", c.temp, c.humidity, got, c.humidex)
+		}
+		if got := wbgt(c.temp, c.humidity); math.Abs(got-c.wbgt) > 0.2 {
+			t.Errorf("wbgt(%.0f, %d) = %.1f, ожидалось %.1f", c.temp, c.humidity, got, c.wbgt)
+		}
+	}
+
+	if level := heatRiskLevelFor(29); heatRiskLevels[level].name != "высокий" {
+		t.Errorf("WBGT 29 — риск %q", heatRiskLevels[level].name)
+	}
+}
+
+func TestParseHeatStressArgs(t *testing.T) {
+	for args, want := range map[string]struct {
+		city  string
+		level int
+	}{
+		"Краснодар опасный":    {"Краснодар", 3},
+		"Ростов-на-Дону 1":     {"Ростов-на-Дону", 1},
+		"Нижний Новгород":      {"Нижний Новгород", 0},
+		"Москва Экстремальный": {"Москва", 4},
+		"": {"", 0},
+	} {
+		if city, level := parseHeatStressArgs(args); city != want.city || level != want.level {
+			t.Errorf("parseHeatStressArgs(%q) = %q, %d", args, city, level)
+		}
+	}
+}
+
+func TestHeatStressPeriods(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	forecast := &Forecast{City: "Краснодар", Location: moscow}
+	start := time.Date(2026, 7, 14, 6, 0, 0, 0, moscow)
+	for i, temp := range []float64{24, 30, 33, 34, 29, 24, 22, 26, 24, 33} {
+		forecast.Items = append(forecast.Items, ForecastItem{Time: start.Add(time.Duration(i) * forecastStep), Temp: temp, Humidity: 50})
+	}
+
+	// Сутки от 7:00: жара с 9 до 21, следующий жаркий интервал за горизонтом
+	periods := heatStressPeriods(forecast, start.Add(time.Hour), 2)
+	if len(periods) != 1 || !periods[0].start.Equal(start.Add(3*time.Hour)) || !periods[0].end.Equal(start.Add(15*time.Hour)) {
+		t.Fatalf("отрезки %+v, ожидался один с 9:00 до 21:00", periods)
+	}
+
+	text := formatHeatStressAlert("Краснодар", 2, periods)
+	for _, want := range []string{"риск «высокий» и выше", "⏰ 14.07 09:00–21:00: WBGT до 34°C", "⚫️ Риск экстремальный"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в предупреждении %q нет %q", text, want)
+		}
+	}
+
+	if periods := heatStressPeriods(forecast, start.Add(time.Hour), 4); len(periods) != 1 || !periods[0].start.Equal(start.Add(6*time.Hour)) {
+		t.Errorf("экстремальный риск %+v, ожидался с 12:00", periods)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Тип подписки на предупреждения о затяжной жаре и морозах
+const alertHeatwave = "heatwave"
+
+// Пороги аномальной погоды: дневной максимум в жару и ночной минимум в мороз, °C
+const (
+	heatwaveThreshold = 33.0
+	coldSnapThreshold = -25.0
+)
+
+// Сколько дней подряд должна держаться аномалия
+const heatwaveMinDays = 3
+
+func init() {
+	// Прогноз на 5 дней почти не меняет картину за сутки, поэтому
+	// о той же волне напоминаем не чаще раза в два дня
+	alertKinds[alertHeatwave] = alertKind{title: "Жара и морозы", check: checkHeatwave, cooldown: 48 * time.Hour}
+}
+
+// Несколько дней подряд аномальной жары или мороза
+type temperatureStretch struct {
+	heat     bool
+	from, to time.Time // первый и последний день
+	days     int
+	extreme  float64 // самый высокий максимум в жару или самый низкий минимум в мороз
+}
+
+// Первая в прогнозе полоса из heatwaveMinDays и больше дней подряд с
+// максимумом не ниже heatwaveThreshold или минимумом не выше coldSnapThreshold.
+// Дни считаются по местному времени города
+func findTemperatureStretch(forecast *Forecast) (temperatureStretch, bool) {
+	type dayRange struct {
+		date     time.Time
+		min, max float64
+	}
+	var days []*dayRange
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		if len(days) == 0 || !days[len(days)-1].date.Equal(date) {
+			days = append(days, &dayRange{date: date, min: item.Temp, max: item.Temp})
+			continue
+		}
+		day := days[len(days)-1]
+		day.min = math.Min(day.min, item.Temp)
+		day.max = math.Max(day.max, item.Temp)
+	}
+
+	for _, heat := range []bool{true, false} {
+		var current temperatureStretch
+		for _, day := range days {
+			extreme := day.min
+			anomaly := day.min <= coldSnapThreshold
+			if heat {
+				extreme = day.max
+				anomaly = day.max >= heatwaveThreshold
+			}
+			if !anomaly {
+				if current.days >= heatwaveMinDays {
+					return current, true
+				}
+				current = temperatureStretch{}
+				continue
+			}
+			if current.days == 0 {
+				current = temperatureStretch{heat: heat, from: day.date, extreme: extreme}
+			}
+			current.to = day.date
+			current.days++
+			if (heat && extreme > current.extreme) || (!heat && extreme < current.extreme) {
+				current.extreme = extreme
+			}
+		}
+		if current.days >= heatwaveMinDays {
+			return current, true
+		}
+	}
+	return temperatureStretch{}, false
+}
+
+// Проверка подписки: затяжная жара или мороз в прогнозе на 5 дней
+func checkHeatwave(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := fetchForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	stretch, ok := findTemperatureStretch(forecast)
+	if !ok {
+		return "", false, nil
+	}
+	return formatTemperatureStretch(sub.City, stretch), true, nil
+}
+
+func formatTemperatureStretch(city string, stretch temperatureStretch) string {
+	if stretch.heat {
+		return fmt.Sprintf(
+			"🥵 Жара выше %.0f° ожидается %d %s подряд в %s: с %s по %s, до %+.0f°C.\n"+
+				"💧 Пейте воду понемногу, но часто, не дожидаясь жажды.\n"+
+				"🧢 С 11 до 17 старайтесь не бывать на солнце, носите головной убор и светлую одежду.\n"+
+				"🚗 Не оставляйте детей и животных в машине даже ненадолго.",
+			heatwaveThreshold,
+			stretch.days,
+			pluralDays(stretch.days),
+			city,
+			stretch.from.Format("02.01"),
+			stretch.to.Format("02.01"),
+			stretch.extreme,
+		)
+	}
+	return fmt.Sprintf(
+		"🥶 Мороз ниже %.0f° ожидается %d %s подряд в %s: с %s по %s, до %.0f°C.\n"+
+			"🧣 Одевайтесь слоями, закрывайте лицо и руки — при таком морозе обморожение наступает быстро.\n"+
+			"☕️ Пейте горячее и сократите время на улице, особенно с детьми и пожилыми.\n"+
+			"🔋 Проверьте аккумулятор машины и не оставляйте ее надолго на открытой стоянке.",
+		coldSnapThreshold,
+		stretch.days,
+		pluralDays(stretch.days),
+		city,
+		stretch.from.Format("02.01"),
+		stretch.to.Format("02.01"),
+		stretch.extreme,
+	)
+}
+
+// Подписка чата на предупреждения о затяжной жаре и морозах
+func subscribeHeatwave(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertHeatwave,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🌡 Подписка оформлена! Предупрежу, если в %s жара выше %.0f° или мороз ниже %.0f° продержится %d дня подряд.\n"+
+			"Отписаться: /heatwave off",
+		point.DisplayName(),
+		heatwaveThreshold,
+		coldSnapThreshold,
+		heatwaveMinDays,
+	), nil
+}
+
+// Слово "день" в нужной форме для числа n: "3 дня", "5 дней", "21 день"
+func pluralDays(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "день"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "дня"
+	}
+	return "дней"
+}
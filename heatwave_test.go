@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Прогноз с шагом 3 часа: дневной максимум и ночной минимум по дням
+func stretchForecast(days [][2]float64) *Forecast {
+	volgograd := time.FixedZone("MSK", 3*3600)
+	forecast := &Forecast{City: "Волгоград", Location: volgograd}
+	start := time.Date(2026, 7, 14, 0, 0, 0, 0, volgograd)
+	for d, day := range days {
+		for i := 0; i < 8; i++ {
+			temp := day[0]
+			if i >= 4 {
+				temp = day[1]
+			}
+			forecast.Items = append(forecast.Items, ForecastItem{Time: start.Add(time.Duration(d*8+i) * forecastStep), Temp: temp})
+		}
+	}
+	return forecast
+}
+
+func TestFindTemperatureStretch(t *testing.T) {
+	// Два жарких дня, перерыв и три жарких дня
+	forecast := stretchForecast([][2]float64{{22, 34}, {23, 35}, {20, 30}, {24, 33}, {25, 36}, {24, 34}})
+	stretch, ok := findTemperatureStretch(forecast)
+	if !ok || !stretch.heat || stretch.days != 3 || stretch.extreme != 36 {
+		t.Fatalf("полоса %+v, %v; ожидались 3 дня жары до 36°", stretch, ok)
+	}
+	text := formatTemperatureStretch("Волгоград", stretch)
+	for _, want := range []string{"Жара выше 33° ожидается 3 дня подряд в Волгоград: с 17.07 по 19.07, до +36°C", "💧 Пейте воду"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в предупреждении %q нет %q", text, want)
+		}
+	}
+
+	cold, ok := findTemperatureStretch(stretchForecast([][2]float64{{-26, -18}, {-31, -22}, {-27, -20}, {-28, -21}}))
+	if !ok || cold.heat || cold.days != 4 || cold.extreme != -31 {
+		t.Errorf("мороз %+v, %v", cold, ok)
+	}
+
+	if _, ok := findTemperatureStretch(stretchForecast([][2]float64{{22, 34}, {23, 35}, {20, 30}})); ok {
+		t.Error("два дня жары приняты за волну")
+	}
+}
+
+func TestPluralDays(t *testing.T) {
+	for n, want := range map[int]string{1: "день", 3: "дня", 5: "дней", 11: "дней", 12: "дней", 21: "день", 22: "дня"} {
+		if got := pluralDays(n); got != want {
+			t.Errorf("pluralDays(%d) = %q, ожидалось %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// На сколько вперед ищем окно для похода
+const hikeHorizon = 48 * time.Hour
+
+// Короче этого окно для похода не предлагаем
+const hikeMinWindow = 2 * time.Hour
+
+// Пороги сухого и спокойного интервала прогноза
+const (
+	hikeMaxPop    = 0.3
+	hikeMaxPrecip = 0.2 // мм за интервал
+	hikeMaxWind   = 8.0 // м/с
+	hikeMaxGust   = 13.0
+)
+
+// Высота Солнца на восходе и закате с учетом рефракции, градусы
+const sunHorizonElevation = -0.833
+
+// Подходит ли интервал прогноза для похода: без осадков, гроз и сильного ветра
+func hikeSlotOK(item ForecastItem) bool {
+	return item.Pop < hikeMaxPop &&
+		item.Rain+item.Snow < hikeMaxPrecip &&
+		item.WindSpeed < hikeMaxWind &&
+		item.WindGust < hikeMaxGust &&
+		!isThunderstorm(item.Condition)
+}
+
+// Восход и закат в день day (полночь местного времени) с точностью до минуты.
+// В полярный день светло весь день, в полярную ночь rise == set
+func sunriseSunset(lat, lon float64, day time.Time) (rise, set time.Time) {
+	end := day.AddDate(0, 0, 1)
+	previous := sunElevation(lat, lon, day)
+	wasUp := previous >= sunHorizonElevation
+	for t := day.Add(time.Minute); t.Before(end); t = t.Add(time.Minute) {
+		up := sunElevation(lat, lon, t) >= sunHorizonElevation
+		switch {
+		case up && !wasUp && rise.IsZero():
+			rise = t
+		case !up && wasUp:
+			set = t
+		}
+		wasUp = up
+	}
+
+	if rise.IsZero() && set.IsZero() {
+		if wasUp {
+			return day, end
+		}
+		return day, day
+	}
+	if rise.IsZero() || (!set.IsZero() && set.Before(rise)) {
+		// Солнце не садилось с прошлого дня: светло с полуночи
+		rise = day
+	}
+	if set.IsZero() || set.Before(rise) {
+		set = end
+	}
+	return rise, set
+}
+
+// Непрерывное сухое светлое окно
+type hikeWindow struct {
+	start, end       time.Time
+	minTemp, maxTemp float64
+	maxWind          float64
+}
+
+func (w hikeWindow) duration() time.Duration {
+	return w.end.Sub(w.start)
+}
+
+// Сухие светлые окна в ближайшие hikeHorizon от now в порядке времени.
+// Интервалы прогноза обрезаются по восходу и закату, поэтому окно не
+// переходит через ночь
+func hikeWindows(forecast *Forecast, now time.Time) []hikeWindow {
+	horizon := now.Add(hikeHorizon)
+	daylight := make(map[string][2]time.Time)
+
+	var windows []hikeWindow
+	var current *hikeWindow
+	closeWindow := func() {
+		if current != nil {
+			windows = append(windows, *current)
+			current = nil
+		}
+	}
+
+	for _, item := range forecast.Items {
+		start := forecast.LocalTime(item)
+		end := start.Add(forecastStep)
+		if !end.After(now) || !start.Before(horizon) {
+			continue
+		}
+		if !hikeSlotOK(item) {
+			closeWindow()
+			continue
+		}
+
+		key := start.Format("2006-01-02")
+		sun, ok := daylight[key]
+		if !ok {
+			day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, forecast.Location)
+			sun[0], sun[1] = sunriseSunset(forecast.Lat, forecast.Lon, day)
+			daylight[key] = sun
+		}
+
+		from := latestTime(start, sun[0], now)
+		to := earliestTime(end, sun[1], horizon)
+		if !from.Before(to) {
+			closeWindow()
+			continue
+		}
+
+		if current != nil && current.end.Equal(from) {
+			current.end = to
+			current.minTemp = math.Min(current.minTemp, item.Temp)
+			current.maxTemp = math.Max(current.maxTemp, item.Temp)
+			current.maxWind = math.Max(current.maxWind, item.WindSpeed)
+		} else {
+			closeWindow()
+			current = &hikeWindow{start: from, end: to, minTemp: item.Temp, maxTemp: item.Temp, maxWind: item.WindSpeed}
+		}
+		// Окно, обрезанное закатом, дальше не продолжается
+		if to.Before(end) {
+			closeWindow()
+		}
+	}
+	closeWindow()
+	return windows
+}
+
+func latestTime(times ...time.Time) time.Time {
+	result := times[0]
+	for _, t := range times[1:] {
+		if t.After(result) {
+			result = t
+		}
+	}
+	return result
+}
+
+func earliestTime(times ...time.Time) time.Time {
+	result := times[0]
+	for _, t := range times[1:] {
+		if t.Before(result) {
+			result = t
+		}
+	}
+	return result
+}
+
+// Длительность для ответа: "5 ч 30 мин"
+func formatHikeDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case minutes == 0:
+		return fmt.Sprintf("%d ч", hours)
+	case hours == 0:
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	return fmt.Sprintf("%d ч %d мин", hours, minutes)
+}
+
+// Функция для поиска окна для похода на ближайшие 48 часов
+func getHikeWindow(city string) (string, error) {
+	forecast, err := fetchForecast(city)
+	if err != nil {
+		return "", err
+	}
+	if len(forecast.Items) == 0 {
+		return "", fmt.Errorf("нет данных прогноза")
+	}
+	return formatHikeWindows(forecast, forecast.Now()), nil
+}
+
+func formatHikeWindows(forecast *Forecast, now time.Time) string {
+	hikeMsg := fmt.Sprintf("🥾 Окна для похода в %s на ближайшие 48 часов:\n", forecast.City)
+
+	// Восход и закат по дням, на которые приходится поиск
+	for day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, forecast.Location); day.Before(now.Add(hikeHorizon)); day = day.AddDate(0, 0, 1) {
+		rise, set := sunriseSunset(forecast.Lat, forecast.Lon, day)
+		line := fmt.Sprintf("🌅 %s %s: восход %s, закат %s", weekdayName(day.Weekday()), day.Format("02.01"), rise.Format("15:04"), set.Format("15:04"))
+		switch {
+		case rise.Equal(set):
+			line = fmt.Sprintf("🌑 %s %s: полярная ночь", weekdayName(day.Weekday()), day.Format("02.01"))
+		case set.Sub(rise) >= 24*time.Hour:
+			line = fmt.Sprintf("☀️ %s %s: полярный день", weekdayName(day.Weekday()), day.Format("02.01"))
+		}
+		hikeMsg += line + "\n"
+	}
+	hikeMsg += "\n"
+
+	var windows []hikeWindow
+	for _, window := range hikeWindows(forecast, now) {
+		if window.duration() >= hikeMinWindow {
+			windows = append(windows, window)
+		}
+	}
+	if len(windows) == 0 {
+		return hikeMsg + fmt.Sprintf("🌧 Сухого светлого окна дольше %s без сильного ветра в ближайшие 48 часов нет — поход лучше отложить.",
+			formatHikeDuration(hikeMinWindow))
+	}
+
+	for _, window := range windows {
+		hikeMsg += fmt.Sprintf("✅ %s %s–%s (%s): %.0f…%.0f°C, ветер до %.0f м/с\n",
+			window.start.Format("02.01"),
+			window.start.Format("15:04"),
+			window.end.Format("15:04"),
+			formatHikeDuration(window.duration()),
+			window.minTemp,
+			window.maxTemp,
+			window.maxWind,
+		)
+	}
+
+	// Лучшее окно — самое длинное, при равной длине — с более слабым ветром
+	sort.SliceStable(windows, func(i, j int) bool {
+		if windows[i].duration() != windows[j].duration() {
+			return windows[i].duration() > windows[j].duration()
+		}
+		return windows[i].maxWind < windows[j].maxWind
+	})
+	best := windows[0]
+	hikeMsg += fmt.Sprintf("\n🏔 Лучшее окно: %s, %s с %s до %s — %s сухо и светло.",
+		weekdayName(best.start.Weekday()),
+		best.start.Format("02.01"),
+		best.start.Format("15:04"),
+		best.end.Format("15:04"),
+		formatHikeDuration(best.duration()),
+	)
+	return hikeMsg
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSunriseSunset(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	rise, set := sunriseSunset(55.75, 37.62, time.Date(2026, 6, 21, 0, 0, 0, 0, moscow))
+	if want := time.Date(2026, 6, 21, 3, 45, 0, 0, moscow); rise.Sub(want).Abs() > 5*time.Minute {
+		t.Errorf("восход %s, ожидался около %s", rise.Format("15:04"), want.Format("15:04"))
+	}
+	if want := time.Date(2026, 6, 21, 21, 18, 0, 0, moscow); set.Sub(want).Abs() > 5*time.Minute {
+		t.Errorf("закат %s, ожидался около %s", set.Format("15:04"), want.Format("15:04"))
+	}
+
+	// Мурманск: полярная ночь и полярный день
+	if rise, set := sunriseSunset(68.97, 33.07, time.Date(2026, 12, 21, 0, 0, 0, 0, moscow)); !rise.Equal(set) {
+		t.Errorf("в полярную ночь светло с %s до %s", rise, set)
+	}
+	if rise, set := sunriseSunset(68.97, 33.07, time.Date(2026, 6, 21, 0, 0, 0, 0, moscow)); set.Sub(rise) != 24*time.Hour {
+		t.Errorf("в полярный день светло с %s до %s", rise, set)
+	}
+}
+
+func TestHikeWindows(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	forecast := &Forecast{City: "Москва", Lat: 55.75, Lon: 37.62, Location: moscow}
+	start := time.Date(2026, 10, 16, 0, 0, 0, 0, moscow)
+	for i := 0; i < 16; i++ {
+		item := ForecastItem{Time: start.Add(time.Duration(i) * forecastStep), Temp: float64(i % 8), WindSpeed: 3}
+		// Дождь в первый день с 12 до 15
+		if i == 4 {
+			item.Rain, item.Pop = 1.5, 0.9
+		}
+		// Сильный ветер во второй день с 15 до 18
+		if i == 13 {
+			item.WindSpeed = 11
+		}
+		forecast.Items = append(forecast.Items, item)
+	}
+
+	now := start.Add(6 * time.Hour)
+	windows := hikeWindows(forecast, now)
+	if len(windows) != 3 {
+		t.Fatalf("окон %d, ожидалось 3: %+v", len(windows), windows)
+	}
+
+	rise, set := sunriseSunset(55.75, 37.62, start)
+	if !windows[0].start.Equal(rise) || !windows[0].end.Equal(start.Add(12*time.Hour)) {
+		t.Errorf("первое окно %s–%s, ожидалось от восхода %s до 12:00", windows[0].start, windows[0].end, rise)
+	}
+	if !windows[1].start.Equal(start.Add(15*time.Hour)) || !windows[1].end.Equal(set) {
+		t.Errorf("второе окно %s–%s, ожидалось с 15:00 до заката %s", windows[1].start, windows[1].end, set)
+	}
+	// Во второй день окно обрывает ветер, а не закат
+	if got := windows[2].end; !got.Equal(start.Add(39 * time.Hour)) {
+		t.Errorf("третье окно заканчивается в %s, ожидалось 15:00", got)
+	}
+
+	text := formatHikeWindows(forecast, now)
+	for _, want := range []string{"восход " + rise.Format("15:04"), "закат " + set.Format("15:04"), "🏔 Лучшее окно: Суббота, 17.10 с ", " до 15:00"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в ответе %q нет %q", text, want)
+		}
+	}
+}
+
+func TestHikeNoWindow(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	forecast := &Forecast{City: "Москва", Lat: 55.75, Lon: 37.62, Location: moscow}
+	start := time.Date(2026, 10, 16, 0, 0, 0, 0, moscow)
+	for i := 0; i < 16; i++ {
+		forecast.Items = append(forecast.Items, ForecastItem{Time: start.Add(time.Duration(i) * forecastStep), Pop: 0.8, Rain: 2})
+	}
+	if text := formatHikeWindows(forecast, start); !strings.Contains(text, "поход лучше отложить") {
+		t.Errorf("ответ без окон %q", text)
+	}
+}
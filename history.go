@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько последних городов помним для /recent
+const recentCitiesLimit = 10
+
+// Запоминание города: он переезжает в начало списка, повтор с другим
+// регистром не добавляется второй раз
+func (s *Store) AddRecentCity(chatID int64, city string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recent := []string{city}
+	for _, old := range s.data.RecentCities[chatID] {
+		if !strings.EqualFold(old, city) && len(recent) < recentCitiesLimit {
+			recent = append(recent, old)
+		}
+	}
+	s.data.RecentCities[chatID] = recent
+	return s.save()
+}
+
+// Недавние города, начиная с последнего
+func (s *Store) RecentCities(chatID int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.data.RecentCities[chatID]...)
+}
+
+func (s *Store) ClearRecentCities(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.RecentCities, chatID)
+	return s.save()
+}
+
+// /recent — недавние города с кнопками для повторного запроса, /recent clear — очистка
+func handleRecentCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	if strings.EqualFold(strings.TrimSpace(c.args), "clear") {
+		if err := c.bot.store.ClearRecentCities(chatID); err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		c.msg.Text = "🧹 История городов очищена."
+		return
+	}
+
+	recent := c.bot.store.RecentCities(chatID)
+	if len(recent) == 0 {
+		c.msg.Text = "🕘 Вы еще не спрашивали погоду. Напишите название города, и он появится здесь."
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, city := range recent {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🌤 "+city, encodeCallback(c.bot.store, CallbackPayload{
+				Action: actionRecent,
+				City:   city,
+			})),
+		))
+	}
+	c.msg.Text = "🕘 Недавние города — нажмите, чтобы узнать погоду.\nОчистить список: /recent clear"
+	c.msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Нажатие на город из /recent: новая карточка погоды, как на запрос текстом
+func handleRecentCallback(bot *Bot, callback *tgbotapi.CallbackQuery, city string) error {
+	chatID := callback.Message.Chat.ID
+	prefs := bot.store.Preferences(chatID)
+
+	weatherInfo, err := getWeather(city, prefs)
+	if err != nil {
+		_, err = bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка: "+err.Error()))
+		return err
+	}
+
+	bot.lastCity[chatID] = city
+	if err := bot.store.AddRecentCity(chatID, city); err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, weatherInfo)
+	msg.ParseMode = replyParseMode(prefs)
+	msg.ReplyMarkup = weatherKeyboard(bot.store, city, prefs)
+	if _, err := bot.Send(msg); err != nil {
+		return err
+	}
+	return sendCitySticker(bot, chatID, city)
+}
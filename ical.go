@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Прогноз в формате iCalendar (RFC 5545): по событию на весь день
+// для каждого дня прогноза, чтобы его можно было добавить в календарь
+
+// Итоги одного дня прогноза по местному времени города
+type icalDay struct {
+	date        time.Time
+	min, max    float64
+	maxPop      float64
+	maxWind     float64
+	description string
+	lines       []string
+}
+
+// Группировка интервалов прогноза по дням в часовом поясе города
+func icalDays(forecast *Forecast, prefs UserPreferences) []*icalDay {
+	wind := windUnitFor(prefs)
+
+	var days []*icalDay
+	var day *icalDay
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		if day == nil || !day.date.Equal(date) {
+			day = &icalDay{date: date, min: item.Temp, max: item.Temp, description: item.Description}
+			days = append(days, day)
+		}
+		if item.Temp < day.min {
+			day.min = item.Temp
+		}
+		if item.Temp > day.max {
+			day.max = item.Temp
+			day.description = item.Description
+		}
+		day.maxPop = math.Max(day.maxPop, item.Pop)
+		day.maxWind = math.Max(day.maxWind, item.WindSpeed)
+		day.lines = append(day.lines, fmt.Sprintf("%s: %s, %s",
+			local.Format("15:04"), formatTemp(item.Temp, prefs.Units), item.Description))
+	}
+	for _, day := range days {
+		day.lines = append(day.lines, fmt.Sprintf("Осадки до %.0f%%, ветер до %s",
+			day.maxPop*100, formatWindSpeed(day.maxWind, wind, prefs.Language)))
+	}
+	return days
+}
+
+// Экранирование текста в значениях iCalendar
+func icalEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// Строка iCalendar: длиннее 75 байт переносится, продолжение начинается
+// с пробела. Многобайтные символы не разрываются
+func icalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Пробел в начале продолжения тоже занимает байт
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// Файл .ics с событием на весь день для каждого дня прогноза
+func buildForecastICS(forecast *Forecast, prefs UserPreferences, now time.Time) []byte {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(forecast.City)))
+	citySum := hash.Sum32()
+
+	var b strings.Builder
+	icalLine(&b, "BEGIN:VCALENDAR")
+	icalLine(&b, "VERSION:2.0")
+	icalLine(&b, "PRODID:-//donedron_bot//weather forecast//RU")
+	icalLine(&b, "CALSCALE:GREGORIAN")
+	icalLine(&b, "METHOD:PUBLISH")
+	icalLine(&b, "X-WR-CALNAME:"+icalEscape("Погода: "+forecast.City))
+
+	for _, day := range icalDays(forecast, prefs) {
+		summary := fmt.Sprintf("%s: %s…%s, %s",
+			forecast.City, formatTemp(day.min, prefs.Units), formatTemp(day.max, prefs.Units), day.description)
+
+		icalLine(&b, "BEGIN:VEVENT")
+		// Один и тот же день города получает тот же UID, поэтому повторный
+		// импорт обновляет события, а не дублирует их
+		icalLine(&b, fmt.Sprintf("UID:%s-%08x@donedron_bot", day.date.Format("20060102"), citySum))
+		icalLine(&b, "DTSTAMP:"+now.UTC().Format("20060102T150405Z"))
+		icalLine(&b, "DTSTART;VALUE=DATE:"+day.date.Format("20060102"))
+		icalLine(&b, "DTEND;VALUE=DATE:"+day.date.AddDate(0, 0, 1).Format("20060102"))
+		icalLine(&b, "SUMMARY:"+icalEscape(summary))
+		icalLine(&b, "DESCRIPTION:"+icalEscape(strings.Join(day.lines, "\n")))
+		icalLine(&b, "TRANSP:TRANSPARENT")
+		icalLine(&b, "END:VEVENT")
+	}
+
+	icalLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// /ical [город] — прогноз файлом для календаря
+func handleICalCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /ical Сочи"
+		return
+	}
+
+	prefs := c.bot.store.Preferences(c.message.Chat.ID)
+	forecast, err := fetchForecastLang(city, prefs.Language)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	document := tgbotapi.NewDocument(c.message.Chat.ID, tgbotapi.FileBytes{
+		Name:  "forecast.ics",
+		Bytes: buildForecastICS(forecast, prefs, time.Now()),
+	})
+	document.Caption = fmt.Sprintf("📅 Прогноз для %s по дням. Откройте файл, чтобы добавить его в календарь.", forecast.City)
+	if _, err := c.bot.Send(document); err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	}
+}
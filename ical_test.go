@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildForecastICS(t *testing.T) {
+	zone := time.FixedZone("", 3*3600)
+	forecast := &Forecast{City: "Сочи", Location: zone}
+	// Интервалы с 22:00 до 04:00 по местному времени попадают в два дня
+	start := time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC)
+	for i, temp := range []float64{14, 12, 11} {
+		forecast.Items = append(forecast.Items, ForecastItem{
+			Time:        start.Add(time.Duration(i) * forecastStep),
+			Temp:        temp,
+			Description: "дождь, гроза",
+			Pop:         0.8,
+			WindSpeed:   6,
+		})
+	}
+
+	prefs := UserPreferences{Units: unitsMetric, Language: langRU}
+	ics := string(buildForecastICS(forecast, prefs, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)))
+
+	if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Fatalf("нет рамки календаря:\n%s", ics)
+	}
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("событий %d, ожидалось 2", n)
+	}
+	for _, want := range []string{
+		"DTSTART;VALUE=DATE:20261016\r\n",
+		"DTEND;VALUE=DATE:20261018\r\n",
+		`SUMMARY:Сочи: 11°C…12°C\, дождь\, гроза`,
+		"DTSTAMP:20261016T120000Z\r\n",
+	} {
+		if !strings.Contains(strings.ReplaceAll(ics, "\r\n ", ""), want) {
+			t.Errorf("нет %q:\n%s", want, ics)
+		}
+	}
+
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("строка длиннее 75 байт: %q", line)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько секунд Telegram может кэшировать ответ на инлайн-запрос
+const inlineCacheTime = 300
+
+// Пустой ответ (слишком короткий запрос, город не найден) от набора
+// дальше не изменится, его Telegram может помнить дольше
+const inlineEmptyCacheTime = 3600
+
+// Короче этого запрос не отправляем в OWM: по двум буквам город не угадать
+const inlineMinQueryLength = 3
+
+// Инлайн-запрос приходит на каждую набранную букву. Если погоды еще нет
+// в кэше, отвечаем только после паузы в наборе, а промежуточные запросы
+// пропускаем; в тестах пауза короче
+var inlineDebounce = 700 * time.Millisecond
+
+// Последний инлайн-запрос каждого пользователя, ожидающий паузы в наборе
+type InlineDebouncer struct {
+	latest map[int64]string
+	mu     sync.Mutex
+}
+
+var inlineDebouncer = &InlineDebouncer{
+	latest: make(map[int64]string),
+}
+
+// Запуск answer через delay, если за это время от пользователя не пришло
+// нового запроса
+func (d *InlineDebouncer) Schedule(userID int64, queryID string, delay time.Duration, answer func()) {
+	d.mu.Lock()
+	d.latest[userID] = queryID
+	d.mu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		current := d.latest[userID] == queryID
+		if current {
+			delete(d.latest, userID)
+		}
+		d.mu.Unlock()
+
+		if current {
+			answer()
+		}
+	})
+}
+
+// Ответ на инлайн-запрос "@бот Город": несколько карточек погоды на выбор.
+// Погода из кэша показывается сразу, остальное — после паузы в наборе
+func answerInlineQuery(bot *Bot, query *tgbotapi.InlineQuery) error {
+	city := strings.TrimSpace(query.Query)
+	if city == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(city) < inlineMinQueryLength {
+		return answerInlineEmpty(bot, query.ID)
+	}
+
+	// Карточки отправят в чужой чат, поэтому без разметки
+	prefs := bot.store.Preferences(query.From.ID)
+	prefs.Format = formatPlain
+	if _, ok := weatherCache.Get(city + "|" + prefs.Language); ok {
+		return answerInlineWeather(bot, query.ID, city, prefs)
+	}
+
+	inlineDebouncer.Schedule(query.From.ID, query.ID, inlineDebounce, func() {
+		// Недописанные названия и опечатки отсекает геокодер: его ответы,
+		// в том числе "не найден", кэшируются
+		var err error
+		if _, geoErr := geocodeCity(city); geoErr != nil {
+			err = answerInlineEmpty(bot, query.ID)
+		} else {
+			err = answerInlineWeather(bot, query.ID, city, prefs)
+		}
+		if err != nil {
+			log.Printf("Ошибка ответа на инлайн-запрос: %v", err)
+		}
+	})
+	return nil
+}
+
+// Пустой ответ: пользователь еще печатает название или город не найден
+func answerInlineEmpty(bot *Bot, queryID string) error {
+	_, err := bot.Request(tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       []interface{}{},
+		CacheTime:     inlineEmptyCacheTime,
+	})
+	return err
+}
+
+// Карточки погоды на выбор: сейчас, на сегодня, на 5 дней и кратко. Все
+// строятся из одних и тех же данных: текущая погода из кэша и один прогноз
+func answerInlineWeather(bot *Bot, queryID, city string, prefs UserPreferences) error {
+	data, err := cachedWeather(city, prefs.Language)
+	if err != nil {
+		return answerInlineEmpty(bot, queryID)
+	}
+
+	// Без прогноза отвечаем хотя бы текущей погодой
+	forecast, err := inlineForecast(data, prefs.Language)
+	if err != nil {
+		log.Printf("Ошибка получения прогноза для инлайн-запроса: %v", err)
+	}
+
+	results, err := inlineResults(city, data, forecast, prefs)
+	if err != nil {
+		return err
+	}
+	_, err = bot.Request(tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+	})
+	return err
+}
+
+// Прогноз для инлайн-карточек. На русском он общий со сводками (кэш по
+// координатам), на других языках описания другие и прогноз запрашивается
+func inlineForecast(data *CurrentWeather, lang string) (*Forecast, error) {
+	if lang == langRU {
+		return cachedForecastByCoords(data.Lat, data.Lon)
+	}
+	return fetchForecastByCoordsLang(data.Lat, data.Lon, lang)
+}
+
+// Карточки для выбора: сейчас, на сегодня, на 5 дней и кратко. Без
+// прогноза остаются только карточки текущей погоды
+func inlineResults(city string, data *CurrentWeather, forecast *Forecast, prefs UserPreferences) ([]interface{}, error) {
+	id := strings.ToLower(city)
+
+	// Полная карточка та же, что в чате; погода для нее уже в кэше
+	current, err := getWeather(city, prefs)
+	if err != nil {
+		return nil, err
+	}
+	compactPrefs := prefs
+	compactPrefs.Format = formatCompact
+	compact, err := renderReply("weather", compactPrefs, newWeatherCardData(data, compactPrefs))
+	if err != nil {
+		return nil, err
+	}
+
+	now := tgbotapi.NewInlineQueryResultArticle("weather:"+id, fmt.Sprintf("Сейчас: %s", data.City), current)
+	now.Description = compact
+	results := []interface{}{now}
+
+	if forecast != nil {
+		// Город как в карточке текущей погоды, а не как его назвал прогноз по координатам
+		named := *forecast
+		named.City = data.City
+
+		if day, ok := newTodayData(&named); ok {
+			text, err := renderReply("today", prefs, day)
+			if err != nil {
+				return nil, err
+			}
+			summary, err := renderReply("today", compactPrefs, day)
+			if err != nil {
+				return nil, err
+			}
+			title := "Сегодня"
+			if day.Tomorrow {
+				title = "Завтра"
+			}
+			today := tgbotapi.NewInlineQueryResultArticle("today:"+id, fmt.Sprintf("%s: %s", title, data.City), text)
+			today.Description = summary
+			results = append(results, today)
+		}
+
+		text, err := renderReply("forecast", prefs, newForecastData(&named, 15))
+		if err != nil {
+			return nil, err
+		}
+		days := tgbotapi.NewInlineQueryResultArticle("forecast:"+id, fmt.Sprintf("Прогноз на 5 дней: %s", data.City), text)
+		days.Description = "Температура и погода по трехчасовым интервалам"
+		results = append(results, days)
+	}
+
+	short := tgbotapi.NewInlineQueryResultArticle("compact:"+id, fmt.Sprintf("Кратко: %s", data.City), compact)
+	short.Description = "Одной строкой"
+	return append(results, short), nil
+}
+
+// Кнопка "Поделиться": открывает выбор чата и подставляет инлайн-запрос с городом
+func shareButton(city string) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonSwitch("📤 Поделиться", city)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestNewTodayData(t *testing.T) {
+	location := time.FixedZone("MSK", 3*3600)
+	forecast := &Forecast{City: "Москва", Location: location}
+	start := time.Date(2026, 10, 16, 15, 0, 0, 0, location)
+	for i, temp := range []float64{8, 11, 6, 3, 2} {
+		forecast.Items = append(forecast.Items, ForecastItem{
+			Time:        start.Add(time.Duration(i) * 3 * time.Hour),
+			Temp:        temp,
+			Description: "облачно",
+			Pop:         float64(i) / 10,
+			WindSpeed:   float64(i + 2),
+		})
+	}
+
+	useManualClock(t, time.Date(2026, 10, 16, 13, 0, 0, 0, location))
+	day, ok := newTodayData(forecast)
+	if !ok || day.Tomorrow || len(day.Day.Items) != 3 || day.Day.Min != 6 || day.Day.Max != 11 || day.Wind != 4 || day.Pop != 0.2 {
+		t.Fatalf("сегодня: %+v, %+v", day, day.Day)
+	}
+	if day.Day.Items[0].Time != "15:00" {
+		t.Errorf("время интервала по местному времени: %q", day.Day.Items[0].Time)
+	}
+
+	// Поздним вечером сегодняшних интервалов нет — показываем завтра
+	forecast.Items = forecast.Items[3:]
+	useManualClock(t, time.Date(2026, 10, 16, 23, 30, 0, 0, location))
+	day, ok = newTodayData(forecast)
+	if !ok || !day.Tomorrow || day.Day.Date != "17.10" || len(day.Day.Items) != 2 {
+		t.Errorf("завтра: %+v, %+v", day, day.Day)
+	}
+	text, err := renderReply("today", UserPreferences{Language: langRU, Units: unitsMetric}, day)
+	if err != nil || !strings.HasPrefix(text, "📆 Погода в Москва на завтра, 17.10:") {
+		t.Errorf("карточка: %q, %v", text, err)
+	}
+}
+
+// Ответы на инлайн-запросы после паузы в наборе приходят не сразу
+func waitInlineAnswers(t *testing.T, f *fakeTelegram, n int) []telegramCall {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(f.sent("answerInlineQuery")) < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return f.sent("answerInlineQuery")
+}
+
+func inlineQueryUpdate(userID int64, id, query string) tgbotapi.Update {
+	return tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{
+		ID:    id,
+		From:  &tgbotapi.User{ID: userID},
+		Query: query,
+	}}
+}
+
+func useInlineDebounce(t *testing.T, d time.Duration) {
+	t.Helper()
+	previous := inlineDebounce
+	inlineDebounce = d
+	t.Cleanup(func() { inlineDebounce = previous })
+}
+
+func TestInlineQueryVariants(t *testing.T) {
+	f := useMockReports(t)
+	useInlineDebounce(t, 0)
+	previousCoords := coordsCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { coordsCache = previousCoords })
+
+	f.send(inlineQueryUpdate(4321, "q1", "Казань"))
+	answers := waitInlineAnswers(t, f, 1)
+	if len(answers) != 1 {
+		t.Fatalf("ответов на инлайн-запрос %d", len(answers))
+	}
+	var results []struct {
+		ID          string `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Content     struct {
+			Text string `json:"message_text"`
+		} `json:"input_message_content"`
+	}
+	if err := json.Unmarshal([]byte(answers[0].Params["results"]), &results); err != nil {
+		t.Fatalf("results: %v", err)
+	}
+
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.ID)
+		if result.Content.Text == "" || result.Description == "" {
+			t.Errorf("пустая карточка %+v", result)
+		}
+	}
+	if got := strings.Join(ids, " "); got != "weather:казань today:казань forecast:казань compact:казань" {
+		t.Fatalf("варианты: %s", got)
+	}
+	if !strings.HasPrefix(results[0].Content.Text, "🌤 Погода в ") || !strings.HasPrefix(results[2].Content.Text, "🔮 Прогноз погоды на 5 дней") {
+		t.Errorf("карточки: %+v", results)
+	}
+	// Краткая карточка — одна строка, и она же описание текущей погоды
+	if strings.Contains(results[3].Content.Text, "\n") || results[0].Description != results[3].Content.Text {
+		t.Errorf("краткая карточка: %+v", results[3])
+	}
+}
+
+func TestInlineQueryThrottling(t *testing.T) {
+	f := useMockReports(t)
+	useInlineDebounce(t, 100*time.Millisecond)
+
+	// Две буквы — пустой ответ сразу и надолго
+	f.send(inlineQueryUpdate(4322, "q1", "Ка"))
+	answers := f.sent("answerInlineQuery")
+	if len(answers) != 1 || answers[0].Params["results"] != "[]" || answers[0].Params["cache_time"] != "3600" {
+		t.Fatalf("короткий запрос: %+v", answers)
+	}
+
+	// Пока пользователь печатает, отвечаем только на последний запрос
+	f.reset()
+	for i, query := range []string{"Каз", "Казан", "Казань"} {
+		f.send(inlineQueryUpdate(4322, fmt.Sprintf("q%d", i+2), query))
+	}
+	if answers := f.sent("answerInlineQuery"); len(answers) != 0 {
+		t.Fatalf("ответ до паузы в наборе: %+v", answers)
+	}
+	waitInlineAnswers(t, f, 1)
+	time.Sleep(150 * time.Millisecond)
+	answers = f.sent("answerInlineQuery")
+	if len(answers) != 1 || answers[0].Params["inline_query_id"] != "q4" || answers[0].Params["cache_time"] != "300" {
+		t.Fatalf("ответы после паузы: %+v", answers)
+	}
+
+	// Погода уже в кэше — ответ сразу, без паузы
+	f.reset()
+	f.send(inlineQueryUpdate(4323, "q5", "казань"))
+	if answers := f.sent("answerInlineQuery"); len(answers) != 1 || answers[0].Params["inline_query_id"] != "q5" {
+		t.Errorf("ответ из кэша: %+v", answers)
+	}
+}
+
+func TestGeocodeCache(t *testing.T) {
+	requests := fakeOWMRoutes(t, map[string]string{
+		"/geo/1.0/direct": `[{"name":"Kazan","country":"RU","lat":55.79,"lon":49.12,"local_names":{"ru":"Казань"}}]`,
+	})
+	geocodeCacheMu.Lock()
+	geocodeCache = make(map[string]geocodeCacheEntry)
+	geocodeCacheMu.Unlock()
+	clock := useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	for _, city := range []string{"Казань", "казань", " Казань "} {
+		point, err := geocodeCity(city)
+		if err != nil || point.DisplayName() != "Казань" {
+			t.Fatalf("geocodeCity(%q) = %+v, %v", city, point, err)
+		}
+	}
+	if n := requests["/geo/1.0/direct"]; n != 1 {
+		t.Errorf("запросов к геокодеру %d, ожидался 1", n)
+	}
+
+	clock.Advance(geocodeCacheTTL)
+	if _, err := geocodeCity("Казань"); err != nil {
+		t.Fatalf("geocodeCity: %v", err)
+	}
+	if n := requests["/geo/1.0/direct"]; n != 2 {
+		t.Errorf("после срока кэша запросов %d, ожидалось 2", n)
+	}
+
+	// Названия из инлайн-режима не копятся: устаревшие записи удаляются
+	// при следующих запросах
+	for _, prefix := range []string{"К", "Ка", "Каз"} {
+		geocodeCity(prefix)
+	}
+	clock.Advance(geocodeCacheTTL)
+	geocodeCity("Тула")
+	geocodeCacheMu.Lock()
+	_, kept := geocodeCache["тула"]
+	size := len(geocodeCache)
+	geocodeCacheMu.Unlock()
+	if size != 1 || !kept {
+		t.Errorf("записей в кэше %d, ожидалась только Тула", size)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Прогноз планетарного Kp-индекса от NOAA SWPC (наблюдения и прогноз на 3 дня)
+const kpForecastURL = "https://services.swpc.noaa.gov/products/noaa-planetary-k-index-forecast.json"
+
+// Kp обновляется раз в три часа, поэтому кэшируем ответ
+const kpCacheTTL = 30 * time.Minute
+
+// Значение Kp-индекса на трехчасовой интервал
+type KpEntry struct {
+	Time     time.Time
+	Kp       float64
+	Observed bool
+}
+
+var (
+	kpCache     []KpEntry
+	kpCacheTime time.Time
+	kpCacheMu   sync.Mutex
+)
+
+var kpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Получение наблюдаемых и прогнозных значений Kp-индекса
+func fetchKpForecast() ([]KpEntry, error) {
+	kpCacheMu.Lock()
+	defer kpCacheMu.Unlock()
+
+	if kpCache != nil && clockNow().Sub(kpCacheTime) < kpCacheTTL {
+		return kpCache, nil
+	}
+
+	resp, err := kpClient.Get(kpForecastURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса Kp-индекса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения Kp-индекса: статус %d", resp.StatusCode)
+	}
+
+	// Ответ - таблица строк, первая строка содержит заголовки:
+	// ["time_tag","kp","observed","noaa_scale"]
+	var rows [][]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга Kp-индекса: %v", err)
+	}
+
+	var entries []KpEntry
+	for i, row := range rows {
+		if i == 0 || len(row) < 3 {
+			continue
+		}
+		timeTag, _ := row[0].(string)
+		kpText, _ := row[1].(string)
+		status, _ := row[2].(string)
+
+		t, err := time.Parse("2006-01-02 15:04:05", timeTag)
+		if err != nil {
+			continue
+		}
+		kp, err := strconv.ParseFloat(kpText, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, KpEntry{Time: t, Kp: kp, Observed: status == "observed"})
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("нет данных Kp-индекса")
+	}
+
+	kpCache = entries
+	kpCacheTime = clockNow()
+
+	return entries, nil
+}
+
+// Kp-индекс в указанный момент (берется интервал, в который попадает момент)
+func kpAt(entries []KpEntry, t time.Time) (float64, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Time.After(t) {
+			return entries[i].Kp, true
+		}
+	}
+	return 0, false
+}
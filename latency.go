@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Бюджет ожидания источника погоды. Если свежие данные не пришли за это
+// время, а в кэше есть устаревшие, бот сразу отвечает ими с пометкой
+// «обновляю…» и правит сообщение, когда источник ответит
+
+// Сколько ждем источник, прежде чем ответить устаревшими данными; в тестах
+// короче
+var weatherLatencyBudget = 2 * time.Second
+
+// Данные старше этого не показываем даже как предварительные
+const staleWeatherMaxAge = 6 * time.Hour
+
+// Результат запроса к источнику, который пришел позже ответа
+type weatherResult struct {
+	data *CurrentWeather
+	err  error
+}
+
+// Данные из кэша без учета CACHE_TTL и время, когда они были получены
+func (c *WeatherCache) Stale(key string) (*CurrentWeather, time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.data[strings.ToLower(key)]
+	if !exists || clockNow().Sub(item.timestamp) > staleWeatherMaxAge {
+		return nil, time.Time{}, false
+	}
+	return item.weather, item.timestamp, true
+}
+
+// Погода в городе в пределах бюджета ожидания. Если источник не успел, а
+// в кэше есть устаревшие данные, возвращаются они, время их получения и
+// канал, в который придет свежий результат
+func budgetedWeather(city, lang string) (*CurrentWeather, time.Time, <-chan weatherResult, error) {
+	cacheKey := city + "|" + lang
+	if data, ok := weatherCache.Get(cacheKey); ok {
+		return data, time.Time{}, nil, nil
+	}
+	stale, fetched, ok := weatherCache.Stale(cacheKey)
+	if !ok {
+		data, err := cachedWeather(city, lang)
+		return data, time.Time{}, nil, err
+	}
+
+	results := make(chan weatherResult, 1)
+	go func() {
+		data, err := fetchWeatherLang(city, lang)
+		if err == nil {
+			weatherCache.Set(cacheKey, data)
+		}
+		results <- weatherResult{data: data, err: err}
+	}()
+
+	select {
+	case result := <-results:
+		return result.data, time.Time{}, nil, result.err
+	case <-time.After(weatherLatencyBudget):
+		return stale, fetched, results, nil
+	}
+}
+
+// Пометка над карточкой из устаревших данных
+func staleWeatherNote(fetched time.Time) string {
+	return fmt.Sprintf("⏳ Данные %s, обновляю…", skyPhotoAge(fetched, clockNow()))
+}
+
+// Замена предварительной карточки свежей, когда источник ответит. Если
+// источник так и не ответил, в пометке об этом говорится, а карточка
+// остается прежней
+func refreshStaleWeather(bot *Bot, chatID int64, messageID int, city, staleCard string, fetched time.Time, prefs UserPreferences, results <-chan weatherResult) {
+	result := <-results
+	text := ""
+	if result.err != nil {
+		log.Printf("Ошибка обновления погоды в %s: %v", city, result.err)
+		text = fmt.Sprintf("⚠️ Данные %s: источник сейчас не отвечает.\n\n%s", skyPhotoAge(fetched, clockNow()), staleCard)
+	} else {
+		card, err := weatherCard(city, result.data, prefs)
+		if err != nil {
+			log.Printf("Ошибка обновления погоды в %s: %v", city, err)
+			return
+		}
+		text = card
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, accessibleText(bot.store, chatID, text), weatherKeyboard(bot.store, city, prefs))
+	edit.ParseMode = replyParseMode(prefs)
+	if _, err := bot.Request(edit); err != nil && !isNotModified(err) {
+		log.Printf("Ошибка обновления сообщения: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Поддельный OWM, который отвечает на запрос погоды только после release
+func slowOWM(t *testing.T) (release chan struct{}) {
+	t.Helper()
+	release = make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(owmWeatherFixture))
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerOWM
+	c.OWMAPIKeys = []string{"test-key"}
+	c.OWMAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	previousBudget := weatherLatencyBudget
+	weatherLatencyBudget = 50 * time.Millisecond
+	t.Cleanup(func() { weatherLatencyBudget = previousBudget })
+	return release
+}
+
+func waitEdits(t *testing.T, f *fakeTelegram, n int) []telegramCall {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(f.sent("editMessageText")) < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return f.sent("editMessageText")
+}
+
+func TestStaleWeatherAnswer(t *testing.T) {
+	f := useMockReports(t)
+	clock := useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	release := slowOWM(t)
+	climateCache.mu.Lock()
+	climateCache.data[climateKey(55.75, 37.62)] = [365]float64{}
+	climateCache.mu.Unlock()
+
+	stale := mockCurrentWeather("Москва", 55.75, 37.62, langRU)
+	stale.Description = "ясно"
+	weatherCache.Set("Москва|ru", stale)
+	clock.Advance(40 * time.Minute)
+
+	// Источник не успел: ответ из кэша с пометкой
+	f.send(textUpdate(4371, "Москва"))
+	reply := f.reply(t, 4371)
+	if !strings.HasPrefix(reply, "⏳ Данные 40 мин назад, обновляю…") || !strings.Contains(reply, "ясно") {
+		t.Fatalf("предварительный ответ: %q", reply)
+	}
+	if edits := f.sent("editMessageText"); len(edits) != 0 {
+		t.Fatalf("сообщение обновлено до ответа источника: %+v", edits)
+	}
+
+	// Источник ответил: сообщение заменяется свежей карточкой
+	close(release)
+	edits := waitEdits(t, f, 1)
+	if len(edits) != 1 {
+		t.Fatalf("обновлений сообщения %d", len(edits))
+	}
+	if text := edits[0].Params["text"]; strings.Contains(text, "обновляю") || !strings.Contains(text, "небольшой снег") {
+		t.Errorf("обновленная карточка: %q", text)
+	}
+	if !strings.Contains(edits[0].Params["reply_markup"], "Прогноз") {
+		t.Errorf("у обновленной карточки нет кнопок: %q", edits[0].Params["reply_markup"])
+	}
+
+	// Свежие данные теперь в кэше, ответ сразу без пометки
+	f.reset()
+	f.send(textUpdate(4371, "Москва"))
+	if reply := f.reply(t, 4371); strings.Contains(reply, "⏳") {
+		t.Errorf("ответ по свежему кэшу с пометкой: %q", reply)
+	}
+}
+
+func TestStaleWeatherTooOld(t *testing.T) {
+	useMockReports(t)
+	clock := useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	weatherCache.Set("Москва|ru", &CurrentWeather{City: "Москва"})
+
+	clock.Advance(staleWeatherMaxAge)
+	if _, fetched, ok := weatherCache.Stale("москва|ru"); !ok || !fetched.Equal(clock.Now().Add(-staleWeatherMaxAge)) {
+		t.Errorf("Stale = %v, %v", fetched, ok)
+	}
+	clock.Advance(time.Minute)
+	if _, _, ok := weatherCache.Stale("москва|ru"); ok {
+		t.Error("показываются данные старше staleWeatherMaxAge")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Сколько часов прогноза просматриваем для сушки белья
+const laundryHorizon = 24 * time.Hour
+
+// Дефицит упругости водяного пара, кПа: чем он больше, тем быстрее сохнет белье
+func vaporPressureDeficit(temp float64, humidity int) float64 {
+	saturation := 0.6108 * math.Exp(17.27*temp/(temp+237.3))
+	return saturation * (1 - float64(humidity)/100)
+}
+
+// Доля высыхания белья за час в условиях интервала прогноза
+func dryingRate(item ForecastItem) float64 {
+	rate := 0.08 * vaporPressureDeficit(item.Temp, item.Humidity)
+	rate *= 1 + 0.25*math.Min(item.WindSpeed, 8)
+	// Солнце заметно ускоряет сушку, но только днем
+	if item.Daytime {
+		rate *= 1 + 0.5*(1-float64(item.Clouds)/100)
+	}
+	return rate
+}
+
+// Промокнет ли белье в этом интервале
+func isWetSlot(item ForecastItem) bool {
+	return item.Pop >= 0.5 || item.Rain+item.Snow >= 0.2
+}
+
+// Функция для оценки времени сушки белья на улице
+func getLaundryIndex(city string) (string, error) {
+	forecast, err := fetchForecast(city)
+	if err != nil {
+		return "", err
+	}
+	if len(forecast.Items) == 0 {
+		return "", fmt.Errorf("нет данных прогноза")
+	}
+
+	laundryMsg := fmt.Sprintf("🧺 Сушка белья на улице в %s:\n\n", forecast.City)
+
+	first := forecast.Items[0]
+	hourlyRate := dryingRate(first)
+	laundryMsg += fmt.Sprintf("🌡 %.0f°C, 💧 %d%%, 🌬 %.0f м/с, ☔️ %.0f%%\n",
+		first.Temp,
+		first.Humidity,
+		first.WindSpeed,
+		first.Pop*100,
+	)
+
+	// Моделируем высыхание по интервалам прогноза: дождь сводит прогресс к нулю
+	start := forecast.LocalTime(first)
+	progress := 0.0
+	rained := false
+	for _, item := range forecast.Items {
+		slotStart := forecast.LocalTime(item)
+		if slotStart.Sub(start) >= laundryHorizon {
+			break
+		}
+		if isWetSlot(item) {
+			progress = 0
+			rained = true
+			continue
+		}
+
+		rate := dryingRate(item)
+		if progress+rate*forecastStep.Hours() >= 1 {
+			hoursLeft := (1 - progress) / rate
+			done := slotStart.Add(time.Duration(hoursLeft * float64(time.Hour)))
+			laundryMsg += fmt.Sprintf("\n⏱ Если повесить сейчас, высохнет примерно к %s", done.Format("15:04"))
+			if rained {
+				laundryMsg += " (после дождя придется перевесить)"
+			}
+			laundryMsg += "\n" + laundryVerdict(hourlyRate)
+			return laundryMsg, nil
+		}
+		progress += rate * forecastStep.Hours()
+	}
+
+	if rained {
+		laundryMsg += "\n☔️ В ближайшие сутки ожидаются осадки — лучше сушить дома."
+	} else {
+		laundryMsg += "\n🏠 За сутки на улице белье не высохнет — лучше сушить дома."
+	}
+
+	return laundryMsg, nil
+}
+
+// Словесная оценка условий для сушки
+func laundryVerdict(rate float64) string {
+	switch {
+	case rate >= 0.25:
+		return "✅ Отличная погода для сушки!"
+	case rate >= 0.12:
+		return "👌 Сохнет нормально."
+	default:
+		return "🐌 Сохнет медленно — возможно, стоит досушить дома."
+	}
+}
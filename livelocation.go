@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Как часто повторно запрашивать погоду при обновлении трансляции геопозиции
+const liveRecheckInterval = 15 * time.Minute
+
+// Пороги, при которых изменение погоды считается значительным
+const (
+	liveTempThreshold = 3.0 // °C
+	liveWindThreshold = 5.0 // м/с
+)
+
+// Тексты уведомления о погоде по пути
+type liveNoticeTexts struct {
+	warmer, colder, windUp, windDown, now, notice string
+}
+
+var liveTexts = map[string]liveNoticeTexts{
+	langRU: {
+		warmer:   "🌡 потеплело на %s",
+		colder:   "🌡 похолодало на %s",
+		windUp:   "🌬 ветер усилился до %s",
+		windDown: "🌬 ветер ослаб до %s",
+		now:      "📝 теперь %s",
+		notice:   "🚗 Погода по пути изменилась (%s):\n%s\n\nСейчас: %s, %s",
+	},
+	langEN: {
+		warmer:   "🌡 %s warmer",
+		colder:   "🌡 %s colder",
+		windUp:   "🌬 wind picked up to %s",
+		windDown: "🌬 wind eased to %s",
+		now:      "📝 now %s",
+		notice:   "🚗 The weather along your way has changed (%s):\n%s\n\nNow: %s, %s",
+	},
+}
+
+// Состояние трансляции геопозиции одного чата
+type liveSession struct {
+	expires   time.Time
+	lastCheck time.Time
+	temp      float64
+	wind      float64
+	condition int
+	desc      string
+}
+
+// Структура для отслеживания трансляций геопозиции
+type LiveTracker struct {
+	sessions map[int64]*liveSession
+	mu       sync.Mutex
+}
+
+// Трекер трансляций, у каждого бота свой
+func newLiveTracker() *LiveTracker {
+	return &LiveTracker{sessions: make(map[int64]*liveSession)}
+}
+
+// Группа погодных условий (2xx гроза, 5xx дождь, 6xx снег, 800 ясно и т.д.)
+func weatherCondition(data *CurrentWeather) int {
+	if data.Condition == 800 {
+		return 800
+	}
+	return data.Condition / 100
+}
+
+// Начинаем отслеживание трансляции геопозиции на livePeriod секунд
+func (t *LiveTracker) Start(chatID int64, livePeriod int, data *CurrentWeather) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := clockNow()
+	session := &liveSession{
+		expires:   now.Add(time.Duration(livePeriod) * time.Second),
+		lastCheck: now,
+		temp:      data.Temp,
+		wind:      data.WindSpeed,
+		condition: weatherCondition(data),
+	}
+	session.desc = data.Description
+	t.sessions[chatID] = session
+}
+
+// Прекращаем отслеживание трансляции чата
+func (t *LiveTracker) Stop(chatID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.sessions, chatID)
+}
+
+// Удаление закончившихся трансляций. Telegram не присылает обновление,
+// когда трансляция заканчивается, поэтому их убирает планировщик
+func (t *LiveTracker) Expire(now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expired := 0
+	for chatID, session := range t.sessions {
+		if now.After(session.expires) {
+			delete(t.sessions, chatID)
+			expired++
+		}
+	}
+	return expired
+}
+
+// Обрабатываем новые координаты трансляции. Возвращает текст уведомления
+// на языке и в единицах пользователя, если погода по пути заметно изменилась
+func (t *LiveTracker) Update(chatID int64, lat, lon float64, prefs UserPreferences) (string, bool, error) {
+	t.mu.Lock()
+	session, exists := t.sessions[chatID]
+	if !exists {
+		t.mu.Unlock()
+		return "", false, nil
+	}
+	now := clockNow()
+	if now.After(session.expires) {
+		delete(t.sessions, chatID)
+		t.mu.Unlock()
+		return "", false, nil
+	}
+	if now.Sub(session.lastCheck) < liveRecheckInterval {
+		t.mu.Unlock()
+		return "", false, nil
+	}
+	// Отмечаем проверку заранее, чтобы параллельные обновления не дублировали запросы
+	session.lastCheck = now
+	t.mu.Unlock()
+
+	data, err := cachedLocationWeather(lat, lon, prefs.Language)
+	if err != nil {
+		return "", false, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	texts, ok := liveTexts[prefs.Language]
+	if !ok {
+		texts = liveTexts[langRU]
+	}
+	wind := formatWindSpeed(data.WindSpeed, windUnitFor(prefs), prefs.Language)
+
+	var changes []string
+	if diff := data.Temp - session.temp; math.Abs(diff) >= liveTempThreshold {
+		if diff > 0 {
+			changes = append(changes, fmt.Sprintf(texts.warmer, formatTempDelta(diff, prefs.Units)))
+		} else {
+			changes = append(changes, fmt.Sprintf(texts.colder, formatTempDelta(-diff, prefs.Units)))
+		}
+	}
+	if diff := data.WindSpeed - session.wind; math.Abs(diff) >= liveWindThreshold {
+		if diff > 0 {
+			changes = append(changes, fmt.Sprintf(texts.windUp, wind))
+		} else {
+			changes = append(changes, fmt.Sprintf(texts.windDown, wind))
+		}
+	}
+	desc := session.desc
+	if condition := weatherCondition(data); condition != session.condition && data.Description != "" {
+		desc = data.Description
+		changes = append(changes, fmt.Sprintf(texts.now, desc))
+	}
+
+	if len(changes) == 0 {
+		return "", false, nil
+	}
+
+	// Запоминаем новую точку отсчета, чтобы не повторять одно и то же уведомление
+	session.temp = data.Temp
+	session.wind = data.WindSpeed
+	session.condition = weatherCondition(data)
+	session.desc = desc
+
+	notice := fmt.Sprintf(
+		texts.notice,
+		data.City,
+		strings.Join(changes, "\n"),
+		formatTemp(data.Temp, prefs.Units),
+		desc,
+	)
+
+	return notice, true, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLiveTrackerNotice(t *testing.T) {
+	useMockReports(t)
+	clock := useManualClock(t, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	tracker := &LiveTracker{sessions: make(map[int64]*liveSession)}
+	const chatID = 3391
+	const lat, lon = 54.19, 37.62
+	prefs := UserPreferences{Units: unitsImperial, Language: langEN}
+
+	tracker.Start(chatID, 3600, &CurrentWeather{Temp: 10, WindSpeed: 2, Condition: 800, Description: "clear sky"})
+
+	// Повторно погоду запрашиваем не чаще liveRecheckInterval
+	clock.Advance(5 * time.Minute)
+	if _, changed, err := tracker.Update(chatID, lat, lon, prefs); err != nil || changed {
+		t.Fatalf("уведомление раньше интервала: %v, %v", changed, err)
+	}
+
+	clock.Advance(liveRecheckInterval)
+	weatherCache.Set("geo:"+geohash(lat, lon, geohashCachePrecision)+"|"+langEN,
+		&CurrentWeather{City: "Tula", Temp: 4, WindSpeed: 9, Condition: 500, Description: "light rain"})
+	notice, changed, err := tracker.Update(chatID, lat, lon, prefs)
+	if err != nil || !changed {
+		t.Fatalf("нет уведомления: %v, %v", changed, err)
+	}
+	for _, want := range []string{"(Tula)", "🌡 11°F colder", "🌬 wind picked up to 20 mph", "📝 now light rain", "Now: 39°F, light rain"} {
+		if !strings.Contains(notice, want) {
+			t.Errorf("в уведомлении нет %q:\n%s", want, notice)
+		}
+	}
+
+	// Та же погода на следующей проверке уже не новость
+	clock.Advance(liveRecheckInterval)
+	if _, changed, _ := tracker.Update(chatID, lat, lon, prefs); changed {
+		t.Error("повторное уведомление о той же погоде")
+	}
+}
+
+func TestLiveTrackerExpire(t *testing.T) {
+	clock := useManualClock(t, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	tracker := &LiveTracker{sessions: make(map[int64]*liveSession)}
+	data := &CurrentWeather{Temp: 10, Condition: 800}
+
+	tracker.Start(1, 600, data)
+	tracker.Start(2, 3600, data)
+
+	clock.Advance(11 * time.Minute)
+	if expired := tracker.Expire(clockNow()); expired != 1 {
+		t.Errorf("удалено трансляций %d, ожидалась 1", expired)
+	}
+	if _, ok := tracker.sessions[1]; ok {
+		t.Error("закончившаяся трансляция осталась")
+	}
+	if _, ok := tracker.sessions[2]; !ok {
+		t.Error("удалена идущая трансляция")
+	}
+}
@@ -1,59 +1,19 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
 )
 
-// Структура для парсинга ответа OpenWeatherMap
-type WeatherResponse struct {
-	Name string `json:"name"`
-	Main struct {
-		Temp      float64 `json:"temp"`
-		FeelsLike float64 `json:"feels_like"`
-		Humidity  int     `json:"humidity"`
-	} `json:"main"`
-	Wind struct {
-		Speed float64 `json:"speed"`
-	} `json:"wind"`
-	Weather []struct {
-		Description string `json:"description"`
-		Icon        string `json:"icon"`
-	} `json:"weather"`
-}
-
-// Структура для парсинга прогноза на 5 дней
-type ForecastResponse struct {
-	List []struct {
-		Dt   int64 `json:"dt"`
-		Main struct {
-			Temp      float64 `json:"temp"`
-			FeelsLike float64 `json:"feels_like"`
-			Humidity  int     `json:"humidity"`
-		} `json:"main"`
-		Weather []struct {
-			Description string `json:"description"`
-			Icon        string `json:"icon"`
-		} `json:"weather"`
-		Wind struct {
-			Speed float64 `json:"speed"`
-		} `json:"wind"`
-		DtTxt string `json:"dt_txt"`
-	} `json:"list"`
-	City struct {
-		Name string `json:"name"`
-	} `json:"city"`
-}
-
 // Структура для кэширования погоды
 type WeatherCache struct {
 	data map[string]CacheItem
@@ -61,8 +21,8 @@ type WeatherCache struct {
 }
 
 type CacheItem struct {
-	weatherData string
-	timestamp   time.Time
+	weather   *CurrentWeather
+	timestamp time.Time
 }
 
 // Создаем глобальный кэш
@@ -71,326 +31,658 @@ var weatherCache = &WeatherCache{
 }
 
 // Метод для получения данных из кэша
-func (c *WeatherCache) Get(city string) (string, bool) {
+func (c *WeatherCache) Get(key string) (*CurrentWeather, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	item, exists := c.data[strings.ToLower(city)]
+	item, exists := c.data[strings.ToLower(key)]
 	if !exists {
-		return "", false
+		return nil, false
 	}
 
-	// Проверяем актуальность кэша (30 минут)
-	if time.Since(item.timestamp) > 30*time.Minute {
-		return "", false
+	// Проверяем актуальность кэша (CACHE_TTL, по умолчанию 30 минут)
+	if clockNow().Sub(item.timestamp) > config().CacheTTL {
+		return nil, false
 	}
 
-	return item.weatherData, true
+	return item.weather, true
 }
 
 // Метод для сохранения данных в кэш
-func (c *WeatherCache) Set(city, data string) {
+func (c *WeatherCache) Set(key string, data *CurrentWeather) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.data[strings.ToLower(city)] = CacheItem{
-		weatherData: data,
-		timestamp:   time.Now(),
+	c.data[strings.ToLower(key)] = CacheItem{
+		weather:   data,
+		timestamp: clockNow(),
 	}
 }
 
-func getWeather(city string) (string, error) {
-	// Проверяем кэш
-	if cachedData, ok := weatherCache.Get(city); ok {
-		return cachedData, nil
+// Текущая погода в городе из кэша или от источника данных. Описание
+// зависит от языка, поэтому языки кэшируются раздельно
+func cachedWeather(city, lang string) (*CurrentWeather, error) {
+	cacheKey := city + "|" + lang
+	if data, ok := weatherCache.Get(cacheKey); ok {
+		return data, nil
 	}
 
-	apiKey := os.Getenv("OWM_API_KEY")
-
-	url := fmt.Sprintf(
-		"http://api.openweathermap.org/data/2.5/weather?q=%s&appid=%s&units=metric&lang=ru",
-		city,
-		apiKey,
-	)
-
-	resp, err := http.Get(url)
+	data, err := fetchWeatherLang(city, lang)
 	if err != nil {
-		return "", fmt.Errorf("ошибка запроса: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	weatherCache.Set(cacheKey, data)
+	return data, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("город не найден или ошибка API")
+// Текущая погода по координатам из кэша или от источника данных.
+// Ключ — ячейка геохеша около 5 км, поэтому близкие точки (соседи,
+// повторная отправка геопозиции) получают одни и те же данные
+func cachedLocationWeather(lat, lon float64, lang string) (*CurrentWeather, error) {
+	cacheKey := "geo:" + geohash(lat, lon, geohashCachePrecision) + "|" + lang
+	if data, ok := weatherCache.Get(cacheKey); ok {
+		return data, nil
 	}
 
-	var data WeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", fmt.Errorf("ошибка парсинга данных: %v", err)
+	data, err := fetchWeatherByCoordsLang(lat, lon, lang)
+	if err != nil {
+		return nil, err
 	}
-
-	weatherMsg := fmt.Sprintf(
-		"🌤 Погода в %s:\n"+
-			"🌡 Температура: %.0f°C (ощущается как %.0f°C)\n"+
-			"💧 Влажность: %d%%\n"+
-			"🌬 Ветер: %.0f м/с\n"+
-			"📝 %s",
-		data.Name,
-		data.Main.Temp,
-		data.Main.FeelsLike,
-		data.Main.Humidity,
-		data.Wind.Speed,
-		data.Weather[0].Description,
-	)
-
-	// Сохраняем в кэш
-	weatherCache.Set(city, weatherMsg)
-
-	return weatherMsg, nil
+	weatherCache.Set(cacheKey, data)
+	return data, nil
 }
 
-// Функция для получения прогноза погоды на 5 дней
-func getForecast(city string) (string, error) {
-	apiKey := os.Getenv("OWM_API_KEY")
-
-	url := fmt.Sprintf(
-		"http://api.openweathermap.org/data/2.5/forecast?q=%s&appid=%s&units=metric&lang=ru",
-		city,
-		apiKey,
-	)
-
-	resp, err := http.Get(url)
+func getWeather(city string, prefs UserPreferences) (string, error) {
+	data, err := cachedWeather(city, prefs.Language)
 	if err != nil {
-		return "", fmt.Errorf("ошибка запроса: %v", err)
+		return "", err
 	}
-	defer resp.Body.Close()
+	return weatherCard(city, data, prefs)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("город не найден или ошибка API")
-	}
+// Карточка текущей погоды в городе
+func weatherCard(city string, data *CurrentWeather, prefs UserPreferences) (string, error) {
+	return renderReply("weather", prefs, newWeatherCardData(data, prefs,
+		climateLine(data, prefs),
+		recordAndCompare(city, data, prefs),
+		reportsLine(data.Lat, data.Lon),
+	))
+}
 
-	var data ForecastResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", fmt.Errorf("ошибка парсинга данных: %v", err)
+// Функция для получения прогноза погоды на 5 дней
+func getForecast(city string, prefs UserPreferences) (string, error) {
+	data, err := fetchForecastLang(city, prefs.Language)
+	if err != nil {
+		return "", err
 	}
 
-	forecastMsg := fmt.Sprintf("🔮 Прогноз погоды на 5 дней для %s:\n\n", data.City.Name)
+	// Ограничиваем до 5 дней (максимум 15 интервалов)
+	return renderReply("forecast", prefs, newForecastData(data, 15))
+}
 
-	// Группируем данные по дням
-	currentDay := ""
-	for i, item := range data.List {
-		// Ограничиваем до 5 дней (максимум 15 элементов)
-		if i >= 15 {
-			break
-		}
+// Форматирование погоды по координатам
+func formatLocationWeather(data *CurrentWeather, lat, lon float64, place string, prefs UserPreferences) (string, error) {
+	card := newWeatherCardData(data, prefs, climateLine(data, prefs), elevationLine(data, lat, lon, prefs), reportsLine(lat, lon))
+	card.City = place
+	card.Location = true
+	return renderReply("weather", prefs, card)
+}
 
-		// Из формата "2023-05-15 12:00:00" получаем только дату
-		date := strings.Split(item.DtTxt, " ")[0]
-		t, _ := time.Parse("2006-01-02", date)
-		formattedDate := t.Format("02.01")
+// Строка сравнения с климатической нормой для карточки погоды (пустая при ошибке)
+func climateLine(data *CurrentWeather, prefs UserPreferences) string {
+	// В демо-режиме сеть не нужна, норму не запрашиваем
+	if mockWeatherMode() {
+		return ""
+	}
 
-		// Если день изменился, выводим новый заголовок
-		if currentDay != formattedDate {
-			currentDay = formattedDate
-			forecastMsg += fmt.Sprintf("\n📅 %s:\n", formattedDate)
-		}
+	line, err := climateComparison(data.Lat, data.Lon, data.Temp, data.Time, prefs)
+	if err != nil {
+		log.Printf("Ошибка получения климатической нормы: %v", err)
+		return ""
+	}
 
-		// Время
-		timeStr := strings.Split(item.DtTxt, " ")[1]
-		timeStr = strings.Split(timeStr, ":")[0] + ":00"
+	return "\n" + line
+}
 
-		forecastMsg += fmt.Sprintf("⏰ %s: %.0f°C, %s\n",
-			timeStr,
-			item.Main.Temp,
-			item.Weather[0].Description,
-		)
+// Кнопки под карточкой текущей погоды
+func weatherKeyboard(store *Store, city string, prefs UserPreferences) tgbotapi.InlineKeyboardMarkup {
+	forecastButton := tgbotapi.NewInlineKeyboardButtonData("🔮 Прогноз на 5 дней", encodeCallback(store, CallbackPayload{
+		Action: actionForecast,
+		City:   city,
+		Units:  prefs.Units,
+		Lang:   prefs.Language,
+	}))
+	refreshButton := tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", encodeCallback(store, CallbackPayload{
+		Action: actionRefresh,
+		City:   city,
+		Units:  prefs.Units,
+		Lang:   prefs.Language,
+	}))
+	// Единицы переключаются только в этой карточке, настройки не меняются
+	other, title := unitsImperial, "🌡 В °F"
+	if prefs.Units == unitsImperial {
+		other, title = unitsMetric, "🌡 В °C"
 	}
-
-	return forecastMsg, nil
+	unitsButton := tgbotapi.NewInlineKeyboardButtonData(title, encodeCallback(store, CallbackPayload{
+		Action: actionUnits,
+		City:   city,
+		Units:  other,
+		Lang:   prefs.Language,
+	}))
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(forecastButton),
+		tgbotapi.NewInlineKeyboardRow(refreshButton, unitsButton),
+		tgbotapi.NewInlineKeyboardRow(shareButton(city)),
+	)
 }
 
-// Получение погоды по координатам
-func getWeatherByCoords(lat, lon float64) (string, error) {
-	apiKey := os.Getenv("OWM_API_KEY")
+// Переключение сообщения между текущей погодой и прогнозом на 5 дней,
+// обновление карточки и смена единиц в ней. Возвращает текст всплывающей
+// подсказки для быстрых действий
+func handleWeatherCallback(bot *Bot, callback *tgbotapi.CallbackQuery, payload CallbackPayload) (string, error) {
+	chatID := callback.Message.Chat.ID
 
-	url := fmt.Sprintf(
-		"http://api.openweathermap.org/data/2.5/weather?lat=%.6f&lon=%.6f&appid=%s&units=metric&lang=ru",
-		lat,
-		lon,
-		apiKey,
-	)
+	// Кнопка помнит единицы и язык, с которыми была показана карточка
+	prefs := bot.store.Preferences(chatID)
+	if payload.Units != "" {
+		prefs.Units = payload.Units
+	}
+	if payload.Lang != "" {
+		prefs.Language = payload.Lang
+	}
 
-	resp, err := http.Get(url)
+	var text string
+	var markup tgbotapi.InlineKeyboardMarkup
+	var err error
+	if payload.Action == actionForecast {
+		text, err = getForecast(payload.City, prefs)
+		markup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Текущая погода", encodeCallback(bot.store, CallbackPayload{
+				Action: actionWeather,
+				City:   payload.City,
+				Units:  prefs.Units,
+				Lang:   prefs.Language,
+			})),
+		))
+	} else {
+		text, err = getWeather(payload.City, prefs)
+		markup = weatherKeyboard(bot.store, payload.City, prefs)
+	}
 	if err != nil {
-		return "", fmt.Errorf("ошибка запроса: %v", err)
+		_, err = bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка: "+err.Error()))
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ошибка получения данных API")
+	var toast string
+	switch payload.Action {
+	case actionRefresh:
+		// Данные из кэша: в подсказке время наблюдения, а не нажатия
+		if data, err := cachedWeather(payload.City, prefs.Language); err == nil {
+			toast = "Обновлено, " + data.Time.Format("15:04")
+		}
+	case actionUnits:
+		toast = "Температура в °C"
+		if prefs.Units == unitsImperial {
+			toast = "Температура в °F"
+		}
 	}
 
-	var data WeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", fmt.Errorf("ошибка парсинга данных: %v", err)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, callback.Message.MessageID, accessibleText(bot.store, chatID, text), markup)
+	edit.ParseMode = replyParseMode(prefs)
+	// Погода в кэше не изменилась — сообщение тоже, это не ошибка
+	if _, err := bot.Request(edit); err != nil && !isNotModified(err) {
+		return "", err
 	}
+	return toast, nil
+}
 
-	weatherMsg := fmt.Sprintf(
-		"📍 Погода в вашем местоположении (%s):\n"+
-			"🌡 Температура: %.0f°C (ощущается как %.0f°C)\n"+
-			"💧 Влажность: %d%%\n"+
-			"🌬 Ветер: %.0f м/с\n"+
-			"📝 %s",
-		data.Name,
-		data.Main.Temp,
-		data.Main.FeelsLike,
-		data.Main.Humidity,
-		data.Wind.Speed,
-		data.Weather[0].Description,
-	)
+// Telegram отказывается редактировать сообщение, если текст и кнопки не изменились
+func isNotModified(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
 
-	return weatherMsg, nil
+// Город из аргументов команды, последний запрошенный или домашний город
+func commandCity(bot *Bot, message *tgbotapi.Message) (string, bool) {
+	if city := strings.TrimSpace(message.CommandArguments()); city != "" {
+		return city, true
+	}
+	if city, exists := bot.lastCity[message.Chat.ID]; exists {
+		return city, true
+	}
+	home := bot.store.Preferences(message.Chat.ID).HomeCity
+	return home, home != ""
 }
 
 func main() {
+	// Ключи и токены не должны попадать в логи, в том числе в отладочные
+	// логи telegram-bot-api с адресами запросов
+	log.SetOutput(redactingWriter{os.Stderr})
+	tgbotapi.SetLogger(log.New(redactingWriter{os.Stderr}, "", log.LstdFlags))
+
 	// Загружаем переменные окружения из .env файла
+	rememberProcessEnv()
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Ошибка загрузки .env файла: %v", err)
 	}
 
-	// Загружаем токены
-	telegramToken := os.Getenv("TELEGRAM_TOKEN")
-	if telegramToken == "" {
-		log.Fatal("TELEGRAM_TOKEN не задан")
+	// Загружаем настройки: YAML-файл, переменные окружения и флаги
+	loaded, err := loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
 	}
+	setConfig(loaded)
+	applyProxies(loaded)
 
-	owmApiKey := os.Getenv("OWM_API_KEY")
-	if owmApiKey == "" {
-		log.Fatal("OWM_API_KEY не задан")
+	// Пробный прогон оповещений на модельных часах вместо запуска бота
+	if loaded.DryRun {
+		if err := runDryRun(loaded); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	// Инициализируем бота
-	bot, err := tgbotapi.NewBotAPI(telegramToken)
+	// Подключаем ботов и открываем их хранилища состояния (подписки и т.п.)
+	bots, err = openBots(config())
 	if err != nil {
-		log.Fatalf("Ошибка инициализации бота: %v", err)
+		log.Fatal(err)
+	}
+	go runUpdateLogFlusher()
+
+	// У событий оператора общий интервал, поэтому о запуске всех ботов
+	// сообщаем одним событием
+	names := make([]string, 0, len(bots))
+	for _, bot := range bots {
+		log.Printf("Бот запущен: @%s", bot.Self.UserName)
+		names = append(names, "@"+bot.Self.UserName)
+
+		// Фоновая проверка подписок на оповещения и публикации сводок
+		// в группах по расписанию
+		go runAlertChecker(bot)
+		go runGroupPosts(bot)
+
+		// Запись наблюдений для городов с подписками
+		go runObservationRecorder(bot)
+
+		// Резервные копии состояния по расписанию
+		go runBackups(bot)
+	}
+	notifyOperator(eventStarted, fmt.Sprintf("бот %s запущен", strings.Join(names, ", ")))
+
+	// Прогрев кэша погоды перед утренними рассылками
+	go runCacheWarmer()
+
+	// Мини-приложение с панелью погоды (если задан адрес для HTTP-сервера)
+	if config().WebAppAddr != "" {
+		go runWebApp(config().WebAppAddr)
 	}
-	bot.Debug = true // Включить логирование (опционально)
 
-	log.Printf("Бот запущен: @%s", bot.Self.UserName)
+	// HTTP API с погодой для других сервисов
+	if config().APIAddr != "" {
+		go runAPIServer(config().APIAddr)
+	}
+
+	// Публикация погоды в MQTT для домашней автоматизации
+	go runMQTTPublisher()
+
+	// Профилирование для операторов (pprof), только если задан DEBUG_ADDR
+	if config().DebugAddr != "" {
+		go runDebugServer(config().DebugAddr, config().DebugToken)
+	}
+
+	// SIGHUP перечитывает настройки без перезапуска
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := reloadConfig(os.Args[1:]); err != nil {
+				log.Printf("Ошибка перезагрузки настроек: %v", err)
+				continue
+			}
+			log.Printf("Настройки перечитаны")
+		}
+	}()
+
+	// Корректное завершение по SIGINT/SIGTERM: каналы обновлений закроются и циклы завершатся
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	stopPolling := make(chan struct{})
+	go func() {
+		sig := <-stop
+		notifyOperator(eventShuttingDown, fmt.Sprintf("бот %s останавливается (%v)", strings.Join(names, ", "), sig))
+		close(stopPolling)
+	}()
+
+	// У каждого бота свой цикл обработки обновлений
+	var running sync.WaitGroup
+	for _, bot := range bots {
+		running.Add(1)
+		go func(bot *Bot) {
+			defer running.Done()
+			bot.run(stopPolling)
+		}(bot)
+	}
+	running.Wait()
+}
 
-	// Настройка обновлений (updates)
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := bot.GetUpdatesChan(u)
+const defaultTelegramURL = "https://api.telegram.org"
 
-	// Карта последних запросов пользователей
-	userLastCity := make(map[int64]string)
+// Клиент Telegram. TELEGRAM_API_URL позволяет направить его на локальный
+// Bot API сервер или на поддельный сервер в тестах
+func newBotAPI(c *Config, token string) (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithClient(token, c.TelegramAPIURL+"/bot%s/%s", telegramClient)
+}
+
+// Полная цепочка обработки обновления: промежуточные обработчики перед основным
+func newUpdatePipeline() updateHandler {
+	return chainMiddleware(handleUpdate,
+		withLogging,
+		withDedupe,
+		withRecovery,
+		withRateLimit,
+		withBanCheck,
+		withMetrics,
+	)
+}
+
+// Основной обработчик обновления
+func handleUpdate(bot *Bot, update tgbotapi.Update) {
+	// Подтверждение оплаты перед списанием
+	if update.PreCheckoutQuery != nil {
+		if err := handlePreCheckout(bot, update.PreCheckoutQuery); err != nil {
+			reportUpdateError(update, "Ошибка подтверждения оплаты", err)
+		}
+	}
 
 	// Обработка сообщений
-	for update := range updates {
-		// Обработка сообщений
-		if update.Message != nil {
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
-
-			// Обработка команд
-			switch update.Message.Text {
-			case "/start", "/help":
-				msg.Text = "Привет! Я бот погоды. 🌤\n\n" +
-					"Вы можете:\n" +
-					"• Написать название города для получения текущей погоды\n" +
-					"• Нажать кнопку 'Прогноз на 5 дней' для получения прогноза\n" +
-					"• Отправить своё местоположение для погоды в вашей точке\n\n" +
-					"Команды:\n" +
-					"/start - Информация о боте\n" +
-					"/help - Показать эту справку\n" +
-					"/forecast - Прогноз на 5 дней для последнего запрошенного города"
-
-				// Добавляем кнопку для отправки геолокации
-				locationButton := tgbotapi.NewKeyboardButtonLocation("📍 Отправить местоположение")
-				msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(
-					tgbotapi.NewKeyboardButtonRow(locationButton),
-				)
-
-			case "/forecast":
-				// Проверяем, был ли у пользователя последний запрос города
-				city, exists := userLastCity[update.Message.Chat.ID]
-				if !exists {
-					msg.Text = "Пожалуйста, сначала запросите погоду для какого-либо города."
-				} else {
-					forecast, err := getForecast(city)
-					if err != nil {
-						msg.Text = "❌ Ошибка: " + err.Error()
-					} else {
-						msg.Text = forecast
-					}
+	if update.Message != nil {
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
+
+		// Сообщение об успешной оплате
+		if update.Message.SuccessfulPayment != nil {
+			msg.Text = handleSuccessfulPayment(bot, update.Message)
+			if _, err := bot.Send(msg); err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения", err)
+			}
+			return
+		}
+
+		// Голосовое сообщение распознаем и обрабатываем как текстовый запрос
+		if update.Message.Voice != nil && featureEnabled(featureVoice, update.Message.Chat.ID) {
+			text, err := transcribeVoice(bot, update.Message.Voice)
+			if err != nil {
+				msg.Text = "❌ Ошибка: " + err.Error()
+				if _, err := bot.Send(msg); err != nil {
+					reportUpdateError(update, "Ошибка отправки сообщения", err)
 				}
+				return
+			}
+
+			notice := tgbotapi.NewMessage(update.Message.Chat.ID, "🎤 Распознано: «"+text+"»")
+			if _, err := bot.Send(notice); err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения", err)
+			}
+			update.Message.Text = text
+		}
+
+		// Фото с подписью-командой (/report снег) обрабатываем как команду
+		if update.Message.Text == "" && len(update.Message.Photo) > 0 && strings.HasPrefix(update.Message.Caption, "/") {
+			update.Message.Text = update.Message.Caption
+			update.Message.Entities = update.Message.CaptionEntities
+		}
+
+		// Ссылки вида t.me/bot?start=city_London сразу показывают погоду в городе
+		if city, ok := startPayloadCity(update.Message); ok {
+			update.Message.Text = city
+			update.Message.Entities = nil
+		}
 
-			default:
-				city := update.Message.Text
-				weatherInfo, err := getWeather(city)
+		// Любая команда прерывает начатый диалог, а обычный текст
+		// внутри диалога считается ответом на последний вопрос. В группе
+		// диалог настройки ведет только администратор
+		dialogCancelled := false
+		if dialogInputAllowed(bot, update.Message) {
+			if update.Message.IsCommand() {
+				cancelled, err := bot.store.ClearDialog(update.Message.Chat.ID)
 				if err != nil {
-					msg.Text = "❌ Ошибка: " + err.Error()
-				} else {
-					// Сохраняем последний запрошенный город
-					userLastCity[update.Message.Chat.ID] = city
-
-					msg.Text = weatherInfo
-
-					// Добавляем кнопку для прогноза
-					forecastButton := tgbotapi.NewInlineKeyboardButtonData("🔮 Прогноз на 5 дней", "forecast:"+city)
-					msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
-						tgbotapi.NewInlineKeyboardRow(forecastButton),
-					)
+					reportUpdateError(update, "Ошибка сохранения состояния", err)
 				}
+				dialogCancelled = cancelled
+			} else if reply, ok := handleDialogMessage(bot, update.Message); ok {
+				if _, err := bot.Send(reply); err != nil {
+					reportUpdateError(update, "Ошибка отправки сообщения", err)
+				}
+				return
 			}
+		}
 
-			if _, err := bot.Send(msg); err != nil {
-				log.Printf("Ошибка отправки сообщения: %v", err)
+		// Премиум-команды без оплаченного премиума
+		c := newCommandContext(bot, update.Message)
+		c.dialogCancelled = dialogCancelled
+		if premiumCommands[commands.Resolve(c.command)] && !bot.store.IsPremium(update.Message.Chat.ID) {
+			c.msg.Text = premiumRequiredText()
+		} else {
+			commands.Dispatch(c)
+		}
+
+		// Сообщение без текста (например, геопозиция) остается без ответа
+		if c.msg.Text != "" {
+			c.msg.Text = accessibleText(bot.store, update.Message.Chat.ID, c.msg.Text)
+			sent, err := sendSplit(bot, c.msg)
+			if err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения", err)
+			} else if c.afterSend != nil {
+				c.afterSend(sent)
+			}
+		}
+		// Ответ дошел, значит бот снова может писать в чат: напоминаем о
+		// пропущенных оповещениях
+		if notice := missedNotice(bot.store, update.Message.Chat.ID); notice != "" {
+			if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, accessibleText(bot.store, update.Message.Chat.ID, notice))); err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения", err)
+			}
+		}
+		if c.invoice != nil {
+			if _, err := bot.Send(*c.invoice); err != nil {
+				reportUpdateError(update, "Ошибка отправки счета", err)
+			}
+		}
+		if c.stickerCity != "" {
+			if err := sendCitySticker(bot, update.Message.Chat.ID, c.stickerCity); err != nil {
+				reportUpdateError(update, "Ошибка отправки стикера", err)
 			}
+		}
+		if c.voiceText != "" {
+			if err := sendVoiceReply(bot, update.Message.Chat.ID, c.voiceText); err != nil {
+				reportUpdateError(update, "Ошибка отправки голосового ответа", err)
+			}
+		}
 
-			// Обработка местоположения
-			if update.Message.Location != nil {
-				weather, err := getWeatherByCoords(
-					update.Message.Location.Latitude,
-					update.Message.Location.Longitude,
-				)
+		// Обработка местоположения
+		if update.Message.Location != nil {
+			location := update.Message.Location
+			prefs := bot.store.Preferences(update.Message.Chat.ID)
+			data, err := cachedLocationWeather(location.Latitude, location.Longitude, prefs.Language)
+
+			replyMsg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
+			if err == nil {
+				place, city := locationPlace(location.Latitude, location.Longitude, data.City, prefs.Language)
+				// Запоминаем место как последний город для /forecast
+				if city != "" {
+					bot.lastCity[update.Message.Chat.ID] = city
+				}
+				replyMsg.Text, err = formatLocationWeather(data, location.Latitude, location.Longitude, place, prefs)
+			}
+			if err != nil {
+				replyMsg.Text = "❌ Ошибка получения погоды по координатам: " + err.Error()
+			} else {
+				replyMsg.ParseMode = replyParseMode(prefs)
+
+				// Для трансляции геопозиции продолжаем следить за погодой по пути
+				if location.LivePeriod > 0 {
+					bot.live.Start(update.Message.Chat.ID, location.LivePeriod, data)
+					replyMsg.Text += "\n\n🚗 Буду следить за погодой по пути и сообщу о заметных изменениях."
+				}
 
-				replyMsg := tgbotapi.NewMessage(update.Message.Chat.ID, "")
-				if err != nil {
-					replyMsg.Text = "❌ Ошибка получения погоды по координатам: " + err.Error()
-				} else {
-					replyMsg.Text = weather
+				// Вместо случайной деревни можно выбрать станцию в соседнем городе
+				if markup := nearbyMarkup(bot.store, location.Latitude, location.Longitude, prefs.Language); markup != nil {
+					replyMsg.Text += nearbyHint
+					replyMsg.ReplyMarkup = *markup
 				}
+			}
 
-				if _, err := bot.Send(replyMsg); err != nil {
-					log.Printf("Ошибка отправки сообщения с погодой по координатам: %v", err)
+			replyMsg.Text = accessibleText(bot.store, update.Message.Chat.ID, replyMsg.Text)
+			if _, err := bot.Send(replyMsg); err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения с погодой по координатам", err)
+			}
+			if data != nil {
+				if err := sendWeatherSticker(bot, update.Message.Chat.ID, data); err != nil {
+					reportUpdateError(update, "Ошибка отправки стикера", err)
 				}
 			}
 		}
+	}
 
-		// Обработка колбэков (нажатия на кнопки)
-		if update.CallbackQuery != nil {
-			callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+	// Обновления трансляции геопозиции приходят как отредактированные сообщения
+	if update.EditedMessage != nil && update.EditedMessage.Location != nil {
+		chatID := update.EditedMessage.Chat.ID
+		location := update.EditedMessage.Location
+
+		notice, changed, err := bot.live.Update(chatID, location.Latitude, location.Longitude, bot.store.Preferences(chatID))
+		if err != nil {
+			reportUpdateError(update, "Ошибка обновления погоды по трансляции геопозиции", err)
+		} else if changed {
+			// Уведомление приходит без запроса, как оповещение: с паузой и /missed
+			if err := deliver(bot, bot.store, chatID, "Погода в пути", tgbotapi.NewMessage(chatID, notice), notice); err != nil {
+				reportUpdateError(update, "Ошибка отправки уведомления о погоде по пути", err)
+			}
+		}
+	}
+
+	// Инлайн-запросы "@бот Город" (в том числе от кнопки "Поделиться")
+	if update.InlineQuery != nil {
+		if err := answerInlineQuery(bot, update.InlineQuery); err != nil {
+			reportUpdateError(update, "Ошибка ответа на инлайн-запрос", err)
+		}
+	}
+
+	// Обработка колбэков (нажатия на кнопки)
+	if update.CallbackQuery != nil {
+		payload, ok := decodeCallback(bot.store, update.CallbackQuery.Data)
+
+		// Кнопки настроек в группе нажимают только ее администраторы
+		message := update.CallbackQuery.Message
+		denied := ok && groupSettingsActions[payload.Action] && message != nil &&
+			!canManageChat(bot, message.Chat, update.CallbackQuery.From, nil)
+
+		callback := tgbotapi.NewCallback(update.CallbackQuery.ID, "")
+		if !ok {
+			callback.Text = "Кнопка устарела, повторите запрос."
+		}
+		if denied {
+			callback.Text = groupAdminOnlyText
+			callback.ShowAlert = true
+		}
+		if !ok || denied {
 			if _, err := bot.Request(callback); err != nil {
-				log.Printf("Ошибка обработки колбэка: %v", err)
+				reportUpdateError(update, "Ошибка обработки колбэка", err)
 			}
+			return
+		}
 
-			// Обработка колбэка для прогноза
-			if strings.HasPrefix(update.CallbackQuery.Data, "forecast:") {
-				city := strings.TrimPrefix(update.CallbackQuery.Data, "forecast:")
+		// На быстрые действия отвечаем всплывающей подсказкой с итогом,
+		// поэтому отвечаем на колбэк после обработки
+		var toast string
+		var err error
+		switch payload.Action {
+		// Навигация по меню настроек
+		case actionSettings:
+			lastCity := bot.lastCity[update.CallbackQuery.Message.Chat.ID]
+			if toast, err = handleSettingsCallback(bot, update.CallbackQuery, payload.Value, lastCity); err != nil {
+				reportUpdateError(update, "Ошибка обработки меню настроек", err)
+			}
 
-				forecast, err := getForecast(city)
-				msg := tgbotapi.NewMessage(update.CallbackQuery.Message.Chat.ID, "")
+		// Ответы в мастере знакомства с ботом
+		case actionOnboarding:
+			if err := handleOnboardingCallback(bot, update.CallbackQuery, payload.Value); err != nil {
+				reportUpdateError(update, "Ошибка обработки мастера настройки", err)
+			}
 
-				if err != nil {
-					msg.Text = "❌ Ошибка: " + err.Error()
-				} else {
-					msg.Text = forecast
-				}
+		// Администратор отвечает на отзыв
+		case actionFeedbackReply:
+			chatID := update.CallbackQuery.Message.Chat.ID
+			if !isAdmin(chatID) {
+				break
+			}
+			reply, err := startDialog(bot.store, chatID, flowFeedbackReply, map[string]string{"id": payload.Value})
+			if err != nil {
+				reportUpdateError(update, "Ошибка начала ответа на отзыв", err)
+				break
+			}
+			if _, err := bot.Send(reply); err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения", err)
+			}
 
-				if _, err := bot.Send(msg); err != nil {
-					log.Printf("Ошибка отправки сообщения с прогнозом: %v", err)
+		// Выбор суммы пожертвования
+		case actionDonate:
+			amount, err := parseDonateAmount(payload.Value)
+			if err == nil && amount > 0 {
+				if _, err := bot.Send(donateInvoice(update.CallbackQuery.Message.Chat.ID, amount)); err != nil {
+					reportUpdateError(update, "Ошибка отправки счета", err)
 				}
 			}
+
+		// Повторный запрос города из /recent
+		case actionRecent:
+			if err := handleRecentCallback(bot, update.CallbackQuery, payload.City); err != nil {
+				reportUpdateError(update, "Ошибка отправки погоды для недавнего города", err)
+			}
+
+		// Погода в соседнем городе под карточкой по геопозиции
+		case actionNearby:
+			if err := handleNearbyCallback(bot, update.CallbackQuery, payload); err != nil {
+				reportUpdateError(update, "Ошибка отправки погоды в соседнем городе", err)
+			}
+
+		// Подтверждение удаления данных из /forgetme
+		case actionForgetMe:
+			if err := handleForgetMeCallback(bot, update.CallbackQuery, payload.Value); err != nil {
+				reportUpdateError(update, "Ошибка удаления данных пользователя", err)
+			}
+
+		// Жалоба на фото неба из /sky
+		case actionSkyFlag:
+			if err := handleSkyFlagCallback(bot, update.CallbackQuery, payload.Value); err != nil {
+				reportUpdateError(update, "Ошибка обработки жалобы на фото", err)
+			}
+
+		// Администратор удаляет фото неба или блокирует автора
+		case actionSkyDelete, actionSkyBan:
+			if err := handleSkyModerationCallback(bot, update.CallbackQuery, payload); err != nil {
+				reportUpdateError(update, "Ошибка модерации фото", err)
+			}
+
+		// Выбор блоков утренней сводки
+		case actionDigest:
+			if toast, err = handleDigestCallback(bot, update.CallbackQuery, payload.Value); err != nil {
+				reportUpdateError(update, "Ошибка изменения состава сводки", err)
+			}
+
+		// Пауза оповещений кнопками под оповещением
+		case actionPause:
+			if toast, err = handlePauseCallback(bot, update.CallbackQuery, payload.Value); err != nil {
+				reportUpdateError(update, "Ошибка паузы оповещений", err)
+			}
+
+		// Прогноз и текущая погода показываются в том же сообщении
+		case actionForecast, actionWeather, actionRefresh, actionUnits:
+			if toast, err = handleWeatherCallback(bot, update.CallbackQuery, payload); err != nil {
+				reportUpdateError(update, "Ошибка отправки сообщения с прогнозом", err)
+			}
+		}
+
+		callback.Text = toast
+		if _, err := bot.Request(callback); err != nil {
+			reportUpdateError(update, "Ошибка обработки колбэка", err)
 		}
 	}
 }
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Авиационная погода аэропорта: METAR (фактическая) и TAF (прогноз) из
+// бесплатного API NOAA Aviation Weather Center. Сводки показываем как есть
+// и расшифровываем по-русски
+
+// Адрес API Aviation Weather Center; в тестах подменяется
+var aviationWeatherURL = "https://aviationweather.gov/api/data"
+
+var aviationClient = &http.Client{Timeout: 10 * time.Second}
+
+// METAR выходит раз в полчаса, TAF — раз в несколько часов
+const metarCacheTTL = 10 * time.Minute
+
+// Значение, которое API отдает то числом, то строкой: "VRB" вместо
+// направления ветра, "6+" вместо видимости
+type aviationValue string
+
+func (v *aviationValue) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*v = aviationValue(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*v = aviationValue(number.String())
+	return nil
+}
+
+// Слой облаков: покрытие и нижняя граница в футах
+type aviationCloud struct {
+	Cover string `json:"cover"`
+	Base  *int   `json:"base"`
+}
+
+// METAR в ответе /metar?format=json
+type metarReport struct {
+	ICAO      string          `json:"icaoId"`
+	Name      string          `json:"name"`
+	ObsTime   int64           `json:"obsTime"`
+	Temp      *float64        `json:"temp"`
+	Dewpoint  *float64        `json:"dewp"`
+	WindDir   aviationValue   `json:"wdir"`
+	WindSpeed *float64        `json:"wspd"` // узлы
+	WindGust  *float64        `json:"wgst"`
+	Visib     aviationValue   `json:"visib"` // уставные мили
+	Altimeter *float64        `json:"altim"` // гПа
+	Weather   string          `json:"wxString"`
+	Clouds    []aviationCloud `json:"clouds"`
+	Category  string          `json:"fltCat"`
+	Raw       string          `json:"rawOb"`
+}
+
+// Период TAF: основной прогноз или изменение (FM, BECMG, TEMPO, PROB)
+type tafPeriod struct {
+	TimeFrom  int64           `json:"timeFrom"`
+	TimeTo    int64           `json:"timeTo"`
+	Change    string          `json:"fcstChange"`
+	Prob      *int            `json:"probability"`
+	WindDir   aviationValue   `json:"wdir"`
+	WindSpeed *float64        `json:"wspd"`
+	WindGust  *float64        `json:"wgst"`
+	Visib     aviationValue   `json:"visib"`
+	Weather   string          `json:"wxString"`
+	Clouds    []aviationCloud `json:"clouds"`
+}
+
+// TAF в ответе /taf?format=json
+type tafReport struct {
+	ICAO     string      `json:"icaoId"`
+	Raw      string      `json:"rawTAF"`
+	Forecast []tafPeriod `json:"fcsts"`
+}
+
+// Кэш сводок по коду аэропорта
+type aviationCacheItem struct {
+	metar   *metarReport
+	taf     *tafReport
+	fetched time.Time
+}
+
+var (
+	aviationCache   = make(map[string]aviationCacheItem)
+	aviationCacheMu sync.Mutex
+)
+
+// Код ICAO: четыре латинские буквы или цифры, первая — буква
+func normalizeICAO(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 4 || code[0] < 'A' || code[0] > 'Z' {
+		return "", false
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", false
+		}
+	}
+	return code, true
+}
+
+// Запрос к Aviation Weather Center: METAR или TAF в JSON
+func fetchAviation(product, icao string, result interface{}) error {
+	params := url.Values{}
+	params.Set("ids", icao)
+	params.Set("format", "json")
+	resp, err := aviationClient.Get(aviationWeatherURL + "/" + product + "?" + params.Encode())
+	if err != nil {
+		return fmt.Errorf("ошибка запроса %s: %v", strings.ToUpper(product), err)
+	}
+	defer resp.Body.Close()
+
+	// На неизвестный код API отвечает 204 без тела
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ошибка получения %s: статус %d", strings.ToUpper(product), resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("ошибка парсинга %s: %v", strings.ToUpper(product), err)
+	}
+	return nil
+}
+
+// Свежие METAR и TAF аэропорта. TAF есть не у всех аэропортов, тогда nil
+func fetchAirportWeather(icao string) (*metarReport, *tafReport, error) {
+	aviationCacheMu.Lock()
+	cached, ok := aviationCache[icao]
+	aviationCacheMu.Unlock()
+	if ok && clockNow().Sub(cached.fetched) < metarCacheTTL {
+		return cached.metar, cached.taf, nil
+	}
+
+	var metars []metarReport
+	if err := fetchAviation("metar", icao, &metars); err != nil {
+		return nil, nil, err
+	}
+	if len(metars) == 0 {
+		return nil, nil, fmt.Errorf("нет METAR для аэропорта %s — проверьте код ICAO", icao)
+	}
+
+	var tafs []tafReport
+	if err := fetchAviation("taf", icao, &tafs); err != nil {
+		return nil, nil, err
+	}
+	var taf *tafReport
+	if len(tafs) > 0 {
+		taf = &tafs[0]
+	}
+
+	item := aviationCacheItem{metar: &metars[0], taf: taf, fetched: clockNow()}
+	aviationCacheMu.Lock()
+	aviationCache[icao] = item
+	aviationCacheMu.Unlock()
+	return item.metar, item.taf, nil
+}
+
+// Явление погоды и род слова: он нужен, чтобы согласовать интенсивность
+// и дескриптор — "слабая морось", "ливневый снег"
+type aviationPhenomenon struct {
+	word   string
+	gender string // "m", "f" или "pl"
+}
+
+// Расшифровка кодов явлений погоды
+var aviationWeatherCodes = map[string]aviationPhenomenon{
+	"TS": {"гроза", "f"}, "RA": {"дождь", "m"}, "DZ": {"морось", "f"}, "SN": {"снег", "m"},
+	"SG": {"снежные зерна", "pl"}, "PL": {"ледяная крупа", "f"}, "GR": {"град", "m"}, "GS": {"мелкий град", "m"},
+	"UP": {"осадки", "pl"}, "BR": {"дымка", "f"}, "FG": {"туман", "m"}, "HZ": {"мгла", "f"},
+	"FU": {"дым", "m"}, "DU": {"пыль", "f"}, "SA": {"песок", "m"}, "SQ": {"шквал", "m"},
+	"FC": {"смерч", "m"}, "SS": {"песчаная буря", "f"}, "DS": {"пыльная буря", "f"}, "VA": {"вулканический пепел", "m"},
+	// Группы, у которых есть свое название
+	"BLSN": {"низовая метель", "f"}, "DRSN": {"поземок", "m"}, "SHRA": {"ливень", "m"}, "VCSH": {"ливни поблизости", "pl"},
+	"TSRA": {"гроза с дождем", "f"}, "TSSN": {"гроза со снегом", "f"}, "TSGR": {"гроза с градом", "f"},
+}
+
+// Дескрипторы-прилагательные: основа, окончание зависит от рода
+var aviationWeatherAdjectives = map[string]string{
+	"SH": "ливнев", "FZ": "переохлажденн", "MI": "поземн", "PR": "частичн",
+}
+
+// Дескрипторы, которые не согласуются: пишутся после явления
+var aviationWeatherAdverbs = map[string]string{
+	"BC": "местами", "VC": "поблизости", "BL": "переносится ветром", "DR": "переносится ветром у земли",
+}
+
+// Прилагательное в нужном роде: основа "слаб" → "слабый", "слабая", "слабые"
+func adjectiveForm(stem, gender string) string {
+	switch gender {
+	case "f":
+		return stem + "ая"
+	case "pl":
+		return stem + "ые"
+	}
+	return stem + "ый"
+}
+
+// Явления погоды словами: "-SHSN BR" → "слабый ливневый снег, дымка"
+func decodeAviationWeather(codes string) string {
+	var parts []string
+	for _, group := range strings.Fields(codes) {
+		var stems, words, adverbs []string
+		switch {
+		case strings.HasPrefix(group, "-"):
+			stems = append(stems, "слаб")
+			group = group[1:]
+		case strings.HasPrefix(group, "+"):
+			stems = append(stems, "сильн")
+			group = group[1:]
+		}
+
+		gender := "m"
+		if phenomenon, ok := aviationWeatherCodes[group]; ok {
+			words, gender = append(words, phenomenon.word), phenomenon.gender
+			group = ""
+		}
+		for len(group) >= 2 {
+			code := group[:2]
+			group = group[2:]
+			if stem, ok := aviationWeatherAdjectives[code]; ok {
+				stems = append(stems, stem)
+			} else if adverb, ok := aviationWeatherAdverbs[code]; ok {
+				adverbs = append(adverbs, adverb)
+			} else if phenomenon, ok := aviationWeatherCodes[code]; ok {
+				words, gender = append(words, phenomenon.word), phenomenon.gender
+			} else {
+				words = append(words, code)
+			}
+		}
+
+		var phrase []string
+		for _, stem := range stems {
+			phrase = append(phrase, adjectiveForm(stem, gender))
+		}
+		phrase = append(phrase, words...)
+		if len(adverbs) > 0 {
+			phrase = append(phrase, "("+strings.Join(adverbs, ", ")+")")
+		}
+		parts = append(parts, strings.Join(phrase, " "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Покрытие облаков словами
+var aviationCloudCovers = map[string]string{
+	"FEW": "небольшая облачность",
+	"SCT": "рассеянная облачность",
+	"BKN": "значительная облачность",
+	"OVC": "сплошная облачность",
+	"VV":  "вертикальная видимость",
+}
+
+// Облака словами: "значительная облачность 370 м, сплошная облачность 900 м"
+func decodeAviationClouds(clouds []aviationCloud) string {
+	var parts []string
+	for _, cloud := range clouds {
+		switch cloud.Cover {
+		case "CAVOK":
+			return "облаков ниже 1500 м нет, видимость хорошая"
+		case "CLR", "SKC", "NSC", "NCD":
+			return "без существенной облачности"
+		}
+		title, ok := aviationCloudCovers[cloud.Cover]
+		if !ok {
+			title = cloud.Cover
+		}
+		if cloud.Base != nil {
+			title += fmt.Sprintf(" %.0f м", float64(*cloud.Base)*0.3048)
+		}
+		parts = append(parts, title)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Ветер словами: направление в градусах, скорость из узлов в м/с
+func decodeAviationWind(dir aviationValue, speed, gust *float64) string {
+	if speed == nil {
+		return ""
+	}
+	if *speed == 0 {
+		return "штиль"
+	}
+	wind := fmt.Sprintf("%.0f м/с", *speed*knotsToMS)
+	switch {
+	case dir == "VRB":
+		wind = "переменный " + wind
+	case dir != "":
+		if degrees, err := strconv.Atoi(string(dir)); err == nil {
+			wind = fmt.Sprintf("%s (%d°) %s", windDirection(degrees, langRU), degrees, wind)
+		}
+	}
+	if gust != nil && *gust > 0 {
+		wind += fmt.Sprintf(", порывы до %.0f м/с", *gust*knotsToMS)
+	}
+	return wind
+}
+
+// Видимость из уставных миль в километры; "6+" значит 10 км и больше
+func decodeAviationVisibility(visib aviationValue) string {
+	if strings.HasSuffix(string(visib), "+") {
+		return "10 км и больше"
+	}
+	miles, err := strconv.ParseFloat(string(visib), 64)
+	if err != nil {
+		return ""
+	}
+	km := miles * 1.609
+	if km < 1 {
+		return fmt.Sprintf("%.0f м", km*1000)
+	}
+	return fmt.Sprintf("%.1f км", km)
+}
+
+// Узлы в метры в секунду
+const knotsToMS = 0.514444
+
+// Категория условий для полетов по правилам
+var flightCategories = map[string]string{
+	"VFR":  "🟢 VFR — визуальные полеты",
+	"MVFR": "🔵 MVFR — ограниченные визуальные полеты",
+	"IFR":  "🔴 IFR — полеты по приборам",
+	"LIFR": "🟣 LIFR — сложные условия, низкая облачность или плохая видимость",
+}
+
+// Функция для получения METAR и TAF аэропорта по коду ICAO
+func getAirportWeather(code string) (string, error) {
+	icao, ok := normalizeICAO(code)
+	if !ok {
+		return "", fmt.Errorf("неверный код ICAO «%s»: нужны 4 латинские буквы, например UUEE", code)
+	}
+	metar, taf, err := fetchAirportWeather(icao)
+	if err != nil {
+		return "", err
+	}
+	return formatAirportWeather(metar, taf), nil
+}
+
+func formatAirportWeather(metar *metarReport, taf *tafReport) string {
+	title := metar.ICAO
+	if metar.Name != "" {
+		title += " — " + metar.Name
+	}
+	text := fmt.Sprintf("✈️ METAR %s, %s UTC:\n%s\n\n", title, time.Unix(metar.ObsTime, 0).UTC().Format("02.01 15:04"), metar.Raw)
+
+	if metar.Temp != nil {
+		line := fmt.Sprintf("🌡 %.0f°C", *metar.Temp)
+		if metar.Dewpoint != nil {
+			line += fmt.Sprintf(", точка росы %.0f°C", *metar.Dewpoint)
+		}
+		text += line + "\n"
+	}
+	if wind := decodeAviationWind(metar.WindDir, metar.WindSpeed, metar.WindGust); wind != "" {
+		text += "🌬 Ветер " + wind + "\n"
+	}
+	if visibility := decodeAviationVisibility(metar.Visib); visibility != "" {
+		text += "👁 Видимость " + visibility + "\n"
+	}
+	if metar.Weather != "" {
+		text += "🌧 " + decodeAviationWeather(metar.Weather) + "\n"
+	}
+	if clouds := decodeAviationClouds(metar.Clouds); clouds != "" {
+		text += "☁️ " + clouds + "\n"
+	}
+	if metar.Altimeter != nil {
+		text += fmt.Sprintf("🧭 Давление %s\n", formatPressure(*metar.Altimeter, langRU))
+	}
+	if category, ok := flightCategories[metar.Category]; ok {
+		text += category + "\n"
+	}
+
+	if taf == nil {
+		return text + "\n📋 TAF для этого аэропорта не выпускается."
+	}
+
+	text += fmt.Sprintf("\n📋 TAF:\n%s\n\n", taf.Raw)
+	for _, period := range taf.Forecast {
+		text += formatTAFPeriod(period) + "\n"
+	}
+	return strings.TrimSpace(text)
+}
+
+// Период TAF одной строкой: "⏰ TEMPO 16.10 12:00–15:00: ветер 5 м/с, слабый снег"
+func formatTAFPeriod(period tafPeriod) string {
+	label := period.Change
+	if period.Prob != nil {
+		label = strings.TrimSpace(fmt.Sprintf("%s вероятность %d%%", label, *period.Prob))
+	}
+	from, to := time.Unix(period.TimeFrom, 0).UTC(), time.Unix(period.TimeTo, 0).UTC()
+	line := "⏰ "
+	if label != "" {
+		line += label + " "
+	}
+	line += from.Format("02.01 15:04") + "–"
+	if to.Format("02.01") != from.Format("02.01") {
+		line += to.Format("02.01 ")
+	}
+	line += to.Format("15:04")
+
+	var details []string
+	if wind := decodeAviationWind(period.WindDir, period.WindSpeed, period.WindGust); wind != "" {
+		details = append(details, "ветер "+wind)
+	}
+	if visibility := decodeAviationVisibility(period.Visib); visibility != "" {
+		details = append(details, "видимость "+visibility)
+	}
+	if period.Weather != "" {
+		details = append(details, decodeAviationWeather(period.Weather))
+	}
+	if clouds := decodeAviationClouds(period.Clouds); clouds != "" {
+		details = append(details, clouds)
+	}
+	if len(details) == 0 {
+		return line
+	}
+	return line + ": " + strings.Join(details, "; ")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const metarFixture = `[{"icaoId": "UUEE", "name": "Moscow/Sheremetyevo Intl, MO, RU", "obsTime": 1792146600,
+	"temp": -3, "dewp": -5, "wdir": 240, "wspd": 10, "wgst": 20, "visib": "6+", "altim": 1012,
+	"wxString": "-SHSN BR", "clouds": [{"cover": "BKN", "base": 1200}, {"cover": "OVC", "base": 3000}],
+	"fltCat": "MVFR", "rawOb": "UUEE 161030Z 24005G10MPS 9999 -SHSN BR BKN012 OVC030 M03/M05 Q1012 NOSIG"}]`
+
+const tafFixture = `[{"icaoId": "UUEE", "rawTAF": "TAF UUEE 161000Z 1612/1718 24005MPS 9999 BKN015 TEMPO 1612/1618 2000 SHSN BKN008CB",
+	"fcsts": [
+		{"timeFrom": 1792152000, "timeTo": 1792245600, "fcstChange": null, "wdir": 240, "wspd": 10, "visib": "6+", "clouds": [{"cover": "BKN", "base": 1500}]},
+		{"timeFrom": 1792152000, "timeTo": 1792173600, "fcstChange": "TEMPO", "wdir": "VRB", "wspd": 4, "visib": 1.24, "wxString": "+TSRA", "clouds": [{"cover": "BKN", "base": 800}]}
+	]}]`
+
+func TestDecodeAviationWeather(t *testing.T) {
+	for codes, want := range map[string]string{
+		"-SHSN BR": "слабый ливневый снег, дымка",
+		"+TSRA":    "сильная гроза с дождем",
+		"FZDZ":     "переохлажденная морось",
+		"BCFG":     "туман (местами)",
+		"-SG":      "слабые снежные зерна",
+	} {
+		if got := decodeAviationWeather(codes); got != want {
+			t.Errorf("decodeAviationWeather(%q) = %q, ожидалось %q", codes, got, want)
+		}
+	}
+}
+
+func TestNormalizeICAO(t *testing.T) {
+	if code, ok := normalizeICAO(" uuee "); !ok || code != "UUEE" {
+		t.Errorf("normalizeICAO = %q, %v", code, ok)
+	}
+	for _, code := range []string{"UUE", "УУЕЕ", "1UEE", "UUEE1"} {
+		if _, ok := normalizeICAO(code); ok {
+			t.Errorf("normalizeICAO(%q) принят", code)
+		}
+	}
+}
+
+func TestPipelineMetar(t *testing.T) {
+	f := newFakeTelegram(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ids") != "UUEE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		switch r.URL.Path {
+		case "/metar":
+			w.Write([]byte(metarFixture))
+		case "/taf":
+			w.Write([]byte(tafFixture))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	previousURL := aviationWeatherURL
+	aviationWeatherURL = server.URL
+	t.Cleanup(func() { aviationWeatherURL = previousURL })
+	aviationCacheMu.Lock()
+	aviationCache = make(map[string]aviationCacheItem)
+	aviationCacheMu.Unlock()
+
+	const chatID = 4231
+	f.send(textUpdate(chatID, "/metar uuee"))
+	reply := f.reply(t, chatID)
+	for _, want := range []string{
+		"✈️ METAR UUEE — Moscow/Sheremetyevo Intl, MO, RU, 16.10 10:30 UTC",
+		"UUEE 161030Z 24005G10MPS",
+		"🌬 Ветер ЮЗ (240°) 5 м/с, порывы до 10 м/с",
+		"👁 Видимость 10 км и больше",
+		"🌧 слабый ливневый снег, дымка",
+		"☁️ значительная облачность 366 м, сплошная облачность 914 м",
+		"🔵 MVFR",
+		"📋 TAF:\nTAF UUEE 161000Z",
+		"⏰ 16.10 12:00–17.10 14:00: ветер ЮЗ (240°) 5 м/с",
+		"⏰ TEMPO 16.10 12:00–18:00: ветер переменный 2 м/с; видимость 2.0 км; сильная гроза с дождем",
+	} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("в ответе %q нет %q", reply, want)
+		}
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/taf ZZZZ"))
+	if got := f.reply(t, chatID); !strings.Contains(got, "нет METAR для аэропорта ZZZZ") {
+		t.Errorf("ответ на неизвестный код %q", got)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Обработчик обновления от Telegram
+type updateHandler func(bot *Bot, update tgbotapi.Update)
+
+// Промежуточный обработчик: делает свою часть работы и вызывает следующий
+type middleware func(next updateHandler) updateHandler
+
+// Сборка цепочки: первый промежуточный обработчик в списке вызывается первым
+func chainMiddleware(handler updateHandler, middlewares ...middleware) updateHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Вид обновления для логов и статистики
+func updateKind(update tgbotapi.Update) string {
+	switch {
+	case update.PreCheckoutQuery != nil:
+		return "pre_checkout"
+	case update.Message != nil && update.Message.SuccessfulPayment != nil:
+		return "payment"
+	case update.Message != nil && update.Message.IsCommand():
+		return "command"
+	case update.Message != nil && update.Message.Location != nil:
+		return "location"
+	case update.Message != nil && update.Message.Voice != nil:
+		return "voice"
+	case update.Message != nil:
+		return "message"
+	case update.EditedMessage != nil:
+		return "edited"
+	case update.InlineQuery != nil:
+		return "inline"
+	case update.CallbackQuery != nil:
+		return "callback"
+	default:
+		return "other"
+	}
+}
+
+// Пользователь, от которого пришло обновление (0, если неизвестен).
+// В личном чате его ID совпадает с ID чата
+func updateUserID(update tgbotapi.Update) int64 {
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// Логирование каждого обновления с временем обработки
+func withLogging(next updateHandler) updateHandler {
+	return func(bot *Bot, update tgbotapi.Update) {
+		start := time.Now()
+		next(bot, update)
+		log.Printf("Обновление %d (%s) от %d обработано за %s",
+			update.UpdateID, updateKind(update), updateUserID(update), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// Паника в обработчике не должна останавливать бота
+func withRecovery(next updateHandler) updateHandler {
+	return func(bot *Bot, update tgbotapi.Update) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			stack := debug.Stack()
+			log.Printf("Паника при обработке обновления %d: %v\n%s", update.UpdateID, r, stack)
+			reportError(errorLevelFatal, fmt.Sprintf("паника: %v", r), &update, string(stack))
+			botMetrics.add(metricPanics)
+
+			if update.Message != nil {
+				reply := tgbotapi.NewMessage(update.Message.Chat.ID, "❌ Что-то пошло не так, попробуйте еще раз.")
+				if _, err := bot.Send(reply); err != nil {
+					log.Printf("Ошибка отправки сообщения: %v", err)
+				}
+			}
+		}()
+		next(bot, update)
+	}
+}
+
+// Ограничение частоты запросов: не больше rateLimitBurst обновлений подряд,
+// дальше по одному в rateLimitInterval
+const (
+	rateLimitBurst    = 10
+	rateLimitInterval = 3 * time.Second
+)
+
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+	warned  bool
+}
+
+type rateLimiter struct {
+	buckets map[int64]*rateBucket
+	mu      sync.Mutex
+}
+
+var limiter = &rateLimiter{buckets: make(map[int64]*rateBucket)}
+
+// Можно ли обработать еще одно обновление от пользователя. Второе значение
+// сообщает, что пользователя нужно предупредить (один раз за период ограничения)
+func (l *rateLimiter) Allow(userID int64, now time.Time) (bool, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Полные корзины ничем не отличаются от отсутствующих, поэтому их удаляем
+	for id, b := range l.buckets {
+		if now.Sub(b.updated) > rateLimitBurst*rateLimitInterval {
+			delete(l.buckets, id)
+		}
+	}
+
+	b, exists := l.buckets[userID]
+	if !exists {
+		b = &rateBucket{tokens: rateLimitBurst, updated: now}
+		l.buckets[userID] = b
+	}
+	b.tokens += float64(now.Sub(b.updated)) / float64(rateLimitInterval)
+	if b.tokens > rateLimitBurst {
+		b.tokens = rateLimitBurst
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		warn := !b.warned
+		b.warned = true
+		return false, warn
+	}
+	b.tokens--
+	b.warned = false
+	return true, false
+}
+
+// Ограничиваем сообщения, нажатия кнопок и инлайн-запросы. Платежи и
+// обновления трансляции геопозиции пропускаем всегда
+func withRateLimit(next updateHandler) updateHandler {
+	return func(bot *Bot, update tgbotapi.Update) {
+		userID := updateUserID(update)
+		limited := update.InlineQuery != nil || update.CallbackQuery != nil ||
+			(update.Message != nil && update.Message.SuccessfulPayment == nil)
+		if !limited || userID == 0 || isAdmin(userID) {
+			next(bot, update)
+			return
+		}
+
+		allowed, warn := limiter.Allow(userID, time.Now())
+		if allowed {
+			next(bot, update)
+			return
+		}
+		botMetrics.add(metricRateLimited)
+
+		const text = "⏳ Слишком много запросов, подождите немного."
+		switch {
+		case update.CallbackQuery != nil:
+			if _, err := bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, text)); err != nil {
+				log.Printf("Ошибка обработки колбэка: %v", err)
+			}
+		case update.Message != nil && warn:
+			if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, text)); err != nil {
+				log.Printf("Ошибка отправки сообщения: %v", err)
+			}
+		}
+	}
+}
+
+// Обновления от заблокированных пользователей молча отбрасываем
+func withBanCheck(next updateHandler) updateHandler {
+	return func(bot *Bot, update tgbotapi.Update) {
+		if userID := updateUserID(update); userID != 0 && bot.store.IsBanned(userID) {
+			botMetrics.add(metricBanned)
+			return
+		}
+		next(bot, update)
+	}
+}
+
+// Счетчики обработанных обновлений и время обработки по видам
+const (
+	metricPanics      = "panics"
+	metricRateLimited = "rate_limited"
+	metricBanned      = "banned"
+)
+
+type metrics struct {
+	counters  map[string]int
+	durations map[string]time.Duration
+	mu        sync.Mutex
+}
+
+var botMetrics = &metrics{
+	counters:  make(map[string]int),
+	durations: make(map[string]time.Duration),
+}
+
+func (m *metrics) add(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[name]++
+}
+
+func (m *metrics) observe(kind string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters["updates_"+kind]++
+	m.durations[kind] += duration
+}
+
+func withMetrics(next updateHandler) updateHandler {
+	return func(bot *Bot, update tgbotapi.Update) {
+		start := time.Now()
+		defer func() {
+			botMetrics.observe(updateKind(update), time.Since(start))
+		}()
+		next(bot, update)
+	}
+}
+
+// Отчет для администраторов (/stats)
+func (m *metrics) Report() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kinds := make([]string, 0, len(m.durations))
+	for kind := range m.durations {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var report strings.Builder
+	report.WriteString("📈 Статистика с момента запуска:\n")
+	if len(kinds) == 0 {
+		report.WriteString("Обновлений еще не было\n")
+	}
+	for _, kind := range kinds {
+		count := m.counters["updates_"+kind]
+		average := m.durations[kind] / time.Duration(count)
+		fmt.Fprintf(&report, "• %s: %d (в среднем %s)\n", kind, count, average.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&report, "\nОграничено по частоте: %d\nОт заблокированных: %d\nПаник: %d",
+		m.counters[metricRateLimited], m.counters[metricBanned], m.counters[metricPanics])
+	return report.String()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Миграция файла состояния. Работает с сырым JSON верхнего уровня, поэтому
+// может переименовывать и перекладывать поля до разбора в storeData
+type stateMigration struct {
+	version     int
+	description string
+	apply       func(state map[string]json.RawMessage) error
+}
+
+// Миграции по возрастанию версий. Новую миграцию добавляем в конец
+// со следующим номером, уже выпущенные не меняем
+var stateMigrations = []stateMigration{
+	{
+		version:     1,
+		description: "номер версии в файле состояния",
+		apply:       func(state map[string]json.RawMessage) error { return nil },
+	},
+}
+
+// Версия формата, которую понимает эта сборка
+func stateSchemaVersion() int {
+	return stateMigrations[len(stateMigrations)-1].version
+}
+
+// Применение недостающих миграций. Возвращает обновленный JSON и версию,
+// с которой начали. Файл от более новой сборки не трогаем, чтобы не
+// потерять незнакомые поля
+func migrateState(raw []byte, migrations []stateMigration) ([]byte, int, error) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, 0, fmt.Errorf("ошибка парсинга файла состояния: %v", err)
+	}
+
+	from := 0
+	if version, ok := state["schema_version"]; ok {
+		if err := json.Unmarshal(version, &from); err != nil {
+			return nil, 0, fmt.Errorf("ошибка парсинга версии файла состояния: %v", err)
+		}
+	}
+	latest := migrations[len(migrations)-1].version
+	if from > latest {
+		return nil, from, fmt.Errorf("файл состояния версии %d создан более новой сборкой бота (эта понимает до %d)", from, latest)
+	}
+	if from == latest {
+		return raw, from, nil
+	}
+
+	for _, m := range migrations {
+		if m.version <= from {
+			continue
+		}
+		if err := m.apply(state); err != nil {
+			return nil, from, fmt.Errorf("ошибка миграции %d (%s): %v", m.version, m.description, err)
+		}
+		state["schema_version"], _ = json.Marshal(m.version)
+		log.Printf("Файл состояния: применена миграция %d (%s)", m.version, m.description)
+	}
+
+	migrated, err := json.Marshal(state)
+	if err != nil {
+		return nil, from, fmt.Errorf("ошибка сериализации состояния: %v", err)
+	}
+	return migrated, from, nil
+}
+
+// Копия файла перед миграцией, чтобы можно было откатиться на старую сборку
+func backupStateFile(path string, raw []byte, version int) error {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backup, raw, 0o600); err != nil {
+		return fmt.Errorf("ошибка резервного копирования файла состояния: %v", err)
+	}
+	return nil
+}
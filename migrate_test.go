@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateState(t *testing.T) {
+	migrations := []stateMigration{
+		{version: 1, description: "базовая", apply: func(state map[string]json.RawMessage) error { return nil }},
+		{version: 2, description: "переименование", apply: func(state map[string]json.RawMessage) error {
+			state["preferences"] = state["prefs"]
+			delete(state, "prefs")
+			return nil
+		}},
+	}
+
+	migrated, from, err := migrateState([]byte(`{"prefs": {"1": {"units": "imperial"}}}`), migrations)
+	if err != nil {
+		t.Fatalf("migrateState: %v", err)
+	}
+	if from != 0 {
+		t.Errorf("исходная версия %d, ожидалась 0", from)
+	}
+	var data storeData
+	if err := json.Unmarshal(migrated, &data); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if data.SchemaVersion != 2 || data.Preferences[1] == nil || data.Preferences[1].Units != unitsImperial {
+		t.Errorf("миграции применены неверно: %s", migrated)
+	}
+
+	// Актуальный файл возвращается без изменений
+	raw := []byte(`{"schema_version": 2}`)
+	if same, _, err := migrateState(raw, migrations); err != nil || string(same) != string(raw) {
+		t.Errorf("актуальный файл изменен: %s, %v", same, err)
+	}
+
+	// Файл от более новой сборки не открываем
+	if _, _, err := migrateState([]byte(`{"schema_version": 3}`), migrations); err == nil || !strings.Contains(err.Error(), "более новой") {
+		t.Errorf("ожидалась ошибка для более новой версии, получено %v", err)
+	}
+}
+
+func TestOpenStoreMigratesLegacyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	legacy := []byte(`{"preferences": {"7": {"home_city": "Омск"}}}`)
+	if err := os.WriteFile(path, legacy, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := openStore(path)
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	if s.Preferences(7).HomeCity != "Омск" {
+		t.Errorf("данные потеряны при миграции")
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil || string(backup) != string(legacy) {
+		t.Errorf("нет резервной копии исходного файла: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), `"schema_version": 1`) {
+		t.Errorf("версия не записана в файл: %s", saved)
+	}
+}
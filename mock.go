@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// Демо-режим (WEATHER_PROVIDER=mock): вместо запросов к OpenWeatherMap
+// выдаем правдоподобные данные, которые зависят только от города и часа.
+// Ключ API не нужен, поэтому бота можно показывать, нагружать и
+// разрабатывать без сети
+func mockWeatherMode() bool {
+	return config().DefaultProvider == providerMock
+}
+
+// Погодные условия демо-режима в нумерации OWM
+var mockConditions = []struct {
+	ID int
+	RU string
+	EN string
+}{
+	{800, "ясно", "clear sky"},
+	{801, "небольшая облачность", "few clouds"},
+	{803, "облачно с прояснениями", "broken clouds"},
+	{804, "пасмурно", "overcast clouds"},
+	{500, "небольшой дождь", "light rain"},
+	{501, "дождь", "moderate rain"},
+	{600, "небольшой снег", "light snow"},
+	{701, "туман", "mist"},
+	{211, "гроза", "thunderstorm"},
+}
+
+// Число, однозначно определяемое строкой
+func mockSeed(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+// Условные координаты города
+func mockCoords(city string) (float64, float64) {
+	seed := mockSeed(city)
+	lat := float64(seed%12000)/100 - 50          // от -50° до 70°
+	lon := float64((seed/12000)%36000)/100 - 180 // от -180° до 180°
+	return math.Round(lat*100) / 100, math.Round(lon*100) / 100
+}
+
+// Часовой пояс по долготе, с точностью до часа
+func mockLocation(lon float64) *time.Location {
+	return time.FixedZone("", int(math.Round(lon/15))*3600)
+}
+
+// Погода в момент t: базовая температура зависит от широты и города,
+// суточный ход — от местного времени, условия меняются раз в 6 часов
+func mockSample(key string, lat float64, t time.Time) ForecastItem {
+	seed := mockSeed(key)
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+
+	base := 25 - math.Abs(lat)*0.5 + float64(seed%10) - 5
+	temp := base + 5*math.Sin((hour-9)*math.Pi/12)
+	wind := 1 + float64(seed%7) + 2*math.Sin(float64(t.Unix()/3600)*0.7)
+
+	condition := mockConditions[(int(seed)+int(t.Unix()/(6*3600)))%len(mockConditions)]
+	if condition.ID/100 == 6 && temp > 2 {
+		condition = mockConditions[4]
+	}
+
+	item := ForecastItem{
+		Time:       t,
+		Temp:       math.Round(temp*10) / 10,
+		FeelsLike:  math.Round((temp-wind*0.7)*10) / 10,
+		Humidity:   50 + int(seed%40),
+		Pressure:   math.Round(1013 + 8*math.Sin(float64(t.Unix()/3600)*0.05+float64(seed%6))),
+		WindSpeed:  math.Round(math.Abs(wind)*10) / 10,
+		WindGust:   math.Round(math.Abs(wind)*16) / 10,
+		Condition:  condition.ID,
+		Daytime:    hour >= 6 && hour < 21,
+		Visibility: 10000,
+	}
+	switch condition.ID / 100 {
+	case 2, 5:
+		item.Rain, item.Pop, item.Clouds = 1.5, 0.8, 90
+	case 6:
+		item.Snow, item.Pop, item.Clouds = 1, 0.7, 90
+	case 7:
+		item.Visibility, item.Clouds = 800, 100
+	case 8:
+		item.Clouds = (condition.ID % 100) * 25
+	}
+	return item
+}
+
+func mockDescription(id int, lang string) string {
+	for _, c := range mockConditions {
+		if c.ID == id {
+			if lang == langEN {
+				return c.EN
+			}
+			return c.RU
+		}
+	}
+	return ""
+}
+
+// Текущая погода демо-режима. Время округляется до 10 минут,
+// чтобы повторные запросы давали одинаковый ответ
+func mockCurrentWeather(city string, lat, lon float64, lang string) *CurrentWeather {
+	location := mockLocation(lon)
+	now := clockNow().Truncate(10 * time.Minute).In(location)
+	sample := mockSample(city, lat, now)
+
+	return &CurrentWeather{
+		City:        city,
+		Lat:         lat,
+		Lon:         lon,
+		Time:        now,
+		Temp:        sample.Temp,
+		FeelsLike:   sample.FeelsLike,
+		Humidity:    sample.Humidity,
+		Pressure:    sample.Pressure,
+		WindSpeed:   sample.WindSpeed,
+		WindGust:    sample.WindGust,
+		WindDeg:     int(mockSeed(city) % 360),
+		Condition:   sample.Condition,
+		Description: mockDescription(sample.Condition, lang),
+		Daytime:     sample.Daytime,
+		Clouds:      sample.Clouds,
+		Rain:        sample.Rain / 3,
+		Snow:        sample.Snow / 3,
+		Visibility:  sample.Visibility,
+		Sunrise:     time.Date(now.Year(), now.Month(), now.Day(), 6, 0, 0, 0, location),
+		Sunset:      time.Date(now.Year(), now.Month(), now.Day(), 21, 0, 0, 0, location),
+	}
+}
+
+// Прогноз демо-режима: 40 интервалов по 3 часа, как у OWM
+func mockForecast(city string, lat, lon float64, lang string) *Forecast {
+	forecast := &Forecast{
+		City:     city,
+		Lat:      lat,
+		Lon:      lon,
+		Location: mockLocation(lon),
+	}
+	start := clockNow().Truncate(forecastStep).Add(forecastStep)
+	for i := 0; i < 40; i++ {
+		item := mockSample(city, lat, start.Add(time.Duration(i)*forecastStep).In(forecast.Location))
+		item.Time = item.Time.UTC()
+		item.Description = mockDescription(item.Condition, lang)
+		forecast.Items = append(forecast.Items, item)
+	}
+	return forecast
+}
+
+// Название точки, для которой известны только координаты
+func mockPlaceName(lat, lon float64) string {
+	return fmt.Sprintf("Демо %.2f, %.2f", lat, lon)
+}
+
+// Прогноз качества воздуха демо-режима: почасовой на 4 суток
+func mockAirPollution(lat, lon float64) *AirPollutionResponse {
+	var data AirPollutionResponse
+	seed := mockSeed(mockPlaceName(lat, lon))
+	start := clockNow().Truncate(time.Hour)
+	for i := 0; i < 96; i++ {
+		item := airPollutionItem{Dt: start.Add(time.Duration(i) * time.Hour).Unix()}
+		item.Main.AQI = 1 + int((seed+uint32(item.Dt/(6*3600)))%3)
+		item.Components.PM25 = float64(item.Main.AQI) * 8
+		item.Components.PM10 = float64(item.Main.AQI) * 15
+		item.Components.CO = 200 + float64(seed%100)
+		data.List = append(data.List, item)
+	}
+	return &data
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Средняя продолжительность синодического месяца, дней
+const synodicMonth = 29.530588853
+
+// Опорное новолуние: 6 января 2000, 18:14 UTC
+var referenceNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// Фаза Луны от 0 до 1: 0 - новолуние, 0.5 - полнолуние
+func moonPhase(t time.Time) float64 {
+	days := t.Sub(referenceNewMoon).Hours() / 24
+	phase := math.Mod(days/synodicMonth, 1)
+	if phase < 0 {
+		phase++
+	}
+	return phase
+}
+
+// Название фазы Луны и значок
+func moonPhaseName(phase float64) string {
+	switch {
+	case phase < 0.03 || phase >= 0.97:
+		return "🌑 новолуние"
+	case phase < 0.22:
+		return "🌒 растущий серп"
+	case phase < 0.28:
+		return "🌓 первая четверть"
+	case phase < 0.47:
+		return "🌔 растущая Луна"
+	case phase < 0.53:
+		return "🌕 полнолуние"
+	case phase < 0.72:
+		return "🌖 убывающая Луна"
+	case phase < 0.78:
+		return "🌗 последняя четверть"
+	default:
+		return "🌘 убывающий серп"
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Публикация погоды в MQTT для Home Assistant, Node-RED и т. п.
+// Нужна только публикация с QoS 0, поэтому вместо сторонней библиотеки
+// небольшой клиент MQTT 3.1.1: соединение на пакет сообщений,
+// CONNECT, PUBLISH..., DISCONNECT
+
+const mqttTimeout = 10 * time.Second
+
+// Коды отказа в CONNACK
+var mqttConnectErrors = map[byte]string{
+	1: "неподдерживаемая версия протокола",
+	2: "идентификатор клиента отклонен",
+	3: "сервер недоступен",
+	4: "неверный логин или пароль",
+	5: "нет прав на подключение",
+}
+
+// Сообщение MQTT. Retained-сообщения брокер отдает новым подписчикам сразу
+type mqttMessage struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Схемы адреса брокера и порты по умолчанию
+var mqttSchemes = map[string]string{"mqtt": "1883", "tcp": "1883", "mqtts": "8883", "ssl": "8883"}
+
+func parseMQTTURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Hostname() == "" || mqttSchemes[parsed.Scheme] == "" {
+		return nil, fmt.Errorf("ожидается адрес вида mqtt://хост:1883 или mqtts://логин:пароль@хост:8883, получено %q", value)
+	}
+	return parsed, nil
+}
+
+// Длина оставшейся части пакета: по 7 бит в байте, старший бит — продолжение
+func mqttRemainingLength(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			return out
+		}
+	}
+}
+
+// Строка MQTT: длина в двух байтах и UTF-8
+func mqttString(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
+
+func mqttPacket(header byte, body []byte) []byte {
+	packet := append([]byte{header}, mqttRemainingLength(len(body))...)
+	return append(packet, body...)
+}
+
+func mqttConnectPacket(clientID string, user *url.Userinfo) []byte {
+	// Чистая сессия, keep alive 60 секунд (соединение все равно короткое)
+	flags := byte(0x02)
+	payload := mqttString(clientID)
+	if user != nil {
+		flags |= 0x80
+		payload = append(payload, mqttString(user.Username())...)
+		if password, ok := user.Password(); ok {
+			flags |= 0x40
+			payload = append(payload, mqttString(password)...)
+		}
+	}
+	body := append(mqttString("MQTT"), 4, flags, 0, 60)
+	return mqttPacket(0x10, append(body, payload...))
+}
+
+func mqttPublishPacket(message mqttMessage) []byte {
+	header := byte(0x30)
+	if message.Retain {
+		header |= 0x01
+	}
+	return mqttPacket(header, append(mqttString(message.Topic), message.Payload...))
+}
+
+// Отправка сообщений брокеру в одном соединении
+func mqttPublish(brokerURL, clientID string, messages []mqttMessage) error {
+	broker, err := parseMQTTURL(brokerURL)
+	if err != nil {
+		return err
+	}
+	addr := broker.Host
+	if broker.Port() == "" {
+		addr = net.JoinHostPort(broker.Hostname(), mqttSchemes[broker.Scheme])
+	}
+
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	var conn net.Conn
+	if broker.Scheme == "mqtts" || broker.Scheme == "ssl" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: broker.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к MQTT: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(mqttTimeout))
+
+	if _, err := conn.Write(mqttConnectPacket(clientID, broker.User)); err != nil {
+		return fmt.Errorf("ошибка подключения к MQTT: %v", err)
+	}
+	connack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		return fmt.Errorf("ошибка подключения к MQTT: %v", err)
+	}
+	if connack[0] != 0x20 {
+		return fmt.Errorf("ошибка подключения к MQTT: неожиданный ответ 0x%02x", connack[0])
+	}
+	if code := connack[3]; code != 0 {
+		reason, ok := mqttConnectErrors[code]
+		if !ok {
+			reason = "код " + strconv.Itoa(int(code))
+		}
+		return fmt.Errorf("брокер MQTT отказал в подключении: %s", reason)
+	}
+
+	for _, message := range messages {
+		if _, err := conn.Write(mqttPublishPacket(message)); err != nil {
+			return fmt.Errorf("ошибка публикации в MQTT: %v", err)
+		}
+	}
+	conn.Write([]byte{0xE0, 0x00})
+	return nil
+}
+
+// Часть топика для города: без символов, которые в MQTT значат подстановку
+// или уровень, в нижнем регистре и с "_" вместо пробелов
+func mqttTopicCity(city string) string {
+	city = strings.NewReplacer("+", "", "#", "", "/", "", "$", "").Replace(strings.ToLower(strings.TrimSpace(city)))
+	return strings.Join(strings.Fields(city), "_")
+}
+
+// Сообщения с текущей погодой: по значению на топик и весь объект в .../json
+func mqttWeatherMessages(prefix, city string, data *CurrentWeather) []mqttMessage {
+	base := prefix + "/" + mqttTopicCity(city) + "/"
+	value := func(v float64) []byte { return []byte(strconv.FormatFloat(v, 'f', -1, 64)) }
+
+	messages := []mqttMessage{
+		{Topic: base + "temp", Payload: value(data.Temp)},
+		{Topic: base + "feels_like", Payload: value(data.FeelsLike)},
+		{Topic: base + "humidity", Payload: []byte(strconv.Itoa(data.Humidity))},
+		{Topic: base + "pressure", Payload: value(data.Pressure)},
+		{Topic: base + "wind_speed", Payload: value(data.WindSpeed)},
+		{Topic: base + "wind_gust", Payload: value(data.WindGust)},
+		{Topic: base + "clouds", Payload: []byte(strconv.Itoa(data.Clouds))},
+		{Topic: base + "condition", Payload: []byte(strconv.Itoa(data.Condition))},
+		{Topic: base + "description", Payload: []byte(data.Description)},
+	}
+	if payload, err := json.Marshal(newAPIWeather(data)); err == nil {
+		messages = append(messages, mqttMessage{Topic: base + "json", Payload: payload})
+	}
+	for i := range messages {
+		messages[i].Retain = true
+	}
+	return messages
+}
+
+func mqttEnabled() bool {
+	return config().MQTTURL != ""
+}
+
+// Идентификатор клиента: у каждого процесса свой, чтобы две копии бота
+// не выбивали друг друга с брокера
+var mqttClientID = sync.OnceValue(func() string {
+	return fmt.Sprintf("donedron_bot-%d", time.Now().UnixNano()%1000000)
+})
+
+// Публикация текущей погоды во всех городах MQTT_CITIES
+func publishMQTTWeather() error {
+	c := config()
+	var messages []mqttMessage
+	for _, city := range c.MQTTCities {
+		data, err := cachedWeather(city, langRU)
+		if err != nil {
+			log.Printf("Ошибка получения погоды для MQTT (%s): %v", city, err)
+			continue
+		}
+		messages = append(messages, mqttWeatherMessages(c.MQTTTopicPrefix, city, data)...)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return mqttPublish(c.MQTTURL, mqttClientID(), messages)
+}
+
+// Когда оповещение каждого типа последний раз публиковалось в топик города.
+// Одно и то же оповещение срабатывает у всех подписчиков города, а в MQTT
+// его достаточно отправить один раз
+var (
+	mqttAlertsSent   = make(map[string]time.Time)
+	mqttAlertsSentMu sync.Mutex
+)
+
+// Утренняя сводка приходит подписчикам раз в сутки, но в разное время,
+// а в топик города ее публикуем один раз
+const mqttDailyCooldown = 20 * time.Hour
+
+// Интервал между одинаковыми оповещениями в топике города
+func mqttAlertCooldown(kind string) time.Duration {
+	if kind == alertDaily {
+		return mqttDailyCooldown
+	}
+	return alertKinds[kind].cooldown
+}
+
+// Оповещение в топике города: тип и погода в городе. Текст сообщения
+// подписчика не публикуется — в нем его личные блоки сводки, советы и единицы
+type mqttAlert struct {
+	Kind    string     `json:"kind"`
+	Title   string     `json:"title"`
+	City    string     `json:"city"`
+	Time    string     `json:"time"`
+	Weather apiWeather `json:"weather"`
+}
+
+func mqttAlertMessage(prefix, city, kind string, data *CurrentWeather, now time.Time) (mqttMessage, error) {
+	payload, err := json.Marshal(mqttAlert{
+		Kind:    kind,
+		Title:   alertKinds[kind].title,
+		City:    city,
+		Time:    now.Format(time.RFC3339),
+		Weather: newAPIWeather(data),
+	})
+	if err != nil {
+		return mqttMessage{}, fmt.Errorf("ошибка сериализации оповещения: %v", err)
+	}
+	return mqttMessage{Topic: prefix + "/" + mqttTopicCity(city) + "/alert", Payload: payload}, nil
+}
+
+// Оповещение в .../alert, если город подписки есть в MQTT_CITIES
+func publishMQTTAlert(sub AlertSubscription) {
+	c := config()
+	if c.MQTTURL == "" {
+		return
+	}
+
+	for _, city := range c.MQTTCities {
+		if mqttTopicCity(city) != mqttTopicCity(sub.City) {
+			continue
+		}
+
+		key := mqttTopicCity(city) + ":" + sub.Kind
+		mqttAlertsSentMu.Lock()
+		last, sent := mqttAlertsSent[key]
+		if sent && clockNow().Sub(last) < mqttAlertCooldown(sub.Kind) {
+			mqttAlertsSentMu.Unlock()
+			return
+		}
+		mqttAlertsSent[key] = clockNow()
+		mqttAlertsSentMu.Unlock()
+
+		data, err := cachedWeather(city, langRU)
+		if err != nil {
+			log.Printf("Ошибка получения погоды для MQTT (%s): %v", city, err)
+			return
+		}
+		message, err := mqttAlertMessage(c.MQTTTopicPrefix, city, sub.Kind, data, clockNow())
+		if err != nil {
+			log.Printf("Ошибка публикации оповещения в MQTT: %v", err)
+			return
+		}
+		if err := mqttPublish(c.MQTTURL, mqttClientID(), []mqttMessage{message}); err != nil {
+			log.Printf("Ошибка публикации оповещения в MQTT: %v", err)
+		}
+		return
+	}
+}
+
+// Фоновая публикация погоды в MQTT
+func runMQTTPublisher() {
+	ticker := time.NewTicker(config().MQTTInterval)
+	defer ticker.Stop()
+
+	for {
+		if mqttEnabled() {
+			if err := publishMQTTWeather(); err != nil {
+				log.Printf("Ошибка публикации погоды в MQTT: %v", err)
+			}
+		}
+		<-ticker.C
+	}
+}
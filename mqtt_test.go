@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// Поддельный брокер MQTT: принимает одно соединение, отвечает на CONNECT
+// кодом code и возвращает все полученные пакеты (тип и тело)
+func fakeMQTTBroker(t *testing.T, code byte) (string, <-chan [][2][]byte) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	packets := make(chan [][2][]byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var got [][2][]byte
+		for {
+			header := make([]byte, 1)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				break
+			}
+			length, multiplier := 0, 1
+			for {
+				b := make([]byte, 1)
+				if _, err := io.ReadFull(conn, b); err != nil {
+					break
+				}
+				length += int(b[0]&0x7f) * multiplier
+				multiplier *= 128
+				if b[0]&0x80 == 0 {
+					break
+				}
+			}
+			body := make([]byte, length)
+			io.ReadFull(conn, body)
+			got = append(got, [2][]byte{header, body})
+
+			switch header[0] >> 4 {
+			case 1:
+				conn.Write([]byte{0x20, 0x02, 0x00, code})
+			case 14:
+				packets <- got
+				return
+			}
+		}
+		packets <- got
+	}()
+	return "mqtt://user:secret@" + listener.Addr().String(), packets
+}
+
+func TestMQTTPublish(t *testing.T) {
+	addr, packets := fakeMQTTBroker(t, 0)
+
+	messages := mqttWeatherMessages("home/weather", "Нижний Новгород", &CurrentWeather{City: "Нижний Новгород", Temp: -2.5, Humidity: 90})
+	if err := mqttPublish(addr, "test", messages); err != nil {
+		t.Fatalf("mqttPublish: %v", err)
+	}
+
+	got := <-packets
+	if len(got) != len(messages)+2 {
+		t.Fatalf("пакетов %d, ожидалось %d", len(got), len(messages)+2)
+	}
+
+	connect := got[0][1]
+	if string(connect[2:6]) != "MQTT" || connect[6] != 4 || connect[7] != 0xC2 {
+		t.Errorf("неверный CONNECT: % x", connect[:10])
+	}
+	if string(connect[len(connect)-6:]) != "secret" {
+		t.Errorf("в CONNECT нет пароля: %q", connect)
+	}
+
+	publish := got[1]
+	if publish[0][0] != 0x31 {
+		t.Errorf("PUBLISH без retain: 0x%02x", publish[0][0])
+	}
+	topicLength := int(publish[1][0])<<8 | int(publish[1][1])
+	topic, payload := string(publish[1][2:2+topicLength]), string(publish[1][2+topicLength:])
+	if topic != "home/weather/нижний_новгород/temp" || payload != "-2.5" {
+		t.Errorf("опубликовано %q = %q", topic, payload)
+	}
+
+	if last := got[len(got)-1]; last[0][0] != 0xE0 {
+		t.Errorf("последний пакет 0x%02x, ожидался DISCONNECT", last[0][0])
+	}
+}
+
+func TestMQTTPublishRejected(t *testing.T) {
+	addr, _ := fakeMQTTBroker(t, 4)
+	if err := mqttPublish(addr, "test", nil); err == nil || err.Error() != "брокер MQTT отказал в подключении: неверный логин или пароль" {
+		t.Errorf("ошибка %v", err)
+	}
+}
+
+func TestMQTTRemainingLength(t *testing.T) {
+	tests := map[int][]byte{0: {0}, 127: {0x7f}, 128: {0x80, 0x01}, 16383: {0xff, 0x7f}, 321: {0xc1, 0x02}}
+	for n, want := range tests {
+		if got := mqttRemainingLength(n); string(got) != string(want) {
+			t.Errorf("mqttRemainingLength(%d) = % x, ожидалось % x", n, got, want)
+		}
+	}
+}
+
+func TestMQTTAlertMessage(t *testing.T) {
+	data := &CurrentWeather{City: "Moscow", Temp: 18.5, WindSpeed: 14, Condition: 211, Description: "гроза"}
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	message, err := mqttAlertMessage("weather", "Нижний Новгород", alertDaily, data, now)
+	if err != nil {
+		t.Fatalf("mqttAlertMessage: %v", err)
+	}
+	if message.Topic != "weather/нижний_новгород/alert" {
+		t.Errorf("топик %q", message.Topic)
+	}
+
+	var alert map[string]interface{}
+	if err := json.Unmarshal(message.Payload, &alert); err != nil {
+		t.Fatalf("тело: %v", err)
+	}
+	if _, ok := alert["text"]; ok {
+		t.Errorf("в топике города текст сообщения подписчика: %s", message.Payload)
+	}
+	weather, _ := alert["weather"].(map[string]interface{})
+	if alert["kind"] != alertDaily || alert["city"] != "Нижний Новгород" || alert["time"] != "2026-10-16T09:30:00Z" ||
+		weather["temp"] != 18.5 || weather["condition"] != 211.0 {
+		t.Errorf("тело оповещения: %s", message.Payload)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Значения кнопок подтверждения /forgetme
+const (
+	forgetConfirm = "confirm"
+	forgetCancel  = "cancel"
+)
+
+// Все, что бот хранит о пользователе (чате)
+type userDataExport struct {
+	ChatID        int64                `json:"chat_id"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Preferences   *UserPreferences     `json:"preferences,omitempty"`
+	Subscriptions []*AlertSubscription `json:"subscriptions,omitempty"`
+	RecentCities  []string             `json:"recent_cities,omitempty"`
+	GroupPost     *GroupPost           `json:"group_post,omitempty"`
+	Webhooks      []*Webhook           `json:"webhooks,omitempty"`
+	Places        []*SavedPlace        `json:"places,omitempty"`
+	Events        []*WeatherEvent      `json:"events,omitempty"`
+	LastCity      string               `json:"last_city,omitempty"`
+	Dialog        *DialogState         `json:"dialog,omitempty"`
+	PremiumUntil  *time.Time           `json:"premium_until,omitempty"`
+	Donations     []Donation           `json:"donations,omitempty"`
+	InvitedBy     *Referral            `json:"invited_by,omitempty"`
+	Invited       []int64              `json:"invited,omitempty"`
+	ReferrerName  string               `json:"referrer_name,omitempty"`
+	Feedback      []*Feedback          `json:"feedback,omitempty"`
+	SkyPhotos     []*SkyPhoto          `json:"sky_photos,omitempty"`
+	Missed        []*MissedMessage     `json:"missed,omitempty"`
+	PausedUntil   *time.Time           `json:"paused_until,omitempty"`
+	Ban           *Ban                 `json:"ban,omitempty"`
+}
+
+// Сбор данных пользователя из хранилища
+func (s *Store) ExportUserData(chatID int64) userDataExport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	export := userDataExport{
+		ChatID:       chatID,
+		ExportedAt:   time.Now(),
+		Preferences:  s.data.Preferences[chatID],
+		RecentCities: s.data.RecentCities[chatID],
+		GroupPost:    s.data.GroupPosts[chatID],
+		Webhooks:     s.data.Webhooks[chatID],
+		Places:       s.data.Places[chatID],
+		Events:       s.data.Events[chatID],
+		Dialog:       s.data.Dialogs[chatID],
+		InvitedBy:    s.data.Referrals[chatID],
+		ReferrerName: s.data.ReferrerNames[chatID],
+		Ban:          s.data.Banned[chatID],
+		Missed:       s.data.Missed[chatID],
+	}
+	for _, sub := range s.data.Subscriptions {
+		if sub.ChatID == chatID {
+			export.Subscriptions = append(export.Subscriptions, sub)
+		}
+	}
+	if until, ok := s.data.Premium[chatID]; ok {
+		export.PremiumUntil = &until
+	}
+	if until, ok := s.data.PausedUntil[chatID]; ok {
+		export.PausedUntil = &until
+	}
+	for _, donation := range s.data.Donations {
+		if donation.ChatID == chatID {
+			export.Donations = append(export.Donations, donation)
+		}
+	}
+	for invitee, referral := range s.data.Referrals {
+		if referral.Referrer == chatID {
+			export.Invited = append(export.Invited, invitee)
+		}
+	}
+	for _, feedback := range s.data.Feedback {
+		if feedback.ChatID == chatID {
+			export.Feedback = append(export.Feedback, feedback)
+		}
+	}
+	for _, photo := range s.data.SkyPhotos {
+		if photo.UserID == chatID {
+			export.SkyPhotos = append(export.SkyPhotos, photo)
+		}
+	}
+	return export
+}
+
+// Удаление данных пользователя. Блокировка остается, иначе ее можно было бы
+// снять самому. Записи о пожертвованиях нужны для возвратов, поэтому
+// в них стирается только имя. Отзывы обезличиваются, чтобы не сбить их номера
+func (s *Store) DeleteUserData(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.Preferences, chatID)
+	delete(s.data.RecentCities, chatID)
+	delete(s.data.GroupPosts, chatID)
+	delete(s.data.Webhooks, chatID)
+	delete(s.data.Places, chatID)
+	delete(s.data.Events, chatID)
+	delete(s.data.Dialogs, chatID)
+	delete(s.data.Premium, chatID)
+	delete(s.data.Referrals, chatID)
+	delete(s.data.Missed, chatID)
+	delete(s.data.PausedUntil, chatID)
+	delete(s.data.ReferrerNames, chatID)
+	for key, sub := range s.data.Subscriptions {
+		if sub.ChatID == chatID {
+			delete(s.data.Subscriptions, key)
+		}
+	}
+	for invitee, referral := range s.data.Referrals {
+		if referral.Referrer == chatID {
+			delete(s.data.Referrals, invitee)
+		}
+	}
+	for i := range s.data.Donations {
+		if s.data.Donations[i].ChatID == chatID {
+			s.data.Donations[i].Name = ""
+		}
+	}
+	for _, feedback := range s.data.Feedback {
+		if feedback.ChatID == chatID {
+			feedback.ChatID = 0
+			feedback.Name = ""
+			feedback.Text = "[удалено по просьбе пользователя]"
+		}
+	}
+	s.removeUserSkyPhotos(chatID)
+	return s.save()
+}
+
+// /mydata — выгрузка всех данных пользователя JSON-файлом
+func handleMyDataCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	export := c.bot.store.ExportUserData(chatID)
+	export.LastCity = c.bot.lastCity[chatID]
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	document := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("mydata-%d.json", chatID),
+		Bytes: data,
+	})
+	document.Caption = "📦 Все, что бот хранит о вас. Удалить эти данные: /forgetme"
+	if _, err := c.bot.Send(document); err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	}
+}
+
+// /forgetme — удаление данных после подтверждения кнопкой
+func handleForgetMeCommand(c *commandContext) {
+	c.msg.Text = "⚠️ Удалить все ваши данные: настройки, подписки, историю городов, приглашения и премиум? " +
+		"Это нельзя отменить. Сначала можно скачать их командой /mydata."
+	c.msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗑 Да, удалить", encodeCallback(c.bot.store, CallbackPayload{Action: actionForgetMe, Value: forgetConfirm})),
+		tgbotapi.NewInlineKeyboardButtonData("Отмена", encodeCallback(c.bot.store, CallbackPayload{Action: actionForgetMe, Value: forgetCancel})),
+	))
+}
+
+// Ответ на кнопку подтверждения: сообщение с вопросом заменяется итогом
+func handleForgetMeCallback(bot *Bot, callback *tgbotapi.CallbackQuery, value string) error {
+	chatID := callback.Message.Chat.ID
+
+	text := "Хорошо, ничего не удаляю."
+	if value == forgetConfirm {
+		if err := bot.store.DeleteUserData(chatID); err != nil {
+			return err
+		}
+		delete(bot.lastCity, chatID)
+		bot.live.Stop(chatID)
+		crowdReports.Forget(chatID)
+		text = "🗑 Ваши данные удалены. Если напишете снова, бот начнет с чистого листа."
+	}
+
+	_, err := bot.Request(tgbotapi.NewEditMessageText(chatID, callback.Message.MessageID, text))
+	return err
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Соседние города для отправленной геопозиции. В сельской местности
+// погода по координатам приходит от ближайшей к точке деревни, а
+// пользователю понятнее станция в соседнем городе, поэтому под карточкой
+// предлагаем выбрать город из поиска OWM /data/2.5/find
+
+// Сколько городов показывать кнопками
+const nearbyCitiesCount = 5
+
+// Подпись к кнопкам соседних городов под карточкой
+const nearbyHint = "\n\n🏘 Погода на станциях в соседних городах — кнопками ниже."
+
+// Город рядом с отправленной точкой
+type NearbyCity struct {
+	Name       string
+	Lat, Lon   float64
+	DistanceKm float64
+}
+
+// Ответ OWM /data/2.5/find: погода в городах вокруг точки
+type owmFindResponse struct {
+	List []owmWeatherResponse `json:"list"`
+}
+
+// Кэш соседних городов по ячейке геохеша: города не переезжают,
+// поэтому без срока хранения
+var (
+	nearbyCache   = make(map[string][]NearbyCity)
+	nearbyCacheMu sync.Mutex
+)
+
+// Ближайшие к точке города, начиная с самого близкого
+func nearbyCities(lat, lon float64, lang string) ([]NearbyCity, error) {
+	key := geohash(lat, lon, geohashCachePrecision) + "|" + lang
+	nearbyCacheMu.Lock()
+	cached, ok := nearbyCache[key]
+	nearbyCacheMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var found []owmWeatherResponse
+	if mockWeatherMode() {
+		for _, offset := range [][2]float64{{0.1, 0}, {0, 0.15}, {-0.2, -0.1}} {
+			var item owmWeatherResponse
+			item.Coord.Lat, item.Coord.Lon = lat+offset[0], lon+offset[1]
+			item.Name = mockPlaceName(item.Coord.Lat, item.Coord.Lon)
+			found = append(found, item)
+		}
+	} else {
+		params := owmCoordsParams(lat, lon, lang)
+		params.Set("cnt", strconv.Itoa(nearbyCitiesCount*2))
+		var data owmFindResponse
+		if err := fetchOWM("/data/2.5/find", params, "города рядом не найдены или ошибка API", &data); err != nil {
+			return nil, err
+		}
+		found = data.List
+	}
+
+	// У станций OWM бывают тезки в одной округе, оставляем ближайшую
+	seen := make(map[string]bool)
+	var cities []NearbyCity
+	for _, item := range found {
+		name := strings.TrimSpace(item.Name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		cities = append(cities, NearbyCity{
+			Name:       name,
+			Lat:        item.Coord.Lat,
+			Lon:        item.Coord.Lon,
+			DistanceKm: haversineKm(lat, lon, item.Coord.Lat, item.Coord.Lon),
+		})
+	}
+	sort.SliceStable(cities, func(i, j int) bool { return cities[i].DistanceKm < cities[j].DistanceKm })
+	if len(cities) > nearbyCitiesCount {
+		cities = cities[:nearbyCitiesCount]
+	}
+
+	nearbyCacheMu.Lock()
+	nearbyCache[key] = cities
+	nearbyCacheMu.Unlock()
+	return cities, nil
+}
+
+// Координаты города в данных кнопки: "55.8891,37.4449"
+func formatNearbyCoords(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'f', 4, 64) + "," + strconv.FormatFloat(lon, 'f', 4, 64)
+}
+
+func parseNearbyCoords(value string) (float64, float64, error) {
+	latText, lonText, ok := strings.Cut(value, ",")
+	lat, latErr := strconv.ParseFloat(latText, 64)
+	lon, lonErr := strconv.ParseFloat(lonText, 64)
+	if !ok || latErr != nil || lonErr != nil {
+		return 0, 0, fmt.Errorf("неверные координаты: %q", value)
+	}
+	return lat, lon, nil
+}
+
+// Кнопки соседних городов под карточкой погоды по геопозиции
+func nearbyKeyboard(store *Store, cities []NearbyCity) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, city := range cities {
+		text := fmt.Sprintf("🏘 %s · %.0f км", city.Name, city.DistanceKm)
+		if city.DistanceKm < 1 {
+			text = "🏘 " + city.Name + " · рядом"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text, encodeCallback(store, CallbackPayload{
+				Action: actionNearby,
+				City:   city.Name,
+				Value:  formatNearbyCoords(city.Lat, city.Lon),
+			})),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Кнопки соседних городов для точки или nil, если их не нашлось.
+// Ошибка поиска не мешает ответу с погодой, поэтому только пишется в лог
+func nearbyMarkup(store *Store, lat, lon float64, lang string) *tgbotapi.InlineKeyboardMarkup {
+	cities, err := nearbyCities(lat, lon, lang)
+	if err != nil {
+		log.Printf("Ошибка поиска городов рядом: %v", err)
+		return nil
+	}
+	if len(cities) == 0 {
+		return nil
+	}
+	keyboard := nearbyKeyboard(store, cities)
+	return &keyboard
+}
+
+// Нажатие на соседний город: карточка погоды на его станции
+func handleNearbyCallback(bot *Bot, callback *tgbotapi.CallbackQuery, payload CallbackPayload) error {
+	chatID := callback.Message.Chat.ID
+	prefs := bot.store.Preferences(chatID)
+
+	lat, lon, err := parseNearbyCoords(payload.Value)
+	if err != nil {
+		return err
+	}
+	data, err := cachedLocationWeather(lat, lon, prefs.Language)
+	var text string
+	if err == nil {
+		card := newWeatherCardData(data, prefs, climateLine(data, prefs))
+		card.City = payload.City
+		text, err = renderReply("weather", prefs, card)
+	}
+	if err != nil {
+		_, err = bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка: "+err.Error()))
+		return err
+	}
+
+	bot.lastCity[chatID] = payload.City
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = replyParseMode(prefs)
+	msg.ReplyMarkup = weatherKeyboard(bot.store, payload.City, prefs)
+	if _, err := bot.Send(msg); err != nil {
+		return err
+	}
+	return sendWeatherSticker(bot, chatID, data)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ответ OWM /data/2.5/find: тезка подальше и точка без названия отбрасываются
+const owmFindFixture = `{"list": [
+	{"name": "Химки", "coord": {"lat": 55.8970, "lon": 37.4297}},
+	{"name": "Долгопрудный", "coord": {"lat": 55.9386, "lon": 37.5010}},
+	{"name": "", "coord": {"lat": 55.9, "lon": 37.4}},
+	{"name": "химки", "coord": {"lat": 55.95, "lon": 37.3}},
+	{"name": "Сходня", "coord": {"lat": 55.9487, "lon": 37.3044}}
+]}`
+
+func TestNearbyCities(t *testing.T) {
+	requests := fakeOWMRoutes(t, map[string]string{"/data/2.5/find": owmFindFixture})
+
+	for i := 0; i < 2; i++ {
+		cities, err := nearbyCities(55.8891, 37.4449, langRU)
+		if err != nil {
+			t.Fatalf("nearbyCities: %v", err)
+		}
+		var names []string
+		for _, city := range cities {
+			names = append(names, city.Name)
+		}
+		if got := strings.Join(names, ", "); got != "Химки, Долгопрудный, Сходня" {
+			t.Errorf("города %q", got)
+		}
+		if d := cities[0].DistanceKm; d < 1 || d > 2 {
+			t.Errorf("до Химок %.1f км", d)
+		}
+	}
+	if n := requests["/data/2.5/find"]; n != 1 {
+		t.Errorf("запросов поиска %d, ожидался 1", n)
+	}
+}
+
+func TestParseNearbyCoords(t *testing.T) {
+	if lat, lon, err := parseNearbyCoords(formatNearbyCoords(55.89701, -37.4297)); err != nil || lat != 55.897 || lon != -37.4297 {
+		t.Errorf("parseNearbyCoords = %v, %v, %v", lat, lon, err)
+	}
+	for _, value := range []string{"", "55.9", "a,b"} {
+		if _, _, err := parseNearbyCoords(value); err == nil {
+			t.Errorf("parseNearbyCoords(%q) без ошибки", value)
+		}
+	}
+}
+
+func TestPipelineNearbyCities(t *testing.T) {
+	f := newFakeTelegram(t)
+	fakeOWMRoutes(t, map[string]string{
+		"/data/2.5/weather": owmWeatherFixture,
+		"/data/2.5/find":    owmFindFixture,
+		"/geo/1.0/reverse":  owmReverseFixture,
+	})
+
+	climateCache.mu.Lock()
+	climateCache.data[climateKey(55.75, 37.62)] = [365]float64{}
+	climateCache.mu.Unlock()
+
+	const chatID = 2501
+	update := textUpdate(chatID, "")
+	update.Message.Location = &tgbotapi.Location{Latitude: 55.8891, Longitude: 37.4449}
+	f.send(update)
+
+	if card := f.reply(t, chatID); !strings.Contains(card, "соседних городах") {
+		t.Errorf("в карточке нет подписи к кнопкам: %q", card)
+	}
+	markup := f.sent("sendMessage")[0].Params["reply_markup"]
+	if !strings.Contains(markup, "Долгопрудный · 7 км") {
+		t.Errorf("нет кнопки соседнего города: %s", markup)
+	}
+
+	f.reset()
+	f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: chatID},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}},
+		Data: encodeCallback(store, CallbackPayload{
+			Action: actionNearby,
+			City:   "Долгопрудный",
+			Value:  formatNearbyCoords(55.9386, 37.5010),
+		}),
+	}})
+	if card := f.reply(t, chatID); !strings.Contains(card, "Долгопрудный") {
+		t.Errorf("карточка соседнего города %q", card)
+	}
+	if f.bot.lastCity[chatID] != "Долгопрудный" {
+		t.Errorf("последний город %q", f.bot.lastCity[chatID])
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Время суток в запросе
+const (
+	partMorning = "morning"
+	partDay     = "day"
+	partEvening = "evening"
+	partNight   = "night"
+)
+
+// Что именно интересует пользователя
+const (
+	metricRain = "rain"
+	metricSnow = "snow"
+	metricTemp = "temp"
+	metricWind = "wind"
+)
+
+// Разобранный запрос на естественном языке
+type WeatherQuery struct {
+	City      string
+	DayOffset int // смещение от сегодняшнего дня, -1 если день не указан
+	TimeOfDay string
+	Metric    string
+}
+
+// Слова, обозначающие день
+var queryDayWords = map[string]int{
+	"сегодня": 0, "today": 0, "tonight": 0,
+	"завтра": 1, "tomorrow": 1,
+	"послезавтра": 2,
+}
+
+// Дни недели в разных формах
+var queryWeekdayWords = map[string]time.Weekday{
+	"понедельник": time.Monday, "monday": time.Monday,
+	"вторник": time.Tuesday, "tuesday": time.Tuesday,
+	"среду": time.Wednesday, "среда": time.Wednesday, "wednesday": time.Wednesday,
+	"четверг": time.Thursday, "thursday": time.Thursday,
+	"пятницу": time.Friday, "пятница": time.Friday, "friday": time.Friday,
+	"субботу": time.Saturday, "суббота": time.Saturday, "saturday": time.Saturday,
+	"воскресенье": time.Sunday, "sunday": time.Sunday,
+}
+
+// Слова, обозначающие время суток
+var queryPartWords = map[string]string{
+	"утром": partMorning, "утро": partMorning, "morning": partMorning,
+	"днем": partDay, "днём": partDay, "день": partDay, "afternoon": partDay,
+	"вечером": partEvening, "вечер": partEvening, "evening": partEvening, "tonight": partEvening,
+	"ночью": partNight, "ночь": partNight, "night": partNight,
+}
+
+// Слова, обозначающие интересующий параметр
+var queryMetricWords = map[string]string{
+	"дождь": metricRain, "дождик": metricRain, "зонт": metricRain, "зонтик": metricRain,
+	"rain": metricRain, "umbrella": metricRain, "осадки": metricRain,
+	"снег": metricSnow, "snow": metricSnow,
+	"температура": metricTemp, "холодно": metricTemp, "тепло": metricTemp, "жарко": metricTemp,
+	"temperature": metricTemp, "cold": metricTemp, "warm": metricTemp, "hot": metricTemp,
+	"ветер": metricWind, "ветрено": metricWind, "wind": metricWind, "windy": metricWind,
+}
+
+// Служебные слова, которые не относятся к названию города
+var queryStopWords = map[string]bool{
+	"погода": true, "погоду": true, "погоды": true, "какая": true, "какой": true, "будет": true,
+	"ли": true, "а": true, "и": true, "нужен": true, "нужно": true, "брать": true, "идет": true,
+	"пойдет": true, "что": true, "там": true, "с": true, "по": true, "прогноз": true,
+	"weather": true, "will": true, "it": true, "be": true, "the": true, "what": true, "whats": true,
+	"is": true, "on": true, "for": true, "a": true, "how": true, "do": true, "i": true, "need": true,
+	"going": true, "to": true, "an": true, "forecast": true, "this": true,
+}
+
+// Предлоги перед названием города
+var queryCityPrepositions = map[string]bool{"в": true, "во": true, "in": true, "at": true}
+
+// Неформальные названия городов
+var cityAliases = map[string]string{
+	"питер": "Санкт-Петербург", "питере": "Санкт-Петербург", "спб": "Санкт-Петербург",
+	"мск": "Москва", "екб": "Екатеринбург", "екат": "Екатеринбург", "нск": "Новосибирск",
+}
+
+// Разбор фразы вроде "погода в Питере завтра вечером" или
+// "will it rain in Berlin on Saturday?". Возвращает false, если фраза
+// не похожа на запрос и ее стоит считать просто названием города
+func parseWeatherQuery(text string, now time.Time) (WeatherQuery, bool) {
+	query := WeatherQuery{DayOffset: -1}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+
+	markers := 0
+	var cityWords []string
+	// После предлога начинается новое название города, но только если за ним
+	// не следует служебное слово ("в Казани в субботу")
+	newCity := false
+	for _, word := range words {
+		if offset, ok := queryDayWords[word]; ok {
+			query.DayOffset = offset
+			if part, ok := queryPartWords[word]; ok {
+				query.TimeOfDay = part
+			}
+			markers++
+			continue
+		}
+		if weekday, ok := queryWeekdayWords[word]; ok {
+			query.DayOffset = (int(weekday) - int(now.Weekday()) + 7) % 7
+			markers++
+			continue
+		}
+		if part, ok := queryPartWords[word]; ok {
+			query.TimeOfDay = part
+			markers++
+			continue
+		}
+		if metric, ok := queryMetricWords[word]; ok {
+			query.Metric = metric
+			markers++
+			continue
+		}
+		if queryStopWords[word] {
+			markers++
+			continue
+		}
+		if queryCityPrepositions[word] {
+			newCity = true
+			continue
+		}
+		if newCity {
+			cityWords = nil
+			newCity = false
+		}
+		cityWords = append(cityWords, word)
+	}
+
+	if markers == 0 || len(cityWords) == 0 {
+		return query, false
+	}
+
+	query.City = strings.Join(cityWords, " ")
+	return query, true
+}
+
+// Варианты названия города в именительном падеже для слова в предложном
+// ("в Москве" → "Москва", "в Казани" → "Казань", "в Воронеже" → "Воронеж")
+func cityNameCandidates(city string) []string {
+	if alias, ok := cityAliases[city]; ok {
+		return []string{alias}
+	}
+
+	candidates := []string{city}
+	runes := []rune(city)
+	if len(runes) < 3 {
+		return candidates
+	}
+	stem := string(runes[:len(runes)-1])
+	switch runes[len(runes)-1] {
+	case 'е':
+		candidates = append(candidates, stem+"а", stem, stem+"я", stem+"о")
+	case 'и':
+		candidates = append(candidates, stem+"ь", stem+"а", stem+"я")
+	}
+	return candidates
+}
+
+// Поиск города по вариантам названия
+func resolveQueryCity(city string) (*GeoPoint, error) {
+	for _, candidate := range cityNameCandidates(city) {
+		point, err := geocodeCity(candidate)
+		if err == nil {
+			return point, nil
+		}
+	}
+	return nil, fmt.Errorf("город «%s» не найден", city)
+}
+
+// Границы времени суток в часах
+func partHours(part string) (int, int) {
+	switch part {
+	case partMorning:
+		return 6, 12
+	case partDay:
+		return 12, 18
+	case partEvening:
+		return 18, 24
+	case partNight:
+		return 0, 6
+	default:
+		return 6, 24
+	}
+}
+
+// Название времени суток для ответа
+func partName(part, lang string) string {
+	names := map[string]string{partMorning: "утром", partDay: "днем", partEvening: "вечером", partNight: "ночью"}
+	if lang == langEN {
+		names = map[string]string{partMorning: "in the morning", partDay: "in the afternoon", partEvening: "in the evening", partNight: "at night"}
+	}
+	return names[part]
+}
+
+// Название дня для ответа
+func dayName(offset int, date time.Time, lang string) string {
+	if lang == langEN {
+		switch offset {
+		case 0:
+			return "today"
+		case 1:
+			return "tomorrow"
+		case 2:
+			return "the day after tomorrow"
+		default:
+			return fmt.Sprintf("%s, %s", date.Weekday(), date.Format("02.01"))
+		}
+	}
+	switch offset {
+	case 0:
+		return "сегодня"
+	case 1:
+		return "завтра"
+	case 2:
+		return "послезавтра"
+	default:
+		return fmt.Sprintf("%s, %s", strings.ToLower(weekdayName(date.Weekday())), date.Format("02.01"))
+	}
+}
+
+// Ответ на запрос о погоде в определенный день и время суток на языке и
+// в единицах пользователя
+func answerWeatherQuery(query WeatherQuery, point *GeoPoint, prefs UserPreferences) (string, error) {
+	forecast, err := cachedForecastByCoordsLang(point.Lat, point.Lon, prefs.Language)
+	if err != nil {
+		return "", err
+	}
+
+	offset := query.DayOffset
+	if offset < 0 {
+		offset = 0
+	}
+	now := forecast.Now()
+	target := now.AddDate(0, 0, offset)
+	from, to := partHours(query.TimeOfDay)
+
+	var slots []ForecastItem
+	for _, item := range forecast.Items {
+		local := forecast.LocalTime(item)
+		if local.Format("2006-01-02") != target.Format("2006-01-02") {
+			continue
+		}
+		if local.Hour() < from || local.Hour() >= to {
+			continue
+		}
+		slots = append(slots, item)
+	}
+
+	en := prefs.Language == langEN
+	when := dayName(offset, target, prefs.Language)
+	if part := partName(query.TimeOfDay, prefs.Language); part != "" {
+		when += " " + part
+	}
+	if len(slots) == 0 {
+		return "", fmt.Errorf("нет данных прогноза на %s (прогноз доступен на 5 дней вперед)", when)
+	}
+
+	minTemp, maxTemp := math.Inf(1), math.Inf(-1)
+	maxPop, maxWind, rain, snow := 0.0, 0.0, 0.0, 0.0
+	for _, item := range slots {
+		minTemp = math.Min(minTemp, item.Temp)
+		maxTemp = math.Max(maxTemp, item.Temp)
+		maxPop = math.Max(maxPop, item.Pop)
+		maxWind = math.Max(maxWind, item.WindSpeed)
+		rain += item.Rain
+		snow += item.Snow
+	}
+	wind := func(ms float64) string { return formatWindSpeed(ms, windUnitFor(prefs), prefs.Language) }
+
+	answer := fmt.Sprintf("📍 %s, %s:\n", point.DisplayName(), when)
+	switch query.Metric {
+	case metricRain:
+		switch {
+		case (rain > 0 || maxPop >= 0.5) && en:
+			answer += fmt.Sprintf("☔️ Yes, rain is likely (up to %.0f%%, ~%.1f mm) — take an umbrella.", maxPop*100, rain)
+		case rain > 0 || maxPop >= 0.5:
+			answer += fmt.Sprintf("☔️ Да, вероятен дождь (до %.0f%%, ~%.1f мм) — возьмите зонт.", maxPop*100, rain)
+		case en:
+			answer += fmt.Sprintf("🌂 No rain expected (chance up to %.0f%%).", maxPop*100)
+		default:
+			answer += fmt.Sprintf("🌂 Дождя не ожидается (вероятность до %.0f%%).", maxPop*100)
+		}
+	case metricSnow:
+		switch {
+		case snow > 0 && en:
+			answer += fmt.Sprintf("❄️ Yes, snow is expected (~%.1f mm).", snow)
+		case snow > 0:
+			answer += fmt.Sprintf("❄️ Да, ожидается снег (~%.1f мм).", snow)
+		case en:
+			answer += "❄️ No snow expected."
+		default:
+			answer += "❄️ Снега не ожидается."
+		}
+	case metricWind:
+		if en {
+			answer += fmt.Sprintf("🌬 Wind up to %s.", wind(maxWind))
+		} else {
+			answer += fmt.Sprintf("🌬 Ветер до %s.", wind(maxWind))
+		}
+	case metricTemp:
+		if en {
+			answer += fmt.Sprintf("🌡 From %s to %s.", formatTemp(minTemp, prefs.Units), formatTemp(maxTemp, prefs.Units))
+		} else {
+			answer += fmt.Sprintf("🌡 От %s до %s.", formatTemp(minTemp, prefs.Units), formatTemp(maxTemp, prefs.Units))
+		}
+	default:
+		windWord := "ветер"
+		if en {
+			windWord = "wind"
+		}
+		for _, item := range slots {
+			answer += fmt.Sprintf("⏰ %s: %s, %s, %s %s\n",
+				forecast.LocalTime(item).Format("15:04"),
+				formatTemp(item.Temp, prefs.Units),
+				item.Description,
+				windWord,
+				wind(item.WindSpeed),
+			)
+		}
+		answer = strings.TrimRight(answer, "\n")
+	}
+
+	return answer, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnswerWeatherQueryPrefs(t *testing.T) {
+	useMockReports(t)
+	useManualClock(t, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	previous := localizedCoordsCaches[langEN]
+	localizedCoordsCaches[langEN] = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { localizedCoordsCaches[langEN] = previous })
+
+	point := &GeoPoint{Name: "Moscow", Lat: 55.75, Lon: 37.62}
+	prefs := UserPreferences{Units: unitsImperial, Language: langEN, WindUnit: windKMH}
+
+	answer, err := answerWeatherQuery(WeatherQuery{DayOffset: 1, TimeOfDay: partEvening}, point, prefs)
+	if err != nil {
+		t.Fatalf("answerWeatherQuery: %v", err)
+	}
+	if !strings.HasPrefix(answer, "📍 Moscow, tomorrow in the evening:") ||
+		!strings.Contains(answer, "°F") || !strings.Contains(answer, "km/h") ||
+		strings.Contains(answer, "°C") || strings.Contains(answer, "ветер") {
+		t.Errorf("ответ не в настройках пользователя:\n%s", answer)
+	}
+}
+
+func TestParseWeatherQuery(t *testing.T) {
+	// Пятница
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text  string
+		ok    bool
+		query WeatherQuery
+	}{
+		{"погода в Питере завтра вечером", true, WeatherQuery{City: "питере", DayOffset: 1, TimeOfDay: partEvening}},
+		{"будет ли дождь в Казани в субботу?", true, WeatherQuery{City: "казани", DayOffset: 1, Metric: metricRain}},
+		{"погода в Туле в пятницу", true, WeatherQuery{City: "туле", DayOffset: 0}},
+		{"погода в Туле в четверг", true, WeatherQuery{City: "туле", DayOffset: 6}},
+		{"will it rain in Berlin tonight", true, WeatherQuery{City: "berlin", DayOffset: 0, TimeOfDay: partEvening, Metric: metricRain}},
+		{"what's the weather in New York on Sunday", true, WeatherQuery{City: "new york", DayOffset: 2}},
+		{"Нижний Новгород послезавтра утром", true, WeatherQuery{City: "нижний новгород", DayOffset: 2, TimeOfDay: partMorning}},
+		// Новый предлог начинает новое название города
+		{"в Москве или в Туле завтра", true, WeatherQuery{City: "туле", DayOffset: 1}},
+		// Просто название города — не запрос
+		{"Москва", false, WeatherQuery{City: "", DayOffset: -1}},
+		{"Санкт-Петербург", false, WeatherQuery{City: "", DayOffset: -1}},
+		{"погода завтра", false, WeatherQuery{DayOffset: 1}},
+	}
+	for _, tt := range tests {
+		query, ok := parseWeatherQuery(tt.text, now)
+		if ok != tt.ok || (ok && query != tt.query) {
+			t.Errorf("parseWeatherQuery(%q) = %+v, %v, ожидалось %+v, %v", tt.text, query, ok, tt.query, tt.ok)
+		}
+	}
+}
+
+func TestCityNameCandidates(t *testing.T) {
+	tests := []struct {
+		city string
+		want []string
+	}{
+		{"москве", []string{"москве", "москва", "москв", "москвя", "москво"}},
+		{"казани", []string{"казани", "казань", "казана", "казаня"}},
+		{"питере", []string{"Санкт-Петербург"}},
+		{"мск", []string{"Москва"}},
+		{"уфа", []string{"уфа"}},
+		{"ош", []string{"ош"}},
+	}
+	for _, tt := range tests {
+		if got := cityNameCandidates(tt.city); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("cityNameCandidates(%q) = %v, ожидалось %v", tt.city, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Осадки с шагом 15 минут от Open-Meteo
+type NowcastResponse struct {
+	UTCOffset  int `json:"utc_offset_seconds"`
+	Minutely15 struct {
+		Time          []int64    `json:"time"`
+		Precipitation []*float64 `json:"precipitation"`
+	} `json:"minutely_15"`
+}
+
+// Сколько 15-минутных интервалов показываем (2 часа)
+const nowcastSteps = 8
+
+func fetchNowcast(lat, lon float64) (*NowcastResponse, error) {
+	reqURL := fmt.Sprintf(
+		"https://api.open-meteo.com/v1/forecast?latitude=%.4f&longitude=%.4f"+
+			"&minutely_15=precipitation&forecast_minutely_15=%d&timeformat=unixtime&timezone=auto",
+		lat,
+		lon,
+		nowcastSteps,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения данных Open-Meteo: статус %d", resp.StatusCode)
+	}
+
+	var data NowcastResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	return &data, nil
+}
+
+// Осадки на ближайшие 2 часа
+func getNowcast(city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := fetchNowcast(point.Lat, point.Lon)
+	if err != nil {
+		return "", err
+	}
+
+	zone := time.FixedZone("", data.UTCOffset)
+	var lines []string
+	total := 0.0
+	for i, ts := range data.Minutely15.Time {
+		amount, ok := valueAt(data.Minutely15.Precipitation, i)
+		if !ok {
+			continue
+		}
+		total += amount
+
+		bar := "·"
+		switch {
+		case amount >= 2:
+			bar = "🌧🌧🌧"
+		case amount >= 0.5:
+			bar = "🌧🌧"
+		case amount > 0:
+			bar = "🌧"
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s %.1f мм", time.Unix(ts, 0).In(zone).Format("15:04"), bar, amount))
+	}
+	if len(lines) == 0 {
+		return "", fmt.Errorf("нет данных об осадках для %s", point.DisplayName())
+	}
+
+	summary := "☀️ В ближайшие 2 часа осадков не ожидается."
+	if total > 0 {
+		summary = fmt.Sprintf("☔️ В ближайшие 2 часа ожидается %.1f мм осадков.", total)
+	}
+
+	return fmt.Sprintf("⏱ Осадки в %s по 15 минут:\n\n%s\n\n%s",
+		point.DisplayName(), strings.Join(lines, "\n"), summary), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Сколько хранить наблюдения и насколько вчерашнее наблюдение может
+// отличаться по времени суток от текущего
+const (
+	observationRetention = 48 * time.Hour
+	observationTolerance = 3 * time.Hour
+)
+
+// Снимок фактической погоды в городе
+type Observation struct {
+	Time     time.Time
+	Temp     float64
+	Wind     float64
+	Humidity int
+}
+
+// Структура для хранения недавних наблюдений по городам
+type ObservationStore struct {
+	data map[string][]Observation
+	mu   sync.Mutex
+}
+
+var observationStore = &ObservationStore{
+	data: make(map[string][]Observation),
+}
+
+// Сохранение наблюдения с удалением устаревших записей. Повторно
+// показанная из кэша погода имеет то же время и второй раз не сохраняется
+func (s *ObservationStore) Record(city string, obs Observation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(city)
+	var kept []Observation
+	for _, old := range s.data[key] {
+		if old.Time.Equal(obs.Time) {
+			return
+		}
+		if obs.Time.Sub(old.Time) <= observationRetention {
+			kept = append(kept, old)
+		}
+	}
+	s.data[key] = append(kept, obs)
+}
+
+// Наблюдение примерно на сутки раньше указанного момента
+func (s *ObservationStore) DayBefore(city string, now time.Time) (Observation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := now.Add(-24 * time.Hour)
+	var best Observation
+	found := false
+	for _, obs := range s.data[strings.ToLower(city)] {
+		delta := absDuration(obs.Time.Sub(target))
+		if delta > observationTolerance {
+			continue
+		}
+		if !found || delta < absDuration(best.Time.Sub(target)) {
+			best = obs
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// Данные шаблона "yesterday.note"
+type yesterdayNoteData struct {
+	tempComparison
+	WindDoubled bool
+	WindHalved  bool
+}
+
+// Строка сравнения с погодой сутки назад, например
+// "На 2°C холоднее, чем вчера, ветер усилился вдвое"
+func yesterdayComparison(yesterday, today Observation, prefs UserPreferences) string {
+	note := yesterdayNoteData{
+		tempComparison: compareTemp(today.Temp, yesterday.Temp),
+		WindDoubled:    today.Wind >= 4 && today.Wind >= 2*yesterday.Wind,
+	}
+	note.WindHalved = !note.WindDoubled && yesterday.Wind >= 4 && today.Wind <= yesterday.Wind/2
+
+	line, err := renderTemplate("yesterday.note", prefs, note)
+	if err != nil {
+		log.Printf("Ошибка сравнения со вчера: %v", err)
+		return ""
+	}
+	return line
+}
+
+// Записываем текущую погоду и возвращаем строку сравнения со вчерашним днем
+// (пустую, если вчерашних наблюдений нет)
+func recordAndCompare(city string, data *CurrentWeather, prefs UserPreferences) string {
+	obs := Observation{
+		Time:     data.Time,
+		Temp:     data.Temp,
+		Wind:     data.WindSpeed,
+		Humidity: data.Humidity,
+	}
+
+	yesterday, found := observationStore.DayBefore(city, obs.Time)
+	if !found {
+		// После перезапуска в памяти пусто, но для городов с подписками
+		// есть записанные наблюдения
+		var sample ObservationSample
+		sample, found = botsObservationAround(data.Lat, data.Lon, obs.Time.Add(-24*time.Hour), observationTolerance)
+		yesterday = Observation{Time: sample.Time, Temp: sample.Temp, Wind: sample.Wind, Humidity: sample.Humidity}
+	}
+	observationStore.Record(city, obs)
+	if !found {
+		return ""
+	}
+
+	return "\n" + yesterdayComparison(yesterday, obs, prefs)
+}
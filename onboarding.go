@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Шаги знакомства с ботом
+const (
+	onboardingLang   = "lang"
+	onboardingUnits  = "units"
+	onboardingHome   = "home"
+	onboardingDigest = "digest"
+)
+
+// Диалог, в котором ждем название домашнего города текстом
+const flowOnboardingHome = "onboarding_home"
+
+func init() {
+	dialogFlows[flowOnboardingHome] = dialogFlow{
+		first: onboardingHome,
+		steps: map[string]dialogStep{
+			onboardingHome: {prompt: promptOnboardingHome, handle: handleOnboardingHome},
+		},
+		finish: finishOnboardingHome,
+	}
+}
+
+// Данные кнопок знакомства с ботом: "<шаг>:<значение>"
+func onboardingData(store *Store, step, value string) string {
+	return encodeCallback(store, CallbackPayload{Action: actionOnboarding, Value: step + ":" + value})
+}
+
+// Первое сообщение для нового пользователя: выбор языка
+func startOnboarding(store *Store, chatID int64) (tgbotapi.MessageConfig, error) {
+	// Сохраняем настройки по умолчанию, чтобы повторный /start не начинал знакомство заново
+	if err := store.UpdatePreferences(chatID, func(prefs *UserPreferences) {}); err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	msg := tgbotapi.NewMessage(chatID,
+		"Привет! Я бот погоды. 🌤\n"+
+			"Давайте настроим меня за четыре коротких шага.\n\n"+
+			"🌐 На каком языке показывать описания погоды?")
+	msg.ReplyMarkup = onboardingKeyboard(store, onboardingLang, langOrder, langTitles)
+	return msg, nil
+}
+
+// Клавиатура выбора одного варианта в строку
+func onboardingKeyboard(store *Store, step string, order []string, titles map[string]string) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, value := range order {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(titles[value], onboardingData(store, step, value)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// Вопрос про утреннюю сводку для выбранного домашнего города
+func onboardingDigestPrompt(store *Store, city string) (string, tgbotapi.InlineKeyboardMarkup) {
+	return fmt.Sprintf("☀️ Присылать каждое утро сводку погоды в %s?", city),
+		tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Да", onboardingData(store, onboardingDigest, "yes")),
+			tgbotapi.NewInlineKeyboardButtonData("Нет", onboardingData(store, onboardingDigest, "no")),
+		))
+}
+
+// Завершение знакомства
+func onboardingDoneText(prefix string) string {
+	return prefix + "✅ Готово! Напишите название города, чтобы узнать погоду.\n" +
+		"Изменить настройки: /settings, все команды: /help"
+}
+
+func promptOnboardingHome(state *DialogState) dialogPrompt {
+	return dialogPrompt{text: "🏠 Напишите название вашего города."}
+}
+
+func handleOnboardingHome(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	point, err := geocodeCity(input)
+	if err != nil {
+		return "", "", err
+	}
+	err = bot.store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.HomeCity = point.Name })
+	if err != nil {
+		return "", "", err
+	}
+	return "", point.Name, nil
+}
+
+// После выбора домашнего города продолжаем вопросом про сводку
+func finishOnboardingHome(bot *Bot, chatID int64, state *DialogState, city string) tgbotapi.MessageConfig {
+	text, markup := onboardingDigestPrompt(bot.store, city)
+	msg := tgbotapi.NewMessage(chatID, "🏠 Домашний город: "+city+"\n\n"+text)
+	msg.ReplyMarkup = markup
+	return msg
+}
+
+// Обработка нажатий в мастере знакомства: каждый ответ сохраняется сразу,
+// а следующий вопрос показывается в том же сообщении
+func handleOnboardingCallback(bot *Bot, callback *tgbotapi.CallbackQuery, data string) error {
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+
+	parts := strings.SplitN(data, ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	step, value := parts[0], parts[1]
+
+	var text string
+	var markup *tgbotapi.InlineKeyboardMarkup
+	switch step {
+	case onboardingLang:
+		if _, ok := langTitles[value]; !ok {
+			return nil
+		}
+		if err := bot.store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.Language = value }); err != nil {
+			return err
+		}
+		keyboard := onboardingKeyboard(bot.store, onboardingUnits, unitsOrder, unitsTitles)
+		text, markup = "🌡 В каких единицах показывать температуру и ветер?", &keyboard
+
+	case onboardingUnits:
+		if _, ok := unitsTitles[value]; !ok {
+			return nil
+		}
+		if err := bot.store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.Units = value }); err != nil {
+			return err
+		}
+		// Название города ждем обычным сообщением
+		if err := bot.store.SetDialog(chatID, DialogState{Flow: flowOnboardingHome, Step: onboardingHome, Data: map[string]string{}}); err != nil {
+			return err
+		}
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Пропустить", onboardingData(bot.store, onboardingHome, "skip")),
+		))
+		text = "🏠 Напишите название вашего города — я буду показывать его погоду, " +
+			"когда в команде не указан город."
+		markup = &keyboard
+
+	case onboardingHome:
+		if _, err := bot.store.ClearDialog(chatID); err != nil {
+			return err
+		}
+		text = onboardingDoneText("")
+
+	case onboardingDigest:
+		text = onboardingDoneText("")
+		if value == "yes" {
+			reply, err := subscribeDaily(bot.store, chatID, bot.store.Preferences(chatID).HomeCity)
+			if err != nil {
+				return err
+			}
+			text = onboardingDoneText(reply + "\n\n")
+		}
+
+	default:
+		return nil
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ReplyMarkup = markup
+	_, err := bot.Request(edit)
+	return err
+}
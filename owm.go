@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Адрес API OpenWeatherMap по умолчанию (переопределяется через OWM_API_URL)
+const defaultOWMURL = "http://api.openweathermap.org"
+
+var owmClient = &http.Client{Timeout: 15 * time.Second}
+
+// Структура для парсинга ответа OpenWeatherMap
+type owmWeatherResponse struct {
+	Name     string `json:"name"`
+	Dt       int64  `json:"dt"`
+	Timezone int    `json:"timezone"`
+	Coord    struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"coord"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		Humidity  int     `json:"humidity"`
+		Pressure  float64 `json:"pressure"`
+	} `json:"main"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+		Gust  float64 `json:"gust"`
+		Deg   int     `json:"deg"`
+	} `json:"wind"`
+	Weather []owmCondition `json:"weather"`
+	Clouds  struct {
+		All int `json:"all"`
+	} `json:"clouds"`
+	Rain struct {
+		OneHour float64 `json:"1h"`
+	} `json:"rain"`
+	Snow struct {
+		OneHour float64 `json:"1h"`
+	} `json:"snow"`
+	Visibility int `json:"visibility"`
+	Sys        struct {
+		Sunrise int64 `json:"sunrise"`
+		Sunset  int64 `json:"sunset"`
+	} `json:"sys"`
+}
+
+// Структура для парсинга прогноза на 5 дней
+type owmForecastResponse struct {
+	List []owmForecastItem `json:"list"`
+	City struct {
+		Name     string `json:"name"`
+		Timezone int    `json:"timezone"`
+		Coord    struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"coord"`
+	} `json:"city"`
+}
+
+// Один трехчасовой интервал прогноза OWM
+type owmForecastItem struct {
+	Dt   int64 `json:"dt"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		Humidity  int     `json:"humidity"`
+		Pressure  float64 `json:"pressure"`
+	} `json:"main"`
+	Weather []owmCondition `json:"weather"`
+	Clouds  struct {
+		All int `json:"all"`
+	} `json:"clouds"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+		Gust  float64 `json:"gust"`
+	} `json:"wind"`
+	Rain struct {
+		ThreeHours float64 `json:"3h"`
+	} `json:"rain"`
+	Snow struct {
+		ThreeHours float64 `json:"3h"`
+	} `json:"snow"`
+	Visibility int     `json:"visibility"`
+	Pop        float64 `json:"pop"`
+}
+
+// Погодные условия OWM. Иконки оканчиваются на "d" днем и на "n" ночью
+type owmCondition struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// Шаг прогноза OWM
+const forecastStep = 3 * time.Hour
+
+// Первое (основное) условие из ответа
+func firstCondition(conditions []owmCondition) (int, string, bool) {
+	if len(conditions) == 0 {
+		return 0, "", false
+	}
+	c := conditions[0]
+	return c.ID, c.Description, strings.HasSuffix(c.Icon, "d")
+}
+
+// Перевод ответа OWM в текущую погоду
+func (r *owmWeatherResponse) toCurrentWeather() *CurrentWeather {
+	w := &CurrentWeather{
+		City:       r.Name,
+		Lat:        r.Coord.Lat,
+		Lon:        r.Coord.Lon,
+		Time:       time.Unix(r.Dt, 0).In(time.FixedZone("", r.Timezone)),
+		Temp:       r.Main.Temp,
+		FeelsLike:  r.Main.FeelsLike,
+		Humidity:   r.Main.Humidity,
+		Pressure:   r.Main.Pressure,
+		WindSpeed:  r.Wind.Speed,
+		WindGust:   r.Wind.Gust,
+		WindDeg:    r.Wind.Deg,
+		Clouds:     r.Clouds.All,
+		Rain:       r.Rain.OneHour,
+		Snow:       r.Snow.OneHour,
+		Visibility: r.Visibility,
+	}
+	w.Condition, w.Description, w.Daytime = firstCondition(r.Weather)
+	if r.Sys.Sunrise != 0 && r.Sys.Sunset != 0 {
+		w.Sunrise = time.Unix(r.Sys.Sunrise, 0).In(w.Time.Location())
+		w.Sunset = time.Unix(r.Sys.Sunset, 0).In(w.Time.Location())
+	}
+	return w
+}
+
+// Перевод прогноза OWM в прогноз
+func (r *owmForecastResponse) toForecast() *Forecast {
+	f := &Forecast{
+		City:     r.City.Name,
+		Lat:      r.City.Coord.Lat,
+		Lon:      r.City.Coord.Lon,
+		Location: time.FixedZone("", r.City.Timezone),
+		Items:    make([]ForecastItem, 0, len(r.List)),
+	}
+	for _, raw := range r.List {
+		item := ForecastItem{
+			Time:       time.Unix(raw.Dt, 0),
+			Temp:       raw.Main.Temp,
+			FeelsLike:  raw.Main.FeelsLike,
+			Humidity:   raw.Main.Humidity,
+			Pressure:   raw.Main.Pressure,
+			WindSpeed:  raw.Wind.Speed,
+			WindGust:   raw.Wind.Gust,
+			Clouds:     raw.Clouds.All,
+			Rain:       raw.Rain.ThreeHours,
+			Snow:       raw.Snow.ThreeHours,
+			Visibility: raw.Visibility,
+			Pop:        raw.Pop,
+		}
+		item.Condition, item.Description, item.Daytime = firstCondition(raw.Weather)
+		f.Items = append(f.Items, item)
+	}
+	return f
+}
+
+// Адрес запроса к OWM с ключом API
+func owmURL(path string, params url.Values, key string) string {
+	params.Set("appid", key)
+	return config().OWMAPIURL + path + "?" + params.Encode()
+}
+
+// Параметры запроса по городу или по координатам. Значения всегда метрические
+func owmCityParams(city, lang string) url.Values {
+	return url.Values{"q": {city}, "units": {"metric"}, "lang": {lang}}
+}
+
+func owmCoordsParams(lat, lon float64, lang string) url.Values {
+	return url.Values{
+		"lat":   {fmt.Sprintf("%.6f", lat)},
+		"lon":   {fmt.Sprintf("%.6f", lon)},
+		"units": {"metric"},
+		"lang":  {lang},
+	}
+}
+
+// Запрос к OWM и разбор ответа в v. notFound — текст ошибки при неуспешном
+// ответе. При 401 и 429 ключ отключается и запрос повторяется со следующим
+func fetchOWM(path string, params url.Values, notFound string, v interface{}) error {
+	keys := config().OWMAPIKeys
+	for attempt := 0; ; attempt++ {
+		key := owmKeys.Pick(keys, time.Now())
+
+		resp, err := owmClient.Get(owmURL(path, params, key))
+		if err != nil {
+			return fmt.Errorf("ошибка запроса: %v", redactError(err))
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			retry := owmKeys.Report(keys, key, resp.StatusCode, time.Now())
+			if retry && attempt+1 < len(keys) && owmKeys.Available(keys, time.Now()) > 0 {
+				continue
+			}
+			return fmt.Errorf("%s", notFound)
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(v)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("ошибка парсинга данных: %v", err)
+		}
+		return nil
+	}
+}
+
+// Запрос текущей погоды в городе без форматирования
+func fetchWeather(city string) (*CurrentWeather, error) {
+	return fetchWeatherLang(city, langRU)
+}
+
+// Запрос текущей погоды в городе с описанием на указанном языке
+func fetchWeatherLang(city, lang string) (*CurrentWeather, error) {
+	city, err := normalizeCity(city)
+	if err != nil {
+		return nil, err
+	}
+	if mockWeatherMode() {
+		lat, lon := mockCoords(city)
+		return mockCurrentWeather(city, lat, lon, lang), nil
+	}
+
+	var data owmWeatherResponse
+	if err := fetchOWM("/data/2.5/weather", owmCityParams(city, lang), "город не найден или ошибка API", &data); err != nil {
+		return nil, err
+	}
+	return data.toCurrentWeather(), nil
+}
+
+// Запрос текущей погоды по координатам без форматирования
+func fetchWeatherByCoords(lat, lon float64) (*CurrentWeather, error) {
+	return fetchWeatherByCoordsLang(lat, lon, langRU)
+}
+
+// Запрос текущей погоды по координатам с описанием на указанном языке
+func fetchWeatherByCoordsLang(lat, lon float64, lang string) (*CurrentWeather, error) {
+	if mockWeatherMode() {
+		return mockCurrentWeather(mockPlaceName(lat, lon), lat, lon, lang), nil
+	}
+
+	var data owmWeatherResponse
+	if err := fetchOWM("/data/2.5/weather", owmCoordsParams(lat, lon, lang), "ошибка получения данных API", &data); err != nil {
+		return nil, err
+	}
+	return data.toCurrentWeather(), nil
+}
+
+// Запрос прогноза на 5 дней для города без форматирования
+func fetchForecast(city string) (*Forecast, error) {
+	return fetchForecastLang(city, langRU)
+}
+
+// Запрос прогноза на 5 дней для города с описаниями на указанном языке
+func fetchForecastLang(city, lang string) (*Forecast, error) {
+	city, err := normalizeCity(city)
+	if err != nil {
+		return nil, err
+	}
+	if mockWeatherMode() {
+		lat, lon := mockCoords(city)
+		return mockForecast(city, lat, lon, lang), nil
+	}
+
+	var data owmForecastResponse
+	if err := fetchOWM("/data/2.5/forecast", owmCityParams(city, lang), "город не найден или ошибка API", &data); err != nil {
+		return nil, err
+	}
+	return data.toForecast(), nil
+}
+
+// Запрос прогноза на 5 дней по координатам без форматирования
+func fetchForecastByCoords(lat, lon float64) (*Forecast, error) {
+	return fetchForecastByCoordsLang(lat, lon, langRU)
+}
+
+// Запрос прогноза на 5 дней по координатам с описаниями на указанном языке
+func fetchForecastByCoordsLang(lat, lon float64, lang string) (*Forecast, error) {
+	if mockWeatherMode() {
+		return mockForecast(mockPlaceName(lat, lon), lat, lon, lang), nil
+	}
+
+	var data owmForecastResponse
+	if err := fetchOWM("/data/2.5/forecast", owmCoordsParams(lat, lon, lang), "ошибка получения данных API", &data); err != nil {
+		return nil, err
+	}
+	return data.toForecast(), nil
+}
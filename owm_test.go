@@ -0,0 +1,237 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	owmWeatherFixture = `{
+		"name": "Москва", "dt": 1700000000, "timezone": 10800,
+		"coord": {"lat": 55.75, "lon": 37.62},
+		"main": {"temp": -3.5, "feels_like": -8.1, "humidity": 81, "pressure": 1012},
+		"wind": {"speed": 4.2, "gust": 7.5, "deg": 200},
+		"weather": [{"id": 600, "description": "небольшой снег", "icon": "13d"}],
+		"clouds": {"all": 90},
+		"snow": {"1h": 0.4},
+		"visibility": 8000,
+		"sys": {"sunrise": 1699936200, "sunset": 1699967400}
+	}`
+	owmForecastFixture = `{
+		"list": [
+			{"dt": 1700000000, "main": {"temp": 1.5, "pressure": 1010}, "weather": [{"id": 500, "description": "дождь", "icon": "10n"}], "rain": {"3h": 1.2}, "pop": 0.7},
+			{"dt": 1700010800, "main": {"temp": 2.5, "pressure": 1008}, "weather": [], "pop": 0}
+		],
+		"city": {"name": "Москва", "timezone": 10800, "coord": {"lat": 55.75, "lon": 37.62}}
+	}`
+)
+
+// Поддельный сервер OWM: отвечает status и body и запоминает последний запрос
+func fakeOWM(t *testing.T, status int, body string) *http.Request {
+	t.Helper()
+
+	last := &http.Request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = *r
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.OWMAPIKeys = []string{"test-key"}
+	c.OWMAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	return last
+}
+
+func TestFetchWeatherLang(t *testing.T) {
+	request := fakeOWM(t, http.StatusOK, owmWeatherFixture)
+
+	data, err := fetchWeatherLang("Нижний Новгород", langEN)
+	if err != nil {
+		t.Fatalf("fetchWeatherLang: %v", err)
+	}
+
+	if request.URL.Path != "/data/2.5/weather" {
+		t.Errorf("путь запроса %q", request.URL.Path)
+	}
+	query := request.URL.Query()
+	for key, want := range map[string]string{"q": "Нижний Новгород", "appid": "test-key", "units": "metric", "lang": langEN} {
+		if got := query.Get(key); got != want {
+			t.Errorf("параметр %s = %q, ожидалось %q", key, got, want)
+		}
+	}
+
+	if data.City != "Москва" || data.Temp != -3.5 || data.FeelsLike != -8.1 || data.Humidity != 81 {
+		t.Errorf("неверные основные значения: %+v", data)
+	}
+	if data.WindSpeed != 4.2 || data.WindGust != 7.5 || data.WindDeg != 200 {
+		t.Errorf("неверный ветер: %+v", data)
+	}
+	if data.Condition != 600 || data.Description != "небольшой снег" || !data.Daytime {
+		t.Errorf("неверные условия: %+v", data)
+	}
+	if data.Snow != 0.4 || data.Clouds != 90 || data.Visibility != 8000 {
+		t.Errorf("неверные осадки и облачность: %+v", data)
+	}
+	if _, offset := data.Time.Zone(); offset != 10800 || data.Time.Unix() != 1700000000 {
+		t.Errorf("неверное время наблюдения: %v", data.Time)
+	}
+	if got := data.Sunrise.Format("15:04") + "-" + data.Sunset.Format("15:04"); got != "07:30-16:10" {
+		t.Errorf("неверные восход и закат по местному времени: %s", got)
+	}
+}
+
+func TestFetchWeatherErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"город не найден", http.StatusNotFound, `{"cod": "404", "message": "city not found"}`, "город не найден"},
+		{"ошибка сервера", http.StatusInternalServerError, `{"cod": 500}`, "ошибка API"},
+		{"битый JSON", http.StatusOK, `{"name": "Москва", "main": {`, "ошибка парсинга данных"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeOWM(t, tt.status, tt.body)
+
+			data, err := fetchWeather("Москва")
+			if err == nil {
+				t.Fatalf("ожидалась ошибка, получено %+v", data)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ошибка %q не содержит %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchWeatherEmptyConditions(t *testing.T) {
+	fakeOWM(t, http.StatusOK, `{"name": "Москва", "main": {"temp": 5}, "weather": []}`)
+
+	data, err := fetchWeather("Москва")
+	if err != nil {
+		t.Fatalf("fetchWeather: %v", err)
+	}
+	if data.Condition != 0 || data.Description != "" || data.Daytime {
+		t.Errorf("без условий ожидались пустые значения: %+v", data)
+	}
+	if weatherCondition(data) != 0 {
+		t.Errorf("weatherCondition = %d, ожидалось 0", weatherCondition(data))
+	}
+
+	// Карточка без описания все равно собирается
+	prefs := UserPreferences{Units: unitsMetric, Language: langRU, Format: formatPlain}
+	if _, err := renderReply("weather", prefs, newWeatherCardData(data, prefs)); err != nil {
+		t.Errorf("renderReply: %v", err)
+	}
+}
+
+func TestFetchForecastByCoords(t *testing.T) {
+	request := fakeOWM(t, http.StatusOK, owmForecastFixture)
+
+	forecast, err := fetchForecastByCoords(55.75, 37.62)
+	if err != nil {
+		t.Fatalf("fetchForecastByCoords: %v", err)
+	}
+
+	query := request.URL.Query()
+	if request.URL.Path != "/data/2.5/forecast" || query.Get("lat") != "55.750000" || query.Get("lon") != "37.620000" {
+		t.Errorf("неверный запрос %s", request.URL)
+	}
+
+	if forecast.City != "Москва" || len(forecast.Items) != 2 {
+		t.Fatalf("неверный прогноз: %+v", forecast)
+	}
+	first, second := forecast.Items[0], forecast.Items[1]
+	if first.Rain != 1.2 || first.Pop != 0.7 || first.Description != "дождь" || first.Daytime {
+		t.Errorf("неверный первый интервал: %+v", first)
+	}
+	if second.Description != "" || second.Condition != 0 {
+		t.Errorf("интервал без условий: %+v", second)
+	}
+	if got := forecast.LocalTime(first).Hour(); got != 1 {
+		t.Errorf("местное время интервала %d:00, ожидалось 1:00", got)
+	}
+}
+
+func TestFetchForecastErrors(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		fakeOWM(t, status, `{}`)
+		if _, err := fetchForecast("Москва"); err == nil {
+			t.Errorf("статус %d: ожидалась ошибка", status)
+		}
+	}
+
+	fakeOWM(t, http.StatusOK, `not json`)
+	if _, err := fetchForecast("Москва"); err == nil || !strings.Contains(err.Error(), "ошибка парсинга") {
+		t.Errorf("битый JSON: ошибка %v", err)
+	}
+}
+
+func TestFetchWeatherKeyRotation(t *testing.T) {
+	previousKeys := owmKeys
+	owmKeys = &owmKeyPool{disabled: make(map[string]time.Time)}
+	t.Cleanup(func() { owmKeys = previousKeys })
+
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("appid")
+		used = append(used, key)
+		switch key {
+		case "exhausted":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Write([]byte(owmWeatherFixture))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.OWMAPIKeys = []string{"exhausted", "revoked", "good"}
+	c.OWMAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	// Первый запрос перебирает ключи до рабочего
+	if _, err := fetchWeather("Москва"); err != nil {
+		t.Fatalf("fetchWeather: %v", err)
+	}
+	if strings.Join(used, ",") != "exhausted,revoked,good" {
+		t.Errorf("порядок ключей %v", used)
+	}
+
+	// Отключенные ключи дальше пропускаются
+	used = nil
+	for i := 0; i < 3; i++ {
+		if _, err := fetchWeather("Москва"); err != nil {
+			t.Fatalf("fetchWeather: %v", err)
+		}
+	}
+	if strings.Join(used, ",") != "good,good,good" {
+		t.Errorf("после отключения использованы ключи %v", used)
+	}
+
+	// Когда все ключи отключены, ошибка OWM возвращается пользователю
+	c.OWMAPIKeys = []string{"exhausted", "revoked"}
+	used = nil
+	if _, err := fetchWeather("Москва"); err == nil {
+		t.Error("ожидалась ошибка без рабочих ключей")
+	}
+	if len(used) != 1 {
+		t.Errorf("без рабочих ключей сделано %d запросов, ожидался один", len(used))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// На сколько отключается ключ OWM: 401 означает отозванный или еще не
+// активированный ключ, 429 — исчерпанную квоту ключа
+const (
+	owmKeyUnauthorizedPause = time.Hour
+	owmKeyRateLimitPause    = 10 * time.Minute
+)
+
+// Ротация ключей OWM: запросы распределяются по кругу, ключи с ответами
+// 401 и 429 временно пропускаются
+type owmKeyPool struct {
+	next     int
+	disabled map[string]time.Time
+	mu       sync.Mutex
+}
+
+var owmKeys = &owmKeyPool{disabled: make(map[string]time.Time)}
+
+// Следующий ключ по кругу. Если отключены все, берем тот, что включится
+// раньше остальных: с одним ключом бот ведет себя как раньше
+func (p *owmKeyPool) Pick(keys []string, now time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(keys) == 0 {
+		return ""
+	}
+
+	soonest := ""
+	for i := 0; i < len(keys); i++ {
+		key := keys[(p.next+i)%len(keys)]
+		until, off := p.disabled[key]
+		if !off || !now.Before(until) {
+			delete(p.disabled, key)
+			p.next = (p.next + i + 1) % len(keys)
+			return key
+		}
+		if soonest == "" || until.Before(p.disabled[soonest]) {
+			soonest = key
+		}
+	}
+	return soonest
+}
+
+// Отключение ключа после ответа OWM. Возвращает true, если стоит
+// повторить запрос с другим ключом. Оператору сообщаем об отключении
+// ключа, а если это был последний ключ из keys — только об этом
+func (p *owmKeyPool) Report(keys []string, key string, status int, now time.Time) bool {
+	var pause time.Duration
+	switch status {
+	case http.StatusUnauthorized:
+		pause = owmKeyUnauthorizedPause
+	case http.StatusTooManyRequests:
+		pause = owmKeyRateLimitPause
+	default:
+		return false
+	}
+
+	p.mu.Lock()
+	p.disabled[key] = now.Add(pause)
+	p.mu.Unlock()
+
+	if p.Available(keys, now) == 0 {
+		go notifyOperator(eventOWMKeysExhausted, fmt.Sprintf(
+			"все ключи OWM (%d) отключены, последний — …%s на %s (HTTP %d); пока они не включатся, запросы идут с ключом, который включится раньше остальных",
+			len(keys), keySuffix(key), pause, status))
+	} else {
+		go notifyOperator(eventOWMKeyDisabled, fmt.Sprintf("ключ OWM …%s отключен на %s (HTTP %d)", keySuffix(key), pause, status))
+	}
+	return true
+}
+
+// Число ключей, доступных прямо сейчас
+func (p *owmKeyPool) Available(keys []string, now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	available := 0
+	for _, key := range keys {
+		if until, off := p.disabled[key]; !off || !now.Before(until) {
+			available++
+		}
+	}
+	return available
+}
+
+// Последние символы ключа, чтобы различать ключи в логах, не раскрывая их
+func keySuffix(key string) string {
+	if len(key) <= 4 {
+		return ""
+	}
+	return key[len(key)-4:]
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Пауза оповещений: под каждым оповещением есть кнопки, которые выключают
+// их на сутки или неделю (например, на время отпуска). Подписки при этом
+// остаются, а по окончании паузы оповещения приходят как раньше
+
+// Значения кнопок паузы
+const (
+	pauseDay    = "day"
+	pauseWeek   = "week"
+	pauseResume = "resume"
+)
+
+var pauseDurations = map[string]time.Duration{
+	pauseDay:  24 * time.Hour,
+	pauseWeek: 7 * 24 * time.Hour,
+}
+
+// Оповещения чата на паузе в момент now
+func alertsPaused(store *Store, chatID int64, now time.Time) bool {
+	return now.Before(store.PausedUntil(chatID))
+}
+
+// До какого момента оповещения чата на паузе
+func (s *Store) PausedUntil(chatID int64) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.PausedUntil[chatID]
+}
+
+// Включение паузы до until; нулевое время снимает паузу. Пауза хранится
+// отдельно от настроек: нажатие кнопки под оповещением не делает чат
+// «настроенным», и /start все равно предложит знакомство с ботом
+func (s *Store) SetPausedUntil(chatID int64, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if until.IsZero() {
+		delete(s.data.PausedUntil, chatID)
+	} else {
+		s.data.PausedUntil[chatID] = until
+	}
+	return s.save()
+}
+
+// Кнопки паузы под оповещением
+func pauseKeyboard(store *Store) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⏸ 1 день", encodeCallback(store, CallbackPayload{Action: actionPause, Value: pauseDay})),
+		tgbotapi.NewInlineKeyboardButtonData("🛑 Неделя", encodeCallback(store, CallbackPayload{Action: actionPause, Value: pauseWeek})),
+	))
+}
+
+// Кнопка под оповещением, после которого включили паузу
+func resumeKeyboard(store *Store) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("▶️ Включить оповещения", encodeCallback(store, CallbackPayload{Action: actionPause, Value: pauseResume})),
+	))
+}
+
+// Оповещение с кнопками паузы. Если у оповещения уже есть кнопки,
+// кнопки паузы добавляются под ними отдельной строкой
+func withPauseButtons(store *Store, c tgbotapi.Chattable) tgbotapi.Chattable {
+	switch message := c.(type) {
+	case tgbotapi.MessageConfig:
+		message.ReplyMarkup = withPauseRow(store, message.ReplyMarkup)
+		return message
+	case tgbotapi.PhotoConfig:
+		message.ReplyMarkup = withPauseRow(store, message.ReplyMarkup)
+		return message
+	}
+	return c
+}
+
+// Кнопки оповещения со строкой кнопок паузы в конце
+func withPauseRow(store *Store, markup interface{}) interface{} {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	switch keyboard := markup.(type) {
+	case nil:
+	case tgbotapi.InlineKeyboardMarkup:
+		rows = keyboard.InlineKeyboard
+	case *tgbotapi.InlineKeyboardMarkup:
+		rows = keyboard.InlineKeyboard
+	default:
+		// Клавиатура под полем ввода не совмещается с кнопками в сообщении
+		return markup
+	}
+	rows = append(rows[:len(rows):len(rows)], pauseKeyboard(store).InlineKeyboard...)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Нажатие на кнопку паузы: кнопки под оповещением меняются на «Включить»
+// и обратно
+func handlePauseCallback(bot *Bot, callback *tgbotapi.CallbackQuery, value string) (string, error) {
+	chatID := callback.Message.Chat.ID
+
+	var until time.Time
+	if duration, ok := pauseDurations[value]; ok {
+		until = clockNow().Add(duration)
+	} else if value != pauseResume {
+		return "", nil
+	}
+	if err := bot.store.SetPausedUntil(chatID, until); err != nil {
+		return "", err
+	}
+
+	toast := "▶️ Оповещения снова включены"
+	markup := pauseKeyboard(bot.store)
+	switch value {
+	case pauseDay:
+		toast, markup = "⏸ Оповещения на паузе на сутки, подписки сохранены", resumeKeyboard(bot.store)
+	case pauseWeek:
+		toast, markup = "🛑 Оповещения на паузе на неделю, подписки сохранены", resumeKeyboard(bot.store)
+	}
+	if _, err := bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, callback.Message.MessageID, markup)); err != nil {
+		// Кнопки могли удалить вместе с сообщением, пауза все равно включена
+		log.Printf("Ошибка обновления кнопок паузы: %v", err)
+	}
+	return toast, nil
+}
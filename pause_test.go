@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestPauseAlerts(t *testing.T) {
+	f := newFakeTelegram(t)
+	clock := useManualClock(t, time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC))
+	const chatID = 4461
+
+	alert := func() []telegramCall {
+		t.Helper()
+		f.reset()
+		if err := deliver(f.bot, store, chatID, "Грозы", tgbotapi.NewMessage(chatID, "⛈ Гроза"), "⛈ Гроза"); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+		return f.sent("sendMessage")
+	}
+	press := func(value string) string {
+		t.Helper()
+		f.reset()
+		f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "1",
+			From:    &tgbotapi.User{ID: chatID},
+			Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}},
+			Data:    encodeCallback(store, CallbackPayload{Action: actionPause, Value: value}),
+		}})
+		answers := f.sent("answerCallbackQuery")
+		if len(answers) != 1 {
+			t.Fatalf("ответов на колбэк %d", len(answers))
+		}
+		return answers[0].Params["text"]
+	}
+
+	sent := alert()
+	if len(sent) != 1 || !strings.Contains(sent[0].Params["reply_markup"], "⏸ 1 день") ||
+		!strings.Contains(sent[0].Params["reply_markup"], "🛑 Неделя") {
+		t.Fatalf("кнопки паузы под оповещением: %+v", sent)
+	}
+
+	if toast := press(pauseWeek); !strings.Contains(toast, "на неделю") {
+		t.Errorf("подсказка: %q", toast)
+	}
+	// Пауза не считается настройкой: /start все равно предложит знакомство
+	if store.HasPreferences(chatID) {
+		t.Error("пауза создала настройки чата")
+	}
+	edits := f.sent("editMessageReplyMarkup")
+	if len(edits) != 1 || !strings.Contains(edits[0].Params["reply_markup"], "Включить оповещения") {
+		t.Errorf("кнопка возобновления: %+v", edits)
+	}
+
+	clock.Advance(6 * 24 * time.Hour)
+	if sent := alert(); len(sent) != 0 {
+		t.Errorf("оповещение на паузе: %+v", sent)
+	}
+	if missed, _ := store.TakeMissed(chatID, clockNow()); len(missed) != 0 {
+		t.Errorf("оповещение на паузе попало в /missed: %+v", missed)
+	}
+	clock.Advance(24 * time.Hour)
+	if sent := alert(); len(sent) != 1 {
+		t.Errorf("после паузы оповещений %d", len(sent))
+	}
+
+	press(pauseDay)
+	if toast := press(pauseResume); toast != "▶️ Оповещения снова включены" {
+		t.Errorf("подсказка: %q", toast)
+	}
+	if sent := alert(); len(sent) != 1 {
+		t.Errorf("после возобновления оповещений %d", len(sent))
+	}
+}
+
+func TestPauseButtonsMergeKeyboard(t *testing.T) {
+	newFakeTelegram(t)
+	msg := tgbotapi.NewMessage(1, "🌅 Сводка")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "refresh"),
+	))
+
+	merged := withPauseButtons(store, msg).(tgbotapi.MessageConfig).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if len(merged.InlineKeyboard) != 2 || merged.InlineKeyboard[0][0].Text != "🔄 Обновить" ||
+		merged.InlineKeyboard[1][0].Text != "⏸ 1 день" {
+		t.Errorf("кнопки оповещения: %+v", merged.InlineKeyboard)
+	}
+	if original := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); len(original.InlineKeyboard) != 1 {
+		t.Errorf("исходные кнопки изменились: %+v", original.InlineKeyboard)
+	}
+
+	// Клавиатуру под полем ввода не трогаем
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	if _, ok := withPauseButtons(store, msg).(tgbotapi.MessageConfig).ReplyMarkup.(tgbotapi.ReplyKeyboardRemove); !ok {
+		t.Error("клавиатура под полем ввода заменена")
+	}
+}
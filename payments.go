@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Подтверждение оплаты: Telegram ждет ответа в течение 10 секунд
+func handlePreCheckout(bot *Bot, query *tgbotapi.PreCheckoutQuery) error {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+	if query.InvoicePayload != premiumPayload && query.InvoicePayload != donatePayload {
+		answer.OK = false
+		answer.ErrorMessage = "Этот счет устарел, запросите новый."
+	}
+	_, err := bot.Request(answer)
+	return err
+}
+
+// Обработка успешной оплаты
+func handleSuccessfulPayment(bot *Bot, message *tgbotapi.Message) string {
+	chatID := message.Chat.ID
+	payment := message.SuccessfulPayment
+	log.Printf("Оплата от чата %d: %s, %d %s (%s)",
+		chatID, payment.InvoicePayload, payment.TotalAmount, payment.Currency, payment.TelegramPaymentChargeID)
+
+	switch payment.InvoicePayload {
+	case premiumPayload:
+		until, err := bot.store.ExtendPremium(chatID, premiumPeriod)
+		if err != nil {
+			log.Printf("Ошибка сохранения премиума для чата %d: %v", chatID, err)
+		}
+		return fmt.Sprintf("⭐️ Спасибо! Премиум активен до %s.", until.Format("02.01.2006"))
+
+	case donatePayload:
+		donation := Donation{
+			ChatID:   chatID,
+			Name:     payerName(message.From),
+			Amount:   payment.TotalAmount,
+			Currency: payment.Currency,
+			ChargeID: payment.TelegramPaymentChargeID,
+			At:       time.Now(),
+		}
+		if err := bot.store.AddDonation(donation); err != nil {
+			log.Printf("Ошибка сохранения пожертвования от чата %d: %v", chatID, err)
+		}
+		notifyAdmins(bot, fmt.Sprintf("💰 Новое пожертвование: %s — %d %s",
+			donation.Name, donation.Amount, donation.Currency))
+		return "💙 Огромное спасибо за поддержку! Ваш вклад помогает оплачивать API погоды и сервер."
+	}
+
+	return "Спасибо за оплату!"
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Название места для отправленной геопозиции. В ответе OWM по координатам
+// поле name бывает пустым или указывает на соседний микрорайон, поэтому
+// название берется из обратного геокодирования: "пункт, регион, страна"
+
+// Длина геохеша для кэша названий: около 1,2 × 0,6 км, точнее, чем для погоды
+const placeGeohashPrecision = 6
+
+// Названия стран по коду ISO 3166 для подписи места. Остальные страны
+// показываются кодом
+var countryNames = map[string]map[string]string{
+	langRU: {
+		"RU": "Россия", "BY": "Беларусь", "UA": "Украина", "KZ": "Казахстан",
+		"UZ": "Узбекистан", "KG": "Киргизия", "TJ": "Таджикистан", "AM": "Армения",
+		"GE": "Грузия", "AZ": "Азербайджан", "MD": "Молдова", "LV": "Латвия",
+		"LT": "Литва", "EE": "Эстония", "FI": "Финляндия", "TR": "Турция",
+		"DE": "Германия", "FR": "Франция", "IT": "Италия", "ES": "Испания",
+		"GB": "Великобритания", "US": "США", "CN": "Китай", "TH": "Таиланд",
+		"AE": "ОАЭ", "EG": "Египет", "RS": "Сербия", "MN": "Монголия",
+	},
+	langEN: {
+		"RU": "Russia", "US": "USA", "GB": "United Kingdom", "AE": "UAE",
+	},
+}
+
+// Результат обратного геокодирования OWM
+type ReversePlace struct {
+	GeoPoint
+	// Регион (область, штат), в OWM только по-английски
+	State string `json:"state"`
+}
+
+// Подпись места на языке пользователя: "Химки, Moscow Oblast, Россия"
+func (p ReversePlace) Label(lang string) string {
+	name := p.Name
+	if local := p.LocalNames[lang]; local != "" {
+		name = local
+	}
+
+	parts := []string{name}
+	if p.State != "" && p.State != name {
+		parts = append(parts, p.State)
+	}
+	if p.Country != "" {
+		country := p.Country
+		if localized := countryNames[lang][p.Country]; localized != "" {
+			country = localized
+		}
+		parts = append(parts, country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Кэш названий мест: названия не меняются, поэтому без срока хранения
+var (
+	placeCache   = make(map[string]ReversePlace)
+	placeCacheMu sync.Mutex
+)
+
+// Место по координатам через обратное геокодирование OWM
+func reverseGeocode(lat, lon float64) (*ReversePlace, error) {
+	key := geohash(lat, lon, placeGeohashPrecision)
+	placeCacheMu.Lock()
+	cached, ok := placeCache[key]
+	placeCacheMu.Unlock()
+	if ok {
+		return &cached, nil
+	}
+
+	var place ReversePlace
+	if mockWeatherMode() {
+		place = ReversePlace{GeoPoint: GeoPoint{Name: mockPlaceName(lat, lon), Lat: lat, Lon: lon}}
+	} else {
+		params := url.Values{
+			"lat":   {fmt.Sprintf("%.6f", lat)},
+			"lon":   {fmt.Sprintf("%.6f", lon)},
+			"limit": {"1"},
+		}
+		var places []ReversePlace
+		if err := fetchOWM("/geo/1.0/reverse", params, "ошибка обратного геокодирования", &places); err != nil {
+			return nil, err
+		}
+		if len(places) == 0 || places[0].Name == "" {
+			return nil, fmt.Errorf("место по координатам не найдено")
+		}
+		place = places[0]
+	}
+
+	placeCacheMu.Lock()
+	placeCache[key] = place
+	placeCacheMu.Unlock()
+	return &place, nil
+}
+
+// Подпись места и название города для /forecast по отправленной
+// геопозиции. Если геокодирование не удалось, остается название из ответа
+// о погоде, а если пусто и оно — координаты
+func locationPlace(lat, lon float64, weatherCity, lang string) (label, city string) {
+	place, err := reverseGeocode(lat, lon)
+	if err == nil {
+		return place.Label(lang), place.DisplayName()
+	}
+	log.Printf("Ошибка определения места по координатам: %v", err)
+
+	if weatherCity != "" {
+		return weatherCity, weatherCity
+	}
+	return fmt.Sprintf("%.4f, %.4f", lat, lon), ""
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const owmReverseFixture = `[{
+	"name": "Khimki", "local_names": {"ru": "Химки", "en": "Khimki"},
+	"lat": 55.89, "lon": 37.44, "country": "RU", "state": "Moscow Oblast"
+}]`
+
+// Поддельный сервер OWM с ответами по путям. Возвращает счетчик запросов по путям
+func fakeOWMRoutes(t *testing.T, routes map[string]string) map[string]int {
+	t.Helper()
+
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.OWMAPIKeys = []string{"test-key"}
+	c.OWMAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	// Высоты Open-Meteo тоже отдает этот сервер
+	previousElevationURL := elevationAPIURL
+	elevationAPIURL = server.URL + "/v1/elevation"
+	t.Cleanup(func() { elevationAPIURL = previousElevationURL })
+	elevationCacheMu.Lock()
+	elevationCache = make(map[string]float64)
+	elevationCacheMu.Unlock()
+
+	placeCacheMu.Lock()
+	placeCache = make(map[string]ReversePlace)
+	placeCacheMu.Unlock()
+	nearbyCacheMu.Lock()
+	nearbyCache = make(map[string][]NearbyCity)
+	nearbyCacheMu.Unlock()
+
+	return requests
+}
+
+func TestReversePlaceLabel(t *testing.T) {
+	place := ReversePlace{
+		GeoPoint: GeoPoint{Name: "Khimki", Country: "RU", LocalNames: map[string]string{"ru": "Химки"}},
+		State:    "Moscow Oblast",
+	}
+	if got := place.Label(langRU); got != "Химки, Moscow Oblast, Россия" {
+		t.Errorf("Label(ru) = %q", got)
+	}
+	if got := place.Label(langEN); got != "Khimki, Moscow Oblast, Russia" {
+		t.Errorf("Label(en) = %q", got)
+	}
+
+	// Город-регион не повторяется, неизвестная страна остается кодом
+	city := ReversePlace{GeoPoint: GeoPoint{Name: "Reykjavik", Country: "IS"}, State: "Reykjavik"}
+	if got := city.Label(langRU); got != "Reykjavik, IS" {
+		t.Errorf("Label = %q", got)
+	}
+}
+
+func TestLocationPlace(t *testing.T) {
+	requests := fakeOWMRoutes(t, map[string]string{"/geo/1.0/reverse": owmReverseFixture})
+
+	for i := 0; i < 2; i++ {
+		label, city := locationPlace(55.8891, 37.4449, "", langRU)
+		if label != "Химки, Moscow Oblast, Россия" || city != "Химки" {
+			t.Errorf("locationPlace = %q, %q", label, city)
+		}
+	}
+	if n := requests["/geo/1.0/reverse"]; n != 1 {
+		t.Errorf("запросов обратного геокодирования %d, ожидался 1", n)
+	}
+
+	// Без ответа геокодирования остается название из погоды, а без него — координаты
+	fakeOWMRoutes(t, nil)
+	if label, city := locationPlace(10, 20, "Деревня", langRU); label != "Деревня" || city != "Деревня" {
+		t.Errorf("locationPlace без геокодирования = %q, %q", label, city)
+	}
+	if label, city := locationPlace(10, 20, "", langRU); label != "10.0000, 20.0000" || city != "" {
+		t.Errorf("locationPlace без названий = %q, %q", label, city)
+	}
+}
+
+func TestPipelineLocationPlace(t *testing.T) {
+	f := newFakeTelegram(t)
+	fakeOWMRoutes(t, map[string]string{
+		"/data/2.5/weather":  owmWeatherFixture,
+		"/data/2.5/forecast": owmForecastFixture,
+		"/geo/1.0/reverse":   owmReverseFixture,
+	})
+
+	climateCache.mu.Lock()
+	climateCache.data[climateKey(55.75, 37.62)] = [365]float64{}
+	climateCache.mu.Unlock()
+
+	const chatID = 2301
+	update := textUpdate(chatID, "")
+	update.Message.Location = &tgbotapi.Location{Latitude: 55.8891, Longitude: 37.4449}
+	f.send(update)
+
+	if card := f.reply(t, chatID); !strings.Contains(card, "Химки, Moscow Oblast, Россия") {
+		t.Errorf("в карточке нет места: %q", card)
+	}
+	if f.bot.lastCity[chatID] != "Химки" {
+		t.Errorf("последний город %q, ожидались Химки", f.bot.lastCity[chatID])
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сохраненные места: координаты под своим названием ("Дом", "Дача", "Офис").
+// Для дачного поселка точка на карте надежнее названия, у которого
+// в геокодере десяток тезок
+
+// Сохранение места: /place add Дача → отправка геопозиции
+const flowPlace = "place"
+
+// Сколько мест можно сохранить в одном чате
+const maxSavedPlaces = 10
+
+// Длина названия места в символах: название становится кнопкой клавиатуры
+const maxPlaceLabelLength = 32
+
+// Место, сохраненное под названием. Name — населенный пункт
+// по обратному геокодированию, он нужен для /forecast
+type SavedPlace struct {
+	Label   string    `json:"label"`
+	Name    string    `json:"name,omitempty"`
+	Lat     float64   `json:"lat"`
+	Lon     float64   `json:"lon"`
+	Created time.Time `json:"created"`
+}
+
+// Название места в карточке погоды: "Дача (Химки)"
+func (p SavedPlace) Title() string {
+	if p.Name == "" || strings.EqualFold(p.Name, p.Label) {
+		return p.Label
+	}
+	return p.Label + " (" + p.Name + ")"
+}
+
+func init() {
+	dialogFlows[flowPlace] = dialogFlow{
+		first: "location",
+		steps: map[string]dialogStep{
+			"location": {prompt: promptPlaceLocation, handle: handlePlaceLocationText, location: handlePlaceLocation},
+		},
+		finish: finishPlace,
+	}
+}
+
+// Проверка названия места: без лишних пробелов, не команда и не слишком длинное
+func normalizePlaceLabel(input string) (string, error) {
+	label := strings.Join(strings.Fields(input), " ")
+	switch {
+	case label == "":
+		return "", fmt.Errorf("укажите название места, например: /place add Дача")
+	case strings.HasPrefix(label, "/"):
+		return "", fmt.Errorf("название места не может начинаться с «/»")
+	case utf8.RuneCountInString(label) > maxPlaceLabelLength:
+		return "", fmt.Errorf("название места длиннее %d символов", maxPlaceLabelLength)
+	case label == dialogCancel:
+		return "", fmt.Errorf("выберите другое название места")
+	}
+	return label, nil
+}
+
+// Сохранение места. Место с тем же названием (без учета регистра) заменяется
+func (s *Store) SavePlace(chatID int64, place SavedPlace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	places := s.data.Places[chatID]
+	for i, existing := range places {
+		if strings.EqualFold(existing.Label, place.Label) {
+			places[i] = &place
+			return s.save()
+		}
+	}
+	if len(places) >= maxSavedPlaces {
+		return fmt.Errorf("в чате может быть не больше %d мест, удалите лишнее: /place del <название>", maxSavedPlaces)
+	}
+	s.data.Places[chatID] = append(places, &place)
+	return s.save()
+}
+
+// Копии мест чата в порядке добавления
+func (s *Store) Places(chatID int64) []SavedPlace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	places := make([]SavedPlace, 0, len(s.data.Places[chatID]))
+	for _, place := range s.data.Places[chatID] {
+		places = append(places, *place)
+	}
+	return places
+}
+
+// Место по названию без учета регистра
+func (s *Store) Place(chatID int64, label string) (SavedPlace, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	label = strings.Join(strings.Fields(label), " ")
+	for _, place := range s.data.Places[chatID] {
+		if strings.EqualFold(place.Label, label) {
+			return *place, true
+		}
+	}
+	return SavedPlace{}, false
+}
+
+// Удаление места по названию. Возвращает false, если такого нет
+func (s *Store) DeletePlace(chatID int64, label string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	label = strings.Join(strings.Fields(label), " ")
+	places := s.data.Places[chatID]
+	for i, place := range places {
+		if !strings.EqualFold(place.Label, label) {
+			continue
+		}
+		places = append(places[:i:i], places[i+1:]...)
+		if len(places) == 0 {
+			delete(s.data.Places, chatID)
+		} else {
+			s.data.Places[chatID] = places
+		}
+		return true, s.save()
+	}
+	return false, nil
+}
+
+func promptPlaceLocation(state *DialogState) dialogPrompt {
+	return dialogPrompt{
+		text:     fmt.Sprintf("📍 Отправьте геопозицию места «%s»: кнопкой ниже или через 📎 → Геопозиция, если вы сейчас не там.", state.Data["label"]),
+		location: true,
+	}
+}
+
+func handlePlaceLocationText(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	return "", "", fmt.Errorf("нужна геопозиция, а не текст")
+}
+
+func handlePlaceLocation(bot *Bot, chatID int64, state *DialogState, lat, lon float64) (string, string, error) {
+	lang := bot.store.Preferences(chatID).Language
+	label, city := locationPlace(lat, lon, "", lang)
+
+	place := SavedPlace{Label: state.Data["label"], Name: city, Lat: lat, Lon: lon, Created: time.Now()}
+	if err := bot.store.SavePlace(chatID, place); err != nil {
+		return "", "", err
+	}
+	return "", fmt.Sprintf("✅ Место «%s» сохранено: %s\n\nПогода там — по кнопке ниже или командой /place %s",
+		place.Label, label, place.Label), nil
+}
+
+// После сохранения сразу показываем клавиатуру мест
+func finishPlace(bot *Bot, chatID int64, state *DialogState, reply string) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, reply)
+	msg.ReplyMarkup = placesKeyboard(bot.store.Places(chatID))
+	return msg
+}
+
+// Клавиатура быстрого доступа: нажатие на место присылает его название,
+// и бот отвечает погодой в этой точке
+func placesKeyboard(places []SavedPlace) tgbotapi.ReplyKeyboardMarkup {
+	var rows [][]tgbotapi.KeyboardButton
+	var row []tgbotapi.KeyboardButton
+	for _, place := range places {
+		row = append(row, tgbotapi.NewKeyboardButton(place.Label))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if row != nil {
+		rows = append(rows, row)
+	}
+	keyboard := tgbotapi.NewReplyKeyboard(rows...)
+	keyboard.ResizeKeyboard = true
+	return keyboard
+}
+
+// Карточка погоды в сохраненном месте: как для города, но с названием места
+func formatPlaceWeather(data *CurrentWeather, place SavedPlace, prefs UserPreferences) (string, error) {
+	card := newWeatherCardData(data, prefs, climateLine(data, prefs), elevationLine(data, place.Lat, place.Lon, prefs))
+	card.City = place.Title()
+	return renderReply("weather", prefs, card)
+}
+
+// Погода в сохраненном месте в ответ на команду или нажатие кнопки
+func replyPlaceWeather(c *commandContext, place SavedPlace) {
+	chatID := c.message.Chat.ID
+	prefs := c.bot.store.Preferences(chatID)
+	data, err := cachedLocationWeather(place.Lat, place.Lon, prefs.Language)
+	if err == nil {
+		c.msg.Text, err = formatPlaceWeather(data, place, prefs)
+	}
+	if err != nil {
+		c.msg.Text = "❌ Ошибка получения погоды для места «" + place.Label + "»: " + err.Error()
+		return
+	}
+	c.msg.ParseMode = replyParseMode(prefs)
+
+	// /forecast и кнопка прогноза работают по населенному пункту места
+	if place.Name != "" {
+		c.bot.lastCity[chatID] = place.Name
+		c.msg.ReplyMarkup = weatherKeyboard(c.bot.store, place.Name, prefs)
+	}
+}
+
+// Список мест для ответа на /place
+func placesListText(places []SavedPlace) string {
+	if len(places) == 0 {
+		return "📍 Сохраненных мест нет. Сохраните точку под своим названием и узнавайте погоду в ней одной кнопкой:\n" +
+			"/place add Дача"
+	}
+
+	var b strings.Builder
+	b.WriteString("📍 Сохраненные места — нажмите на кнопку, чтобы узнать погоду:\n")
+	for _, place := range places {
+		fmt.Fprintf(&b, "• %s — %.4f, %.4f\n", place.Title(), place.Lat, place.Lon)
+	}
+	b.WriteString("\nДобавить: /place add <название>, удалить: /place del <название>")
+	return b.String()
+}
+
+// /place [название|add <название>|del <название>] — сохраненные места
+func handlePlaceCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	args := strings.TrimSpace(c.args)
+	if args == "" {
+		places := c.bot.store.Places(chatID)
+		c.msg.Text = placesListText(places)
+		if len(places) > 0 {
+			c.msg.ReplyMarkup = placesKeyboard(places)
+		}
+		return
+	}
+
+	action, rest, _ := strings.Cut(args, " ")
+	switch strings.ToLower(action) {
+	case "add", "del", "delete", "remove":
+		// В группе места меняют только администраторы, погоду смотрят все
+		if !canManageChatMessage(c.bot, c.message) {
+			c.msg.Text = groupAdminOnlyText
+			return
+		}
+	}
+
+	switch strings.ToLower(action) {
+	case "add":
+		label, err := normalizePlaceLabel(rest)
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		reply, err := startDialog(c.bot.store, chatID, flowPlace, map[string]string{"label": label})
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		c.msg = reply
+
+	case "del", "delete", "remove":
+		removed, err := c.bot.store.DeletePlace(chatID, rest)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Место удалено."
+			if places := c.bot.store.Places(chatID); len(places) > 0 {
+				c.msg.ReplyMarkup = placesKeyboard(places)
+			} else {
+				c.msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+			}
+		default:
+			c.msg.Text = "Нет места с таким названием. Список: /place"
+		}
+
+	default:
+		place, ok := c.bot.store.Place(chatID, args)
+		if !ok {
+			c.msg.Text = "Нет места «" + args + "». Список: /place, добавить: /place add " + args
+			return
+		}
+		replyPlaceWeather(c, place)
+	}
+}
+
+// Текст, совпадающий с названием сохраненного места, — запрос погоды в нем
+func replySavedPlace(c *commandContext) bool {
+	place, ok := c.bot.store.Place(c.message.Chat.ID, c.message.Text)
+	if !ok {
+		return false
+	}
+	replyPlaceWeather(c, place)
+	return true
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestNormalizePlaceLabel(t *testing.T) {
+	if got, err := normalizePlaceLabel("  Моя   дача "); err != nil || got != "Моя дача" {
+		t.Errorf("normalizePlaceLabel = %q, %v", got, err)
+	}
+	for _, input := range []string{"", "/start", strings.Repeat("д", maxPlaceLabelLength+1), dialogCancel} {
+		if _, err := normalizePlaceLabel(input); err == nil {
+			t.Errorf("normalizePlaceLabel(%q) без ошибки", input)
+		}
+	}
+}
+
+func TestStorePlaces(t *testing.T) {
+	previousStore := store
+	var err error
+	store, err = openStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	t.Cleanup(func() { store = previousStore })
+
+	if err := store.SavePlace(1, SavedPlace{Label: "Дача", Lat: 55.9, Lon: 37.4}); err != nil {
+		t.Fatalf("SavePlace: %v", err)
+	}
+	// То же название в другом регистре заменяет место
+	if err := store.SavePlace(1, SavedPlace{Label: "дача", Lat: 56.1, Lon: 37.9}); err != nil {
+		t.Fatalf("SavePlace: %v", err)
+	}
+	if places := store.Places(1); len(places) != 1 || places[0].Lat != 56.1 {
+		t.Errorf("места %+v, ожидалось одно обновленное", places)
+	}
+	if place, ok := store.Place(1, "  ДАЧА "); !ok || place.Lon != 37.9 {
+		t.Errorf("Place = %+v, %v", place, ok)
+	}
+
+	for i := 1; i < maxSavedPlaces; i++ {
+		if err := store.SavePlace(1, SavedPlace{Label: strings.Repeat("м", i)}); err != nil {
+			t.Fatalf("SavePlace %d: %v", i, err)
+		}
+	}
+	if err := store.SavePlace(1, SavedPlace{Label: "Лишнее"}); err == nil {
+		t.Error("сохранено больше мест, чем можно")
+	}
+
+	if removed, err := store.DeletePlace(1, "Дача"); !removed || err != nil {
+		t.Errorf("DeletePlace = %v, %v", removed, err)
+	}
+	if removed, _ := store.DeletePlace(1, "Дача"); removed {
+		t.Error("место удалено дважды")
+	}
+	if _, ok := store.Place(1, "Дача"); ok {
+		t.Error("удаленное место нашлось")
+	}
+}
+
+func TestPipelineSavedPlace(t *testing.T) {
+	f := newFakeTelegram(t)
+	fakeOWMRoutes(t, map[string]string{
+		"/data/2.5/weather": owmWeatherFixture,
+		"/geo/1.0/reverse":  owmReverseFixture,
+	})
+
+	climateCache.mu.Lock()
+	climateCache.data[climateKey(55.75, 37.62)] = [365]float64{}
+	climateCache.mu.Unlock()
+
+	const chatID = 2401
+	f.send(textUpdate(chatID, "/place add Дача"))
+	if prompt := f.reply(t, chatID); !strings.Contains(prompt, "Отправьте геопозицию места «Дача»") {
+		t.Errorf("вопрос %q", prompt)
+	}
+	if markup := f.sent("sendMessage")[0].Params["reply_markup"]; !strings.Contains(markup, `"request_location":true`) {
+		t.Errorf("нет кнопки геопозиции: %s", markup)
+	}
+
+	// Текст вместо геопозиции — переспрашиваем
+	f.reset()
+	f.send(textUpdate(chatID, "Химки"))
+	if got := f.reply(t, chatID); !strings.Contains(got, "нужна геопозиция") {
+		t.Errorf("ответ на текст %q", got)
+	}
+
+	// Геопозиция внутри диалога сохраняет место, а не показывает погоду
+	f.reset()
+	update := textUpdate(chatID, "")
+	update.Message.Location = &tgbotapi.Location{Latitude: 55.8891, Longitude: 37.4449}
+	f.send(update)
+	if got := f.reply(t, chatID); !strings.Contains(got, "Место «Дача» сохранено: Химки, Moscow Oblast, Россия") {
+		t.Errorf("ответ на геопозицию %q", got)
+	}
+	if markup := f.sent("sendMessage")[0].Params["reply_markup"]; !strings.Contains(markup, "Дача") {
+		t.Errorf("нет клавиатуры мест: %s", markup)
+	}
+
+	// Название с клавиатуры показывает погоду в точке
+	f.reset()
+	f.send(textUpdate(chatID, "дача"))
+	if card := f.reply(t, chatID); !strings.Contains(card, "Дача (Химки)") || !strings.Contains(card, "небольшой снег") {
+		t.Errorf("карточка %q", card)
+	}
+	if f.bot.lastCity[chatID] != "Химки" {
+		t.Errorf("последний город %q, ожидались Химки", f.bot.lastCity[chatID])
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/place"))
+	if got := f.reply(t, chatID); !strings.Contains(got, "• Дача (Химки) — 55.8891, 37.4449") {
+		t.Errorf("список мест %q", got)
+	}
+
+	f.reset()
+	f.send(textUpdate(chatID, "/place del Дача"))
+	if got := f.reply(t, chatID); got != "Место удалено." {
+		t.Errorf("ответ на удаление %q", got)
+	}
+	if places := store.Places(chatID); len(places) != 0 {
+		t.Errorf("места после удаления: %+v", places)
+	}
+}
+
+func TestPipelineGroupPlaceAdmins(t *testing.T) {
+	f := newFakeTelegram(t)
+	const groupID, memberID = -2402, 2403
+
+	f.send(groupTextUpdate(groupID, memberID, "/place add Офис"))
+	if got := f.reply(t, groupID); got != groupAdminOnlyText {
+		t.Errorf("участник добавляет место: %q", got)
+	}
+	if _, ok := store.Dialog(groupID); ok {
+		t.Error("диалог начат без прав")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Паузы между попытками после ошибок получения обновлений: удваиваются
+// от pollBackoffMin до pollBackoffMax. После pollAlertAfter ошибок подряд
+// сообщаем оператору и администраторам
+const (
+	pollBackoffMin = time.Second
+	pollBackoffMax = time.Minute
+	pollAlertAfter = 5
+)
+
+// Пауза перед следующей попыткой после failures ошибок подряд
+func pollBackoff(failures int) time.Duration {
+	backoff := pollBackoffMin
+	for i := 1; i < failures && backoff < pollBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > pollBackoffMax {
+		backoff = pollBackoffMax
+	}
+	return backoff
+}
+
+// Получение обновлений с переподключением. В отличие от GetUpdatesChan
+// ошибки не теряются в логе: после нескольких неудач подряд об этом узнают
+// оператор и администраторы. Канал закрывается после закрытия stop
+func pollUpdates(bot *Bot, u tgbotapi.UpdateConfig, stop <-chan struct{}) <-chan tgbotapi.Update {
+	updates := make(chan tgbotapi.Update, bot.Buffer)
+
+	go func() {
+		defer close(updates)
+
+		failures := 0
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			batch, err := bot.GetUpdates(u)
+			if err != nil {
+				failures++
+				backoff := pollBackoff(failures)
+				log.Printf("Ошибка получения обновлений (%d подряд), повтор через %s: %v", failures, backoff, err)
+				if failures == pollAlertAfter {
+					text := fmt.Sprintf("не удается получить обновления от Telegram: %d ошибок подряд, последняя: %v", failures, redactError(err))
+					notifyOperator(eventPollingFailing, text)
+					go notifyAdmins(bot, "⚠️ Бот "+text)
+				}
+
+				select {
+				case <-stop:
+					return
+				case <-time.After(backoff):
+				}
+				continue
+			}
+
+			if failures >= pollAlertAfter {
+				text := fmt.Sprintf("получение обновлений восстановлено после %d ошибок подряд", failures)
+				notifyOperator(eventPollingRecovered, text)
+				go notifyAdmins(bot, "✅ "+text)
+			}
+			failures = 0
+
+			for _, update := range batch {
+				if update.UpdateID >= u.Offset {
+					u.Offset = update.UpdateID + 1
+				}
+				updates <- update
+			}
+		}
+	}()
+
+	return updates
+}
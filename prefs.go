@@ -0,0 +1,106 @@
+package main
+
+// Системы единиц измерения
+const (
+	unitsMetric   = "metric"
+	unitsImperial = "imperial"
+)
+
+// Единицы скорости ветра. Если пользователь их не выбрал, они следуют
+// системе единиц: м/с для метрической и mph для имперской
+const (
+	windMS    = "ms"
+	windKMH   = "kmh"
+	windMPH   = "mph"
+	windKnots = "kn"
+)
+
+// Языки ответов
+const (
+	langRU = "ru"
+	langEN = "en"
+)
+
+// Источники данных о погоде. providerMock — демо-режим без ключа API,
+// включается только через WEATHER_PROVIDER
+const (
+	providerOWM  = "owm"
+	providerMock = "mock"
+)
+
+// Настройки пользователя (чата)
+type UserPreferences struct {
+	Units    string `json:"units,omitempty"`
+	WindUnit string `json:"wind_unit,omitempty"`
+	Language string `json:"language,omitempty"`
+	HomeCity string `json:"home_city,omitempty"`
+	// Оформление ответов: обычный текст, HTML или кратко
+	Format string `json:"format,omitempty"`
+	// Отвечать без стикеров
+	PlainText bool `json:"plain_text,omitempty"`
+	// Утренняя сводка без советов и фактов о погоде
+	NoDigestTips bool `json:"no_digest_tips,omitempty"`
+	// Ответы без эмодзи и с единицами словами для экранного диктора
+	ScreenReader bool `json:"screen_reader,omitempty"`
+	// Дублировать ответы с погодой голосовым сообщением (/voice on)
+	VoiceReplies bool `json:"voice_replies,omitempty"`
+}
+
+// Настройки по умолчанию для новых пользователей
+func defaultPreferences() UserPreferences {
+	return UserPreferences{
+		Units:    unitsMetric,
+		Language: langRU,
+		Format:   formatPlain,
+	}
+}
+
+// Настройки чата с подставленными значениями по умолчанию
+func (s *Store) Preferences(chatID int64) UserPreferences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs := defaultPreferences()
+	if saved, ok := s.data.Preferences[chatID]; ok {
+		if saved.Units != "" {
+			prefs.Units = saved.Units
+		}
+		if saved.Language != "" {
+			prefs.Language = saved.Language
+		}
+		if saved.Format != "" {
+			prefs.Format = saved.Format
+		}
+		prefs.WindUnit = saved.WindUnit
+		prefs.HomeCity = saved.HomeCity
+		prefs.PlainText = saved.PlainText
+		prefs.NoDigestTips = saved.NoDigestTips
+		prefs.ScreenReader = saved.ScreenReader
+		prefs.VoiceReplies = saved.VoiceReplies
+	}
+	return prefs
+}
+
+// Сохранял ли чат настройки хотя бы раз (по этому признаку узнаем новых пользователей)
+func (s *Store) HasPreferences(chatID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.data.Preferences[chatID]
+	return ok
+}
+
+// Изменение настроек чата
+func (s *Store) UpdatePreferences(chatID int64, update func(prefs *UserPreferences)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs, ok := s.data.Preferences[chatID]
+	if !ok {
+		defaults := defaultPreferences()
+		prefs = &defaults
+		s.data.Preferences[chatID] = prefs
+	}
+	update(prefs)
+	return s.save()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Валюта Telegram Stars
+const currencyStars = "XTR"
+
+// Цена премиума по умолчанию в звездах (переопределяется через PREMIUM_PRICE_STARS)
+const defaultPremiumPrice = 100
+
+// Срок, на который продлевается премиум после оплаты
+const premiumPeriod = 30 * 24 * time.Hour
+
+// Payload счета на премиум
+const premiumPayload = "premium_30d"
+
+// Сколько подписок на оповещения доступно без премиума
+const freeSubscriptionLimit = 2
+
+// Команды, доступные только с премиумом
+var premiumCommands = map[string]bool{
+	"nowcast": true,
+}
+
+// Цена премиума в звездах
+func premiumPrice() int {
+	return config().PremiumPriceStars
+}
+
+// До какого времени у чата оплачен премиум
+func (s *Store) PremiumUntil(chatID int64) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.Premium[chatID]
+}
+
+// Активен ли премиум у чата
+func (s *Store) IsPremium(chatID int64) bool {
+	return time.Now().Before(s.PremiumUntil(chatID))
+}
+
+// Продление премиума: к оставшемуся сроку, если он еще не истек
+func (s *Store) ExtendPremium(chatID int64, period time.Duration) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := time.Now()
+	if until := s.data.Premium[chatID]; until.After(from) {
+		from = until
+	}
+	until := from.Add(period)
+	s.data.Premium[chatID] = until
+	return until, s.save()
+}
+
+// Ответ на команду премиум-функции без оплаченного премиума
+func premiumRequiredText() string {
+	return "⭐️ Эта функция доступна с премиумом. Подробнее: /premium"
+}
+
+// Описание премиума и счет на оплату звездами
+func premiumOffer(store *Store, chatID int64) (string, *tgbotapi.InvoiceConfig) {
+	text := "⭐️ Премиум на 30 дней:\n" +
+		"• Больше двух подписок на оповещения\n" +
+		"• /nowcast — осадки на ближайшие 2 часа с шагом 15 минут\n"
+
+	if until := store.PremiumUntil(chatID); until.After(time.Now()) {
+		text += fmt.Sprintf("\n✅ Премиум активен до %s. Оплата ниже продлит его еще на 30 дней.", until.Format("02.01.2006"))
+	}
+
+	invoice := tgbotapi.NewInvoice(
+		chatID,
+		"Премиум на 30 дней",
+		"Больше подписок на оповещения и прогноз осадков по минутам",
+		premiumPayload,
+		"",
+		"",
+		currencyStars,
+		[]tgbotapi.LabeledPrice{{Label: "Премиум на 30 дней", Amount: premiumPrice()}},
+	)
+	return text, &invoice
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Тип подписки на оповещения о перепадах давления
+const alertPressure = "pressure"
+
+// Порог перепада давления за сутки по умолчанию, гПа
+const defaultPressureThreshold = 8.0
+
+// Окно, в котором ищем перепад давления
+const pressureWindow = 24 * time.Hour
+
+func init() {
+	alertKinds[alertPressure] = alertKind{title: "Перепады давления", check: checkPressure, cooldown: 24 * time.Hour}
+}
+
+// Проверка подписки: перепад давления в ближайшие сутки не меньше порога
+func checkPressure(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := fetchForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+	if len(forecast.Items) == 0 {
+		return "", false, nil
+	}
+
+	threshold := sub.Threshold
+	if threshold <= 0 {
+		threshold = defaultPressureThreshold
+	}
+
+	first := forecast.Items[0]
+	start := first.Time
+	lowest, highest := first, first
+	for _, item := range forecast.Items {
+		if item.Time.Sub(start) > pressureWindow {
+			break
+		}
+		if item.Pressure < lowest.Pressure {
+			lowest = item
+		}
+		if item.Pressure > highest.Pressure {
+			highest = item
+		}
+	}
+
+	swing := highest.Pressure - lowest.Pressure
+	if swing < threshold {
+		return "", false, nil
+	}
+
+	// Направление перепада определяем по тому, что наступит раньше
+	direction := "упадет"
+	from, to := highest, lowest
+	if lowest.Time.Before(highest.Time) {
+		direction = "вырастет"
+		from, to = lowest, highest
+	}
+
+	return fmt.Sprintf(
+		"🤕 Резкий перепад давления в %s!\n"+
+			"С %s до %s давление %s на %.0f гПа (%.0f → %.0f гПа, %.0f → %.0f мм рт. ст.).\n"+
+			"Метеочувствительным стоит заранее запастись лекарствами и отдыхом.",
+		sub.City,
+		forecast.LocalTime(from).Format("02.01 15:04"),
+		forecast.LocalTime(to).Format("02.01 15:04"),
+		direction,
+		swing,
+		from.Pressure,
+		to.Pressure,
+		hPaToMmHg(from.Pressure),
+		hPaToMmHg(to.Pressure),
+	), true, nil
+}
+
+// Перевод гектопаскалей в миллиметры ртутного столба
+func hPaToMmHg(hPa float64) float64 {
+	return hPa * 0.750062
+}
+
+// Разбор аргументов вида "Москва 6": город и необязательный порог в гПа
+func parsePressureArgs(args string) (string, float64) {
+	fields := strings.Fields(args)
+	if len(fields) > 1 {
+		if threshold, err := strconv.ParseFloat(fields[len(fields)-1], 64); err == nil {
+			return strings.Join(fields[:len(fields)-1], " "), threshold
+		}
+	}
+	return strings.Join(fields, " "), 0
+}
+
+// Подписка чата на оповещения о перепадах давления
+func subscribePressure(store *Store, chatID int64, city string, threshold float64) (string, error) {
+	if threshold == 0 {
+		threshold = defaultPressureThreshold
+	}
+	if threshold < 2 || threshold > 40 || math.IsNaN(threshold) {
+		return "Порог должен быть от 2 до 40 гПа.", nil
+	}
+
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID:    chatID,
+		Kind:      alertPressure,
+		City:      point.DisplayName(),
+		Lat:       point.Lat,
+		Lon:       point.Lon,
+		Threshold: threshold,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🤕 Подписка оформлена! Предупрежу, если в %s давление изменится на %.0f гПа и больше за сутки.\n"+
+			"Отписаться: /pressure off",
+		point.DisplayName(),
+		threshold,
+	), nil
+}
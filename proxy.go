@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Схемы прокси, которые понимает net/http
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+func parseProxyURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || !proxySchemes[parsed.Scheme] {
+		return nil, fmt.Errorf("ожидается адрес вида http://хост:порт или socks5://логин:пароль@хост:порт, получено %q", value)
+	}
+	return parsed, nil
+}
+
+// Транспорт через прокси. Без прокси остается стандартный транспорт,
+// который учитывает HTTP_PROXY и HTTPS_PROXY
+func proxyTransport(proxy string) http.RoundTripper {
+	if proxy == "" {
+		return http.DefaultTransport
+	}
+	parsed, err := parseProxyURL(proxy)
+	if err != nil {
+		// Адрес уже проверен в parseConfig
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(parsed)
+	return transport
+}
+
+// HTTP-клиент Telegram: запросы Bot API и загрузка голосовых сообщений
+var telegramClient = &http.Client{Timeout: 90 * time.Second}
+
+// Прокси применяются при запуске: TELEGRAM_PROXY для Telegram,
+// WEATHER_PROXY для всех источников погоды
+func applyProxies(c *Config) {
+	telegramClient.Transport = proxyTransport(c.TelegramProxy)
+
+	weather := proxyTransport(c.WeatherProxy)
+	for _, client := range []*http.Client{owmClient, climateClient, openMeteoClient, kpClient, aviationClient, usgsClient, gibsClient} {
+		client.Transport = weather
+	}
+}
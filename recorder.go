@@ -0,0 +1,211 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Запись наблюдений: фоновая задача раз в OBSERVATION_INTERVAL сохраняет
+// фактическую погоду во всех городах с подписками в файл состояния. На
+// этих записях строятся /citystats и сравнение со вчерашним днем после
+// перезапуска; записи старше OBSERVATION_RETENTION удаляются
+
+// Длина геохеша для ключа города: около 5 км, координаты одного города
+// из геокодера всегда одинаковые
+const observationGeohashPrecision = 5
+
+// Предел записей на город на случай слишком частой записи
+const observationMaxSamples = 5000
+
+// Наблюдение погоды в городе
+type ObservationSample struct {
+	Time      time.Time `json:"time"` // в часовом поясе города
+	Temp      float64   `json:"temp"`
+	FeelsLike float64   `json:"feels_like"`
+	Humidity  int       `json:"humidity"`
+	Pressure  float64   `json:"pressure"`
+	Wind      float64   `json:"wind"`
+	Condition int       `json:"condition"`
+	// Осадки за последний час, мм
+	Rain float64 `json:"rain,omitempty"`
+	Snow float64 `json:"snow,omitempty"`
+}
+
+// Записанные наблюдения по городу в порядке времени
+type CityObservations struct {
+	City    string              `json:"city"`
+	Lat     float64             `json:"lat"`
+	Lon     float64             `json:"lon"`
+	Samples []ObservationSample `json:"samples"`
+}
+
+// Ключ города в записях наблюдений
+func observationKey(lat, lon float64) string {
+	return geohash(lat, lon, observationGeohashPrecision)
+}
+
+// Сохранение наблюдения. То же наблюдение из кэша второй раз не пишется.
+// Возвращает false, если запись пропущена
+func (s *Store) RecordObservation(city string, lat, lon float64, data *CurrentWeather) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := observationKey(lat, lon)
+	observations, exists := s.data.Observations[key]
+	if !exists {
+		observations = &CityObservations{}
+		s.data.Observations[key] = observations
+	}
+	observations.City, observations.Lat, observations.Lon = city, lat, lon
+
+	if n := len(observations.Samples); n > 0 && !data.Time.After(observations.Samples[n-1].Time) {
+		return false, nil
+	}
+	observations.Samples = append(observations.Samples, ObservationSample{
+		Time:      data.Time,
+		Temp:      data.Temp,
+		FeelsLike: data.FeelsLike,
+		Humidity:  data.Humidity,
+		Pressure:  data.Pressure,
+		Wind:      data.WindSpeed,
+		Condition: data.Condition,
+		Rain:      data.Rain,
+		Snow:      data.Snow,
+	})
+	if len(observations.Samples) > observationMaxSamples {
+		observations.Samples = observations.Samples[len(observations.Samples)-observationMaxSamples:]
+	}
+	return true, s.save()
+}
+
+// Копия наблюдений по городу
+func (s *Store) Observations(lat, lon float64) (CityObservations, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	observations, exists := s.data.Observations[observationKey(lat, lon)]
+	if !exists {
+		return CityObservations{}, false
+	}
+	copied := *observations
+	copied.Samples = append([]ObservationSample(nil), observations.Samples...)
+	return copied, true
+}
+
+// Наблюдение, ближайшее к моменту at, но не дальше tolerance от него
+func (s *Store) ObservationAround(lat, lon float64, at time.Time, tolerance time.Duration) (ObservationSample, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	observations, exists := s.data.Observations[observationKey(lat, lon)]
+	if !exists {
+		return ObservationSample{}, false
+	}
+	var best ObservationSample
+	found := false
+	for _, sample := range observations.Samples {
+		delta := absDuration(sample.Time.Sub(at))
+		if delta > tolerance {
+			continue
+		}
+		if !found || delta < absDuration(best.Time.Sub(at)) {
+			best = sample
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Наблюдения — данные о погоде, а не о пользователях, поэтому для статистики
+// и сравнений берем их у любого бота, у которого город есть в подписках.
+// Выбираем самую длинную историю
+func botsObservations(lat, lon float64) (CityObservations, bool) {
+	var best CityObservations
+	found := false
+	for _, bot := range bots {
+		observations, ok := bot.store.Observations(lat, lon)
+		if ok && (!found || len(observations.Samples) > len(best.Samples)) {
+			best, found = observations, true
+		}
+	}
+	return best, found
+}
+
+// Наблюдение около момента at у любого из ботов
+func botsObservationAround(lat, lon float64, at time.Time, tolerance time.Duration) (ObservationSample, bool) {
+	for _, bot := range bots {
+		if sample, ok := bot.store.ObservationAround(lat, lon, at, tolerance); ok {
+			return sample, true
+		}
+	}
+	return ObservationSample{}, false
+}
+
+// Удаление наблюдений старше before; города без наблюдений удаляются
+// целиком. Возвращает число удаленных записей
+func (s *Store) PruneObservations(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, observations := range s.data.Observations {
+		kept := observations.Samples[:0]
+		for _, sample := range observations.Samples {
+			if sample.Time.Before(before) {
+				removed++
+				continue
+			}
+			kept = append(kept, sample)
+		}
+		observations.Samples = kept
+		if len(kept) == 0 {
+			delete(s.data.Observations, key)
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.save()
+}
+
+// Один обход: наблюдения для всех городов с подписками, по одному запросу
+// на город, и очистка устаревших. Возвращает число новых записей
+func recordObservations(store *Store) int {
+	recorded := 0
+	seen := make(map[string]bool)
+	for _, sub := range store.Subscriptions() {
+		key := observationKey(sub.Lat, sub.Lon)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		data, err := cachedWeatherByCoords(sub.Lat, sub.Lon)
+		if err != nil {
+			log.Printf("Ошибка записи наблюдения для %s: %v", sub.City, err)
+			continue
+		}
+		added, err := store.RecordObservation(sub.City, sub.Lat, sub.Lon, data)
+		if err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		if added {
+			recorded++
+		}
+	}
+
+	if _, err := store.PruneObservations(clockNow().Add(-config().ObservationRetention)); err != nil {
+		log.Printf("Ошибка сохранения состояния: %v", err)
+	}
+	return recorded
+}
+
+func runObservationRecorder(bot *Bot) {
+	ticker := time.NewTicker(config().ObservationInterval)
+	defer ticker.Stop()
+
+	for {
+		recordObservations(bot.store)
+		<-ticker.C
+	}
+}
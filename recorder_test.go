@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordObservation(t *testing.T) {
+	newFakeTelegram(t)
+	local := time.FixedZone("", 3*3600)
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, local)
+	record := func(at time.Time, temp float64) bool {
+		t.Helper()
+		added, err := store.RecordObservation("Казань", 55.79, 49.12, &CurrentWeather{Time: at, Temp: temp})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return added
+	}
+
+	for h := 0; h < 48; h++ {
+		if !record(start.Add(time.Duration(h)*time.Hour), float64(h)) {
+			t.Fatalf("наблюдение %d не записано", h)
+		}
+	}
+	// Повтор из кэша и запоздавшее наблюдение не пишутся
+	if record(start.Add(47*time.Hour), 47) || record(start.Add(30*time.Minute), 0) {
+		t.Error("записан повтор")
+	}
+
+	sample, ok := store.ObservationAround(55.79, 49.12, start.Add(24*time.Hour+20*time.Minute), observationTolerance)
+	if !ok || sample.Temp != 24 {
+		t.Errorf("ближайшее наблюдение %+v, %v", sample, ok)
+	}
+	if _, ok := store.ObservationAround(55.79, 49.12, start.Add(-4*time.Hour), observationTolerance); ok {
+		t.Error("найдено наблюдение дальше допуска")
+	}
+
+	removed, err := store.PruneObservations(start.Add(36 * time.Hour))
+	if err != nil || removed != 36 {
+		t.Errorf("удалено %d записей: %v", removed, err)
+	}
+	observations, _ := store.Observations(55.79, 49.12)
+	if len(observations.Samples) != 12 || observations.Samples[0].Temp != 36 {
+		t.Errorf("осталось %d записей с %+v", len(observations.Samples), observations.Samples[0])
+	}
+
+	// Город без свежих наблюдений удаляется целиком
+	if _, err := store.PruneObservations(start.AddDate(0, 0, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Observations(55.79, 49.12); ok {
+		t.Error("город без наблюдений не удален")
+	}
+}
+
+func TestRecordObservations(t *testing.T) {
+	f := newFakeTelegram(t)
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+	clock := useManualClock(t, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	previousCoords, previousWeather := coordsCache, weatherCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	weatherCache = &WeatherCache{data: make(map[string]CacheItem)}
+	t.Cleanup(func() { coordsCache, weatherCache = previousCoords, previousWeather })
+
+	kazan, err := geocodeCity("Казань")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []AlertSubscription{
+		{ChatID: 4291, Kind: alertThunder, City: "Казань", Lat: kazan.Lat, Lon: kazan.Lon},
+		{ChatID: 4292, Kind: alertHeatwave, City: "Казань", Lat: kazan.Lat, Lon: kazan.Lon},
+		{ChatID: 4292, Kind: alertThunder, City: "Уфа", Lat: 54.74, Lon: 55.97},
+	} {
+		if err := store.Subscribe(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if recorded := recordObservations(store); recorded != 2 {
+		t.Errorf("записано %d наблюдений, ожидалось по одному на город", recorded)
+	}
+	// Погода еще в кэше: новых наблюдений нет
+	if recorded := recordObservations(store); recorded != 0 {
+		t.Errorf("повторно записано %d наблюдений", recorded)
+	}
+	clock.Advance(2 * time.Hour)
+	if recorded := recordObservations(store); recorded != 2 {
+		t.Errorf("через 2 часа записано %d наблюдений", recorded)
+	}
+
+	// Вчерашнее наблюдение находится и без истории в памяти
+	clock.Advance(22 * time.Hour)
+	observationStore.mu.Lock()
+	delete(observationStore.data, "казань")
+	observationStore.mu.Unlock()
+	f.send(textUpdate(4291, "Казань"))
+	if reply := f.reply(t, 4291); !strings.Contains(reply, "вчера") {
+		t.Errorf("нет сравнения со вчерашним днем: %q", reply)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Заглушка вместо вырезанного секрета
+const redactedMark = "***"
+
+// Секреты в адресах: ключ OWM в параметре appid, токен бота в пути
+// /bot<токен>/ и другие ключи в параметрах запроса
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(appid|api_key|apikey|key|token|access_token|sentry_key)=[^&\s"']+`),
+	regexp.MustCompile(`/bot\d+:[\w-]+`),
+}
+
+// Значения секретов из текущих настроек
+func secretValues() []string {
+	c := config()
+	values := []string{c.TelegramToken, c.STTAPIKey, c.TTSAPIKey, c.DebugToken, c.BackupS3SecretKey}
+	values = append(values, c.TelegramTokens...)
+	values = append(values, c.OWMAPIKeys...)
+	values = append(values, c.APITokens...)
+	if _, key, err := parseSentryDSN(c.SentryDSN); err == nil {
+		values = append(values, key)
+	}
+	for _, proxy := range []string{c.TelegramProxy, c.WeatherProxy} {
+		if parsed, err := parseProxyURL(proxy); err == nil && parsed.User != nil {
+			if password, ok := parsed.User.Password(); ok {
+				values = append(values, password)
+			}
+		}
+	}
+	if parsed, err := parseMQTTURL(c.MQTTURL); err == nil && parsed.User != nil {
+		if password, ok := parsed.User.Password(); ok {
+			values = append(values, password)
+		}
+	}
+	return values
+}
+
+// Удаление ключей API и токенов из строки перед записью в лог или показом
+func redactSecrets(s string) string {
+	for _, secret := range secretValues() {
+		// Слишком короткие значения заменять опасно: испортим обычный текст
+		if len(secret) >= 6 {
+			s = strings.ReplaceAll(s, secret, redactedMark)
+		}
+	}
+	s = secretPatterns[0].ReplaceAllString(s, "${1}="+redactedMark)
+	s = secretPatterns[1].ReplaceAllString(s, "/bot"+redactedMark)
+	return s
+}
+
+// Ошибка с тем же текстом, но без секретов. Ошибки сетевых запросов
+// содержат полный адрес, а вместе с ним и ключ API
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(redactSecrets(err.Error()))
+}
+
+// Обертка для вывода логов, вырезающая секреты из каждой строки
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write([]byte(redactSecrets(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	previous := config()
+	c := *previous
+	c.TelegramToken = "123456:SECRET-TOKEN"
+	c.OWMAPIKeys = []string{"owm-secret-key"}
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	tests := []struct {
+		in     string
+		leaked string
+	}{
+		{`Get "http://api.openweathermap.org/data/2.5/weather?appid=owm-secret-key&q=Moscow": timeout`, "owm-secret-key"},
+		{`Post "https://api.telegram.org/bot123456:SECRET-TOKEN/sendMessage": EOF`, "SECRET-TOKEN"},
+		{`Get "https://api.telegram.org/bot999:OTHER_token/getMe": EOF`, "OTHER_token"},
+		{`https://example.com/?api_key=abcdef&x=1`, "abcdef"},
+		{"ключ owm-secret-key в тексте", "owm-secret-key"},
+	}
+	for _, tt := range tests {
+		if got := redactSecrets(tt.in); strings.Contains(got, tt.leaked) {
+			t.Errorf("redactSecrets(%q) = %q: секрет не вырезан", tt.in, got)
+		}
+	}
+
+	if got := redactSecrets("погода в Москве"); got != "погода в Москве" {
+		t.Errorf("обычный текст изменен: %q", got)
+	}
+}
+
+func TestRedactOWMErrors(t *testing.T) {
+	previous := config()
+	c := *previous
+	c.OWMAPIKeys = []string{"owm-secret-key"}
+	c.OWMAPIURL = "http://127.0.0.1:1"
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	_, err := fetchWeather("Москва")
+	if err == nil {
+		t.Fatal("ожидалась ошибка соединения")
+	}
+	if strings.Contains(err.Error(), "owm-secret-key") {
+		t.Errorf("ключ API в тексте ошибки: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger := log.New(redactingWriter{&buf}, "", 0)
+	logger.Printf("Ошибка: %v", errors.New("http://x/?appid=owm-secret-key"))
+	if strings.Contains(buf.String(), "owm-secret-key") {
+		t.Errorf("ключ API в логе: %s", buf.String())
+	}
+}
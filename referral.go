@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Префикс параметра start в пригласительных ссылках t.me/bot?start=ref_<id>
+const startPrefixRef = "ref_"
+
+// Сколько участников показываем в рейтинге приглашений
+const referralLeaderboardSize = 5
+
+// Кто и когда пригласил чат
+type Referral struct {
+	Referrer int64     `json:"referrer"`
+	At       time.Time `json:"at"`
+}
+
+// Учет приглашения. Засчитывается только для новых пользователей и один раз
+func (s *Store) AddReferral(chatID, referrer int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if chatID == referrer {
+		return false, nil
+	}
+	if _, exists := s.data.Referrals[chatID]; exists {
+		return false, nil
+	}
+	if _, exists := s.data.Preferences[chatID]; exists {
+		return false, nil
+	}
+
+	s.data.Referrals[chatID] = &Referral{Referrer: referrer, At: time.Now()}
+	return true, s.save()
+}
+
+// Сколько пользователей пригласил каждый участник
+func (s *Store) ReferralCounts() map[int64]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[int64]int)
+	for _, referral := range s.data.Referrals {
+		counts[referral.Referrer]++
+	}
+	return counts
+}
+
+// Имя участника для рейтинга запоминаем, когда он запрашивает ссылку
+func (s *Store) SetReferrerName(chatID int64, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == "" || s.data.ReferrerNames[chatID] == name {
+		return nil
+	}
+	s.data.ReferrerNames[chatID] = name
+	return s.save()
+}
+
+func (s *Store) ReferrerName(chatID int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.ReferrerNames[chatID]
+}
+
+// Пригласивший из параметра start=ref_<id>
+func startPayloadReferrer(payload string) (int64, bool) {
+	if !strings.HasPrefix(payload, startPrefixRef) {
+		return 0, false
+	}
+	referrer, err := strconv.ParseInt(strings.TrimPrefix(payload, startPrefixRef), 10, 64)
+	return referrer, err == nil
+}
+
+// Личная ссылка и рейтинг приглашений
+func getInviteInfo(store *Store, chatID int64, botUserName string) string {
+	counts := store.ReferralCounts()
+
+	text := fmt.Sprintf(
+		"🤝 Ваша пригласительная ссылка:\nhttps://t.me/%s?start=%s%d\n\nВы пригласили: %d",
+		botUserName,
+		startPrefixRef,
+		chatID,
+		counts[chatID],
+	)
+
+	leaders := make([]int64, 0, len(counts))
+	for id := range counts {
+		leaders = append(leaders, id)
+	}
+	if len(leaders) == 0 {
+		return text
+	}
+	sort.Slice(leaders, func(i, j int) bool {
+		if counts[leaders[i]] != counts[leaders[j]] {
+			return counts[leaders[i]] > counts[leaders[j]]
+		}
+		return leaders[i] < leaders[j]
+	})
+	if len(leaders) > referralLeaderboardSize {
+		leaders = leaders[:referralLeaderboardSize]
+	}
+
+	text += "\n\n🏆 Больше всех пригласили:\n"
+	for i, id := range leaders {
+		name := store.ReferrerName(id)
+		if name == "" {
+			name = "Участник"
+		}
+		if id == chatID {
+			name += " (вы)"
+		}
+		text += fmt.Sprintf("%d. %s — %d\n", i+1, name, counts[id])
+	}
+	return strings.TrimRight(text, "\n")
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сообщения пользователей о погоде за окном: /report дождь. Отчеты за
+// последний час по городу показываются в карточке погоды рядом с данными
+// источника. Отчеты живут недолго, поэтому хранятся только в памяти
+
+// За какое время показываем отчеты
+const reportWindow = time.Hour
+
+// Длина геохеша для города отчета: около 20 км
+const reportGeohashPrecision = 4
+
+// Что можно сообщить о погоде
+type weatherReportKind struct {
+	name    string
+	emoji   string
+	about   string // "о дожде"
+	aliases []string
+}
+
+var weatherReportKinds = []weatherReportKind{
+	{name: "дождь", emoji: "🌧", about: "о дожде", aliases: []string{"ливень", "rain"}},
+	{name: "снег", emoji: "🌨", about: "о снеге", aliases: []string{"снегопад", "snow"}},
+	{name: "солнце", emoji: "☀️", about: "о солнце", aliases: []string{"ясно", "солнечно", "sun"}},
+	{name: "облачно", emoji: "☁️", about: "об облачности", aliases: []string{"пасмурно", "облака", "clouds"}},
+	{name: "гроза", emoji: "⛈", about: "о грозе", aliases: []string{"storm", "thunder"}},
+	{name: "туман", emoji: "🌫", about: "о тумане", aliases: []string{"fog"}},
+	{name: "град", emoji: "🧊", about: "о граде", aliases: []string{"hail"}},
+	{name: "ветер", emoji: "💨", about: "о сильном ветре", aliases: []string{"wind"}},
+}
+
+// Вид погоды по слову из команды
+func parseWeatherReportKind(word string) (weatherReportKind, bool) {
+	word = strings.ToLower(word)
+	for _, kind := range weatherReportKinds {
+		if word == kind.name {
+			return kind, true
+		}
+		for _, alias := range kind.aliases {
+			if word == alias {
+				return kind, true
+			}
+		}
+	}
+	return weatherReportKind{}, false
+}
+
+// Сообщение пользователя о погоде
+type WeatherReport struct {
+	UserID  int64
+	Kind    weatherReportKind
+	City    string
+	Time    time.Time
+	PhotoID string // file_id фото из Telegram, если его приложили
+}
+
+// Недавние отчеты по ячейкам геохеша
+type CrowdReports struct {
+	data map[string][]WeatherReport
+	mu   sync.Mutex
+}
+
+var crowdReports = &CrowdReports{
+	data: make(map[string][]WeatherReport),
+}
+
+func reportKey(lat, lon float64) string {
+	return geohash(lat, lon, reportGeohashPrecision)
+}
+
+// Сохранение отчета: прежний отчет того же пользователя о том же городе
+// заменяется, устаревшие удаляются
+func (r *CrowdReports) Add(lat, lon float64, report WeatherReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := reportKey(lat, lon)
+	var kept []WeatherReport
+	for _, old := range r.data[key] {
+		if old.UserID == report.UserID || report.Time.Sub(old.Time) > reportWindow {
+			continue
+		}
+		kept = append(kept, old)
+	}
+	r.data[key] = append(kept, report)
+}
+
+// Отчеты о городе за последний час, новые первыми
+func (r *CrowdReports) Recent(lat, lon float64, now time.Time) []WeatherReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var recent []WeatherReport
+	for _, report := range r.data[reportKey(lat, lon)] {
+		if now.Sub(report.Time) <= reportWindow {
+			recent = append(recent, report)
+		}
+	}
+	sort.Slice(recent, func(i, j int) bool { return recent[i].Time.After(recent[j].Time) })
+	return recent
+}
+
+// Удаление отчетов пользователя (/forgetme)
+func (r *CrowdReports) Forget(userID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, reports := range r.data {
+		var kept []WeatherReport
+		for _, report := range reports {
+			if report.UserID != userID {
+				kept = append(kept, report)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.data, key)
+		} else {
+			r.data[key] = kept
+		}
+	}
+}
+
+func pluralUsers(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return "пользователь сообщил"
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return "пользователя сообщили"
+	}
+	return "пользователей сообщили"
+}
+
+// Строка для карточки погоды: "👥 За последний час 3 пользователя сообщили
+// о дожде, 1 — о солнце" (пустая, если отчетов нет)
+func reportsLine(lat, lon float64) string {
+	reports := crowdReports.Recent(lat, lon, clockNow())
+	if len(reports) == 0 {
+		return ""
+	}
+
+	counts := make(map[string]int)
+	kinds := make(map[string]weatherReportKind)
+	for _, report := range reports {
+		counts[report.Kind.name]++
+		kinds[report.Kind.name] = report.Kind
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for i, name := range names {
+		if i == 0 {
+			parts = append(parts, fmt.Sprintf("%d %s %s %s", counts[name], pluralUsers(counts[name]), kinds[name].about, kinds[name].emoji))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d — %s %s", counts[name], kinds[name].about, kinds[name].emoji))
+		}
+	}
+	return "👥 За последний час " + strings.Join(parts, ", ")
+}
+
+// Файл самой большой версии фото из сообщения
+func largestPhotoID(photos []tgbotapi.PhotoSize) string {
+	best := ""
+	size := 0
+	for _, photo := range photos {
+		if photo.Width*photo.Height > size {
+			best, size = photo.FileID, photo.Width*photo.Height
+		}
+	}
+	return best
+}
+
+// Подсказка по /report со списком вариантов
+func reportUsage() string {
+	names := make([]string, 0, len(weatherReportKinds))
+	for _, kind := range weatherReportKinds {
+		names = append(names, kind.name)
+	}
+	return "Расскажите, что за окном: /report дождь [город]. Варианты: " + strings.Join(names, ", ") +
+		". Можно приложить фото — отправьте его с подписью /report снег."
+}
+
+// Сводка отчетов о городе и последнее фото, если оно есть
+func showReports(c *commandContext, city string) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	reports := crowdReports.Recent(point.Lat, point.Lon, clockNow())
+	if len(reports) == 0 {
+		c.msg.Text = fmt.Sprintf("👥 О погоде в %s за последний час никто не сообщал.\n\n%s", point.DisplayName(), reportUsage())
+		return
+	}
+	c.msg.Text = fmt.Sprintf("%s: %s.", point.DisplayName(), reportsLine(point.Lat, point.Lon))
+
+	for _, report := range reports {
+		if report.PhotoID == "" {
+			continue
+		}
+		photo := tgbotapi.NewPhoto(c.message.Chat.ID, tgbotapi.FileID(report.PhotoID))
+		photo.Caption = fmt.Sprintf("📸 %s %s, %s, %d мин назад",
+			report.Kind.emoji, report.Kind.name, report.City, int(clockNow().Sub(report.Time).Minutes()))
+		if _, err := c.bot.Send(photo); err != nil {
+			c.msg.Text += "\n❌ Ошибка отправки фото: " + err.Error()
+		}
+		break
+	}
+}
+
+// /report — сообщить о погоде за окном или посмотреть, что сообщают другие
+func handleReportCommand(c *commandContext) {
+	fields := strings.Fields(c.args)
+	if len(fields) == 0 {
+		city, ok := commandCity(c.bot, c.message)
+		if !ok {
+			c.msg.Text = reportUsage()
+			return
+		}
+		showReports(c, city)
+		return
+	}
+
+	kind, ok := parseWeatherReportKind(fields[0])
+	if !ok {
+		c.msg.Text = fmt.Sprintf("Не понял, что за погода «%s». %s", fields[0], reportUsage())
+		return
+	}
+
+	city := strings.Join(fields[1:], " ")
+	if city == "" {
+		city = c.bot.lastCity[c.message.Chat.ID]
+	}
+	if city == "" {
+		city = c.bot.store.Preferences(c.message.Chat.ID).HomeCity
+	}
+	if city == "" {
+		c.msg.Text = fmt.Sprintf("Укажите город, например: /report %s Москва", kind.name)
+		return
+	}
+
+	point, err := geocodeCity(city)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	userID := c.message.Chat.ID
+	if c.message.From != nil {
+		userID = c.message.From.ID
+	}
+	report := WeatherReport{
+		UserID:  userID,
+		Kind:    kind,
+		City:    point.DisplayName(),
+		Time:    clockNow(),
+		PhotoID: largestPhotoID(c.message.Photo),
+	}
+	crowdReports.Add(point.Lat, point.Lon, report)
+
+	c.msg.Text = fmt.Sprintf("✅ Спасибо! Отметил: %s %s в %s.", kind.emoji, kind.name, point.DisplayName())
+	if report.PhotoID != "" {
+		photo, err := c.bot.store.AddSkyPhoto(SkyPhoto{
+			UserID: userID,
+			FileID: report.PhotoID,
+			City:   report.City,
+			Lat:    point.Lat,
+			Lon:    point.Lon,
+			Kind:   kind.name,
+			At:     report.Time,
+		})
+		if err != nil {
+			log.Printf("Ошибка сохранения состояния: %v", err)
+		}
+		c.msg.Text += fmt.Sprintf(" Фото #%d увидят те, кто спросит /sky или /report о городе.", photo.ID)
+	}
+	c.msg.Text += "\n" + reportsLine(point.Lat, point.Lon) + "."
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func useMockReports(t *testing.T) *fakeTelegram {
+	t.Helper()
+	f := newFakeTelegram(t)
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	previousReports, previousWeather := crowdReports, weatherCache
+	crowdReports = &CrowdReports{data: make(map[string][]WeatherReport)}
+	weatherCache = &WeatherCache{data: make(map[string]CacheItem)}
+	t.Cleanup(func() { crowdReports, weatherCache = previousReports, previousWeather })
+	return f
+}
+
+func TestReportsLine(t *testing.T) {
+	useMockReports(t)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	useManualClock(t, now)
+	rain, _ := parseWeatherReportKind("ливень")
+	sun, _ := parseWeatherReportKind("Солнце")
+
+	add := func(userID int64, kind weatherReportKind, ago time.Duration) {
+		crowdReports.Add(55.75, 37.62, WeatherReport{UserID: userID, Kind: kind, Time: now.Add(-ago)})
+	}
+	add(1, sun, 90*time.Minute) // устарел
+	add(2, rain, 40*time.Minute)
+	add(3, rain, 20*time.Minute)
+	add(4, sun, 10*time.Minute)
+	add(5, sun, 5*time.Minute)
+	// Пользователь передумал: засчитывается только последний отчет
+	add(5, rain, time.Minute)
+
+	want := "👥 За последний час 3 пользователя сообщили о дожде 🌧, 1 — о солнце ☀️"
+	if got := reportsLine(55.75, 37.62); got != want {
+		t.Errorf("reportsLine = %q, ожидалось %q", got, want)
+	}
+	// Соседний район того же города попадает в ту же ячейку, другой город — нет
+	if got := reportsLine(55.76, 37.64); got != want {
+		t.Errorf("в соседней точке %q", got)
+	}
+	if got := reportsLine(59.94, 30.31); got != "" {
+		t.Errorf("отчеты в другом городе: %q", got)
+	}
+
+	crowdReports.Forget(5)
+	if got := reportsLine(55.75, 37.62); !strings.Contains(got, "2 пользователя сообщили о дожде") {
+		t.Errorf("после /forgetme %q", got)
+	}
+}
+
+func TestReportCommand(t *testing.T) {
+	f := useMockReports(t)
+	useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	f.send(textUpdate(4301, "/report метель Казань"))
+	if reply := f.reply(t, 4301); !strings.Contains(reply, "Не понял, что за погода «метель»") {
+		t.Errorf("неизвестный вид погоды: %q", reply)
+	}
+
+	f.reset()
+	f.send(textUpdate(4301, "/report дождь Казань"))
+	if reply := f.reply(t, 4301); !strings.HasPrefix(reply, "✅ Спасибо! Отметил: 🌧 дождь в ") ||
+		!strings.Contains(reply, "1 пользователь сообщил о дожде") {
+		t.Errorf("ответ на отчет: %q", reply)
+	}
+
+	// Фото с подписью-командой
+	photo := textUpdate(4302, "")
+	photo.Message.Caption = "/report дождь Казань"
+	photo.Message.CaptionEntities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/report")}}
+	photo.Message.Photo = []tgbotapi.PhotoSize{{FileID: "small", Width: 90, Height: 60}, {FileID: "large", Width: 1280, Height: 960}}
+	f.reset()
+	f.send(photo)
+	if reply := f.reply(t, 4302); !strings.Contains(reply, "Фото #1 увидят") || !strings.Contains(reply, "2 пользователя сообщили") {
+		t.Errorf("ответ на отчет с фото: %q", reply)
+	}
+
+	// Карточка погоды показывает отчеты рядом с данными источника
+	f.reset()
+	f.send(textUpdate(4303, "Казань"))
+	if reply := f.reply(t, 4303); !strings.Contains(reply, "👥 За последний час 2 пользователя сообщили о дожде") {
+		t.Errorf("нет отчетов в карточке: %q", reply)
+	}
+
+	f.reset()
+	f.send(textUpdate(4303, "/report"))
+	if reply := f.reply(t, 4303); !strings.Contains(reply, "2 пользователя сообщили о дожде") {
+		t.Errorf("сводка отчетов: %q", reply)
+	}
+	photos := f.sent("sendPhoto")
+	if len(photos) != 1 || photos[0].Params["photo"] != "large" || !strings.Contains(photos[0].Params["caption"], "📸 🌧 дождь") {
+		t.Errorf("фото в сводке: %+v", photos)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Параметры оценки времени в пути по прямой
+const (
+	routeAverageSpeed = 70.0  // км/ч, средняя скорость с учетом остановок
+	routeRoadFactor   = 1.3   // дороги в среднем длиннее прямой линии
+	routeLegLength    = 150.0 // км между точками маршрута
+	routeMaxPoints    = 8
+	earthRadiusKm     = 6371.0
+)
+
+// Результат прямого геокодирования OWM
+type GeoPoint struct {
+	Name    string  `json:"name"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	// Локализованные названия, например local_names.ru
+	LocalNames map[string]string `json:"local_names"`
+}
+
+// Название точки на русском, если оно известно
+func (p GeoPoint) DisplayName() string {
+	if name, ok := p.LocalNames["ru"]; ok && name != "" {
+		return name
+	}
+	return p.Name
+}
+
+// Поиск координат города через API геокодирования OWM
+func geocodeCity(city string) (*GeoPoint, error) {
+	city, err := normalizeCity(city)
+	if err != nil {
+		return nil, err
+	}
+	if mockWeatherMode() {
+		lat, lon := mockCoords(city)
+		return &GeoPoint{Name: city, Lat: lat, Lon: lon}, nil
+	}
+
+	key := strings.ToLower(city)
+	geocodeCacheMu.Lock()
+	entry, ok := geocodeCache[key]
+	geocodeCacheMu.Unlock()
+	if ok && entry.fresh(clockNow()) {
+		if entry.point == nil {
+			return nil, fmt.Errorf("город «%s» не найден", city)
+		}
+		point := *entry.point
+		return &point, nil
+	}
+
+	params := url.Values{"q": {city}, "limit": {"1"}}
+
+	var points []GeoPoint
+	if err := fetchOWM("/geo/1.0/direct", params, "ошибка геокодирования", &points); err != nil {
+		return nil, err
+	}
+
+	entry = geocodeCacheEntry{fetched: clockNow()}
+	if len(points) > 0 {
+		entry.point = &points[0]
+	}
+	geocodeCacheMu.Lock()
+	geocodeCache[key] = entry
+	sweepGeocodeCache(entry.fetched)
+	geocodeCacheMu.Unlock()
+
+	if entry.point == nil {
+		return nil, fmt.Errorf("город «%s» не найден", city)
+	}
+	point := *entry.point
+	return &point, nil
+}
+
+// Координаты городов меняются редко, а запросы с опечатками и недописанными
+// названиями (инлайн-режим шлет запрос на каждую букву) повторяются часто,
+// поэтому помним и найденные города, и ненайденные
+const (
+	geocodeCacheTTL = 24 * time.Hour
+	geocodeMissTTL  = time.Hour
+)
+
+// Результат геокодирования; point == nil — город не найден
+type geocodeCacheEntry struct {
+	point   *GeoPoint
+	fetched time.Time
+}
+
+func (e geocodeCacheEntry) fresh(now time.Time) bool {
+	if e.point == nil {
+		return now.Sub(e.fetched) < geocodeMissTTL
+	}
+	return now.Sub(e.fetched) < geocodeCacheTTL
+}
+
+var (
+	geocodeCache      = make(map[string]geocodeCacheEntry)
+	geocodeCacheSwept time.Time
+	geocodeCacheMu    sync.Mutex
+)
+
+// Удаление устаревших записей не чаще раза в geocodeMissTTL: иначе
+// недописанные названия из инлайн-режима копились бы до перезапуска.
+// Вызывается под geocodeCacheMu
+func sweepGeocodeCache(now time.Time) {
+	if now.Sub(geocodeCacheSwept) < geocodeMissTTL {
+		return
+	}
+	for key, entry := range geocodeCache {
+		if !entry.fresh(now) {
+			delete(geocodeCache, key)
+		}
+	}
+	geocodeCacheSwept = now
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Расстояние между двумя точками по поверхности Земли (формула гаверсинусов), км
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// Промежуточная точка на дуге большого круга, fraction от 0 до 1
+func intermediatePoint(lat1, lon1, lat2, lon2, fraction float64) (float64, float64) {
+	phi1, lambda1 := toRadians(lat1), toRadians(lon1)
+	phi2, lambda2 := toRadians(lat2), toRadians(lon2)
+
+	delta := haversineKm(lat1, lon1, lat2, lon2) / earthRadiusKm
+	if delta == 0 {
+		return lat1, lon1
+	}
+
+	a := math.Sin((1-fraction)*delta) / math.Sin(delta)
+	b := math.Sin(fraction*delta) / math.Sin(delta)
+
+	x := a*math.Cos(phi1)*math.Cos(lambda1) + b*math.Cos(phi2)*math.Cos(lambda2)
+	y := a*math.Cos(phi1)*math.Sin(lambda1) + b*math.Cos(phi2)*math.Sin(lambda2)
+	z := a*math.Sin(phi1) + b*math.Sin(phi2)
+
+	lat := math.Atan2(z, math.Sqrt(x*x+y*y))
+	lon := math.Atan2(y, x)
+
+	return toDegrees(lat), toDegrees(lon)
+}
+
+// Разбор аргументов вида "Москва - Воронеж"
+func parseRouteArgs(args string) (string, string, bool) {
+	for _, sep := range []string{" - ", " — ", " – ", "->", "→"} {
+		if parts := strings.SplitN(args, sep, 2); len(parts) == 2 {
+			origin := strings.TrimSpace(parts[0])
+			destination := strings.TrimSpace(parts[1])
+			if origin != "" && destination != "" {
+				return origin, destination, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Функция для получения погоды вдоль маршрута между двумя городами
+func getRouteWeather(origin, destination string) (string, error) {
+	from, err := geocodeCity(origin)
+	if err != nil {
+		return "", err
+	}
+	to, err := geocodeCity(destination)
+	if err != nil {
+		return "", err
+	}
+
+	distance := haversineKm(from.Lat, from.Lon, to.Lat, to.Lon)
+	roadDistance := distance * routeRoadFactor
+
+	legs := int(math.Ceil(distance / routeLegLength))
+	if legs < 1 {
+		legs = 1
+	}
+	if legs > routeMaxPoints-1 {
+		legs = routeMaxPoints - 1
+	}
+
+	departure := time.Now()
+	routeMsg := fmt.Sprintf(
+		"🛣 Погода по маршруту %s — %s\n📏 ~%.0f км по дорогам, в пути ~%s\n\n",
+		from.DisplayName(),
+		to.DisplayName(),
+		roadDistance,
+		formatDuration(time.Duration(roadDistance/routeAverageSpeed*float64(time.Hour))),
+	)
+
+	for i := 0; i <= legs; i++ {
+		fraction := float64(i) / float64(legs)
+		lat, lon := intermediatePoint(from.Lat, from.Lon, to.Lat, to.Lon, fraction)
+
+		travelled := roadDistance * fraction
+		eta := departure.Add(time.Duration(travelled / routeAverageSpeed * float64(time.Hour)))
+
+		// Точки маршрута совпадают у всех, кто едет тем же путем, и с
+		// точками подписок рядом, поэтому прогноз берем из общего кэша
+		forecast, err := cachedForecastByCoords(lat, lon)
+		if err != nil {
+			return "", err
+		}
+		if len(forecast.Items) == 0 {
+			continue
+		}
+
+		// Выбираем интервал прогноза, ближайший ко времени прибытия в точку
+		best := 0
+		for j, item := range forecast.Items {
+			if absDuration(item.Time.Sub(eta)) < absDuration(forecast.Items[best].Time.Sub(eta)) {
+				best = j
+			}
+		}
+		item := forecast.Items[best]
+
+		name := forecast.City
+		switch i {
+		case 0:
+			name = from.DisplayName()
+		case legs:
+			name = to.DisplayName()
+		}
+
+		localETA := eta.In(forecast.Location)
+		routeMsg += fmt.Sprintf("📍 %s (%.0f км) — ~%s\n   %.0f°C, %s, ветер %.0f м/с\n",
+			name,
+			travelled,
+			localETA.Format("15:04"),
+			item.Temp,
+			item.Description,
+			item.WindSpeed,
+		)
+	}
+
+	routeMsg += "\nВремя прибытия оценено по прямой при средней скорости 70 км/ч."
+
+	return routeMsg, nil
+}
+
+// Длительность в виде "3 ч 20 мин"
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	return fmt.Sprintf("%d ч %d мин", hours, minutes)
+}
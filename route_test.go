@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseRouteArgs(t *testing.T) {
+	tests := []struct {
+		args         string
+		origin, dest string
+		ok           bool
+	}{
+		{"Москва - Воронеж", "Москва", "Воронеж", true},
+		{"Москва — Санкт-Петербург", "Москва", "Санкт-Петербург", true},
+		{"Тула – Орел", "Тула", "Орел", true},
+		{"Казань->Самара", "Казань", "Самара", true},
+		{" Сочи → Адлер ", "Сочи", "Адлер", true},
+		// Дефис внутри названия — не разделитель
+		{"Ростов-на-Дону", "", "", false},
+		{"Москва - ", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		origin, dest, ok := parseRouteArgs(tt.args)
+		if origin != tt.origin || dest != tt.dest || ok != tt.ok {
+			t.Errorf("parseRouteArgs(%q) = %q, %q, %v", tt.args, origin, dest, ok)
+		}
+	}
+}
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		km                     float64
+	}{
+		{"Москва — Санкт-Петербург", 55.7558, 37.6173, 59.9343, 30.3351, 634},
+		{"одна точка", 55.75, 37.62, 55.75, 37.62, 0},
+		{"градус по экватору", 0, 0, 0, 1, 111.2},
+		{"через антимеридиан", 0, 179.5, 0, -179.5, 111.2},
+	}
+	for _, tt := range tests {
+		if km := haversineKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(km-tt.km) > 1 {
+			t.Errorf("%s: %.1f км, ожидалось %.1f", tt.name, km, tt.km)
+		}
+	}
+}
+
+func TestIntermediatePoint(t *testing.T) {
+	const lat1, lon1, lat2, lon2 = 55.7558, 37.6173, 59.9343, 30.3351
+
+	if lat, lon := intermediatePoint(lat1, lon1, lat2, lon2, 0); math.Abs(lat-lat1) > 1e-9 || math.Abs(lon-lon1) > 1e-9 {
+		t.Errorf("начало маршрута: %f, %f", lat, lon)
+	}
+	if lat, lon := intermediatePoint(lat1, lon1, lat2, lon2, 1); math.Abs(lat-lat2) > 1e-9 || math.Abs(lon-lon2) > 1e-9 {
+		t.Errorf("конец маршрута: %f, %f", lat, lon)
+	}
+
+	// Середина равноудалена от концов и лежит на дуге
+	lat, lon := intermediatePoint(lat1, lon1, lat2, lon2, 0.5)
+	total := haversineKm(lat1, lon1, lat2, lon2)
+	fromStart, toEnd := haversineKm(lat1, lon1, lat, lon), haversineKm(lat, lon, lat2, lon2)
+	if math.Abs(fromStart-toEnd) > 0.01 || math.Abs(fromStart+toEnd-total) > 0.01 {
+		t.Errorf("середина %f, %f: %.2f и %.2f км из %.2f", lat, lon, fromStart, toEnd, total)
+	}
+
+	// Совпадающие точки не дают деления на ноль
+	if lat, lon := intermediatePoint(lat1, lon1, lat1, lon1, 0.5); lat != lat1 || lon != lon1 {
+		t.Errorf("одна точка: %f, %f", lat, lon)
+	}
+}
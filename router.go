@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Данные для обработчика команды и ответ, который он заполняет
+type commandContext struct {
+	bot     *Bot
+	message *tgbotapi.Message
+	// Имя команды без "/" (для псевдонима — имя основной команды)
+	command string
+	// Текст после команды
+	args string
+	// Был ли прерван начатый диалог
+	dialogCancelled bool
+
+	// Ответ на сообщение
+	msg tgbotapi.MessageConfig
+	// Счет на оплату, который отправим следом за ответом
+	invoice *tgbotapi.InvoiceConfig
+	// Город, под погоду в котором после ответа отправим стикер
+	stickerCity string
+	// Текст, который после ответа отправим голосовым сообщением (/voice on)
+	voiceText string
+	// Вызывается с отправленным ответом, например чтобы позже его обновить
+	afterSend func(sent tgbotapi.Message)
+}
+
+func newCommandContext(bot *Bot, message *tgbotapi.Message) *commandContext {
+	return &commandContext{
+		bot:     bot,
+		message: message,
+		command: message.Command(),
+		args:    strings.TrimSpace(message.CommandArguments()),
+		msg:     tgbotapi.NewMessage(message.Chat.ID, ""),
+	}
+}
+
+type commandHandler func(c *commandContext)
+
+// Маршрутизатор команд: обработчики регистрируются по имени команды,
+// у команды может быть несколько псевдонимов
+type commandRouter struct {
+	handlers map[string]commandHandler
+	aliases  map[string]string
+	// Обычный текст без команды
+	text commandHandler
+	// Неизвестная команда
+	notFound commandHandler
+}
+
+// Глобальный маршрутизатор, обработчики добавляются в init
+var commands = newCommandRouter()
+
+func newCommandRouter() *commandRouter {
+	return &commandRouter{
+		handlers: make(map[string]commandHandler),
+		aliases:  make(map[string]string),
+	}
+}
+
+// Регистрация обработчика команды ("/forecast" или "forecast")
+func (r *commandRouter) Handle(command string, handler commandHandler, aliases ...string) {
+	name := strings.TrimPrefix(command, "/")
+	if _, exists := r.handlers[name]; exists {
+		panic("команда /" + name + " зарегистрирована дважды")
+	}
+	r.handlers[name] = handler
+	for _, alias := range aliases {
+		r.aliases[strings.TrimPrefix(alias, "/")] = name
+	}
+}
+
+func (r *commandRouter) HandleText(handler commandHandler) {
+	r.text = handler
+}
+
+func (r *commandRouter) NotFound(handler commandHandler) {
+	r.notFound = handler
+}
+
+// Имя основной команды с учетом псевдонимов
+func (r *commandRouter) Resolve(command string) string {
+	command = strings.ToLower(command)
+	if name, ok := r.aliases[command]; ok {
+		return name
+	}
+	return command
+}
+
+// Вызов обработчика для сообщения
+func (r *commandRouter) Dispatch(c *commandContext) {
+	if !c.message.IsCommand() {
+		if r.text != nil {
+			r.text(c)
+		}
+		return
+	}
+
+	c.command = r.Resolve(c.command)
+	if handler, ok := r.handlers[c.command]; ok {
+		handler(c)
+		return
+	}
+	if r.notFound != nil {
+		r.notFound(c)
+	}
+}
+
+// Обработчик, доступный только администраторам
+func adminOnly(handler commandHandler) commandHandler {
+	return func(c *commandContext) {
+		if !isAdmin(c.message.Chat.ID) {
+			c.msg.Text = "Команда доступна только администраторам."
+			return
+		}
+		handler(c)
+	}
+}
+
+// Обработчик, меняющий настройки чата: в группе доступен только ее администраторам
+func groupAdminOnly(handler commandHandler) commandHandler {
+	return func(c *commandContext) {
+		if !canManageChatMessage(c.bot, c.message) {
+			c.msg.Text = groupAdminOnlyText
+			return
+		}
+		handler(c)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// Сколько интервалов прогноза (по 3 часа) оцениваем для пробежки: ближайшие 12 часов
+const runSlots = 4
+
+// Комфортный диапазон температур для бега и велосипеда
+const (
+	runIdealTempMin = 8.0
+	runIdealTempMax = 18.0
+)
+
+// Оценка интервала прогноза для тренировки на улице по шкале 0..10
+func runScore(item ForecastItem, aqi int) (float64, []string) {
+	score := 10.0
+	var reasons []string
+
+	temp := item.FeelsLike
+	switch {
+	case temp < runIdealTempMin:
+		score -= (runIdealTempMin - temp) * 0.3
+		if temp < 0 {
+			reasons = append(reasons, "холодно")
+		}
+	case temp > runIdealTempMax:
+		score -= (temp - runIdealTempMax) * 0.4
+		if temp > 25 {
+			reasons = append(reasons, "жарко")
+		}
+	}
+
+	if item.WindSpeed > 5 {
+		score -= (item.WindSpeed - 5) * 0.5
+		if item.WindSpeed > 8 {
+			reasons = append(reasons, "сильный ветер")
+		}
+	}
+
+	score -= item.Pop * 3
+	precipitation := item.Rain + item.Snow
+	score -= math.Min(precipitation*1.5, 4)
+	if precipitation > 0.5 {
+		reasons = append(reasons, "осадки")
+	} else if item.Pop >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("вероятность осадков %.0f%%", item.Pop*100))
+	}
+
+	if dewPoint(item.Temp, item.Humidity) >= 18 {
+		score -= 1.5
+		reasons = append(reasons, "душно")
+	}
+
+	switch aqi {
+	case 2:
+		score -= 0.5
+	case 3:
+		score -= 2
+		reasons = append(reasons, "воздух так себе")
+	case 4, 5:
+		score -= 4
+		reasons = append(reasons, "грязный воздух")
+	}
+
+	return math.Max(0, math.Min(10, score)), reasons
+}
+
+// Значок для оценки по шкале 0..10
+func scoreEmoji(score float64) string {
+	switch {
+	case score >= 8:
+		return "🟢"
+	case score >= 5:
+		return "🟡"
+	default:
+		return "🔴"
+	}
+}
+
+// Функция для оценки условий для бега/велосипеда на ближайшие 12 часов
+func getRunConditions(city string) (string, error) {
+	forecast, err := fetchForecast(city)
+	if err != nil {
+		return "", err
+	}
+	if len(forecast.Items) == 0 {
+		return "", fmt.Errorf("нет данных прогноза")
+	}
+
+	// Качество воздуха необязательно: без него оцениваем только погоду
+	air, err := fetchAirPollutionForecast(forecast.Lat, forecast.Lon)
+	if err != nil {
+		log.Printf("Ошибка получения качества воздуха: %v", err)
+		air = &AirPollutionResponse{}
+	}
+
+	runMsg := fmt.Sprintf("🏃 Условия для бега и велосипеда в %s:\n\n", forecast.City)
+
+	best := -1
+	bestScore := -1.0
+	for i, item := range forecast.Items {
+		if i >= runSlots {
+			break
+		}
+
+		aqi := air.AQIAt(item.Time.Unix())
+		score, reasons := runScore(item, aqi)
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+
+		start := forecast.LocalTime(item)
+		line := fmt.Sprintf("%s %s–%s: %.0f/10, %.0f°C, ветер %.0f м/с",
+			scoreEmoji(score),
+			start.Format("15:04"),
+			start.Add(forecastStep).Format("15:04"),
+			score,
+			item.Temp,
+			item.WindSpeed,
+		)
+		if aqi > 0 {
+			line += fmt.Sprintf(", воздух %s", aqiDescription(aqi))
+		}
+		if len(reasons) > 0 {
+			line += " (" + strings.Join(reasons, ", ") + ")"
+		}
+		runMsg += line + "\n"
+	}
+
+	bestStart := forecast.LocalTime(forecast.Items[best])
+	if bestScore >= 5 {
+		runMsg += fmt.Sprintf("\n👟 Лучшее время: %s–%s", bestStart.Format("15:04"), bestStart.Add(forecastStep).Format("15:04"))
+	} else {
+		runMsg += "\n🏠 Ближайшие 12 часов не лучшие для тренировки на улице — может, в зал?"
+	}
+
+	return runMsg, nil
+}
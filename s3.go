@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Минимальный клиент S3-совместимого хранилища (AWS S3, MinIO, Yandex Object
+// Storage и т.п.): запись, чтение и список объектов с подписью AWS Signature V4.
+// Адреса в стиле path: <endpoint>/<bucket>/<ключ>
+type s3Client struct {
+	endpoint  string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+}
+
+var s3HTTPClient = &http.Client{Timeout: 60 * time.Second}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Подпись запроса по AWS Signature V4
+func (c *s3Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := now.UTC().Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+
+	// Пробелы в параметрах кодируются как %20, а не как +
+	canonicalQuery := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func (c *s3Client) do(method, key string, query url.Values, body []byte) ([]byte, error) {
+	target := c.endpoint + "/" + url.PathEscape(c.bucket)
+	if key != "" {
+		target += "/" + url.PathEscape(key)
+	}
+	if len(query) > 0 {
+		target += "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подготовки запроса: %v", err)
+	}
+	c.sign(req, sha256Hex(body), time.Now())
+
+	resp, err := s3HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к хранилищу: %v", redactError(err))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа хранилища: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("хранилище ответило статусом %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+func (c *s3Client) Put(key string, body []byte) error {
+	_, err := c.do(http.MethodPut, key, nil, body)
+	return err
+}
+
+func (c *s3Client) Get(key string) ([]byte, error) {
+	return c.do(http.MethodGet, key, nil, nil)
+}
+
+// Ключи объектов с префиксом, по алфавиту (первая тысяча)
+func (c *s3Client) List(prefix string) ([]string, error) {
+	data, err := c.do(http.MethodGet, "", url.Values{"list-type": {"2"}, "prefix": {prefix}}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Contents []struct {
+			Key string `xml:"Key"`
+		} `xml:"Contents"`
+	}
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга списка объектов: %v", err)
+	}
+
+	keys := make([]string, 0, len(result.Contents))
+	for _, object := range result.Contents {
+		keys = append(keys, object.Key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Режим для экранного диктора: из ответов убираются эмодзи, а сокращения
+// единиц пишутся словами ("5 м/с" → "5 метров в секунду"). Диктор читает
+// эмодзи вслух по названию, и карточка погоды превращается в шум
+
+// Единицы и их полные названия: для русского три формы (1, 2, 5), для
+// английского две (1, много)
+var screenReaderUnits = []struct {
+	short string
+	ru    [3]string
+	en    [2]string
+}{
+	{"°C", [3]string{"градус Цельсия", "градуса Цельсия", "градусов Цельсия"}, [2]string{"degree Celsius", "degrees Celsius"}},
+	{"°F", [3]string{"градус Фаренгейта", "градуса Фаренгейта", "градусов Фаренгейта"}, [2]string{"degree Fahrenheit", "degrees Fahrenheit"}},
+	{"м/с", [3]string{"метр в секунду", "метра в секунду", "метров в секунду"}, [2]string{"meter per second", "meters per second"}},
+	{"m/s", [3]string{"метр в секунду", "метра в секунду", "метров в секунду"}, [2]string{"meter per second", "meters per second"}},
+	{"км/ч", [3]string{"километр в час", "километра в час", "километров в час"}, [2]string{"kilometer per hour", "kilometers per hour"}},
+	{"km/h", [3]string{"километр в час", "километра в час", "километров в час"}, [2]string{"kilometer per hour", "kilometers per hour"}},
+	{"mph", [3]string{"миля в час", "мили в час", "миль в час"}, [2]string{"mile per hour", "miles per hour"}},
+	{"мм рт. ст.", [3]string{"миллиметр ртутного столба", "миллиметра ртутного столба", "миллиметров ртутного столба"}, [2]string{"millimeter of mercury", "millimeters of mercury"}},
+	{"гПа", [3]string{"гектопаскаль", "гектопаскаля", "гектопаскалей"}, [2]string{"hectopascal", "hectopascals"}},
+	{"hPa", [3]string{"гектопаскаль", "гектопаскаля", "гектопаскалей"}, [2]string{"hectopascal", "hectopascals"}},
+	{"мм", [3]string{"миллиметр", "миллиметра", "миллиметров"}, [2]string{"millimeter", "millimeters"}},
+	{"%", [3]string{"процент", "процента", "процентов"}, [2]string{"percent", "percent"}},
+}
+
+// Число с единицей: "-3°C", "5 м/с", "0,4 мм", "80%"
+var screenReaderValue = regexp.MustCompile(`(-|−)?(\d+(?:[.,]\d+)?) ?(°C|°F|м/с|m/s|км/ч|km/h|mph|мм рт\. ст\.|гПа|hPa|мм|%)`)
+
+// Сокращения без чисел
+var screenReaderWords = strings.NewReplacer("ощущ.", "ощущается как")
+
+// Стрелка между значениями читается как пауза, а не «стрелка вправо»
+var screenReaderArrows = strings.NewReplacer("→", "—")
+
+// Форма слова для числа по правилам русского языка: 1 метр, 2 метра, 5 метров.
+// Дробные числа читаются с формой для 2
+func pluralRU(value string, forms [3]string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return forms[1]
+	}
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return forms[0]
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return forms[1]
+	}
+	return forms[2]
+}
+
+// Эмодзи и значки, которые диктор читает вслух по названию
+func screenReaderNoise(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // эмодзи, флаги, символы
+		r >= 0x2600 && r <= 0x27BF, // разные символы и дингбаты: ☀️ ⛈ ✅
+		r >= 0x2B00 && r <= 0x2BFF, // ⬆️ ⭐️
+		r >= 0x2300 && r <= 0x23FF, // ⏰ ⏳ ⌚️
+		r >= 0x25A0 && r <= 0x25FF, // ◀️ ▶️
+		r >= 0x2580 && r <= 0x259F, // ▇ полоски графиков
+		r == '•',
+		r == 0xFE0F, r == 0x200D, r == 0x20E3:
+		return true
+	}
+	return false
+}
+
+// Текст для экранного диктора
+func screenReaderText(text, lang string) string {
+	text = strings.Map(func(r rune) rune {
+		if screenReaderNoise(r) {
+			return -1
+		}
+		return r
+	}, text)
+
+	text = screenReaderValue.ReplaceAllStringFunc(text, func(match string) string {
+		parts := screenReaderValue.FindStringSubmatch(match)
+		sign, value, short := parts[1], parts[2], parts[3]
+		for _, unit := range screenReaderUnits {
+			if unit.short != short {
+				continue
+			}
+			if lang == langEN {
+				word := unit.en[1]
+				if value == "1" {
+					word = unit.en[0]
+				}
+				if sign != "" {
+					sign = "minus "
+				}
+				return sign + value + " " + word
+			}
+			if sign != "" {
+				sign = "минус "
+			}
+			return sign + value + " " + pluralRU(value, unit.ru)
+		}
+		return match
+	})
+	if lang != langEN {
+		text = screenReaderWords.Replace(text)
+	}
+	text = screenReaderArrows.Replace(text)
+
+	// После эмодзи остаются лишние пробелы в начале строк и между словами
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.FieldsFunc(line, unicode.IsSpace), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Ответ чату с учетом режима для экранного диктора
+func accessibleText(store *Store, chatID int64, text string) string {
+	prefs := store.Preferences(chatID)
+	if !prefs.ScreenReader {
+		return text
+	}
+	return screenReaderText(text, prefs.Language)
+}
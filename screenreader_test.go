@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreenReaderText(t *testing.T) {
+	tests := []struct {
+		text, lang, want string
+	}{
+		{
+			"🌤 Погода в Тула:\n🌡 Температура: -3°C (ощущается как −8°C)\n🌬 Ветер: 1 м/с, тихо",
+			langRU,
+			"Погода в Тула:\nТемпература: минус 3 градуса Цельсия (ощущается как минус 8 градусов Цельсия)\nВетер: 1 метр в секунду, тихо",
+		},
+		{
+			"🧭 Давление: 1012 гПа (759 мм рт. ст.)\n☔️ Вероятность осадков до 80%, ⛈ 21%",
+			langRU,
+			"Давление: 1012 гектопаскалей (759 миллиметров ртутного столба)\nВероятность осадков до 80 процентов, 21 процент",
+		},
+		{
+			"🌤 Тула: 3°C (ощущ. 0°C), дождь 0,4 мм (1010 → 1004 гПа)",
+			langRU,
+			"Тула: 3 градуса Цельсия (ощущается как 0 градусов Цельсия), дождь 0,4 миллиметра (1010 — 1004 гектопаскаля)",
+		},
+		{
+			"🌤 Weather in Tula:\n🌡 Temperature: -1°F\n🌬 Wind: 12 mph",
+			langEN,
+			"Weather in Tula:\nTemperature: minus 1 degree Fahrenheit\nWind: 12 miles per hour",
+		},
+	}
+	for _, tt := range tests {
+		if got := screenReaderText(tt.text, tt.lang); got != tt.want {
+			t.Errorf("screenReaderText(%q) =\n%q\nожидалось\n%q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScreenReaderReply(t *testing.T) {
+	f := useMockReports(t)
+	if err := store.UpdatePreferences(4421, func(prefs *UserPreferences) { prefs.ScreenReader = true }); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+
+	f.send(textUpdate(4421, "Москва"))
+	reply := f.reply(t, 4421)
+	if !strings.Contains(reply, "градус") || !strings.Contains(reply, "в секунду") {
+		t.Errorf("единицы не словами: %q", reply)
+	}
+	for _, r := range reply {
+		if screenReaderNoise(r) {
+			t.Fatalf("в ответе остался значок %q: %q", r, reply)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Текущие морские условия из Marine API Open-Meteo
+type MarineResponse struct {
+	Current struct {
+		WaveHeight            *float64 `json:"wave_height"`
+		WavePeriod            *float64 `json:"wave_period"`
+		WaveDirection         *float64 `json:"wave_direction"`
+		SeaSurfaceTemperature *float64 `json:"sea_surface_temperature"`
+	} `json:"current"`
+}
+
+// Запрос морских условий по координатам
+func fetchMarine(lat, lon float64) (*MarineResponse, error) {
+	reqURL := fmt.Sprintf(
+		"https://marine-api.open-meteo.com/v1/marine?latitude=%.4f&longitude=%.4f"+
+			"&current=wave_height,wave_period,wave_direction,sea_surface_temperature&timezone=auto",
+		lat,
+		lon,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения морских данных: статус %d", resp.StatusCode)
+	}
+
+	var data MarineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	return &data, nil
+}
+
+// Направление по сторонам света для угла в градусах
+func compassDirection(deg float64) string {
+	directions := []string{"С", "СВ", "В", "ЮВ", "Ю", "ЮЗ", "З", "СЗ"}
+	return directions[int((deg+22.5)/45)%8]
+}
+
+// Словесная оценка волнения моря по высоте волны (шкала Дугласа, упрощенно)
+func seaState(waveHeight float64) string {
+	switch {
+	case waveHeight < 0.1:
+		return "штиль"
+	case waveHeight < 0.5:
+		return "слабое волнение"
+	case waveHeight < 1.25:
+		return "умеренное волнение"
+	case waveHeight < 2.5:
+		return "значительное волнение"
+	default:
+		return "сильное волнение"
+	}
+}
+
+// Функция для получения морских условий у прибрежного города
+func getSeaConditions(city string) (string, error) {
+	weather, err := fetchWeather(city)
+	if err != nil {
+		return "", err
+	}
+
+	marine, err := fetchMarine(weather.Lat, weather.Lon)
+	if err != nil {
+		return "", err
+	}
+
+	current := marine.Current
+	if current.WaveHeight == nil && current.SeaSurfaceTemperature == nil {
+		return fmt.Sprintf("🏞 Похоже, %s не у моря — морских данных для этой точки нет.", weather.City), nil
+	}
+
+	seaMsg := fmt.Sprintf("🌊 Море у %s:\n", weather.City)
+	if current.SeaSurfaceTemperature != nil {
+		seaMsg += fmt.Sprintf("🌡 Вода: %.0f°C (воздух %.0f°C)\n", *current.SeaSurfaceTemperature, weather.Temp)
+	}
+	if current.WaveHeight != nil {
+		seaMsg += fmt.Sprintf("🌊 Волны: %.1f м — %s", *current.WaveHeight, seaState(*current.WaveHeight))
+		if current.WavePeriod != nil {
+			seaMsg += fmt.Sprintf(", период %.0f с", *current.WavePeriod)
+		}
+		if current.WaveDirection != nil {
+			seaMsg += fmt.Sprintf(", с направления %s", compassDirection(*current.WaveDirection))
+		}
+		seaMsg += "\n"
+	}
+	seaMsg += fmt.Sprintf("🌬 Ветер: %.0f м/с, %s", weather.WindSpeed, compassDirection(float64(weather.WindDeg)))
+	if weather.WindGust > 0 {
+		seaMsg += fmt.Sprintf(", порывы до %.0f м/с", weather.WindGust)
+	}
+
+	if current.WaveHeight != nil && *current.WaveHeight >= 1.25 {
+		seaMsg += "\n\n⚠️ Купаться небезопасно."
+	}
+
+	return seaMsg, nil
+}
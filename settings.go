@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Разделы меню настроек
+const (
+	settingsMenu     = "menu"
+	settingsUnits    = "units"
+	settingsWind     = "wind"
+	settingsLang     = "lang"
+	settingsHome     = "home"
+	settingsNotify   = "notify"
+	settingsFormat   = "format"
+	settingsStickers = "stickers"
+	settingsTips     = "tips"
+	settingsReader   = "reader"
+	settingsClose    = "close"
+)
+
+// Названия вариантов настроек
+var (
+	unitsTitles    = map[string]string{unitsMetric: "Метрические (°C, м/с)", unitsImperial: "Имперские (°F, mph)"}
+	windTitles     = map[string]string{windMS: "м/с", windKMH: "км/ч", windMPH: "mph (мили в час)", windKnots: "Узлы"}
+	langTitles     = map[string]string{langRU: "Русский", langEN: "English"}
+	providerTitles = map[string]string{providerOWM: "OpenWeatherMap", providerMock: "Демо-данные"}
+	formatTitles   = map[string]string{formatPlain: "Обычный текст", formatHTML: "С выделением", formatCompact: "Кратко", formatDetailed: "Подробно", formatSimple: "Простыми словами"}
+)
+
+// Порядок вариантов в меню
+var (
+	unitsOrder    = []string{unitsMetric, unitsImperial}
+	windOrder     = []string{windMS, windKMH, windMPH, windKnots}
+	langOrder     = []string{langRU, langEN}
+	providerOrder = []string{providerOWM}
+	formatOrder   = []string{formatPlain, formatHTML, formatCompact, formatDetailed, formatSimple}
+)
+
+// Данные кнопок меню настроек: "<раздел>" или "<раздел>:<значение>"
+func settingsData(store *Store, parts ...string) string {
+	return encodeCallback(store, CallbackPayload{Action: actionSettings, Value: strings.Join(parts, ":")})
+}
+
+func backButton(store *Store) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", settingsData(store, settingsMenu)))
+}
+
+// Кнопки выбора одного варианта из списка, текущий отмечен галочкой
+func optionRows(store *Store, section string, order []string, titles map[string]string, current string) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, value := range order {
+		title := titles[value]
+		if value == current {
+			title = "✅ " + title
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, settingsData(store, section, value)),
+		))
+	}
+	return append(rows, backButton(store))
+}
+
+// Текст и клавиатура раздела меню настроек
+func settingsView(store *Store, chatID int64, section, lastCity string) (string, tgbotapi.InlineKeyboardMarkup) {
+	prefs := store.Preferences(chatID)
+
+	switch section {
+	case settingsUnits:
+		return "🌡 Единицы измерения:", tgbotapi.NewInlineKeyboardMarkup(
+			optionRows(store, settingsUnits, unitsOrder, unitsTitles, prefs.Units)...)
+
+	case settingsWind:
+		return "🌬 Единицы скорости ветра:", tgbotapi.NewInlineKeyboardMarkup(
+			optionRows(store, settingsWind, windOrder, windTitles, windUnitFor(prefs))...)
+
+	case settingsLang:
+		return "🌐 Язык описаний погоды:", tgbotapi.NewInlineKeyboardMarkup(
+			optionRows(store, settingsLang, langOrder, langTitles, prefs.Language)...)
+
+	case settingsFormat:
+		return "📝 Оформление карточек погоды и прогноза:", tgbotapi.NewInlineKeyboardMarkup(
+			optionRows(store, settingsFormat, formatOrder, formatTitles, prefs.Format)...)
+
+	case settingsHome:
+		text := "🏠 Домашний город используется, когда в команде не указан город.\n\n"
+		if prefs.HomeCity != "" {
+			text += "Сейчас: " + prefs.HomeCity
+		} else {
+			text += "Сейчас не выбран."
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		if lastCity != "" && !strings.EqualFold(lastCity, prefs.HomeCity) {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📍 Сделать домашним: "+lastCity, settingsData(store, settingsHome, "last")),
+			))
+		} else if lastCity == "" {
+			text += "\n\nЧтобы выбрать город, сначала запросите в нем погоду."
+		}
+		if prefs.HomeCity != "" {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🗑 Убрать домашний город", settingsData(store, settingsHome, "clear")),
+			))
+		}
+		rows = append(rows, backButton(store))
+		return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	case settingsNotify:
+		subs := store.ChatSubscriptions(chatID)
+		if len(subs) == 0 {
+			return "🔔 У вас нет подписок на оповещения.\n\nНастроить: /subscribe",
+				tgbotapi.NewInlineKeyboardMarkup(backButton(store))
+		}
+		text := "🔔 Ваши подписки. Нажмите, чтобы отключить:"
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, sub := range subs {
+			title := alertKinds[sub.Kind].title
+			if title == "" {
+				title = sub.Kind
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(
+					fmt.Sprintf("🔕 %s (%s)", title, sub.City),
+					settingsData(store, settingsNotify, "off", sub.Kind),
+				),
+			))
+		}
+		rows = append(rows, backButton(store))
+		return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	homeCity := prefs.HomeCity
+	if homeCity == "" {
+		homeCity = "не выбран"
+	}
+	text := fmt.Sprintf(
+		"⚙️ Настройки\n\n"+
+			"🌡 Единицы: %s\n"+
+			"🌬 Ветер: %s\n"+
+			"🌐 Язык: %s\n"+
+			"🏠 Домашний город: %s\n"+
+			"🔔 Подписок: %d\n"+
+			"📝 Оформление: %s",
+		unitsTitles[prefs.Units],
+		windTitles[windUnitFor(prefs)],
+		langTitles[prefs.Language],
+		homeCity,
+		len(store.ChatSubscriptions(chatID)),
+		formatTitles[replyFormat(prefs)],
+	)
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🌡 Единицы", settingsData(store, settingsUnits)),
+			tgbotapi.NewInlineKeyboardButtonData("🌬 Ветер", settingsData(store, settingsWind)),
+			tgbotapi.NewInlineKeyboardButtonData("🌐 Язык", settingsData(store, settingsLang)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏠 Домашний город", settingsData(store, settingsHome)),
+			tgbotapi.NewInlineKeyboardButtonData("🔔 Уведомления", settingsData(store, settingsNotify)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📝 Оформление", settingsData(store, settingsFormat)),
+		),
+	}
+	// Переключатель стикеров показываем, только если стикеры настроены
+	if stickersConfigured() {
+		title := "🎨 Стикеры: вкл"
+		if prefs.PlainText {
+			title = "🎨 Стикеры: выкл"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, settingsData(store, settingsStickers, "toggle")),
+		))
+	}
+	if featureEnabled(featureDigestTips, chatID) {
+		title := "💡 Советы в сводке: вкл"
+		if prefs.NoDigestTips {
+			title = "💡 Советы в сводке: выкл"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, settingsData(store, settingsTips, "toggle")),
+		))
+	}
+	readerTitle := "🔈 Для экранного диктора: выкл"
+	if prefs.ScreenReader {
+		readerTitle = "Для экранного диктора: вкл"
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(readerTitle, settingsData(store, settingsReader, "toggle")),
+	))
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Закрыть", settingsData(store, settingsClose)),
+	))
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Применение выбранного значения. Возвращает раздел, который нужно показать после изменения
+func applySetting(store *Store, chatID int64, section string, args []string, lastCity string) (string, error) {
+	if len(args) == 0 {
+		return section, nil
+	}
+	value := args[0]
+
+	switch section {
+	case settingsUnits:
+		if _, ok := unitsTitles[value]; !ok {
+			return section, nil
+		}
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.Units = value })
+
+	case settingsWind:
+		if _, ok := windTitles[value]; !ok {
+			return section, nil
+		}
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.WindUnit = value })
+
+	case settingsLang:
+		if _, ok := langTitles[value]; !ok {
+			return section, nil
+		}
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.Language = value })
+
+	case settingsFormat:
+		if _, ok := formatTitles[value]; !ok {
+			return section, nil
+		}
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.Format = value })
+
+	case settingsStickers:
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.PlainText = !prefs.PlainText })
+
+	case settingsTips:
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.NoDigestTips = !prefs.NoDigestTips })
+
+	case settingsReader:
+		return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.ScreenReader = !prefs.ScreenReader })
+
+	case settingsHome:
+		switch value {
+		case "last":
+			if lastCity == "" {
+				return section, nil
+			}
+			return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.HomeCity = lastCity })
+		case "clear":
+			return settingsMenu, store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.HomeCity = "" })
+		}
+
+	case settingsNotify:
+		if value == "off" && len(args) > 1 {
+			_, err := store.Unsubscribe(chatID, args[1])
+			return settingsNotify, err
+		}
+	}
+
+	return section, nil
+}
+
+// Обработка нажатий в меню настроек: изменение настроек и навигация
+// выполняются редактированием того же сообщения. Возвращает текст
+// всплывающей подсказки, если настройка изменилась
+func handleSettingsCallback(bot *Bot, callback *tgbotapi.CallbackQuery, value, lastCity string) (string, error) {
+	chatID := callback.Message.Chat.ID
+	messageID := callback.Message.MessageID
+
+	parts := strings.Split(value, ":")
+	section := parts[0]
+
+	if section == settingsClose {
+		_, err := bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+		return "", err
+	}
+
+	next, err := applySetting(bot.store, chatID, section, parts[1:], lastCity)
+	if err != nil {
+		return "", err
+	}
+
+	text, markup := settingsView(bot.store, chatID, next, lastCity)
+	if _, err := bot.Request(tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, accessibleText(bot.store, chatID, text), markup)); err != nil {
+		return "", err
+	}
+	return settingToast(section, parts[1:], next, lastCity), nil
+}
+
+// Подсказка после изменения настройки: "✅ Единицы: Имперские (°F, mph)".
+// Переход между разделами подсказки не дает
+func settingToast(section string, args []string, next, lastCity string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if section == settingsNotify && args[0] == "off" {
+		return "🔕 Оповещение отключено"
+	}
+	if next != settingsMenu {
+		return ""
+	}
+
+	switch section {
+	case settingsUnits:
+		return "✅ Единицы: " + unitsTitles[args[0]]
+	case settingsWind:
+		return "✅ Ветер: " + windTitles[args[0]]
+	case settingsLang:
+		return "✅ Язык: " + langTitles[args[0]]
+	case settingsFormat:
+		return "✅ Оформление: " + formatTitles[args[0]]
+	case settingsHome:
+		if args[0] == "clear" {
+			return "✅ Домашний город сброшен"
+		}
+		return "✅ Домашний город: " + lastCity
+	}
+	return "✅ Сохранено"
+}
@@ -0,0 +1,116 @@
+package main
+
+import "math"
+
+// Оформление «Простыми словами»: погода объясняется короткими фразами с
+// советом, что надеть и взять с собой. Удобно для детей и пожилых
+// родственников, которым цифры мало что говорят
+
+// Ветер, с которого говорим «ветрено» и «очень сильный ветер», м/с
+const (
+	simpleWindy       = 8.0
+	simpleStrongWindy = 14.0
+)
+
+// Ощущаемая температура: до какого значения (°C) подходит слово и совет
+var simpleTempLevels = []struct {
+	below              float64
+	wordRU, wordEN     string
+	adviceRU, adviceEN string
+}{
+	{-15, "Очень холодно", "Very cold", "надень теплую куртку, шапку, шарф и варежки", "wear a warm coat, a hat, a scarf and mittens"},
+	{-5, "Морозно", "Frosty", "надень теплую куртку, шапку и варежки", "wear a warm coat, a hat and mittens"},
+	{5, "Холодно", "Cold", "надень шапку и куртку", "wear a hat and a jacket"},
+	{12, "Прохладно", "Cool", "надень куртку", "wear a jacket"},
+	{20, "Тепло", "Warm", "хватит кофты", "a sweater is enough"},
+	{28, "Жарко", "Hot", "можно идти в футболке", "a T-shirt is fine"},
+	{math.Inf(1), "Очень жарко", "Very hot", "надень панаму и пей больше воды", "wear a sun hat and drink plenty of water"},
+}
+
+// Насколько явление погоды заметно для совета: гроза важнее снега,
+// снег важнее дождя, дождь важнее тумана
+func conditionWeight(condition int) int {
+	switch condition / 100 {
+	case 2:
+		return 4
+	case 6:
+		return 3
+	case 3, 5:
+		return 2
+	case 7:
+		return 1
+	}
+	return 0
+}
+
+// Самое заметное из двух явлений погоды
+func notableCondition(a, b int) int {
+	if conditionWeight(b) > conditionWeight(a) {
+		return b
+	}
+	return a
+}
+
+// Погода простыми словами: "Холодно и ветрено — надень шапку и куртку.
+// Дождь — возьми зонт."
+func simpleWeather(feelsLike, wind float64, condition int, lang string) string {
+	level := simpleTempLevels[len(simpleTempLevels)-1]
+	for _, l := range simpleTempLevels {
+		if feelsLike < l.below {
+			level = l
+			break
+		}
+	}
+
+	en := lang == langEN
+	text := level.wordRU
+	if en {
+		text = level.wordEN
+	}
+	switch {
+	case wind >= simpleStrongWindy && en:
+		text += " with a very strong wind"
+	case wind >= simpleStrongWindy:
+		text += ", очень сильный ветер"
+	case wind >= simpleWindy && en:
+		text += " and windy"
+	case wind >= simpleWindy:
+		text += " и ветрено"
+	}
+	if en {
+		text += " — " + level.adviceEN + "."
+	} else {
+		text += " — " + level.adviceRU + "."
+	}
+	if wind >= simpleStrongWindy {
+		if en {
+			text += " Stay away from trees and billboards."
+		} else {
+			text += " Держись подальше от деревьев и рекламных щитов."
+		}
+	}
+
+	switch condition / 100 {
+	case 2:
+		if en {
+			return text + " Thunderstorm — better wait it out indoors."
+		}
+		return text + " Гроза — лучше переждать ее дома."
+	case 3, 5:
+		if en {
+			return text + " Rain — take an umbrella."
+		}
+		return text + " Дождь — возьми зонт."
+	case 6:
+		if en {
+			return text + " Snow — wear waterproof boots."
+		}
+		return text + " Снег — надень непромокаемую обувь."
+	case 7:
+		if en {
+			return text + " Fog — take extra care crossing the road."
+		}
+		return text + " Туман — переходи дорогу особенно внимательно."
+	}
+	return text
+}
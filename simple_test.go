@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimpleWeather(t *testing.T) {
+	tests := []struct {
+		feelsLike, wind float64
+		condition       int
+		lang            string
+		want            string
+	}{
+		{2, 9, 803, langRU, "Холодно и ветрено — надень шапку и куртку."},
+		{-20, 2, 600, langRU, "Очень холодно — надень теплую куртку, шапку, шарф и варежки. Снег — надень непромокаемую обувь."},
+		{24, 3, 500, langRU, "Жарко — можно идти в футболке. Дождь — возьми зонт."},
+		{15, 16, 211, langRU, "Тепло, очень сильный ветер — хватит кофты. Держись подальше от деревьев и рекламных щитов. Гроза — лучше переждать ее дома."},
+		{32, 1, 800, langEN, "Very hot — wear a sun hat and drink plenty of water."},
+		{8, 10, 741, langEN, "Cool and windy — wear a jacket. Fog — take extra care crossing the road."},
+	}
+	for _, tt := range tests {
+		if got := simpleWeather(tt.feelsLike, tt.wind, tt.condition, tt.lang); got != tt.want {
+			t.Errorf("simpleWeather(%v, %v, %d, %s) = %q, ожидалось %q", tt.feelsLike, tt.wind, tt.condition, tt.lang, got, tt.want)
+		}
+	}
+
+	if got := notableCondition(notableCondition(800, 500), 211); got != 211 {
+		t.Errorf("самое заметное явление %d, ожидалась гроза", got)
+	}
+}
+
+func TestSimpleFormat(t *testing.T) {
+	prefs := UserPreferences{Units: unitsMetric, Language: langRU, Format: formatSimple}
+	data := &CurrentWeather{City: "Тула", Temp: 3, FeelsLike: -1, WindSpeed: 9, Condition: 500, Description: "небольшой дождь"}
+	text, err := renderReply("weather", prefs, newWeatherCardData(data, prefs))
+	if err != nil {
+		t.Fatalf("renderReply: %v", err)
+	}
+	want := "🌤 Погода в Тула\nСейчас 3°C, а по ощущениям -1°C, небольшой дождь.\nХолодно и ветрено — надень шапку и куртку. Дождь — возьми зонт."
+	if text != want {
+		t.Errorf("карточка простыми словами:\n%s\nожидалось:\n%s", text, want)
+	}
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	forecast := &Forecast{City: "Тула", Items: []ForecastItem{
+		{Time: start, Temp: -3, WindSpeed: 3, Condition: 800, Description: "ясно"},
+		{Time: start.Add(forecastStep), Temp: 1, WindSpeed: 4, Condition: 600, Description: "снег"},
+	}}
+	text, err = renderReply("forecast", prefs, newForecastData(forecast, 40))
+	if err != nil {
+		t.Fatalf("renderReply: %v", err)
+	}
+	if !strings.Contains(text, "📅 16.10: от -3°C до 1°C, снег.\nХолодно — надень шапку и куртку. Снег — надень непромокаемую обувь.") {
+		t.Errorf("прогноз простыми словами:\n%s", text)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Горнолыжный курорт с высотами нижней и верхней станций
+type SkiResort struct {
+	Name    string
+	Aliases []string
+	Lat     float64
+	Lon     float64
+	Base    float64 // м
+	Top     float64 // м
+}
+
+// Популярные курорты: для них известны высоты подъемников
+var skiResorts = []SkiResort{
+	{Name: "Роза Хутор", Aliases: []string{"роза хутор", "rosa khutor"}, Lat: 43.6460, Lon: 40.2980, Base: 940, Top: 2320},
+	{Name: "Красная Поляна", Aliases: []string{"красная поляна", "krasnaya polyana"}, Lat: 43.6780, Lon: 40.2050, Base: 540, Top: 2238},
+	{Name: "Шерегеш", Aliases: []string{"шерегеш", "sheregesh"}, Lat: 52.9220, Lon: 87.9850, Base: 640, Top: 1270},
+	{Name: "Домбай", Aliases: []string{"домбай", "dombay"}, Lat: 43.2890, Lon: 41.6240, Base: 1630, Top: 3012},
+	{Name: "Эльбрус", Aliases: []string{"эльбрус", "elbrus"}, Lat: 43.2900, Lon: 42.4640, Base: 2350, Top: 3847},
+	{Name: "Абзаково", Aliases: []string{"абзаково", "abzakovo"}, Lat: 53.8110, Lon: 58.5990, Base: 540, Top: 820},
+	{Name: "Большой Вудъявр", Aliases: []string{"большой вудъявр", "кировск", "kirovsk"}, Lat: 67.6420, Lon: 33.7330, Base: 370, Top: 1000},
+	{Name: "Губаха", Aliases: []string{"губаха", "gubakha"}, Lat: 58.8600, Lon: 57.5900, Base: 280, Top: 680},
+	{Name: "Архыз", Aliases: []string{"архыз", "arkhyz"}, Lat: 43.5460, Lon: 41.1780, Base: 1650, Top: 2650},
+	{Name: "Белокуриха", Aliases: []string{"белокуриха", "belokurikha"}, Lat: 51.9960, Lon: 84.9840, Base: 250, Top: 800},
+}
+
+// Ответ прогноза Open-Meteo для горной точки
+type MountainForecastResponse struct {
+	Elevation float64 `json:"elevation"`
+	UTCOffset int     `json:"utc_offset_seconds"`
+	Hourly    struct {
+		Time        []string   `json:"time"`
+		Temperature []*float64 `json:"temperature_2m"`
+		SnowDepth   []*float64 `json:"snow_depth"`
+		WindSpeed   []*float64 `json:"wind_speed_10m"`
+	} `json:"hourly"`
+	Daily struct {
+		Time        []string   `json:"time"`
+		SnowfallSum []*float64 `json:"snowfall_sum"`
+	} `json:"daily"`
+}
+
+// Результат геокодирования Open-Meteo (возвращает высоту над уровнем моря)
+type openMeteoGeoResponse struct {
+	Results []struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Elevation float64 `json:"elevation"`
+		Country   string  `json:"country"`
+	} `json:"results"`
+}
+
+var openMeteoClient = &http.Client{Timeout: 15 * time.Second}
+
+// Поиск курорта в справочнике или через геокодер Open-Meteo
+func findSkiResort(name string) (*SkiResort, error) {
+	query := strings.ToLower(strings.TrimSpace(name))
+	for i := range skiResorts {
+		for _, alias := range skiResorts[i].Aliases {
+			if strings.Contains(alias, query) || strings.Contains(query, alias) {
+				return &skiResorts[i], nil
+			}
+		}
+	}
+
+	reqURL := fmt.Sprintf(
+		"https://geocoding-api.open-meteo.com/v1/search?name=%s&count=1&language=ru",
+		url.QueryEscape(name),
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка геокодирования")
+	}
+
+	var data openMeteoGeoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+	if len(data.Results) == 0 {
+		return nil, fmt.Errorf("курорт «%s» не найден", name)
+	}
+
+	place := data.Results[0]
+	return &SkiResort{
+		Name: place.Name,
+		Lat:  place.Latitude,
+		Lon:  place.Longitude,
+		Base: place.Elevation,
+		Top:  place.Elevation,
+	}, nil
+}
+
+// Прогноз Open-Meteo для точки на заданной высоте (с поправкой на высоту)
+func fetchMountainForecast(lat, lon, elevation float64) (*MountainForecastResponse, error) {
+	reqURL := fmt.Sprintf(
+		"https://api.open-meteo.com/v1/forecast?latitude=%.4f&longitude=%.4f&elevation=%.0f"+
+			"&hourly=temperature_2m,snow_depth,wind_speed_10m&daily=snowfall_sum"+
+			"&wind_speed_unit=ms&past_days=3&forecast_days=3&timezone=auto",
+		lat,
+		lon,
+		elevation,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения данных Open-Meteo: статус %d", resp.StatusCode)
+	}
+
+	var data MountainForecastResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	return &data, nil
+}
+
+// Индекс текущего часа в почасовом ряду Open-Meteo (время в часовом поясе точки)
+func (m *MountainForecastResponse) currentHour(now time.Time) int {
+	current := now.Format("2006-01-02T15")
+	for i, t := range m.Hourly.Time {
+		if strings.HasPrefix(t, current) {
+			return i
+		}
+	}
+	return -1
+}
+
+func valueAt(values []*float64, i int) (float64, bool) {
+	if i < 0 || i >= len(values) || values[i] == nil {
+		return 0, false
+	}
+	return *values[i], true
+}
+
+// Сумма снегопада за дни до сегодняшнего (past) и начиная с сегодняшнего (future), см
+func (m *MountainForecastResponse) snowfall(today string) (past, future float64) {
+	for i, day := range m.Daily.Time {
+		value, ok := valueAt(m.Daily.SnowfallSum, i)
+		if !ok {
+			continue
+		}
+		if day < today {
+			past += value
+		} else {
+			future += value
+		}
+	}
+	return past, future
+}
+
+// Функция для получения условий на горнолыжном курорте
+func getSkiConditions(name string) (string, error) {
+	resort, err := findSkiResort(name)
+	if err != nil {
+		return "", err
+	}
+
+	top, err := fetchMountainForecast(resort.Lat, resort.Lon, resort.Top)
+	if err != nil {
+		return "", err
+	}
+
+	// Open-Meteo отдает время в часовом поясе точки, поэтому сверяем по локальному времени
+	now := time.Now().In(time.FixedZone("", top.UTCOffset))
+	hour := top.currentHour(now)
+
+	skiMsg := fmt.Sprintf("⛷ %s\n\n", resort.Name)
+
+	if resort.Top > resort.Base {
+		base, err := fetchMountainForecast(resort.Lat, resort.Lon, resort.Base)
+		if err != nil {
+			return "", err
+		}
+		if temp, ok := valueAt(base.Hourly.Temperature, base.currentHour(now)); ok {
+			skiMsg += fmt.Sprintf("🏠 Внизу (%.0f м): %.0f°C\n", resort.Base, temp)
+		}
+	}
+	if temp, ok := valueAt(top.Hourly.Temperature, hour); ok {
+		skiMsg += fmt.Sprintf("🏔 Наверху (%.0f м): %.0f°C\n", resort.Top, temp)
+	}
+	if wind, ok := valueAt(top.Hourly.WindSpeed, hour); ok {
+		skiMsg += fmt.Sprintf("🌬 Ветер наверху: %.0f м/с\n", wind)
+	}
+	if depth, ok := valueAt(top.Hourly.SnowDepth, hour); ok {
+		skiMsg += fmt.Sprintf("❄️ Высота снежного покрова: %.0f см\n", depth*100)
+	}
+
+	past, future := top.snowfall(now.Format("2006-01-02"))
+	skiMsg += fmt.Sprintf("🌨 Снегопад за 3 дня: %.0f см, ожидается: %.0f см", past, future)
+
+	if future >= 10 {
+		skiMsg += "\n\n🎉 Ожидается хороший свежий снег!"
+	} else if wind, ok := valueAt(top.Hourly.WindSpeed, hour); ok && wind >= 15 {
+		skiMsg += "\n\n⚠️ Сильный ветер — верхние подъемники могут закрыть."
+	}
+
+	return skiMsg, nil
+}
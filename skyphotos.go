@@ -0,0 +1,400 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Фото неба от пользователей: фото, приложенное к /report, сохраняется и
+// показывается всем по /sky [город]. В отличие от самих отчетов фото
+// хранятся в файле состояния неделю. Любой может пожаловаться на фото,
+// администраторы удаляют его или блокируют автора
+
+// Сколько хранятся фото неба
+const skyPhotoTTL = 7 * 24 * time.Hour
+
+// Сколько последних фото показывает /sky
+const skyPhotosShown = 5
+
+// После стольких жалоб фото скрывается до решения администратора
+const skyPhotoFlagsToHide = 3
+
+// Фото неба в городе
+type SkyPhoto struct {
+	ID     int       `json:"id"`
+	UserID int64     `json:"user_id"`
+	FileID string    `json:"file_id"` // file_id фото в Telegram
+	City   string    `json:"city"`
+	Lat    float64   `json:"lat"`
+	Lon    float64   `json:"lon"`
+	Kind   string    `json:"kind,omitempty"` // вид погоды из отчета
+	At     time.Time `json:"at"`
+	// Кто пожаловался на фото
+	Flags  []int64 `json:"flags,omitempty"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// Сохранение фото с очередным номером. Устаревшие фото заодно удаляются
+func (s *Store) AddSkyPhoto(photo SkyPhoto) (SkyPhoto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var kept []*SkyPhoto
+	for _, old := range s.data.SkyPhotos {
+		if photo.At.Sub(old.At) <= skyPhotoTTL {
+			kept = append(kept, old)
+		}
+	}
+	s.data.LastSkyPhotoID++
+	photo.ID = s.data.LastSkyPhotoID
+	s.data.SkyPhotos = append(kept, &photo)
+	return photo, s.save()
+}
+
+// Последние фото неба в городе, новые первыми. Скрытые фото и фото
+// заблокированных пользователей не показываются
+func (s *Store) SkyPhotos(lat, lon float64, now time.Time, limit int) []SkyPhoto {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := reportKey(lat, lon)
+	var photos []SkyPhoto
+	for _, photo := range s.data.SkyPhotos {
+		if _, banned := s.data.Banned[photo.UserID]; banned || photo.Hidden {
+			continue
+		}
+		if now.Sub(photo.At) > skyPhotoTTL || reportKey(photo.Lat, photo.Lon) != key {
+			continue
+		}
+		photos = append(photos, *photo)
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].At.After(photos[j].At) })
+	if len(photos) > limit {
+		photos = photos[:limit]
+	}
+	return photos
+}
+
+// Фото по номеру
+func (s *Store) SkyPhoto(id int) (SkyPhoto, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, photo := range s.data.SkyPhotos {
+		if photo.ID == id {
+			return *photo, true
+		}
+	}
+	return SkyPhoto{}, false
+}
+
+// Фото с жалобами для модерации, новые первыми
+func (s *Store) FlaggedSkyPhotos() []SkyPhoto {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var photos []SkyPhoto
+	for _, photo := range s.data.SkyPhotos {
+		if len(photo.Flags) > 0 {
+			photos = append(photos, *photo)
+		}
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].At.After(photos[j].At) })
+	return photos
+}
+
+// Жалоба на фото. Повторная жалоба того же пользователя не считается;
+// added сообщает, засчитана ли жалоба
+func (s *Store) FlagSkyPhoto(id int, userID int64) (photo SkyPhoto, added bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stored := range s.data.SkyPhotos {
+		if stored.ID != id {
+			continue
+		}
+		for _, flagged := range stored.Flags {
+			if flagged == userID {
+				return *stored, false, nil
+			}
+		}
+		stored.Flags = append(stored.Flags, userID)
+		if len(stored.Flags) >= skyPhotoFlagsToHide {
+			stored.Hidden = true
+		}
+		return *stored, true, s.save()
+	}
+	return SkyPhoto{}, false, fmt.Errorf("фото #%d не найдено", id)
+}
+
+// Удаление фото по номеру. Возвращает false, если фото уже нет
+func (s *Store) DeleteSkyPhoto(id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, photo := range s.data.SkyPhotos {
+		if photo.ID == id {
+			s.data.SkyPhotos = append(s.data.SkyPhotos[:i], s.data.SkyPhotos[i+1:]...)
+			return true, s.save()
+		}
+	}
+	return false, nil
+}
+
+// Удаление всех фото пользователя. Возвращает число удаленных
+func (s *Store) DeleteUserSkyPhotos(userID int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := s.removeUserSkyPhotos(userID)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.save()
+}
+
+// Удаление фото пользователя без сохранения; вызывается под s.mu
+func (s *Store) removeUserSkyPhotos(userID int64) int {
+	var kept []*SkyPhoto
+	for _, photo := range s.data.SkyPhotos {
+		if photo.UserID != userID {
+			kept = append(kept, photo)
+		}
+	}
+	removed := len(s.data.SkyPhotos) - len(kept)
+	s.data.SkyPhotos = kept
+	return removed
+}
+
+// Давность фото: "25 мин назад", "3 ч назад", "2 дня назад"
+func skyPhotoAge(at, now time.Time) string {
+	age := now.Sub(at)
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%d мин назад", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%d ч назад", int(age.Hours()))
+	}
+	days := int(age.Hours() / 24)
+	return fmt.Sprintf("%d %s назад", days, pluralDays(days))
+}
+
+// Подпись фото: "📸 #12 🌧 дождь, Москва, 25 мин назад"
+func skyPhotoCaption(photo SkyPhoto, now time.Time) string {
+	caption := fmt.Sprintf("📸 #%d", photo.ID)
+	if kind, ok := parseWeatherReportKind(photo.Kind); ok {
+		caption += fmt.Sprintf(" %s %s,", kind.emoji, kind.name)
+	}
+	return fmt.Sprintf("%s %s, %s", caption, photo.City, skyPhotoAge(photo.At, now))
+}
+
+// /sky [город] — последние фото неба от пользователей с кнопкой жалобы
+func handleSkyCommand(c *commandContext) {
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /sky Москва"
+		return
+	}
+
+	point, err := geocodeCity(city)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+
+	now := clockNow()
+	photos := c.bot.store.SkyPhotos(point.Lat, point.Lon, now, skyPhotosShown)
+	if len(photos) == 0 {
+		c.msg.Text = fmt.Sprintf("📷 Фото неба в %s за последнюю неделю пока никто не присылал. "+
+			"Будьте первым: отправьте фото с подписью /report солнце %s", point.DisplayName(), city)
+		return
+	}
+
+	sent := 0
+	for _, photo := range photos {
+		msg := tgbotapi.NewPhoto(c.message.Chat.ID, tgbotapi.FileID(photo.FileID))
+		msg.Caption = skyPhotoCaption(photo, now)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚩 Пожаловаться", encodeCallback(c.bot.store, CallbackPayload{
+				Action: actionSkyFlag,
+				Value:  strconv.Itoa(photo.ID),
+			})),
+		))
+		if _, err := c.bot.Send(msg); err != nil {
+			log.Printf("Ошибка отправки фото неба #%d: %v", photo.ID, err)
+			continue
+		}
+		sent++
+	}
+	c.msg.Text = fmt.Sprintf("📷 Небо в %s: последние фото от пользователей (%d). "+
+		"Свое фото можно прислать с подписью /report и видом погоды.", point.DisplayName(), sent)
+}
+
+// Кнопки модерации фото для администратора
+func skyModerationKeyboard(store *Store, photo SkyPhoto) tgbotapi.InlineKeyboardMarkup {
+	value := strconv.Itoa(photo.ID)
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", encodeCallback(store, CallbackPayload{
+			Action: actionSkyDelete,
+			Value:  value,
+		})),
+		tgbotapi.NewInlineKeyboardButtonData("🚫 Удалить все и заблокировать", encodeCallback(store, CallbackPayload{
+			Action: actionSkyBan,
+			Value:  value,
+		})),
+	))
+}
+
+// Описание фото для администратора
+func skyModerationCaption(photo SkyPhoto) string {
+	caption := fmt.Sprintf("🚩 Жалоба на фото неба #%d: %s, автор %d, жалоб %d",
+		photo.ID, photo.City, photo.UserID, len(photo.Flags))
+	if photo.Hidden {
+		caption += ", фото скрыто"
+	}
+	return caption
+}
+
+// Жалоба на фото: первая жалоба пересылается администраторам с кнопками
+// модерации, после нескольких жалоб фото скрывается само
+func handleSkyFlagCallback(bot *Bot, callback *tgbotapi.CallbackQuery, value string) error {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("неверный номер фото %q", value)
+	}
+
+	photo, added, err := bot.store.FlagSkyPhoto(id, callback.From.ID)
+	if err != nil {
+		return err
+	}
+
+	if added && len(photo.Flags) == 1 {
+		admins := adminChatIDs()
+		if len(admins) == 0 {
+			log.Printf("Жалоба на фото неба #%d сохранена, но администраторы не настроены", photo.ID)
+		}
+		for _, adminID := range admins {
+			notice := tgbotapi.NewPhoto(adminID, tgbotapi.FileID(photo.FileID))
+			notice.Caption = skyModerationCaption(photo)
+			notice.ReplyMarkup = skyModerationKeyboard(bot.store, photo)
+			if _, err := bot.Send(notice); err != nil {
+				log.Printf("Ошибка отправки жалобы администратору %d: %v", adminID, err)
+			}
+		}
+	}
+
+	text := fmt.Sprintf("🚩 Спасибо! Жалоба на фото #%d передана модераторам.", photo.ID)
+	if !added {
+		text = fmt.Sprintf("Вы уже жаловались на фото #%d.", photo.ID)
+	}
+	_, err = bot.Send(tgbotapi.NewMessage(callback.Message.Chat.ID, text))
+	return err
+}
+
+// Решение администратора по фото из кнопок модерации. Подпись сообщения
+// с жалобой заменяется итогом
+func handleSkyModerationCallback(bot *Bot, callback *tgbotapi.CallbackQuery, payload CallbackPayload) error {
+	chatID := callback.Message.Chat.ID
+	if !isAdmin(chatID) {
+		return nil
+	}
+	id, err := strconv.Atoi(payload.Value)
+	if err != nil {
+		return fmt.Errorf("неверный номер фото %q", payload.Value)
+	}
+
+	var text string
+	if payload.Action == actionSkyBan {
+		text, err = banSkyPhotoAuthor(bot.store, id)
+	} else {
+		text, err = deleteSkyPhoto(bot.store, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = bot.Request(tgbotapi.NewEditMessageCaption(chatID, callback.Message.MessageID, text))
+	return err
+}
+
+func deleteSkyPhoto(store *Store, id int) (string, error) {
+	removed, err := store.DeleteSkyPhoto(id)
+	if err != nil {
+		return "", err
+	}
+	if !removed {
+		return fmt.Sprintf("Фото #%d уже удалено.", id), nil
+	}
+	return fmt.Sprintf("🗑 Фото #%d удалено.", id), nil
+}
+
+// Блокировка автора фото и удаление всех его фото
+func banSkyPhotoAuthor(store *Store, id int) (string, error) {
+	photo, ok := store.SkyPhoto(id)
+	if !ok {
+		return fmt.Sprintf("Фото #%d уже удалено.", id), nil
+	}
+	if isAdmin(photo.UserID) {
+		return "Администратора заблокировать нельзя.", nil
+	}
+	if err := store.Ban(photo.UserID, fmt.Sprintf("фото неба #%d", id)); err != nil {
+		return "", err
+	}
+	removed, err := store.DeleteUserSkyPhotos(photo.UserID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("🚫 Пользователь %d заблокирован, удалено фото: %d.", photo.UserID, removed), nil
+}
+
+// /skymod — фото с жалобами; /skymod del N — удалить фото, /skymod ban N —
+// удалить все фото автора и заблокировать его
+func handleSkyModCommand(c *commandContext) {
+	fields := strings.Fields(c.args)
+	if len(fields) == 0 {
+		photos := c.bot.store.FlaggedSkyPhotos()
+		if len(photos) == 0 {
+			c.msg.Text = "🚩 Жалоб на фото неба нет."
+			return
+		}
+		lines := []string{"🚩 Фото неба с жалобами:"}
+		for _, photo := range photos {
+			line := fmt.Sprintf("#%d %s, автор %d, жалоб %d", photo.ID, photo.City, photo.UserID, len(photo.Flags))
+			if photo.Hidden {
+				line += ", скрыто"
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, "", "Удалить: /skymod del N, удалить все фото автора и заблокировать: /skymod ban N")
+		c.msg.Text = strings.Join(lines, "\n")
+		return
+	}
+
+	if len(fields) != 2 || (fields[0] != "del" && fields[0] != "ban") {
+		c.msg.Text = "Использование: /skymod, /skymod del N или /skymod ban N"
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+	if err != nil {
+		c.msg.Text = fmt.Sprintf("❌ Ошибка: %q не похоже на номер фото", fields[1])
+		return
+	}
+
+	var text string
+	if fields[0] == "ban" {
+		text, err = banSkyPhotoAuthor(c.bot.store, id)
+	} else {
+		text, err = deleteSkyPhoto(c.bot.store, id)
+	}
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	c.msg.Text = text
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSkyPhotoStore(t *testing.T) {
+	newFakeTelegram(t)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	add := func(userID int64, lat, lon float64, ago time.Duration) SkyPhoto {
+		photo, err := store.AddSkyPhoto(SkyPhoto{UserID: userID, FileID: "f", City: "Москва", Lat: lat, Lon: lon, At: now.Add(-ago)})
+		if err != nil {
+			t.Fatalf("AddSkyPhoto: %v", err)
+		}
+		return photo
+	}
+	old := add(1, 55.75, 37.62, 8*24*time.Hour) // старше недели
+	first := add(2, 55.75, 37.62, 3*time.Hour)
+	second := add(3, 55.76, 37.64, time.Hour)
+	add(4, 59.94, 30.31, time.Hour) // другой город
+
+	photos := store.SkyPhotos(55.75, 37.62, now, skyPhotosShown)
+	if len(photos) != 2 || photos[0].ID != second.ID || photos[1].ID != first.ID {
+		t.Fatalf("фото в городе: %+v", photos)
+	}
+	if _, ok := store.SkyPhoto(old.ID); ok {
+		t.Errorf("устаревшее фото не удалено")
+	}
+
+	// Повторная жалоба того же пользователя не считается, после трех фото скрывается
+	for _, userID := range []int64{10, 10, 11, 12} {
+		if _, _, err := store.FlagSkyPhoto(second.ID, userID); err != nil {
+			t.Fatalf("FlagSkyPhoto: %v", err)
+		}
+	}
+	flagged, _ := store.SkyPhoto(second.ID)
+	if len(flagged.Flags) != 3 || !flagged.Hidden {
+		t.Errorf("жалобы: %+v", flagged)
+	}
+	if photos := store.SkyPhotos(55.75, 37.62, now, skyPhotosShown); len(photos) != 1 {
+		t.Errorf("скрытое фото показывается: %+v", photos)
+	}
+
+	// Фото заблокированных не показываются, номера удаленных не повторяются
+	if err := store.Ban(2, ""); err != nil {
+		t.Fatalf("Ban: %v", err)
+	}
+	if photos := store.SkyPhotos(55.75, 37.62, now, skyPhotosShown); len(photos) != 0 {
+		t.Errorf("фото заблокированного показывается: %+v", photos)
+	}
+	if removed, err := store.DeleteSkyPhoto(second.ID); !removed || err != nil {
+		t.Errorf("DeleteSkyPhoto = %v, %v", removed, err)
+	}
+	if next := add(5, 55.75, 37.62, 0); next.ID <= second.ID {
+		t.Errorf("номер нового фото %d повторяет удаленный", next.ID)
+	}
+}
+
+func TestSkyPhotoAge(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{25 * time.Minute, "25 мин назад"},
+		{3*time.Hour + 10*time.Minute, "3 ч назад"},
+		{26 * time.Hour, "1 день назад"},
+		{5 * 24 * time.Hour, "5 дней назад"},
+	}
+	for _, tt := range tests {
+		if got := skyPhotoAge(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("skyPhotoAge(%v) = %q, ожидалось %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestSkyCommand(t *testing.T) {
+	f := useMockReports(t)
+	useManualClock(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	const adminID = 4319
+	previous := config()
+	c := *previous
+	c.AdminChatIDs = []int64{adminID}
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	f.send(textUpdate(4311, "/sky Казань"))
+	if reply := f.reply(t, 4311); !strings.Contains(reply, "пока никто не присылал") {
+		t.Errorf("без фото: %q", reply)
+	}
+
+	photo := textUpdate(4312, "")
+	photo.Message.Caption = "/report солнце Казань"
+	photo.Message.CaptionEntities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/report")}}
+	photo.Message.Photo = []tgbotapi.PhotoSize{{FileID: "sky", Width: 1280, Height: 960}}
+	f.send(photo)
+
+	f.reset()
+	f.send(textUpdate(4311, "/sky Казань"))
+	if reply := f.reply(t, 4311); !strings.Contains(reply, "последние фото от пользователей (1)") {
+		t.Errorf("ответ /sky: %q", reply)
+	}
+	photos := f.sent("sendPhoto")
+	if len(photos) != 1 || photos[0].Params["photo"] != "sky" ||
+		!strings.HasPrefix(photos[0].Params["caption"], "📸 #1 ☀️ солнце, ") ||
+		!strings.Contains(photos[0].Params["reply_markup"], "Пожаловаться") {
+		t.Fatalf("фото в /sky: %+v", photos)
+	}
+
+	// Жалоба пересылается администратору с кнопками модерации
+	f.reset()
+	f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: 4311},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: 4311}},
+		Data:    encodeCallback(store, CallbackPayload{Action: actionSkyFlag, Value: "1"}),
+	}})
+	if reply := f.reply(t, 4311); !strings.Contains(reply, "Жалоба на фото #1 передана") {
+		t.Errorf("ответ на жалобу: %q", reply)
+	}
+	notices := f.sent("sendPhoto")
+	if len(notices) != 1 || notices[0].Params["chat_id"] != "4319" ||
+		!strings.Contains(notices[0].Params["caption"], "автор 4312, жалоб 1") ||
+		!strings.Contains(notices[0].Params["reply_markup"], "Удалить все и заблокировать") {
+		t.Errorf("жалоба администратору: %+v", notices)
+	}
+
+	f.reset()
+	f.send(textUpdate(adminID, "/skymod"))
+	if reply := f.reply(t, adminID); !strings.Contains(reply, "#1 Казань, автор 4312, жалоб 1") {
+		t.Errorf("список жалоб: %q", reply)
+	}
+
+	// Кнопка блокировки удаляет фото и блокирует автора
+	f.reset()
+	f.send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "2",
+		From:    &tgbotapi.User{ID: adminID},
+		Message: &tgbotapi.Message{MessageID: 8, Chat: &tgbotapi.Chat{ID: adminID}},
+		Data:    encodeCallback(store, CallbackPayload{Action: actionSkyBan, Value: "1"}),
+	}})
+	edits := f.sent("editMessageCaption")
+	if len(edits) != 1 || !strings.Contains(edits[0].Params["caption"], "Пользователь 4312 заблокирован, удалено фото: 1") {
+		t.Errorf("итог модерации: %+v", edits)
+	}
+	if !store.IsBanned(4312) {
+		t.Errorf("автор не заблокирован")
+	}
+	if _, ok := store.SkyPhoto(1); ok {
+		t.Errorf("фото не удалено")
+	}
+
+	f.reset()
+	f.send(textUpdate(adminID, "/skymod del 1"))
+	if reply := f.reply(t, adminID); reply != "Фото #1 уже удалено." {
+		t.Errorf("повторное удаление: %q", reply)
+	}
+}
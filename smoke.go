@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Предупреждения о дыме от лесных пожаров. Дым — это скачок мелкой пыли
+// PM2.5 в прогнозе качества воздуха OWM, а то, что это пожары, а не смог,
+// проверяем по пожароопасности: комплексному показателю Нестерова, которым
+// пользуются лесные службы. К оповещению прикладываем спутниковый снимок
+// NASA с очагами пожаров, если его удалось получить
+
+// Тип подписки на предупреждения о дыме
+const alertSmoke = "smoke"
+
+// На сколько вперед смотрим прогноз качества воздуха
+const smokeHorizon = 24 * time.Hour
+
+// Дымом считаем плохой воздух (индекс OWM от 4) с PM2.5 не ниже этого, мкг/м³
+const smokeMinPM25 = 55.0
+
+// С какого класса пожароопасности плохой воздух связываем с пожарами
+const smokeMinFireClass = 3
+
+// За сколько дней считаем показатель Нестерова: после дождя он обнуляется
+const fireWeatherDays = 14
+
+// Суточные осадки, после которых показатель Нестерова начинается заново, мм
+const nesterovResetRain = 3.0
+
+// Снимок NASA GIBS: настоящие цвета и тепловые аномалии VIIRS
+var smokeSnapshotURL = "https://gibs.earthdata.nasa.gov/wms/epsg4326/best/wms.cgi"
+
+// Половина размера снимка вокруг города в градусах: примерно 400×400 км в средних широтах
+const (
+	smokeSnapshotLat = 2.0
+	smokeSnapshotLon = 3.0
+)
+
+var gibsClient = &http.Client{Timeout: 20 * time.Second}
+
+func init() {
+	alertKinds[alertSmoke] = alertKind{
+		title:    "Дым от пожаров",
+		check:    checkSmoke,
+		cooldown: 12 * time.Hour,
+		snapshot: smokeSnapshot,
+	}
+}
+
+// Классы пожарной опасности по показателю Нестерова
+var fireDangerClasses = []struct {
+	maxIndex float64
+	name     string
+}{
+	{300, "отсутствует"},
+	{1000, "малая"},
+	{4000, "средняя"},
+	{10000, "высокая"},
+	{math.Inf(1), "чрезвычайная"},
+}
+
+// Класс пожарной опасности (1–5) по показателю Нестерова
+func fireDangerClass(index float64) int {
+	for i, class := range fireDangerClasses {
+		if index <= class.maxIndex {
+			return i + 1
+		}
+	}
+	return len(fireDangerClasses)
+}
+
+var romanClasses = []string{"I", "II", "III", "IV", "V"}
+
+// Почасовая погода Open-Meteo за прошедшие две недели для показателя Нестерова
+type fireWeatherResponse struct {
+	UTCOffset int `json:"utc_offset_seconds"`
+	Hourly    struct {
+		Time          []int64    `json:"time"`
+		Temperature   []*float64 `json:"temperature_2m"`
+		DewPoint      []*float64 `json:"dew_point_2m"`
+		Precipitation []*float64 `json:"precipitation"`
+	} `json:"hourly"`
+}
+
+// Часовой пояс точки прогноза
+func (r *fireWeatherResponse) zone() *time.Location {
+	return time.FixedZone("", r.UTCOffset)
+}
+
+func fetchFireWeather(lat, lon float64) (*fireWeatherResponse, error) {
+	if mockWeatherMode() {
+		return mockFireWeather(lat, lon), nil
+	}
+
+	reqURL := fmt.Sprintf(
+		"%s?latitude=%.4f&longitude=%.4f"+
+			"&hourly=temperature_2m,dew_point_2m,precipitation"+
+			"&past_days=%d&forecast_days=1&timeformat=unixtime&timezone=auto",
+		eventForecastURL,
+		lat,
+		lon,
+		fireWeatherDays,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения данных Open-Meteo: статус %d", resp.StatusCode)
+	}
+
+	var data fireWeatherResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	return &data, nil
+}
+
+// Погода демо-режима за те же две недели
+func mockFireWeather(lat, lon float64) *fireWeatherResponse {
+	location := mockLocation(lon)
+	_, offset := clockNow().In(location).Zone()
+	data := &fireWeatherResponse{UTCOffset: offset}
+
+	key := mockPlaceName(lat, lon)
+	start := clockNow().Truncate(time.Hour).Add(-fireWeatherDays * 24 * time.Hour)
+	for i := 0; i < (fireWeatherDays+1)*24; i++ {
+		item := mockSample(key, lat, start.Add(time.Duration(i)*time.Hour).In(location))
+		dew := dewPoint(item.Temp, item.Humidity)
+		precip := item.Rain + item.Snow
+		data.Hourly.Time = append(data.Hourly.Time, item.Time.Unix())
+		data.Hourly.Temperature = append(data.Hourly.Temperature, &item.Temp)
+		data.Hourly.DewPoint = append(data.Hourly.DewPoint, &dew)
+		data.Hourly.Precipitation = append(data.Hourly.Precipitation, &precip)
+	}
+	return data
+}
+
+// Показатель Нестерова на момент now: сумма T·(T − Td) в 13 часов за дни
+// без дождя, начиная с последнего дня с осадками больше nesterovResetRain
+func nesterovIndex(weather *fireWeatherResponse, now time.Time) float64 {
+	type dayWeather struct {
+		temp, dew float64
+		noon      bool
+		rain      float64
+	}
+	days := make(map[string]*dayWeather)
+	for i, unix := range weather.Hourly.Time {
+		at := time.Unix(unix, 0).In(weather.zone())
+		if at.After(now) {
+			break
+		}
+		key := at.Format("2006-01-02")
+		day, ok := days[key]
+		if !ok {
+			day = &dayWeather{}
+			days[key] = day
+		}
+		if rain, ok := valueAt(weather.Hourly.Precipitation, i); ok {
+			day.rain += rain
+		}
+		if at.Hour() != 13 {
+			continue
+		}
+		temp, okTemp := valueAt(weather.Hourly.Temperature, i)
+		dew, okDew := valueAt(weather.Hourly.DewPoint, i)
+		if okTemp && okDew {
+			day.temp, day.dew, day.noon = temp, dew, true
+		}
+	}
+
+	keys := make([]string, 0, len(days))
+	for key := range days {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	index := 0.0
+	for _, key := range keys {
+		day := days[key]
+		switch {
+		case day.rain > nesterovResetRain:
+			index = 0
+		case day.noon && day.temp > 0:
+			index += day.temp * math.Max(0, day.temp-day.dew)
+		}
+	}
+	return index
+}
+
+// Отрезок прогноза с дымом
+type smokePeriod struct {
+	start, end time.Time
+	maxAQI     int
+	maxPM25    float64
+}
+
+// Первый отрезок ближайших суток с дымным воздухом
+func smokeAhead(air *AirPollutionResponse, now time.Time) (smokePeriod, bool) {
+	var period smokePeriod
+	found := false
+	for _, item := range air.List {
+		start := time.Unix(item.Dt, 0).In(now.Location())
+		if start.Add(time.Hour).Before(now) || !start.Before(now.Add(smokeHorizon)) {
+			continue
+		}
+		smoky := item.Main.AQI >= 4 && item.Components.PM25 >= smokeMinPM25
+		if !smoky {
+			if found {
+				break
+			}
+			continue
+		}
+		if !found {
+			period = smokePeriod{start: latestTime(start, now)}
+			found = true
+		}
+		period.end = start.Add(time.Hour)
+		if item.Main.AQI > period.maxAQI {
+			period.maxAQI = item.Main.AQI
+		}
+		period.maxPM25 = math.Max(period.maxPM25, item.Components.PM25)
+	}
+	return period, found
+}
+
+// Проверка подписки: в ближайшие сутки ожидается дым, а пожароопасность высокая
+func checkSmoke(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	weather, err := fetchFireWeather(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+	now := clockNow().In(weather.zone())
+	index := nesterovIndex(weather, now)
+	if fireDangerClass(index) < smokeMinFireClass {
+		return "", false, nil
+	}
+
+	air, err := fetchAirPollutionForecast(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+	period, ok := smokeAhead(air, now)
+	if !ok {
+		return "", false, nil
+	}
+	return formatSmokeAlert(sub.City, period, index), true, nil
+}
+
+func formatSmokeAlert(city string, period smokePeriod, index float64) string {
+	when := "сейчас и"
+	if period.start.After(clockNow()) {
+		when = "с " + period.start.Format("15:04")
+		if period.start.Format("02.01") != clockNow().In(period.start.Location()).Format("02.01") {
+			when = "с " + period.start.Format("02.01 15:04")
+		}
+	}
+	class := fireDangerClass(index)
+
+	lines := []string{
+		fmt.Sprintf("🔥 Дым от лесных пожаров в %s: %s до %s качество воздуха %s, PM2.5 до %.0f мкг/м³.",
+			city, when, period.end.Format("15:04"), aqiDescription(period.maxAQI), period.maxPM25),
+		fmt.Sprintf("🌲 Пожароопасность: класс %s (%s), показатель Нестерова %.0f.",
+			romanClasses[class-1], fireDangerClasses[class-1].name, index),
+		"",
+		"😷 Закройте окна и включите очиститель воздуха, на улице — респиратор FFP2. " +
+			"Пробежки и тренировки лучше перенести в зал, особенно детям, пожилым и людям с астмой.",
+	}
+	return strings.Join(lines, "\n") + "\nОтписаться: /smoke off"
+}
+
+// Спутниковый снимок окрестностей города за вчера: сегодняшний еще не готов.
+// Пустой снимок без ошибки — снимка нет, оповещение уйдет текстом
+func smokeSnapshot(sub *AlertSubscription) ([]byte, error) {
+	if mockWeatherMode() {
+		return nil, nil
+	}
+
+	reqURL := fmt.Sprintf(
+		"%s?SERVICE=WMS&REQUEST=GetMap&VERSION=1.3.0"+
+			"&LAYERS=VIIRS_SNPP_CorrectedReflectance_TrueColor,VIIRS_SNPP_Thermal_Anomalies_375m_All"+
+			"&CRS=EPSG:4326&BBOX=%.3f,%.3f,%.3f,%.3f&WIDTH=768&HEIGHT=512&FORMAT=image/jpeg&TIME=%s",
+		smokeSnapshotURL,
+		sub.Lat-smokeSnapshotLat,
+		sub.Lon-smokeSnapshotLon,
+		sub.Lat+smokeSnapshotLat,
+		sub.Lon+smokeSnapshotLon,
+		clockNow().UTC().AddDate(0, 0, -1).Format("2006-01-02"),
+	)
+
+	resp, err := gibsClient.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса снимка: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Об ошибках WMS сообщает XML-документом со статусом 200
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return nil, fmt.Errorf("ошибка получения снимка: статус %d, %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	image, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения снимка: %v", err)
+	}
+	return image, nil
+}
+
+// Подписка чата на предупреждения о дыме от пожаров
+func subscribeSmoke(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertSmoke,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🔥 Подписка оформлена! Предупрежу, если в %s ожидается дым от лесных пожаров: "+
+			"плохой воздух с PM2.5 от %.0f мкг/м³ при пожароопасности от III класса. "+
+			"К предупреждению приложу спутниковый снимок с очагами, если он будет.\n"+
+			"Отписаться: /smoke off",
+		point.DisplayName(),
+		smokeMinPM25,
+	), nil
+}
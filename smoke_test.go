@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Две недели погоды с 13-часовой точкой T=30, Td=10 (600 в день) и ливнем в день rainDay
+func fireWeatherFixture(start time.Time, days, rainDay int) *fireWeatherResponse {
+	_, offset := start.Zone()
+	data := &fireWeatherResponse{UTCOffset: offset}
+	for i := 0; i < days*24; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		temp, dew, rain := 18.0, 12.0, 0.0
+		if at.Hour() == 13 {
+			temp, dew = 30, 10
+		}
+		if i/24 == rainDay && at.Hour() == 16 {
+			rain = 5
+		}
+		data.Hourly.Time = append(data.Hourly.Time, at.Unix())
+		data.Hourly.Temperature = append(data.Hourly.Temperature, floatPtr(temp))
+		data.Hourly.DewPoint = append(data.Hourly.DewPoint, floatPtr(dew))
+		data.Hourly.Precipitation = append(data.Hourly.Precipitation, floatPtr(rain))
+	}
+	return data
+}
+
+func TestNesterovIndex(t *testing.T) {
+	local := time.FixedZone("", 7*3600)
+	start := time.Date(2026, 7, 6, 0, 0, 0, 0, local)
+	weather := fireWeatherFixture(start, 15, 4)
+
+	// После ливня 10 июля сухие дни с 11-го по 20-е, 20-го 13 часов уже прошло
+	if got := nesterovIndex(weather, time.Date(2026, 7, 20, 15, 0, 0, 0, local)); got != 6000 {
+		t.Errorf("показатель %.0f, ожидалось 6000", got)
+	}
+	// Утром 20-го дневной точки еще нет
+	if got := nesterovIndex(weather, time.Date(2026, 7, 20, 9, 0, 0, 0, local)); got != 5400 {
+		t.Errorf("показатель утром %.0f, ожидалось 5400", got)
+	}
+
+	for index, want := range map[float64]int{0: 1, 300: 1, 301: 2, 2500: 3, 6000: 4, 12000: 5} {
+		if got := fireDangerClass(index); got != want {
+			t.Errorf("fireDangerClass(%.0f) = %d, ожидался %d", index, got, want)
+		}
+	}
+}
+
+func TestSmokeAhead(t *testing.T) {
+	now := time.Date(2026, 7, 20, 12, 30, 0, 0, time.UTC)
+	air := &AirPollutionResponse{}
+	for i, aqi := range []int{2, 2, 5, 5, 4, 3, 5} {
+		item := airPollutionItem{Dt: time.Date(2026, 7, 20, 12+i, 0, 0, 0, time.UTC).Unix()}
+		item.Main.AQI = aqi
+		item.Components.PM25 = float64(aqi) * 25
+		air.List = append(air.List, item)
+	}
+
+	period, ok := smokeAhead(air, now)
+	if !ok {
+		t.Fatal("дым не найден")
+	}
+	// Индекс 4 с PM2.5 100 тоже дым; второй отрезок после чистого часа не берем
+	if period.start.Format("15:04") != "14:00" || period.end.Format("15:04") != "17:00" || period.maxAQI != 5 || period.maxPM25 != 125 {
+		t.Errorf("отрезок %s–%s, AQI %d, PM2.5 %.0f", period.start.Format("15:04"), period.end.Format("15:04"), period.maxAQI, period.maxPM25)
+	}
+}
+
+func TestSmokeAlertWithSnapshot(t *testing.T) {
+	f := newFakeTelegram(t)
+	local := time.FixedZone("", 7*3600)
+	useManualClock(t, time.Date(2026, 7, 20, 15, 0, 0, 0, local))
+
+	var snapshotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/forecast":
+			json.NewEncoder(w).Encode(fireWeatherFixture(time.Date(2026, 7, 6, 0, 0, 0, 0, local), 15, 4))
+		case "/data/2.5/air_pollution/forecast":
+			var list []string
+			for i, aqi := range []int{2, 2, 2, 5, 5, 5, 3} {
+				dt := time.Date(2026, 7, 20, 15+i, 0, 0, 0, local).Unix()
+				list = append(list, fmt.Sprintf(`{"dt": %d, "main": {"aqi": %d}, "components": {"pm2_5": %d}}`, dt, aqi, aqi*30))
+			}
+			fmt.Fprintf(w, `{"list": [%s]}`, strings.Join(list, ","))
+		case "/wms.cgi":
+			snapshotQuery = r.URL.RawQuery
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("\xff\xd8\xff\xe0 снимок"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.OWMAPIKeys = []string{"test-key"}
+	c.OWMAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+	previousForecastURL, previousSnapshotURL := eventForecastURL, smokeSnapshotURL
+	eventForecastURL, smokeSnapshotURL = server.URL+"/v1/forecast", server.URL+"/wms.cgi"
+	t.Cleanup(func() { eventForecastURL, smokeSnapshotURL = previousForecastURL, previousSnapshotURL })
+
+	if err := store.Subscribe(AlertSubscription{ChatID: 4261, Kind: alertSmoke, City: "Красноярск", Lat: 56.01, Lon: 92.87}); err != nil {
+		t.Fatal(err)
+	}
+	checkAlerts(f.bot, store)
+
+	photos := f.sent("sendPhoto")
+	if len(photos) != 1 {
+		t.Fatalf("отправлено снимков %d, сообщений %d", len(photos), len(f.sent("sendMessage")))
+	}
+	caption := photos[0].Params["caption"]
+	for _, want := range []string{
+		"🔥 Дым от лесных пожаров в Красноярск: с 18:00 до 21:00 качество воздуха очень плохое, PM2.5 до 150 мкг/м³.",
+		"класс IV (высокая), показатель Нестерова 6000",
+		"Отписаться: /smoke off",
+	} {
+		if !strings.Contains(caption, want) {
+			t.Errorf("в подписи %q нет %q", caption, want)
+		}
+	}
+	if !strings.Contains(snapshotQuery, "TIME=2026-07-19") || !strings.Contains(snapshotQuery, "BBOX=54.010,89.870,58.010,95.870") {
+		t.Errorf("запрос снимка %s", snapshotQuery)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Тип подписки на утренние оценки выработки солнечных панелей
+const alertSolar = "solar"
+
+// Часы местного времени, в которые отправляем утреннюю оценку
+const (
+	solarMorningFrom = 7
+	solarMorningTo   = 9
+)
+
+// Коэффициент производительности типичной домашней станции
+const solarPerformanceRatio = 0.8
+
+func init() {
+	alertKinds[alertSolar] = alertKind{title: "Выработка солнечных панелей", check: checkSolar, cooldown: 20 * time.Hour}
+}
+
+// Высота Солнца над горизонтом в градусах (упрощенный алгоритм NOAA)
+func sunElevation(lat, lon float64, t time.Time) float64 {
+	t = t.UTC()
+	dayOfYear := float64(t.YearDay())
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+
+	gamma := 2 * math.Pi / 365 * (dayOfYear - 1 + (hour-12)/24)
+	declination := 0.006918 - 0.399912*math.Cos(gamma) + 0.070257*math.Sin(gamma) -
+		0.006758*math.Cos(2*gamma) + 0.000907*math.Sin(2*gamma) -
+		0.002697*math.Cos(3*gamma) + 0.00148*math.Sin(3*gamma)
+	equationOfTime := 229.18 * (0.000075 + 0.001868*math.Cos(gamma) - 0.032077*math.Sin(gamma) -
+		0.014615*math.Cos(2*gamma) - 0.040849*math.Sin(2*gamma))
+
+	solarTime := hour*60 + equationOfTime + 4*lon
+	hourAngle := toRadians(solarTime/4 - 180)
+
+	latRad := toRadians(lat)
+	cosZenith := math.Sin(latRad)*math.Sin(declination) +
+		math.Cos(latRad)*math.Cos(declination)*math.Cos(hourAngle)
+
+	return 90 - toDegrees(math.Acos(math.Max(-1, math.Min(1, cosZenith))))
+}
+
+// Суммарная радиация на горизонтальную поверхность при ясном небе, Вт/м² (модель Haurwitz)
+func clearSkyIrradiance(elevation float64) float64 {
+	if elevation <= 0 {
+		return 0
+	}
+	cosZenith := math.Sin(toRadians(elevation))
+	return 1098 * cosZenith * math.Exp(-0.057/cosZenith)
+}
+
+// Ослабление радиации облачностью (формула Kasten-Czeplak), clouds в процентах
+func cloudFactor(clouds int) float64 {
+	return 1 - 0.75*math.Pow(float64(clouds)/100, 3.4)
+}
+
+// Оценка выработки за день
+type solarDay struct {
+	date      time.Time
+	clearSky  float64 // кВт·ч/м² при ясном небе
+	expected  float64 // кВт·ч/м² с учетом облачности
+	daylight  time.Duration
+	avgClouds float64
+	slots     int
+}
+
+// Доля от выработки в ясный день, %
+func (d solarDay) relative() float64 {
+	if d.clearSky == 0 {
+		return 0
+	}
+	return d.expected / d.clearSky * 100
+}
+
+// Оценка выработки по дням: каждый интервал прогноза покрывает три часа,
+// поэтому считаем радиацию по часам с облачностью этого интервала
+func solarDays(forecast *Forecast) []*solarDay {
+	var days []*solarDay
+	byDate := make(map[string]*solarDay)
+	lat, lon := forecast.Lat, forecast.Lon
+
+	for _, item := range forecast.Items {
+		for offset := -1; offset <= 1; offset++ {
+			t := item.Time.Add(time.Duration(offset) * time.Hour)
+			local := t.In(forecast.Location)
+			key := local.Format("2006-01-02")
+
+			day, ok := byDate[key]
+			if !ok {
+				day = &solarDay{date: local}
+				byDate[key] = day
+				days = append(days, day)
+			}
+
+			clear := clearSkyIrradiance(sunElevation(lat, lon, t)) / 1000
+			if clear == 0 {
+				continue
+			}
+			day.clearSky += clear
+			day.expected += clear * cloudFactor(item.Clouds)
+			day.daylight += time.Hour
+			day.avgClouds += float64(item.Clouds)
+			day.slots++
+		}
+	}
+
+	for _, day := range days {
+		if day.slots > 0 {
+			day.avgClouds /= float64(day.slots)
+		}
+	}
+
+	return days
+}
+
+// Строка с оценкой выработки за день
+func formatSolarDay(label string, day *solarDay) string {
+	relative := day.relative()
+	emoji := "☀️"
+	switch {
+	case relative < 40:
+		emoji = "☁️"
+	case relative < 70:
+		emoji = "⛅️"
+	}
+
+	return fmt.Sprintf("%s %s: ~%.0f%% от ясного дня, ~%.1f кВт·ч на 1 кВт панелей "+
+		"(облачность %.0f%%, светлое время ~%.0f ч)\n",
+		emoji,
+		label,
+		relative,
+		day.expected*solarPerformanceRatio,
+		day.avgClouds,
+		day.daylight.Hours(),
+	)
+}
+
+// Функция для оценки выработки солнечных панелей на сегодня и завтра
+func getSolarEstimate(city string) (string, error) {
+	forecast, err := fetchForecast(city)
+	if err != nil {
+		return "", err
+	}
+	return formatSolarEstimate(forecast)
+}
+
+func formatSolarEstimate(forecast *Forecast) (string, error) {
+	days := solarDays(forecast)
+	if len(days) == 0 {
+		return "", fmt.Errorf("нет данных прогноза")
+	}
+
+	solarMsg := fmt.Sprintf("🔆 Выработка солнечных панелей в %s:\n\n", forecast.City)
+
+	now := forecast.Now()
+	labels := map[string]string{
+		now.Format("2006-01-02"):                  "Сегодня",
+		now.AddDate(0, 0, 1).Format("2006-01-02"): "Завтра",
+	}
+	for _, day := range days {
+		label, ok := labels[day.date.Format("2006-01-02")]
+		if !ok || day.clearSky == 0 {
+			continue
+		}
+		solarMsg += formatSolarDay(label, day)
+	}
+
+	solarMsg += "\nОценка для панелей, установленных горизонтально; реальная выработка зависит от ориентации и затенения."
+
+	return solarMsg, nil
+}
+
+// Утренняя оценка выработки для подписчиков
+func checkSolar(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := fetchForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	hour := forecast.Now().Hour()
+	if hour < solarMorningFrom || hour >= solarMorningTo {
+		return "", false, nil
+	}
+
+	forecast.City = sub.City
+	text, err := formatSolarEstimate(forecast)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.Replace(text, "🔆 Выработка", "🔆 Доброе утро! Выработка", 1), true, nil
+}
+
+// Подписка чата на утренние оценки выработки
+func subscribeSolar(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertSolar,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🔆 Каждое утро с %d:00 до %d:00 пришлю оценку выработки панелей в %s.\n"+
+			"Отписаться: /solar off",
+		solarMorningFrom,
+		solarMorningTo,
+		point.DisplayName(),
+	), nil
+}
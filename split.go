@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Длинные ответы: Telegram принимает не больше 4096 символов в сообщении,
+// поэтому длинный текст отправляется несколькими сообщениями. Режем по
+// пустым строкам — в прогнозах это границы дней, — а если абзац не
+// помещается целиком, то по строкам
+
+// Предел длины текста сообщения в Telegram
+const telegramMessageLimit = 4096
+
+// Разбиение текста на части не длиннее limit символов
+func splitMessage(text string, limit int) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	var parts []string
+	var current []string
+	size := 0
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, strings.Join(current, "\n\n"))
+			current, size = nil, 0
+		}
+	}
+	for _, block := range strings.Split(text, "\n\n") {
+		n := utf8.RuneCountInString(block)
+		if n > limit {
+			flush()
+			parts = append(parts, splitLines(block, limit)...)
+			continue
+		}
+		// Абзацы склеиваются обратно через пустую строку: два символа
+		if len(current) > 0 && size+2+n > limit {
+			flush()
+		}
+		if len(current) > 0 {
+			size += 2
+		}
+		current = append(current, block)
+		size += n
+	}
+	flush()
+	return parts
+}
+
+// Разбиение абзаца по строкам; слишком длинная строка режется по символам
+func splitLines(block string, limit int) []string {
+	var parts []string
+	var current strings.Builder
+	size := 0
+	for _, line := range strings.Split(block, "\n") {
+		runes := []rune(line)
+		for len(runes) > limit {
+			if size > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+				size = 0
+			}
+			parts = append(parts, string(runes[:limit]))
+			runes = runes[limit:]
+		}
+		if size > 0 && size+1+len(runes) > limit {
+			parts = append(parts, current.String())
+			current.Reset()
+			size = 0
+		}
+		if size > 0 {
+			current.WriteString("\n")
+			size++
+		}
+		current.WriteString(string(runes))
+		size += len(runes)
+	}
+	if size > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+// Отправка ответа, который может не поместиться в одно сообщение. Ответы
+// на команды отправляются без повторов: обновления обрабатываются по
+// одному, и ожидание из-за одного чата задержало бы всех остальных
+func sendSplit(bot messageSender, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	return sendParts(msg, bot.Send)
+}
+
+// То же для фоновых оповещений: каждая часть отправляется с повторами
+func sendSplitWithRetry(bot messageSender, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	return sendParts(msg, func(c tgbotapi.Chattable) (tgbotapi.Message, error) { return sendWithRetry(bot, c) })
+}
+
+// Текст по частям. Кнопки прикрепляются к последней части, ответ на
+// сообщение — к первой
+func sendParts(msg tgbotapi.MessageConfig, send func(tgbotapi.Chattable) (tgbotapi.Message, error)) (tgbotapi.Message, error) {
+	parts := splitMessage(msg.Text, telegramMessageLimit)
+	var sent tgbotapi.Message
+	for i, part := range parts {
+		message := msg
+		message.Text = part
+		if i > 0 {
+			message.ReplyToMessageID = 0
+		}
+		if i < len(parts)-1 {
+			message.ReplyMarkup = nil
+		}
+		var err error
+		if sent, err = send(message); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
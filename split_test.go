@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSplitMessage(t *testing.T) {
+	if parts := splitMessage("короткий текст", 100); len(parts) != 1 || parts[0] != "короткий текст" {
+		t.Errorf("короткий текст: %q", parts)
+	}
+
+	// Прогноз режется по дням, а дни не разрываются
+	day := func(date string) string {
+		return "📅 " + date + ":\n⏰ 09:00: +5°C, облачно\n⏰ 12:00: +8°C, дождь"
+	}
+	text := "🔮 Прогноз:\n\n" + strings.Join([]string{day("16.10"), day("17.10"), day("18.10")}, "\n\n")
+	limit := utf8.RuneCountInString("🔮 Прогноз:\n\n" + day("16.10") + "\n\n" + day("17.10"))
+	parts := splitMessage(text, limit)
+	if len(parts) != 2 || parts[1] != day("18.10") || !strings.HasSuffix(parts[0], day("17.10")) {
+		t.Errorf("разбиение по дням: %q", parts)
+	}
+	if strings.Join(parts, "\n\n") != text {
+		t.Error("при разбиении потерялся текст")
+	}
+
+	// Абзац длиннее предела режется по строкам, строка длиннее — по символам
+	parts = splitMessage("раз\nдва\nтри\n"+strings.Repeat("я", 12), 8)
+	want := []string{"раз\nдва", "три", "яяяяяяяя", "яяяя"}
+	if strings.Join(parts, "|") != strings.Join(want, "|") {
+		t.Errorf("разбиение абзаца: %q, ожидалось %q", parts, want)
+	}
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) > 8 {
+			t.Errorf("часть длиннее предела: %q", part)
+		}
+	}
+}
+
+func TestSendSplit(t *testing.T) {
+	f := newFakeTelegram(t)
+	const chatID = 4361
+
+	msg := tgbotapi.NewMessage(chatID, strings.Repeat("📅 день\n", 400)+"\n"+strings.Repeat("📅 еще день\n", 200))
+	msg.ReplyMarkup = weatherKeyboard(store, "Тула", UserPreferences{})
+	if _, err := sendSplit(f.bot, msg); err != nil {
+		t.Fatalf("sendSplit: %v", err)
+	}
+	messages := f.sent("sendMessage")
+	if len(messages) != 2 {
+		t.Fatalf("отправлено %d сообщений, ожидалось 2", len(messages))
+	}
+	if messages[0].Params["reply_markup"] != "" || !strings.Contains(messages[1].Params["reply_markup"], "Прогноз на 5 дней") {
+		t.Errorf("кнопки не у последней части: %+v", messages)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Темы стикеров по погоде
+const (
+	themeSunny  = "SUNNY"
+	themeCloudy = "CLOUDY"
+	themeRainy  = "RAINY"
+	themeSnowy  = "SNOWY"
+	themeStormy = "STORMY"
+	themeFoggy  = "FOGGY"
+)
+
+var stickerThemes = []string{themeSunny, themeCloudy, themeRainy, themeSnowy, themeStormy, themeFoggy}
+
+// Тема стикера по коду погоды OpenWeatherMap
+func stickerTheme(data *CurrentWeather) string {
+	switch condition := weatherCondition(data); condition {
+	case 800:
+		return themeSunny
+	case 2:
+		return themeStormy
+	case 3, 5:
+		return themeRainy
+	case 6:
+		return themeSnowy
+	case 7:
+		return themeFoggy
+	case 8:
+		return themeCloudy
+	default:
+		return ""
+	}
+}
+
+// Стикер или GIF для темы из настройки STICKER_<ТЕМА>: file_id стикера
+// или ссылка на .gif/.mp4
+func stickerFor(theme string) string {
+	return config().Stickers[theme]
+}
+
+// Настроен ли хотя бы один стикер
+func stickersConfigured() bool {
+	for _, theme := range stickerThemes {
+		if stickerFor(theme) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Отправка стикера под погоду, если он настроен и пользователь его не отключил
+func sendWeatherSticker(bot *Bot, chatID int64, data *CurrentWeather) error {
+	if bot.store.Preferences(chatID).PlainText || !featureEnabled(featureStickers, chatID) {
+		return nil
+	}
+	sticker := stickerFor(stickerTheme(data))
+	if sticker == "" {
+		return nil
+	}
+
+	lower := strings.ToLower(sticker)
+	var media tgbotapi.Chattable
+	if strings.HasSuffix(lower, ".gif") || strings.HasSuffix(lower, ".mp4") {
+		media = tgbotapi.NewAnimation(chatID, tgbotapi.FileURL(sticker))
+	} else {
+		media = tgbotapi.NewSticker(chatID, tgbotapi.FileID(sticker))
+	}
+	_, err := bot.Send(media)
+	return err
+}
+
+// Стикер под текущую погоду в городе
+func sendCitySticker(bot *Bot, chatID int64, city string) error {
+	prefs := bot.store.Preferences(chatID)
+	if prefs.PlainText || !stickersConfigured() || !featureEnabled(featureStickers, chatID) {
+		return nil
+	}
+	data, err := cachedWeather(city, prefs.Language)
+	if err != nil {
+		return err
+	}
+	return sendWeatherSticker(bot, chatID, data)
+}
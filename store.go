@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Файл состояния по умолчанию (переопределяется через STATE_FILE)
+const defaultStateFile = "bot_state.json"
+
+// Данные, которые должны пережить перезапуск бота
+type storeData struct {
+	// Версия формата файла, см. stateMigrations
+	SchemaVersion int                           `json:"schema_version"`
+	Subscriptions map[string]*AlertSubscription `json:"subscriptions"`
+	Preferences   map[int64]*UserPreferences    `json:"preferences"`
+	Dialogs       map[int64]*DialogState        `json:"dialogs"`
+	Callbacks     map[string]*CallbackPayload   `json:"callbacks"`
+	Premium       map[int64]time.Time           `json:"premium"`
+	Donations     []Donation                    `json:"donations"`
+	Referrals     map[int64]*Referral           `json:"referrals"`
+	ReferrerNames map[int64]string              `json:"referrer_names"`
+	Feedback      []*Feedback                   `json:"feedback"`
+	Banned        map[int64]*Ban                `json:"banned"`
+	RecentCities  map[int64][]string            `json:"recent_cities"`
+	GroupPosts    map[int64]*GroupPost          `json:"group_posts"`
+	Webhooks      map[int64][]*Webhook          `json:"webhooks"`
+	Places        map[int64][]*SavedPlace       `json:"places"`
+	Events        map[int64][]*WeatherEvent     `json:"events"`
+	Observations  map[string]*CityObservations  `json:"observations"`
+	SkyPhotos     []*SkyPhoto                   `json:"sky_photos"`
+	Missed        map[int64][]*MissedMessage    `json:"missed"`
+	PausedUntil   map[int64]time.Time           `json:"paused_until"`
+	// Номер последнего фото неба, чтобы номера удаленных не повторялись
+	LastSkyPhotoID int `json:"last_sky_photo_id"`
+}
+
+// Хранилище состояния бота в JSON-файле
+type Store struct {
+	path string
+	data storeData
+	mu   sync.Mutex
+}
+
+// Открытие хранилища: если файла еще нет, начинаем с пустого состояния
+func openStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	raw, err := os.ReadFile(path)
+	exists := err == nil
+	switch {
+	case os.IsNotExist(err):
+		raw = []byte("{}")
+	case err != nil:
+		return nil, fmt.Errorf("ошибка чтения файла состояния: %v", err)
+	}
+
+	data, from, err := decodeState(raw)
+	if err != nil {
+		return nil, err
+	}
+	s.data = data
+
+	// Перед сохранением в новом формате оставляем копию старого файла
+	if exists && from < stateSchemaVersion() {
+		if err := backupStateFile(path, raw, from); err != nil {
+			return nil, err
+		}
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Разбор содержимого файла состояния: старые версии сначала приводятся
+// к текущему формату, пустые таблицы создаются. Возвращает и исходную версию
+func decodeState(raw []byte) (storeData, int, error) {
+	var data storeData
+
+	current, from, err := migrateState(raw, stateMigrations)
+	if err != nil {
+		return data, from, err
+	}
+	if err := json.Unmarshal(current, &data); err != nil {
+		return data, from, fmt.Errorf("ошибка парсинга файла состояния: %v", err)
+	}
+	data.SchemaVersion = stateSchemaVersion()
+
+	if data.Subscriptions == nil {
+		data.Subscriptions = make(map[string]*AlertSubscription)
+	}
+	if data.Preferences == nil {
+		data.Preferences = make(map[int64]*UserPreferences)
+	}
+	if data.Dialogs == nil {
+		data.Dialogs = make(map[int64]*DialogState)
+	}
+	if data.Callbacks == nil {
+		data.Callbacks = make(map[string]*CallbackPayload)
+	}
+	if data.Premium == nil {
+		data.Premium = make(map[int64]time.Time)
+	}
+	if data.Referrals == nil {
+		data.Referrals = make(map[int64]*Referral)
+	}
+	if data.ReferrerNames == nil {
+		data.ReferrerNames = make(map[int64]string)
+	}
+	if data.Banned == nil {
+		data.Banned = make(map[int64]*Ban)
+	}
+	if data.RecentCities == nil {
+		data.RecentCities = make(map[int64][]string)
+	}
+	if data.GroupPosts == nil {
+		data.GroupPosts = make(map[int64]*GroupPost)
+	}
+	if data.Webhooks == nil {
+		data.Webhooks = make(map[int64][]*Webhook)
+	}
+	if data.Places == nil {
+		data.Places = make(map[int64][]*SavedPlace)
+	}
+	if data.Events == nil {
+		data.Events = make(map[int64][]*WeatherEvent)
+	}
+	if data.Observations == nil {
+		data.Observations = make(map[string]*CityObservations)
+	}
+	if data.Missed == nil {
+		data.Missed = make(map[int64][]*MissedMessage)
+	}
+	if data.PausedUntil == nil {
+		data.PausedUntil = make(map[int64]time.Time)
+	}
+
+	return data, from, nil
+}
+
+// Сохранение состояния на диск. Вызывается под s.mu
+func (s *Store) save() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации состояния: %v", err)
+	}
+	if err := writeFileAtomic(s.path, raw); err != nil {
+		return fmt.Errorf("ошибка записи файла состояния: %v", err)
+	}
+	return nil
+}
+
+// Запись файла через временный файл и переименование, чтобы не оставить
+// битый JSON при сбое
+func writeFileAtomic(path string, raw []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Пошаговое оформление подписки: тип → город → время или порог → подтверждение
+const flowSubscribe = "subscribe"
+
+// Варианты ответа на вопрос о подтверждении
+const (
+	answerYes = "Да"
+	answerNo  = "Нет"
+)
+
+// Подписки, доступные в диалоге, в порядке показа
+var dialogSubscriptionKinds = []string{alertDaily, alertAurora, alertThunder, alertHeatwave, alertHeatStress, alertHazards, alertSmoke, alertFlood, alertPressure, alertSolar, alertSunset}
+
+func init() {
+	dialogFlows[flowSubscribe] = dialogFlow{
+		first: "kind",
+		steps: map[string]dialogStep{
+			"kind":      {prompt: promptSubscriptionKind, handle: handleSubscriptionKind},
+			"city":      {prompt: promptSubscriptionCity, handle: handleSubscriptionCity},
+			"hour":      {prompt: promptSubscriptionHour, handle: handleSubscriptionHour},
+			"threshold": {prompt: promptSubscriptionThreshold, handle: handleSubscriptionThreshold},
+			"confirm":   {prompt: promptSubscriptionConfirm, handle: handleSubscriptionConfirm},
+		},
+	}
+}
+
+func promptSubscriptionKind(state *DialogState) dialogPrompt {
+	var options []string
+	for _, kind := range dialogSubscriptionKinds {
+		options = append(options, alertKinds[kind].title)
+	}
+	return dialogPrompt{text: "🔔 Какие оповещения настроить?", options: options}
+}
+
+func handleSubscriptionKind(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	for _, kind := range dialogSubscriptionKinds {
+		if strings.EqualFold(input, alertKinds[kind].title) {
+			state.Data["kind"] = kind
+			return "city", "", nil
+		}
+	}
+	return "", "", fmt.Errorf("выберите вариант на клавиатуре")
+}
+
+func promptSubscriptionCity(state *DialogState) dialogPrompt {
+	prompt := dialogPrompt{text: "🏙 Для какого города?"}
+	if city := state.Data["last_city"]; city != "" {
+		prompt.options = []string{city}
+	}
+	return prompt
+}
+
+func handleSubscriptionCity(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	point, err := geocodeCity(input)
+	if err != nil {
+		return "", "", err
+	}
+	state.Data["city"] = point.Name
+	state.Data["city_title"] = point.DisplayName()
+
+	switch state.Data["kind"] {
+	case alertDaily:
+		return "hour", "", nil
+	case alertPressure:
+		return "threshold", "", nil
+	default:
+		return "confirm", "", nil
+	}
+}
+
+func promptSubscriptionHour(state *DialogState) dialogPrompt {
+	return dialogPrompt{
+		text:    fmt.Sprintf("⏰ В котором часу присылать сводку? Местное время, от %d до %d.", digestHourMin, digestHourMax),
+		options: []string{"6", "7", "8", "9"},
+	}
+}
+
+func handleSubscriptionHour(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	hour, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(input, ":00"), " ч"))
+	if err != nil || hour < digestHourMin || hour > digestHourMax {
+		return "", "", fmt.Errorf("нужно число от %d до %d", digestHourMin, digestHourMax)
+	}
+	state.Data["hour"] = strconv.Itoa(hour)
+	return "confirm", "", nil
+}
+
+func promptSubscriptionThreshold(state *DialogState) dialogPrompt {
+	return dialogPrompt{
+		text: fmt.Sprintf(
+			"🤕 При каком перепаде давления за сутки предупреждать? В гПа, от 2 до 40 (по умолчанию %.0f).",
+			defaultPressureThreshold,
+		),
+		options: []string{"4", "6", strconv.Itoa(int(defaultPressureThreshold)), "12"},
+	}
+}
+
+func handleSubscriptionThreshold(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	threshold, err := strconv.ParseFloat(strings.Replace(input, ",", ".", 1), 64)
+	if err != nil || threshold < 2 || threshold > 40 {
+		return "", "", fmt.Errorf("нужно число от 2 до 40")
+	}
+	state.Data["threshold"] = strconv.FormatFloat(threshold, 'f', -1, 64)
+	return "confirm", "", nil
+}
+
+func promptSubscriptionConfirm(state *DialogState) dialogPrompt {
+	text := fmt.Sprintf("Подписаться на «%s» для %s", alertKinds[state.Data["kind"]].title, state.Data["city_title"])
+	if hour := state.Data["hour"]; hour != "" {
+		text += fmt.Sprintf(" в %s:00", hour)
+	}
+	if threshold := state.Data["threshold"]; threshold != "" {
+		text += fmt.Sprintf(" с порогом %s гПа", threshold)
+	}
+	return dialogPrompt{text: text + "?", options: []string{answerYes, answerNo}}
+}
+
+func handleSubscriptionConfirm(bot *Bot, chatID int64, state *DialogState, input string) (string, string, error) {
+	switch {
+	case strings.EqualFold(input, answerNo):
+		return "", "Хорошо, подписка не оформлена.", nil
+	case !strings.EqualFold(input, answerYes):
+		return "", "", fmt.Errorf("ответьте «%s» или «%s»", answerYes, answerNo)
+	}
+
+	city := state.Data["city"]
+	var reply string
+	var err error
+	switch state.Data["kind"] {
+	case alertDaily:
+		hour, _ := strconv.Atoi(state.Data["hour"])
+		reply, err = subscribeDailyAt(bot.store, chatID, city, hour)
+	case alertAurora:
+		reply, err = subscribeAurora(bot.store, chatID, city)
+	case alertThunder:
+		reply, err = subscribeThunder(bot.store, chatID, city)
+	case alertHeatwave:
+		reply, err = subscribeHeatwave(bot.store, chatID, city)
+	case alertHeatStress:
+		reply, err = subscribeHeatStress(bot.store, chatID, city, defaultHeatRiskLevel)
+	case alertHazards:
+		reply, err = subscribeHazards(bot.store, chatID, city)
+	case alertSmoke:
+		reply, err = subscribeSmoke(bot.store, chatID, city)
+	case alertFlood:
+		reply, err = subscribeFlood(bot.store, chatID, city, defaultFloodThreshold)
+	case alertPressure:
+		threshold, _ := strconv.ParseFloat(state.Data["threshold"], 64)
+		reply, err = subscribePressure(bot.store, chatID, city, threshold)
+	case alertSolar:
+		reply, err = subscribeSolar(bot.store, chatID, city)
+	case alertSunset:
+		reply, err = subscribeSunset(bot.store, chatID, city)
+	}
+	if err != nil {
+		// Ошибку сети не стоит превращать в бесконечный переспрос
+		return "", "❌ Ошибка: " + err.Error(), nil
+	}
+	return "", reply, nil
+}
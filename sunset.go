@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Тип подписки на оповещения о красивом закате
+const alertSunset = "sunset"
+
+// Оповещение приходит примерно за 40 минут до заката: проверки идут раз в
+// alertCheckInterval, поэтому ловим окно такой же длины
+const (
+	sunsetLeadMin = 25 * time.Minute
+	sunsetLeadMax = sunsetLeadMin + alertCheckInterval
+)
+
+// Облака над городом, которые подсветит закат, %: при меньшей облачности
+// небо просто голубое, при большей солнце закрыто
+const (
+	sunsetMinClouds = 30
+	sunsetMaxClouds = 80
+)
+
+// Горизонт в стороне заката: точка на этом расстоянии должна быть почти
+// без облаков, иначе лучи не доберутся до облаков над городом
+const (
+	sunsetHorizonKm        = 150.0
+	sunsetHorizonMaxClouds = 30
+)
+
+// При такой вероятности осадков закат скорее всего будет за пеленой
+const sunsetMaxPop = 0.3
+
+func init() {
+	alertKinds[alertSunset] = alertKind{title: "Красивый закат", check: checkSunset, cooldown: 20 * time.Hour}
+}
+
+// Азимут заката в градусах от севера по склонению Солнца в этот день
+func sunsetAzimuth(lat float64, date time.Time) float64 {
+	declination := -23.44 * math.Cos(2*math.Pi/365*float64(date.YearDay()+10))
+	cosAzimuth := math.Sin(toRadians(declination)) / math.Cos(toRadians(lat))
+	return 360 - toDegrees(math.Acos(math.Max(-1, math.Min(1, cosAzimuth))))
+}
+
+// Точка на расстоянии km от исходной по азимуту bearing
+func pointAtBearing(lat, lon, bearing, km float64) (float64, float64) {
+	const kmPerDegree = 111.32
+	b := toRadians(bearing)
+	return lat + km*math.Cos(b)/kmPerDegree, lon + km*math.Sin(b)/(kmPerDegree*math.Cos(toRadians(lat)))
+}
+
+// Интервал прогноза, в который попадает момент t
+func forecastSlotAt(forecast *Forecast, t time.Time) (ForecastItem, bool) {
+	for _, item := range forecast.Items {
+		if !t.Before(item.Time) && t.Before(item.Time.Add(forecastStep)) {
+			return item, true
+		}
+	}
+	return ForecastItem{}, false
+}
+
+// Обещает ли прогноз яркий закат: разорванные облака над городом без
+// осадков и чистое небо у горизонта в стороне заката
+func sunsetFavorable(overhead, horizon ForecastItem) bool {
+	return overhead.Clouds >= sunsetMinClouds && overhead.Clouds <= sunsetMaxClouds &&
+		overhead.Pop < sunsetMaxPop && !isWetCondition(overhead.Condition) &&
+		horizon.Clouds <= sunsetHorizonMaxClouds && !isWetCondition(horizon.Condition)
+}
+
+// Сторона света по азимуту: "северо-западе"
+func sunsetSide(azimuth float64) string {
+	switch {
+	case azimuth < 247.5:
+		return "юго-западе"
+	case azimuth < 292.5:
+		return "западе"
+	}
+	return "северо-западе"
+}
+
+// Проверка подписки на закат: за 40 минут до заката смотрим облачность
+// над городом и у горизонта в стороне заката
+func checkSunset(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := cachedForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	now := forecast.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	_, sunset := sunriseSunset(sub.Lat, sub.Lon, day)
+	// В полярный день и полярную ночь заката нет
+	if sunset.Equal(day) || sunset.Equal(day.AddDate(0, 0, 1)) {
+		return "", false, nil
+	}
+	until := sunset.Sub(clockNow())
+	if until < sunsetLeadMin || until >= sunsetLeadMax {
+		return "", false, nil
+	}
+
+	overhead, ok := forecastSlotAt(forecast, sunset)
+	if !ok {
+		return "", false, nil
+	}
+	azimuth := sunsetAzimuth(sub.Lat, day)
+	horizonLat, horizonLon := pointAtBearing(sub.Lat, sub.Lon, azimuth, sunsetHorizonKm)
+	horizonForecast, err := cachedForecastByCoords(horizonLat, horizonLon)
+	if err != nil {
+		return "", false, err
+	}
+	horizon, ok := forecastSlotAt(horizonForecast, sunset)
+	if !ok || !sunsetFavorable(overhead, horizon) {
+		return "", false, nil
+	}
+
+	return formatSunsetAlert(sub.City, sunset.In(now.Location()), azimuth, overhead.Clouds), true, nil
+}
+
+// Текст оповещения о закате
+func formatSunsetAlert(city string, sunset time.Time, azimuth float64, clouds int) string {
+	return fmt.Sprintf(
+		"🌇 Сегодня в %s стоит посмотреть на закат!\n"+
+			"Солнце сядет в %s на %s. Облака (около %d%%) подсветятся снизу, а у горизонта небо чистое.\n"+
+			"Самые яркие краски — в первые 10–20 минут после заката.",
+		city,
+		sunset.Format("15:04"),
+		sunsetSide(azimuth),
+		clouds,
+	)
+}
+
+// Подписка чата на оповещения о красивом закате
+func subscribeSunset(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertSunset,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"🌇 Подписка оформлена! Примерно за 40 минут до заката сообщу, если в %s он обещает быть красивым: "+
+			"разорванные облака над городом и чистый горизонт.\n"+
+			"Отписаться: /sunset off",
+		point.DisplayName(),
+	), nil
+}
+
+// /sunset
+func handleSunsetCommand(c *commandContext) {
+	if strings.TrimSpace(c.args) == "off" {
+		removed, err := c.bot.store.Unsubscribe(c.message.Chat.ID, alertSunset)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Подписка на красивые закаты отменена."
+		default:
+			c.msg.Text = "Вы не подписаны на красивые закаты."
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /sunset Санкт-Петербург"
+		return
+	}
+	reply, err := subscribeSunset(c.bot.store, c.message.Chat.ID, city)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	} else {
+		c.msg.Text = reply
+	}
+}
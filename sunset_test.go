@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSunsetAzimuth(t *testing.T) {
+	tests := []struct {
+		date     time.Time
+		min, max float64
+	}{
+		{time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), 268, 272},  // равноденствие — запад
+		{time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC), 310, 320},  // лето — северо-запад
+		{time.Date(2026, 12, 21, 0, 0, 0, 0, time.UTC), 220, 230}, // зима — юго-запад
+	}
+	for _, tt := range tests {
+		if got := sunsetAzimuth(55.75, tt.date); got < tt.min || got > tt.max {
+			t.Errorf("азимут заката %s = %.1f, ожидалось %.0f–%.0f", tt.date.Format("02.01"), got, tt.min, tt.max)
+		}
+	}
+
+	lat, lon := pointAtBearing(55.75, 37.62, 270, sunsetHorizonKm)
+	if math.Abs(lat-55.75) > 0.01 || math.Abs(haversineKm(55.75, 37.62, lat, lon)-sunsetHorizonKm) > 2 {
+		t.Errorf("точка горизонта %.3f, %.3f", lat, lon)
+	}
+}
+
+func TestSunsetFavorable(t *testing.T) {
+	broken := ForecastItem{Clouds: 50, Condition: 803}
+	clear := ForecastItem{Clouds: 10, Condition: 800}
+	tests := []struct {
+		name              string
+		overhead, horizon ForecastItem
+		want              bool
+	}{
+		{"облака над городом, чистый горизонт", broken, clear, true},
+		{"чистое небо", clear, clear, false},
+		{"сплошная облачность", ForecastItem{Clouds: 95, Condition: 804}, clear, false},
+		{"дождь", ForecastItem{Clouds: 60, Condition: 500, Pop: 0.6}, clear, false},
+		{"горизонт закрыт", broken, ForecastItem{Clouds: 70, Condition: 803}, false},
+	}
+	for _, tt := range tests {
+		if got := sunsetFavorable(tt.overhead, tt.horizon); got != tt.want {
+			t.Errorf("%s: sunsetFavorable = %v", tt.name, got)
+		}
+	}
+}
+
+func TestSunsetAlert(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	start := time.Date(2026, 10, 16, 15, 0, 0, 0, moscow)
+	forecast := &Forecast{Location: moscow, Items: []ForecastItem{
+		{Time: start, Clouds: 20},
+		{Time: start.Add(forecastStep), Clouds: 55},
+	}}
+	sunset := time.Date(2026, 10, 16, 18, 42, 0, 0, moscow)
+	item, ok := forecastSlotAt(forecast, sunset)
+	if !ok || item.Clouds != 55 {
+		t.Fatalf("интервал заката: %+v, %v", item, ok)
+	}
+	if _, ok := forecastSlotAt(forecast, start.Add(2*forecastStep)); ok {
+		t.Error("найден интервал за пределами прогноза")
+	}
+
+	text := formatSunsetAlert("Москва", sunset, sunsetAzimuth(55.75, sunset), item.Clouds)
+	for _, want := range []string{"🌇 Сегодня в Москва", "сядет в 18:42 на западе", "около 55%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в оповещении %q нет %q", text, want)
+		}
+	}
+}
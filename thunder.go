@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Тип подписки на предупреждения о грозах
+const alertThunder = "thunder"
+
+// На сколько часов вперед ищем грозу в прогнозе
+const thunderHorizon = 6 * time.Hour
+
+func init() {
+	// Одна гроза обычно проходит за несколько часов: не повторяем
+	// предупреждение, пока она остается в прогнозе
+	alertKinds[alertThunder] = alertKind{title: "Грозы", check: checkThunder, cooldown: 6 * time.Hour}
+}
+
+// Гроза ли это по коду условий OWM (2xx)
+func isThunderstorm(condition int) bool {
+	return condition/100 == 2
+}
+
+// Заметка под карточкой погоды, когда гроза идет сейчас
+func thunderNote(lang string) string {
+	if lang == langEN {
+		return "⛈ Thunderstorm! Stay away from open ground, lone trees and water."
+	}
+	return "⛈ Гроза! Держитесь подальше от открытых мест, одиноких деревьев и воды."
+}
+
+// Интервалы с грозой в ближайшие thunderHorizon от now
+type thunderPeriod struct {
+	start, end time.Time
+	pop        float64
+	maxGust    float64
+}
+
+func thunderAhead(forecast *Forecast, now time.Time) (thunderPeriod, bool) {
+	var period thunderPeriod
+	found := false
+	for _, item := range forecast.Items {
+		start := forecast.LocalTime(item)
+		end := start.Add(forecastStep)
+		if !end.After(now) || !start.Before(now.Add(thunderHorizon)) || !isThunderstorm(item.Condition) {
+			continue
+		}
+		if !found {
+			period.start = latestTime(start, now)
+			found = true
+		}
+		period.end = end
+		period.pop = math.Max(period.pop, item.Pop)
+		period.maxGust = math.Max(period.maxGust, math.Max(item.WindGust, item.WindSpeed))
+	}
+	return period, found
+}
+
+// Проверка подписки: гроза в прогнозе на ближайшие часы
+func checkThunder(sub *AlertSubscription, prefs UserPreferences) (string, bool, error) {
+	forecast, err := fetchForecastByCoords(sub.Lat, sub.Lon)
+	if err != nil {
+		return "", false, err
+	}
+
+	period, ok := thunderAhead(forecast, forecast.Now())
+	if !ok {
+		return "", false, nil
+	}
+	return formatThunderAlert(sub.City, period), true, nil
+}
+
+func formatThunderAlert(city string, period thunderPeriod) string {
+	text := fmt.Sprintf("⛈ Гроза в %s в ближайшие часы!\nПримерно с %s до %s, вероятность %.0f%%",
+		city,
+		period.start.Format("15:04"),
+		period.end.Format("15:04"),
+		period.pop*100,
+	)
+	if period.maxGust > 0 {
+		text += fmt.Sprintf(", порывы ветра до %.0f м/с", period.maxGust)
+	}
+	return text + ".\nНе оставайтесь на открытом месте, у одиноких деревьев и у воды."
+}
+
+// Подписка чата на предупреждения о грозах
+func subscribeThunder(store *Store, chatID int64, city string) (string, error) {
+	point, err := geocodeCity(city)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.Subscribe(AlertSubscription{
+		ChatID: chatID,
+		Kind:   alertThunder,
+		City:   point.DisplayName(),
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"⛈ Подписка оформлена! Предупрежу, если в %s в ближайшие %.0f часов ожидается гроза.\n"+
+			"Отписаться: /thunder off",
+		point.DisplayName(),
+		thunderHorizon.Hours(),
+	), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThunderAhead(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	forecast := &Forecast{City: "Краснодар", Location: moscow}
+	start := time.Date(2026, 7, 10, 12, 0, 0, 0, moscow)
+	for i := 0; i < 8; i++ {
+		item := ForecastItem{Time: start.Add(time.Duration(i) * forecastStep), Condition: 800}
+		// Гроза с 15 до 21 и еще одна послезавтра, за горизонтом
+		if i == 1 || i == 2 || i == 7 {
+			item.Condition, item.Pop, item.WindGust = 211, 0.4+0.2*float64(i), 14
+		}
+		forecast.Items = append(forecast.Items, item)
+	}
+
+	period, ok := thunderAhead(forecast, start.Add(time.Hour))
+	if !ok {
+		t.Fatal("гроза не найдена")
+	}
+	if !period.start.Equal(start.Add(3*time.Hour)) || !period.end.Equal(start.Add(9*time.Hour)) {
+		t.Errorf("гроза %s–%s, ожидалась 15:00–21:00", period.start, period.end)
+	}
+	text := formatThunderAlert("Краснодар", period)
+	for _, want := range []string{"⛈ Гроза в Краснодар", "с 15:00 до 21:00", "вероятность 80%", "порывы ветра до 14 м/с"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в предупреждении %q нет %q", text, want)
+		}
+	}
+
+	// Гроза уже прошла, а следующая дальше 6 часов
+	if _, ok := thunderAhead(forecast, start.Add(10*time.Hour)); ok {
+		t.Error("найдена гроза за горизонтом")
+	}
+}
+
+func TestForecastThunderHighlight(t *testing.T) {
+	forecast := &Forecast{City: "Сочи", Items: []ForecastItem{
+		{Time: time.Date(2026, 7, 10, 12, 0, 0, 0, time.UTC), Temp: 27, Description: "ясно", Pop: 0.1},
+		{Time: time.Date(2026, 7, 10, 15, 0, 0, 0, time.UTC), Temp: 25, Description: "гроза", Condition: 211, Pop: 0.7},
+	}}
+	prefs := UserPreferences{Units: unitsMetric}
+
+	text, err := renderReply("forecast", prefs, newForecastData(forecast, 40))
+	if err != nil {
+		t.Fatalf("renderReply: %v", err)
+	}
+	if !strings.Contains(text, "⏰ 15:00: 25°C, гроза, ⛈ вероятность 70%") || strings.Count(text, "⛈") != 1 {
+		t.Errorf("прогноз %q", text)
+	}
+
+	prefs.Format = formatCompact
+	if text, _ := renderReply("forecast", prefs, newForecastData(forecast, 40)); !strings.Contains(text, ", ⛈ 70%") {
+		t.Errorf("краткий прогноз %q", text)
+	}
+}
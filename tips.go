@@ -0,0 +1,114 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Советы и факты о погоде в утренней сводке. Набор лежит в tips/tips.txt
+// и встраивается в бинарник; совет выбирается по сезону в точке подписки
+// и меняется каждый день. Отключается флагом digesttips или в /settings
+
+// Сезоны набора советов
+const (
+	seasonWinter = "зима"
+	seasonSpring = "весна"
+	seasonSummer = "лето"
+	seasonAutumn = "осень"
+	// Подходит в любое время года
+	seasonAny = "всегда"
+)
+
+//go:embed tips/tips.txt
+var digestTipsFile string
+
+// Совет или факт о погоде
+type digestTip struct {
+	Season string
+	Text   string
+}
+
+var digestTips = mustParseDigestTips(digestTipsFile)
+
+// Разбор набора: строки "<сезон>|<текст>", пустые строки и строки с #
+// пропускаются
+func parseDigestTips(data string) ([]digestTip, error) {
+	var tips []digestTip
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		season, text, found := strings.Cut(line, "|")
+		season, text = strings.TrimSpace(season), strings.TrimSpace(text)
+		if !found || text == "" {
+			return nil, fmt.Errorf("строка %d: ожидается сезон|текст", i+1)
+		}
+		switch season {
+		case seasonWinter, seasonSpring, seasonSummer, seasonAutumn, seasonAny:
+		default:
+			return nil, fmt.Errorf("строка %d: неизвестный сезон %q", i+1, season)
+		}
+		tips = append(tips, digestTip{Season: season, Text: text})
+	}
+	return tips, nil
+}
+
+func mustParseDigestTips(data string) []digestTip {
+	tips, err := parseDigestTips(data)
+	if err != nil {
+		panic(fmt.Sprintf("ошибка разбора советов для сводки: %v", err))
+	}
+	return tips
+}
+
+// Сезон по месяцу; в южном полушарии времена года обратные
+func seasonAt(date time.Time, lat float64) string {
+	month := int(date.Month())
+	if lat < 0 {
+		month = (month+5)%12 + 1
+	}
+	switch month {
+	case 12, 1, 2:
+		return seasonWinter
+	case 3, 4, 5:
+		return seasonSpring
+	case 6, 7, 8:
+		return seasonSummer
+	}
+	return seasonAutumn
+}
+
+// Совет на день: из подходящих по сезону, у разных чатов в один день разные
+func pickDigestTip(tips []digestTip, chatID int64, lat float64, date time.Time) (digestTip, bool) {
+	season := seasonAt(date, lat)
+	var matching []digestTip
+	for _, tip := range tips {
+		if tip.Season == season || tip.Season == seasonAny {
+			matching = append(matching, tip)
+		}
+	}
+	if len(matching) == 0 {
+		return digestTip{}, false
+	}
+	n := int64(len(matching))
+	index := (int64(date.YearDay()) + chatID%n) % n
+	if index < 0 {
+		index += n
+	}
+	return matching[index], true
+}
+
+// Совет для утренней сводки (пустая строка, если советы отключены)
+func digestTipText(sub *AlertSubscription, prefs UserPreferences, date time.Time) string {
+	if !featureEnabled(featureDigestTips, sub.ChatID) || prefs.NoDigestTips {
+		return ""
+	}
+	tip, ok := pickDigestTip(digestTips, sub.ChatID, sub.Lat, date)
+	if !ok {
+		return ""
+	}
+	return "💡 " + tip.Text
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestTipsDataset(t *testing.T) {
+	seasons := make(map[string]int)
+	for _, tip := range digestTips {
+		seasons[tip.Season]++
+	}
+	for _, season := range []string{seasonWinter, seasonSpring, seasonSummer, seasonAutumn, seasonAny} {
+		if seasons[season] == 0 {
+			t.Errorf("нет советов для сезона %q", season)
+		}
+	}
+
+	if _, err := parseDigestTips("# комментарий\n\nлето|Жарко\n"); err != nil {
+		t.Errorf("parseDigestTips: %v", err)
+	}
+	for _, data := range []string{"лето", "лето|", "межсезонье|Слякоть"} {
+		if _, err := parseDigestTips(data); err == nil {
+			t.Errorf("parseDigestTips(%q): ожидалась ошибка", data)
+		}
+	}
+}
+
+func TestPickDigestTip(t *testing.T) {
+	tips := []digestTip{
+		{Season: seasonWinter, Text: "зима 1"},
+		{Season: seasonWinter, Text: "зима 2"},
+		{Season: seasonSummer, Text: "лето"},
+		{Season: seasonAny, Text: "всегда"},
+	}
+	january := time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)
+
+	// В январе на севере — зимние советы, на юге — летние
+	seen := make(map[string]bool)
+	for day := 0; day < 3; day++ {
+		tip, _ := pickDigestTip(tips, 42, 55.75, january.AddDate(0, 0, day))
+		if tip.Season == seasonSummer {
+			t.Errorf("летний совет зимой: %+v", tip)
+		}
+		seen[tip.Text] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("советы не меняются по дням: %v", seen)
+	}
+	for day := 0; day < 3; day++ {
+		if tip, _ := pickDigestTip(tips, -100, -33.9, january.AddDate(0, 0, day)); tip.Season == seasonWinter {
+			t.Errorf("зимний совет летом в южном полушарии: %+v", tip)
+		}
+	}
+
+	if _, ok := pickDigestTip(tips[:2], 1, 55.75, january.AddDate(0, 6, 0)); ok {
+		t.Error("совет без подходящих по сезону")
+	}
+}
+
+func TestDailyDigestTipOptOut(t *testing.T) {
+	useMockReports(t)
+	// Долгота 37.6 — в демо-режиме это UTC+3, 7:30 по местному времени
+	useManualClock(t, time.Date(2026, 10, 16, 4, 30, 0, 0, time.UTC))
+	sub := &AlertSubscription{ChatID: 4381, Kind: alertDaily, City: "Москва", Lat: 55.75, Lon: 37.62}
+
+	digest, ok, err := checkDaily(sub, store.Preferences(sub.ChatID))
+	if err != nil || !ok {
+		t.Fatalf("checkDaily = %v, %v", ok, err)
+	}
+	if !strings.Contains(digest, "\n\n💡 ") {
+		t.Errorf("в сводке нет совета: %q", digest)
+	}
+
+	if err := store.UpdatePreferences(sub.ChatID, func(prefs *UserPreferences) { prefs.NoDigestTips = true }); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+	if digest, _, _ := checkDaily(sub, store.Preferences(sub.ChatID)); strings.Contains(digest, "💡") {
+		t.Errorf("совет после отключения: %q", digest)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Голосовые ответы: сводка погоды озвучивается через API синтеза речи,
+// совместимый с OpenAI (TTS_API_KEY, TTS_API_URL), и приходит голосовым
+// сообщением. Можно попросить разово (/voice Москва) или включить для
+// всех ответов с погодой (/voice on)
+
+// Адрес синтеза речи по умолчанию
+const defaultTTSURL = "https://api.openai.com/v1/audio/speech"
+
+// Длиннее сводка не бывает; ограничение защищает от лишних трат на синтез
+const maxSpeechLength = 1000
+
+// Больше голосовое сообщение с погодой не весит
+const maxSpeechBytes = 5 << 20
+
+var ttsClient = &http.Client{Timeout: 30 * time.Second}
+
+// Запрос синтеза речи
+type speechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+func ttsConfigured() bool {
+	return config().TTSAPIKey != ""
+}
+
+// Синтез речи в формате Opus: Telegram показывает такие файлы как голосовые
+func synthesizeSpeech(text string) ([]byte, error) {
+	if !ttsConfigured() {
+		return nil, fmt.Errorf("голосовые ответы не настроены")
+	}
+	if runes := []rune(text); len(runes) > maxSpeechLength {
+		text = string(runes[:maxSpeechLength])
+	}
+
+	payload, err := json.Marshal(speechRequest{
+		Model:          config().TTSModel,
+		Input:          text,
+		Voice:          config().TTSVoice,
+		ResponseFormat: "opus",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подготовки запроса: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, config().TTSAPIURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подготовки запроса: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+config().TTSAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ttsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса синтеза речи: %v", redactError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("сервис синтеза речи ответил статусом %d", resp.StatusCode)
+	}
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxSpeechBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки аудио: %v", err)
+	}
+	if len(audio) == 0 || len(audio) > maxSpeechBytes {
+		return nil, fmt.Errorf("сервис синтеза речи вернул аудио размером %d байт", len(audio))
+	}
+	return audio, nil
+}
+
+// Текст для озвучивания: карточка простыми словами без эмодзи и с
+// единицами словами, чтобы голос не читал "м/с" по буквам
+func speechText(data *CurrentWeather, prefs UserPreferences) (string, error) {
+	prefs.Format = formatSimple
+	card, err := renderReply("weather", prefs, newWeatherCardData(data, prefs))
+	if err != nil {
+		return "", err
+	}
+	return screenReaderText(card, prefs.Language), nil
+}
+
+// Отправка голосового сообщения с озвученным текстом
+func sendVoiceReply(bot messageSender, chatID int64, text string) error {
+	audio, err := synthesizeSpeech(text)
+	if err != nil {
+		return err
+	}
+	voice := tgbotapi.NewVoice(chatID, tgbotapi.FileBytes{Name: "weather.ogg", Bytes: audio})
+	_, err = bot.Send(voice)
+	return err
+}
+
+// /voice [город|on|off] — погода голосовым сообщением
+func handleVoiceCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	if !ttsConfigured() {
+		c.msg.Text = "🔇 Голосовые ответы в этом боте не настроены."
+		return
+	}
+
+	switch strings.ToLower(c.args) {
+	case "on", "off":
+		// В группе озвучку для всех включает только администратор
+		if !canManageChatMessage(c.bot, c.message) {
+			c.msg.Text = groupAdminOnlyText
+			return
+		}
+		on := strings.ToLower(c.args) == "on"
+		if err := c.bot.store.UpdatePreferences(chatID, func(prefs *UserPreferences) { prefs.VoiceReplies = on }); err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		if on {
+			c.msg.Text = "🔊 Теперь к каждому ответу с погодой в городе пришлю голосовое сообщение. Выключить: /voice off"
+		} else {
+			c.msg.Text = "🔇 Голосовые ответы выключены. Разово: /voice [город]"
+		}
+		return
+	}
+
+	city, ok := commandCity(c.bot, c.message)
+	if !ok {
+		c.msg.Text = "Укажите город, например: /voice Москва. Озвучивать все ответы: /voice on"
+		return
+	}
+	prefs := c.bot.store.Preferences(chatID)
+	data, err := cachedWeather(city, prefs.Language)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	text, err := speechText(data, prefs)
+	if err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+		return
+	}
+	if err := sendVoiceReply(c.bot, chatID, text); err != nil {
+		c.msg.Text = "❌ Ошибка: " + err.Error()
+	}
+}
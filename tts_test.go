@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Поддельный сервис синтеза речи. Возвращает запросы, которые он получил
+func fakeTTS(t *testing.T) *[]speechRequest {
+	t.Helper()
+	var requests []speechRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tts-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req speechRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		w.Write([]byte("OggS-voice"))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config()
+	c := *previous
+	c.TTSAPIKey = "tts-key"
+	c.TTSAPIURL = server.URL
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+	return &requests
+}
+
+func TestVoiceCommand(t *testing.T) {
+	f := useMockReports(t)
+	requests := fakeTTS(t)
+
+	f.send(textUpdate(4431, "/voice Москва"))
+	if voices := f.sent("sendVoice"); len(voices) != 1 || voices[0].Params["chat_id"] != "4431" {
+		t.Fatalf("голосовые сообщения: %+v", voices)
+	}
+	if messages := f.sent("sendMessage"); len(messages) != 0 {
+		t.Errorf("лишний текстовый ответ: %+v", messages)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("запросов синтеза %d", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.ResponseFormat != "opus" || req.Model != "tts-1" || !strings.HasPrefix(req.Input, "Погода в Москва") ||
+		strings.Contains(req.Input, "°C") || strings.Contains(req.Input, "🌤") {
+		t.Errorf("запрос синтеза: %+v", req)
+	}
+
+	// С /voice on к ответу с погодой добавляется голосовое
+	f.reset()
+	f.send(textUpdate(4431, "/voice on"))
+	if reply := f.reply(t, 4431); !strings.Contains(reply, "пришлю голосовое") {
+		t.Errorf("ответ /voice on: %q", reply)
+	}
+	f.reset()
+	f.send(textUpdate(4431, "Казань"))
+	if len(f.sent("sendMessage")) != 1 || len(f.sent("sendVoice")) != 1 {
+		t.Errorf("ответ с озвучкой: %+v", f.calls)
+	}
+
+	f.reset()
+	f.send(textUpdate(4431, "/voice off"))
+	f.send(textUpdate(4431, "Казань"))
+	if voices := f.sent("sendVoice"); len(voices) != 0 {
+		t.Errorf("голосовое после /voice off: %+v", voices)
+	}
+}
+
+func TestVoiceNotConfigured(t *testing.T) {
+	f := useMockReports(t)
+	f.send(textUpdate(4432, "/voice Москва"))
+	if reply := f.reply(t, 4432); !strings.Contains(reply, "не настроены") {
+		t.Errorf("ответ без TTS_API_KEY: %q", reply)
+	}
+}
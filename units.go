@@ -0,0 +1,110 @@
+package main
+
+import "fmt"
+
+// Температура в выбранной системе единиц (данные OWM всегда в °C)
+func formatTemp(celsius float64, units string) string {
+	if units == unitsImperial {
+		return fmt.Sprintf("%.0f°F", celsius*9/5+32)
+	}
+	return fmt.Sprintf("%.0f°C", celsius)
+}
+
+// Разница температур в выбранной системе единиц
+func formatTempDelta(celsius float64, units string) string {
+	if units == unitsImperial {
+		return fmt.Sprintf("%.0f°F", celsius*9/5)
+	}
+	return fmt.Sprintf("%.0f°C", celsius)
+}
+
+// Единицы ветра: выбранные пользователем или по системе единиц
+func windUnitFor(prefs UserPreferences) string {
+	if _, ok := windTitles[prefs.WindUnit]; ok {
+		return prefs.WindUnit
+	}
+	if prefs.Units == unitsImperial {
+		return windMPH
+	}
+	return windMS
+}
+
+// Множители для перевода из м/с и подписи единиц ветра по языкам
+var (
+	windFactors = map[string]float64{windMS: 1, windKMH: 3.6, windMPH: 2.23694, windKnots: 1.94384}
+	windLabels  = map[string]map[string]string{
+		langRU: {windMS: "м/с", windKMH: "км/ч", windMPH: "mph", windKnots: "уз"},
+		langEN: {windMS: "m/s", windKMH: "km/h", windMPH: "mph", windKnots: "kn"},
+	}
+)
+
+// Скорость ветра в выбранных единицах (данные OWM всегда в м/с)
+func formatWindSpeed(ms float64, unit, lang string) string {
+	factor, ok := windFactors[unit]
+	if !ok {
+		unit, factor = windMS, 1
+	}
+	labels, ok := windLabels[lang]
+	if !ok {
+		labels = windLabels[langRU]
+	}
+	return fmt.Sprintf("%.0f %s", ms*factor, labels[unit])
+}
+
+// Давление в гПа, для русского языка еще и в миллиметрах ртутного столба
+func formatPressure(hpa float64, lang string) string {
+	if lang == langEN {
+		return fmt.Sprintf("%.0f hPa", hpa)
+	}
+	return fmt.Sprintf("%.0f гПа (%.0f мм рт. ст.)", hpa, hpa*0.750062)
+}
+
+// Направление, откуда дует ветер, по восьми румбам
+var windDirections = map[string][]string{
+	langRU: {"С", "СВ", "В", "ЮВ", "Ю", "ЮЗ", "З", "СЗ"},
+	langEN: {"N", "NE", "E", "SE", "S", "SW", "W", "NW"},
+}
+
+func windDirection(deg int, lang string) string {
+	names, ok := windDirections[lang]
+	if !ok {
+		names = windDirections[langRU]
+	}
+	return names[((deg%360+360)%360+22)/45%8]
+}
+
+// Верхние границы баллов шкалы Бофорта в м/с: ветер слабее beaufortLimits[i]
+// имеет силу i баллов, сильнее последней границы — 12 баллов (ураган)
+var beaufortLimits = []float64{0.3, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+var beaufortNames = map[string][]string{
+	langRU: {
+		"штиль", "тихий ветер", "легкий ветер", "слабый ветер", "умеренный ветер",
+		"свежий ветер", "сильный ветер", "крепкий ветер", "очень крепкий ветер",
+		"шторм", "сильный шторм", "жестокий шторм", "ураган",
+	},
+	langEN: {
+		"calm", "light air", "light breeze", "gentle breeze", "moderate breeze",
+		"fresh breeze", "strong breeze", "near gale", "gale",
+		"strong gale", "storm", "violent storm", "hurricane",
+	},
+}
+
+// Сила ветра в баллах по шкале Бофорта
+func beaufortScale(ms float64) int {
+	for force, limit := range beaufortLimits {
+		if ms < limit {
+			return force
+		}
+	}
+	return len(beaufortLimits)
+}
+
+// Словесное описание ветра по шкале Бофорта, например "свежий ветер"
+func beaufortDescription(ms float64, lang string) string {
+	names, ok := beaufortNames[lang]
+	if !ok {
+		names = beaufortNames[langRU]
+	}
+	return names[beaufortScale(ms)]
+}
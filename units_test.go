@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatWindSpeed(t *testing.T) {
+	tests := []struct {
+		unit, lang, want string
+	}{
+		{windMS, langRU, "10 м/с"},
+		{windKMH, langRU, "36 км/ч"},
+		{windMPH, langEN, "22 mph"},
+		{windKnots, langRU, "19 уз"},
+		{windKnots, langEN, "19 kn"},
+		{"", langRU, "10 м/с"},
+	}
+	for _, tt := range tests {
+		if got := formatWindSpeed(10, tt.unit, tt.lang); got != tt.want {
+			t.Errorf("formatWindSpeed(10, %q, %q) = %q, ожидалось %q", tt.unit, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestWindUnitFor(t *testing.T) {
+	tests := []struct {
+		prefs UserPreferences
+		want  string
+	}{
+		{UserPreferences{Units: unitsMetric}, windMS},
+		{UserPreferences{Units: unitsImperial}, windMPH},
+		{UserPreferences{Units: unitsImperial, WindUnit: windKnots}, windKnots},
+		{UserPreferences{Units: unitsMetric, WindUnit: "bogus"}, windMS},
+	}
+	for _, tt := range tests {
+		if got := windUnitFor(tt.prefs); got != tt.want {
+			t.Errorf("windUnitFor(%+v) = %q, ожидалось %q", tt.prefs, got, tt.want)
+		}
+	}
+}
+
+func TestBeaufort(t *testing.T) {
+	tests := []struct {
+		ms    float64
+		force int
+		ru    string
+	}{
+		{0, 0, "штиль"},
+		{1.5, 1, "тихий ветер"},
+		{9, 5, "свежий ветер"},
+		{17.2, 8, "очень крепкий ветер"},
+		{40, 12, "ураган"},
+	}
+	for _, tt := range tests {
+		if got := beaufortScale(tt.ms); got != tt.force {
+			t.Errorf("beaufortScale(%v) = %d, ожидалось %d", tt.ms, got, tt.force)
+		}
+		if got := beaufortDescription(tt.ms, langRU); got != tt.ru {
+			t.Errorf("beaufortDescription(%v) = %q, ожидалось %q", tt.ms, got, tt.ru)
+		}
+	}
+	if got := beaufortDescription(9, langEN); got != "fresh breeze" {
+		t.Errorf("beaufortDescription(9, en) = %q", got)
+	}
+}
+
+func TestWeatherCardWind(t *testing.T) {
+	data := &CurrentWeather{City: "Тула", Temp: 10, FeelsLike: 8, Humidity: 60, WindSpeed: 9, Description: "облачно"}
+	prefs := UserPreferences{Units: unitsMetric, WindUnit: windKMH, Language: langRU, Format: formatPlain}
+
+	text, err := renderReply("weather", prefs, newWeatherCardData(data, prefs))
+	if err != nil {
+		t.Fatalf("renderReply: %v", err)
+	}
+	if !strings.Contains(text, "Ветер: 32 км/ч, свежий ветер") {
+		t.Errorf("в карточке нет ветра в км/ч с описанием:\n%s", text)
+	}
+}
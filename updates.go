@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько помним обработанные обновления. Повторы приходят сразу после
+// перезапуска, поэтому короткого окна хватает, а файл не растет
+const handledUpdatesWindow = time.Hour
+
+// Как часто журнал обработанных обновлений сбрасывается на диск. После
+// падения могут повториться обновления только за этот промежуток
+const updateLogFlushInterval = 5 * time.Second
+
+// Журнал обработанных обновлений: последнее обработанное и недавние для
+// защиты от повторов. Он меняется на каждое обновление, поэтому живет в
+// памяти, а на диск пишется в отдельный небольшой файл по таймеру и при
+// остановке, не трогая основной файл состояния
+type UpdateLog struct {
+	path  string
+	data  updateLogData
+	dirty bool
+	mu    sync.Mutex
+}
+
+type updateLogData struct {
+	LastUpdateID int               `json:"last_update_id"`
+	Handled      map[int]time.Time `json:"handled"`
+}
+
+// Файл журнала рядом с файлом состояния: bot_state.updates.json
+func updateLogPath(statePath string) string {
+	return strings.TrimSuffix(statePath, ".json") + ".updates.json"
+}
+
+// Открытие журнала: если файла еще нет, начинаем с пустого
+func openUpdateLog(path string) (*UpdateLog, error) {
+	l := &UpdateLog{path: path, data: updateLogData{Handled: make(map[int]time.Time)}}
+
+	raw, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return l, nil
+	case err != nil:
+		return nil, fmt.Errorf("ошибка чтения журнала обновлений: %v", err)
+	}
+	if err := json.Unmarshal(raw, &l.data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга журнала обновлений: %v", err)
+	}
+	if l.data.Handled == nil {
+		l.data.Handled = make(map[int]time.Time)
+	}
+	return l, nil
+}
+
+// Последнее обработанное обновление (0, если бот еще ничего не обрабатывал)
+func (l *UpdateLog) LastUpdateID() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.data.LastUpdateID
+}
+
+func (l *UpdateLog) Handled(updateID int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, handled := l.data.Handled[updateID]
+	return handled
+}
+
+// Отметка об обработке обновления. Старые отметки удаляются
+func (l *UpdateLog) Mark(updateID int, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, at := range l.data.Handled {
+		if now.Sub(at) > handledUpdatesWindow {
+			delete(l.data.Handled, id)
+		}
+	}
+	l.data.Handled[updateID] = now
+	if updateID > l.data.LastUpdateID {
+		l.data.LastUpdateID = updateID
+	}
+	l.dirty = true
+}
+
+// Запись журнала на диск, если он изменился с прошлой записи
+func (l *UpdateLog) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.dirty || l.path == "" {
+		return nil
+	}
+	raw, err := json.Marshal(l.data)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации журнала обновлений: %v", err)
+	}
+	if err := writeFileAtomic(l.path, raw); err != nil {
+		return fmt.Errorf("ошибка записи журнала обновлений: %v", err)
+	}
+	l.dirty = false
+	return nil
+}
+
+// Фоновая запись журналов обновлений всех ботов
+func runUpdateLogFlusher() {
+	ticker := time.NewTicker(updateLogFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, bot := range bots {
+			if err := bot.updateLog.Flush(); err != nil {
+				log.Printf("Ошибка сохранения состояния: %v", err)
+			}
+		}
+	}
+}
+
+// Смещение для первого запроса обновлений после запуска: продолжаем
+// с обновления, следующего за последним обработанным
+func (b *Bot) startUpdateOffset() int {
+	if last := b.updateLog.LastUpdateID(); last > 0 {
+		return last + 1
+	}
+	return 0
+}
+
+// Повторно присланные обновления (например, после падения до подтверждения
+// смещения) пропускаем. Отметка ставится после обработки, поэтому
+// прерванное сбоем обновление после перезапуска будет обработано
+func withDedupe(next updateHandler) updateHandler {
+	return func(bot *Bot, update tgbotapi.Update) {
+		if bot.updateLog.Handled(update.UpdateID) {
+			log.Printf("Обновление %d уже обработано, пропускаем", update.UpdateID)
+			return
+		}
+
+		defer bot.updateLog.Mark(update.UpdateID, time.Now())
+		next(bot, update)
+	}
+}
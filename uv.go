@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Текущий УФ-индекс от Open-Meteo. В ответе OWM 2.5 его нет
+type uvResponse struct {
+	Current struct {
+		UVIndex *float64 `json:"uv_index"`
+	} `json:"current"`
+}
+
+func fetchUVIndex(lat, lon float64) (float64, error) {
+	reqURL := fmt.Sprintf(
+		"https://api.open-meteo.com/v1/forecast?latitude=%.4f&longitude=%.4f&current=uv_index",
+		lat,
+		lon,
+	)
+
+	resp, err := openMeteoClient.Get(reqURL)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("ошибка получения УФ-индекса: статус %d", resp.StatusCode)
+	}
+
+	var data uvResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, fmt.Errorf("ошибка парсинга УФ-индекса: %v", err)
+	}
+	if data.Current.UVIndex == nil {
+		return 0, fmt.Errorf("нет данных об УФ-индексе")
+	}
+	return *data.Current.UVIndex, nil
+}
+
+// Уровни УФ-индекса по шкале ВОЗ
+var uvLevels = map[string][]string{
+	langRU: {"низкий", "умеренный", "высокий", "очень высокий", "экстремальный"},
+	langEN: {"low", "moderate", "high", "very high", "extreme"},
+}
+
+// УФ-индекс с уровнем, например "6, высокий"
+func formatUVIndex(uv float64, lang string) string {
+	levels, ok := uvLevels[lang]
+	if !ok {
+		levels = uvLevels[langRU]
+	}
+
+	level := 0
+	switch {
+	case uv >= 11:
+		level = 4
+	case uv >= 8:
+		level = 3
+	case uv >= 6:
+		level = 2
+	case uv >= 3:
+		level = 1
+	}
+	return fmt.Sprintf("%.0f, %s", uv, levels[level])
+}
+
+// УФ-индекс для подробной карточки (пустой при ошибке и в демо-режиме)
+func uvIndexLine(data *CurrentWeather, lang string) string {
+	if mockWeatherMode() {
+		return ""
+	}
+
+	uv, err := fetchUVIndex(data.Lat, data.Lon)
+	if err != nil {
+		log.Printf("Ошибка получения УФ-индекса: %v", err)
+		return ""
+	}
+	return formatUVIndex(uv, lang)
+}
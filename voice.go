@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Адрес распознавания речи по умолчанию (совместим с OpenAI Whisper API)
+const defaultSTTURL = "https://api.openai.com/v1/audio/transcriptions"
+
+// Длинные голосовые не распознаем: для запроса погоды хватит и нескольких секунд
+const maxVoiceDuration = 60
+
+var sttClient = &http.Client{Timeout: 30 * time.Second}
+
+// Ответ API распознавания речи
+type transcriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// Распознавание голосового сообщения через Whisper-совместимый API (STT_API_KEY, STT_API_URL)
+func transcribeVoice(bot *Bot, voice *tgbotapi.Voice) (string, error) {
+	apiKey := config().STTAPIKey
+	if apiKey == "" {
+		return "", fmt.Errorf("распознавание голосовых сообщений не настроено, напишите город текстом")
+	}
+	if voice.Duration > maxVoiceDuration {
+		return "", fmt.Errorf("голосовое сообщение слишком длинное, уложитесь в %d секунд", maxVoiceDuration)
+	}
+
+	fileURL, err := bot.GetFileDirectURL(voice.FileID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения файла: %v", redactError(err))
+	}
+
+	audio, err := telegramClient.Get(fileURL)
+	if err != nil {
+		return "", fmt.Errorf("ошибка загрузки файла: %v", redactError(err))
+	}
+	defer audio.Body.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "voice.ogg")
+	if err != nil {
+		return "", fmt.Errorf("ошибка подготовки запроса: %v", err)
+	}
+	if _, err := io.Copy(part, audio.Body); err != nil {
+		return "", fmt.Errorf("ошибка загрузки файла: %v", redactError(err))
+	}
+	form.WriteField("model", config().STTModel)
+	form.WriteField("language", "ru")
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("ошибка подготовки запроса: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config().STTAPIURL, &body)
+	if err != nil {
+		return "", fmt.Errorf("ошибка подготовки запроса: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := sttClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка запроса распознавания: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("сервис распознавания ответил статусом %d", resp.StatusCode)
+	}
+
+	var data transcriptionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("ошибка парсинга данных: %v", err)
+	}
+
+	// Whisper добавляет точку в конце фразы, а город с точкой не найдется
+	text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(data.Text), ".!"))
+	if text == "" {
+		return "", fmt.Errorf("не удалось разобрать речь, попробуйте еще раз")
+	}
+
+	return text, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Прогрев кэша перед рассылками: утренние сводки тысяч подписчиков
+// уходят в одну и ту же проверку, и без прогрева каждая сводка — это два
+// запроса к OWM в одну секунду. Незадолго до начала рассылки погода
+// для всех точек подписок запрашивается заранее, по одной точке с паузой
+
+// Как часто проверяем, не пора ли прогревать
+const cacheWarmInterval = 10 * time.Minute
+
+// За сколько до начала рассылки прогреваем кэш
+const cacheWarmLead = 20 * time.Minute
+
+// Пауза между точками при прогреве, чтобы не упереться в лимит OWM
+const cacheWarmDelay = 250 * time.Millisecond
+
+// Сколько сводки берут данные из кэша по координатам. Рассылка начинается
+// в ближайшую проверку оповещений после начала окна (до 30 минут),
+// поэтому срок больше, чем обычный CACHE_TTL
+const coordsCacheTTL = time.Hour
+
+// Кэш погоды и прогнозов по координатам для сводок и публикаций
+type CoordsCache struct {
+	data map[string]CoordsCacheItem
+	mu   sync.RWMutex
+}
+
+type CoordsCacheItem struct {
+	weather     *CurrentWeather
+	weatherTime time.Time
+	forecast    *Forecast
+	// Прогноз остается в кэше и после срока: по нему прогрев знает
+	// часовой пояс точки
+	forecastTime time.Time
+}
+
+var coordsCache = &CoordsCache{
+	data: make(map[string]CoordsCacheItem),
+}
+
+// Ключ точки: ячейка геохеша, как и у кэша погоды по координатам
+func coordsKey(lat, lon float64) string {
+	return geohash(lat, lon, geohashCachePrecision)
+}
+
+func (c *CoordsCache) item(lat, lon float64) CoordsCacheItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.data[coordsKey(lat, lon)]
+}
+
+func (c *CoordsCache) update(lat, lon float64, change func(item *CoordsCacheItem)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := coordsKey(lat, lon)
+	item := c.data[key]
+	change(&item)
+	c.data[key] = item
+}
+
+// Часовой пояс точки по последнему прогнозу, даже устаревшему
+func (c *CoordsCache) Location(lat, lon float64) (*time.Location, bool) {
+	item := c.item(lat, lon)
+	if item.forecast == nil {
+		return nil, false
+	}
+	return item.forecast.Location, true
+}
+
+// Есть ли в кэше свежие погода и прогноз для точки
+func (c *CoordsCache) Fresh(lat, lon float64, now time.Time) bool {
+	item := c.item(lat, lon)
+	return item.weather != nil && now.Sub(item.weatherTime) <= coordsCacheTTL &&
+		item.forecast != nil && now.Sub(item.forecastTime) <= coordsCacheTTL
+}
+
+// Текущая погода по координатам из кэша или от источника
+func cachedWeatherByCoords(lat, lon float64) (*CurrentWeather, error) {
+	item := coordsCache.item(lat, lon)
+	if item.weather != nil && clockNow().Sub(item.weatherTime) <= coordsCacheTTL {
+		return item.weather, nil
+	}
+
+	data, err := fetchWeatherByCoords(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	coordsCache.update(lat, lon, func(item *CoordsCacheItem) {
+		item.weather, item.weatherTime = data, clockNow()
+	})
+	return data, nil
+}
+
+// Прогнозы по координатам с описаниями на других языках. Сводки и
+// публикации идут на русском, поэтому эти кэши не прогреваются
+var localizedCoordsCaches = map[string]*CoordsCache{
+	langEN: {data: make(map[string]CoordsCacheItem)},
+}
+
+// Прогноз по координатам из кэша или от источника
+func cachedForecastByCoords(lat, lon float64) (*Forecast, error) {
+	return coordsCache.forecast(lat, lon, langRU)
+}
+
+// Прогноз по координатам с описаниями на языке пользователя
+func cachedForecastByCoordsLang(lat, lon float64, lang string) (*Forecast, error) {
+	if cache, ok := localizedCoordsCaches[lang]; ok {
+		return cache.forecast(lat, lon, lang)
+	}
+	return cachedForecastByCoords(lat, lon)
+}
+
+func (c *CoordsCache) forecast(lat, lon float64, lang string) (*Forecast, error) {
+	item := c.item(lat, lon)
+	if item.forecast != nil && clockNow().Sub(item.forecastTime) <= coordsCacheTTL {
+		return item.forecast, nil
+	}
+
+	forecast, err := fetchForecastByCoordsLang(lat, lon, lang)
+	if err != nil {
+		return nil, err
+	}
+	c.update(lat, lon, func(item *CoordsCacheItem) {
+		item.forecast, item.forecastTime = forecast, clockNow()
+	})
+	return forecast, nil
+}
+
+// Точка, которую нужно прогреть
+type warmTarget struct {
+	lat, lon float64
+}
+
+// Начинается ли рассылка в hour:minute местного времени в ближайшие cacheWarmLead
+func startsSoon(now time.Time, location *time.Location, hour, minute int) bool {
+	local := now.In(location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, location)
+	if start.Before(local) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start.Sub(local) <= cacheWarmLead
+}
+
+// Точки утренних сводок и публикаций в группах, рассылка для которых
+// скоро начнется. Точки, часовой пояс которых еще не известен, тоже
+// попадают в список: после первого прогноза он станет известен
+func warmTargets(now time.Time) []warmTarget {
+	seen := make(map[string]bool)
+	var targets []warmTarget
+	add := func(lat, lon float64, hour, minute int) {
+		key := coordsKey(lat, lon)
+		if seen[key] {
+			return
+		}
+		if location, ok := coordsCache.Location(lat, lon); ok && !startsSoon(now, location, hour, minute) {
+			return
+		}
+		seen[key] = true
+		targets = append(targets, warmTarget{lat: lat, lon: lon})
+	}
+
+	// Кэш общий, поэтому прогреваем рассылки всех ботов
+	for _, bot := range bots {
+		for _, sub := range bot.store.Subscriptions() {
+			if sub.Kind != alertDaily {
+				continue
+			}
+			// День недели здесь не важен: лишний прогрев в день с другим
+			// временем сводки обходится одним запросом
+			for _, day := range []time.Weekday{time.Monday, time.Saturday} {
+				from, _ := digestWindow(&sub, day)
+				add(sub.Lat, sub.Lon, from/60, from%60)
+			}
+		}
+		for _, post := range bot.store.GroupPosts() {
+			add(post.Lat, post.Lon, post.Hour, post.Minute)
+		}
+	}
+	return targets
+}
+
+// Прогрев кэша для скорых рассылок. Возвращает число запрошенных точек
+func warmCache(now time.Time) int {
+	warmed := 0
+	for _, target := range warmTargets(now) {
+		// Данные должны дожить до проверки, которая отправит сводку
+		if coordsCache.Fresh(target.lat, target.lon, now.Add(cacheWarmLead+alertCheckInterval)) {
+			continue
+		}
+		if warmed > 0 {
+			time.Sleep(cacheWarmDelay)
+		}
+		warmed++
+
+		if err := refreshCoords(target.lat, target.lon); err != nil {
+			log.Printf("Ошибка прогрева кэша (%.2f, %.2f): %v", target.lat, target.lon, err)
+		}
+	}
+	return warmed
+}
+
+// Новые погода и прогноз для точки, даже если в кэше они еще не устарели
+func refreshCoords(lat, lon float64) error {
+	forecast, err := fetchForecastByCoords(lat, lon)
+	if err != nil {
+		return err
+	}
+	data, err := fetchWeatherByCoords(lat, lon)
+	if err != nil {
+		return err
+	}
+
+	coordsCache.update(lat, lon, func(item *CoordsCacheItem) {
+		now := clockNow()
+		item.forecast, item.forecastTime = forecast, now
+		item.weather, item.weatherTime = data, now
+	})
+	return nil
+}
+
+// Фоновый прогрев кэша перед рассылками
+func runCacheWarmer() {
+	ticker := time.NewTicker(cacheWarmInterval)
+	defer ticker.Stop()
+
+	for {
+		if warmed := warmCache(clockNow()); warmed > 0 {
+			log.Printf("Кэш прогрет перед рассылкой: точек %d", warmed)
+		}
+		<-ticker.C
+	}
+}
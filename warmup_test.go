@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartsSoon(t *testing.T) {
+	location := time.FixedZone("", 3*3600)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 16, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(6, 30), false},
+		{at(6, 40), true},
+		{at(6, 59), true},
+		{at(7, 0), true},
+		{at(7, 1), false},
+		{at(23, 50), false},
+	}
+	for _, tt := range tests {
+		if got := startsSoon(tt.now, location, 7, 0); got != tt.want {
+			t.Errorf("startsSoon(%s) = %v, ожидалось %v", tt.now.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestWarmCacheBeforeDigest(t *testing.T) {
+	useTestBot(t)
+
+	previousCache := coordsCache
+	coordsCache = &CoordsCache{data: make(map[string]CoordsCacheItem)}
+	t.Cleanup(func() { coordsCache = previousCache })
+
+	previous := config()
+	c := *previous
+	c.DefaultProvider = providerMock
+	setConfig(&c)
+	t.Cleanup(func() { setConfig(previous) })
+
+	// Две подписки в одной точке прогреваются одним запросом
+	for _, chatID := range []int64{1, 2} {
+		sub := AlertSubscription{ChatID: chatID, Kind: alertDaily, City: "Москва", Lat: 55.75, Lon: 37.62}
+		if err := store.Subscribe(sub); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	}
+
+	local := time.FixedZone("", 3*3600)
+	simClock := useManualClock(t, time.Date(2026, 10, 17, 3, 0, 0, 0, local))
+
+	// Часовой пояс точки еще не известен — прогреваем сразу
+	if warmed := warmCache(clockNow()); warmed != 1 {
+		t.Fatalf("прогрето %d точек при первом запуске, ожидалась 1", warmed)
+	}
+	simClock.Advance(cacheWarmInterval)
+	if warmed := warmCache(clockNow()); warmed != 0 {
+		t.Errorf("прогрето %d точек задолго до сводки", warmed)
+	}
+
+	simClock.Set(time.Date(2026, 10, 17, 6, 45, 0, 0, local))
+	if warmed := warmCache(clockNow()); warmed != 1 {
+		t.Errorf("прогрето %d точек перед сводкой, ожидалась 1", warmed)
+	}
+	simClock.Advance(cacheWarmInterval)
+	if warmed := warmCache(clockNow()); warmed != 0 {
+		t.Errorf("повторный прогрев свежих данных: %d", warmed)
+	}
+
+	// Сводка в 7:30 берет прогретые в 6:45 данные
+	simClock.Set(time.Date(2026, 10, 17, 7, 30, 0, 0, local))
+	sender := &dryRunSender{}
+	checkAlerts(sender, store)
+	if sender.sent != 2 {
+		t.Errorf("отправлено сводок %d, ожидалось 2", sender.sent)
+	}
+	item := coordsCache.item(55.75, 37.62)
+	if got := item.weatherTime.In(local).Format("15:04"); got != "06:45" {
+		t.Errorf("погода для сводки получена в %s, ожидалось из прогрева в 06:45", got)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Страница мини-приложения с графиком, картой и настройками
+//
+//go:embed webapp/index.html
+var webAppPage []byte
+
+// Сколько действительны данные запуска мини-приложения
+const webAppInitDataTTL = 24 * time.Hour
+
+// Кнопка, открывающая мини-приложение. В telegram-bot-api v5.5.1 нет поля
+// web_app, поэтому разметку собираем сами
+type webAppButton struct {
+	Text   string `json:"text"`
+	WebApp struct {
+		URL string `json:"url"`
+	} `json:"web_app"`
+}
+
+type webAppMarkup struct {
+	InlineKeyboard [][]webAppButton `json:"inline_keyboard"`
+}
+
+// Клавиатура с кнопкой мини-приложения (false, если WEBAPP_URL не задан)
+func webAppKeyboard() (webAppMarkup, bool) {
+	if config().WebAppURL == "" {
+		return webAppMarkup{}, false
+	}
+	button := webAppButton{Text: "📊 Открыть панель"}
+	button.WebApp.URL = config().WebAppURL
+	return webAppMarkup{InlineKeyboard: [][]webAppButton{{button}}}, true
+}
+
+// Проверка подписи данных запуска мини-приложения (Telegram.WebApp.initData).
+// Возвращает ID пользователя, в личном чате он совпадает с ID чата
+func validateWebAppInitData(initData, botToken string) (int64, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка разбора данных запуска: %v", err)
+	}
+
+	hash := values.Get("hash")
+	if hash == "" {
+		return 0, fmt.Errorf("нет подписи данных запуска")
+	}
+
+	var pairs []string
+	for key := range values {
+		if key != "hash" {
+			pairs = append(pairs, key+"="+values.Get(key))
+		}
+	}
+	sort.Strings(pairs)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(hash)) {
+		return 0, fmt.Errorf("неверная подпись данных запуска")
+	}
+
+	var authDate int64
+	fmt.Sscan(values.Get("auth_date"), &authDate)
+	if time.Since(time.Unix(authDate, 0)) > webAppInitDataTTL {
+		return 0, fmt.Errorf("данные запуска устарели")
+	}
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return 0, fmt.Errorf("нет пользователя в данных запуска")
+	}
+	return user.ID, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Ошибка отправки ответа мини-приложения: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// Обертка для API: проверяет подпись и передает обработчику хранилище
+// бота, из которого открыто приложение, и ID чата. Подпись сделана токеном
+// этого бота, поэтому бот находится перебором
+func webAppAuth(handler func(w http.ResponseWriter, r *http.Request, store *Store, chatID int64)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		initData := r.Header.Get("X-Telegram-Init-Data")
+		var err error
+		for _, bot := range bots {
+			var chatID int64
+			if chatID, err = validateWebAppInitData(initData, bot.Token); err == nil {
+				handler(w, r, bot.store, chatID)
+				return
+			}
+		}
+		writeJSONError(w, http.StatusUnauthorized, err)
+	}
+}
+
+// Город из запроса, иначе домашний город пользователя
+func webAppCity(r *http.Request, prefs UserPreferences) (string, error) {
+	city := strings.TrimSpace(r.URL.Query().Get("city"))
+	if city == "" {
+		city = prefs.HomeCity
+	}
+	if city == "" {
+		return "", fmt.Errorf("город не указан, выберите домашний город в настройках")
+	}
+	return city, nil
+}
+
+// Текущая погода. Значения всегда в метрических единицах, пересчет делает страница
+func handleWebAppWeather(w http.ResponseWriter, r *http.Request, store *Store, chatID int64) {
+	prefs := store.Preferences(chatID)
+	city, err := webAppCity(r, prefs)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data, err := cachedWeather(city, prefs.Language)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"city":        data.City,
+		"lat":         data.Lat,
+		"lon":         data.Lon,
+		"temp":        data.Temp,
+		"feels_like":  data.FeelsLike,
+		"humidity":    data.Humidity,
+		"pressure":    data.Pressure,
+		"wind_speed":  data.WindSpeed,
+		"description": data.Description,
+		"units":       prefs.Units,
+	})
+}
+
+// Прогноз по шагам в 3 часа для графика
+func handleWebAppForecast(w http.ResponseWriter, r *http.Request, store *Store, chatID int64) {
+	prefs := store.Preferences(chatID)
+	city, err := webAppCity(r, prefs)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	forecast, err := fetchForecastLang(city, prefs.Language)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	type point struct {
+		Time        string  `json:"time"`
+		Temp        float64 `json:"temp"`
+		Pop         float64 `json:"pop"`
+		WindSpeed   float64 `json:"wind_speed"`
+		Description string  `json:"description"`
+	}
+	points := make([]point, 0, len(forecast.Items))
+	for _, item := range forecast.Items {
+		points = append(points, point{
+			Time:        forecast.LocalTime(item).Format("02.01 15:04"),
+			Temp:        item.Temp,
+			Pop:         item.Pop,
+			WindSpeed:   item.WindSpeed,
+			Description: item.Description,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"city":   forecast.City,
+		"units":  prefs.Units,
+		"points": points,
+	})
+}
+
+// Предел размера тела запроса с настройками
+const webAppSettingsMaxBody = 4 << 10
+
+// Изменение настроек из мини-приложения. Отсутствующие поля не меняются,
+// пустой домашний город убирает его
+type webAppSettingsUpdate struct {
+	Units    string  `json:"units"`
+	Language string  `json:"language"`
+	HomeCity *string `json:"home_city"`
+}
+
+// Чтение и изменение настроек
+func handleWebAppSettings(w http.ResponseWriter, r *http.Request, store *Store, chatID int64) {
+	if r.Method == http.MethodPost {
+		var update webAppSettingsUpdate
+		r.Body = http.MaxBytesReader(w, r.Body, webAppSettingsMaxBody)
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("ошибка парсинга настроек: %v", err))
+			return
+		}
+		if _, ok := unitsTitles[update.Units]; update.Units != "" && !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("неизвестные единицы: %s", update.Units))
+			return
+		}
+		if _, ok := langTitles[update.Language]; update.Language != "" && !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("неизвестный язык: %s", update.Language))
+			return
+		}
+		var homeCity string
+		if update.HomeCity != nil && strings.TrimSpace(*update.HomeCity) != "" {
+			city, err := normalizeCity(*update.HomeCity)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err)
+				return
+			}
+			homeCity = city
+		}
+
+		err := store.UpdatePreferences(chatID, func(prefs *UserPreferences) {
+			if update.Units != "" {
+				prefs.Units = update.Units
+			}
+			if update.Language != "" {
+				prefs.Language = update.Language
+			}
+			if update.HomeCity != nil {
+				prefs.HomeCity = homeCity
+			}
+		})
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, store.Preferences(chatID))
+}
+
+// HTTP-сервер мини-приложения
+func runWebApp(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webAppPage)
+	})
+	mux.HandleFunc("/api/weather", webAppAuth(handleWebAppWeather))
+	mux.HandleFunc("/api/forecast", webAppAuth(handleWebAppForecast))
+	mux.HandleFunc("/api/settings", webAppAuth(handleWebAppSettings))
+
+	log.Printf("Мини-приложение слушает %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Ошибка сервера мини-приложения: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebAppSettingsUpdate(t *testing.T) {
+	newFakeTelegram(t)
+	const chatID = 3681
+
+	post := func(body string) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handleWebAppSettings(w, httptest.NewRequest(http.MethodPost, "/api/settings", strings.NewReader(body)), store, chatID)
+		return w.Code
+	}
+
+	if code := post(`{"home_city": "  Нижний   Новгород "}`); code != http.StatusOK {
+		t.Fatalf("код ответа %d", code)
+	}
+	if home := store.Preferences(chatID).HomeCity; home != "Нижний Новгород" {
+		t.Errorf("домашний город %q", home)
+	}
+
+	// Без поля home_city домашний город не меняется
+	if code := post(`{"units": "imperial"}`); code != http.StatusOK {
+		t.Fatalf("код ответа %d", code)
+	}
+	if prefs := store.Preferences(chatID); prefs.HomeCity != "Нижний Новгород" || prefs.Units != unitsImperial {
+		t.Errorf("настройки после смены единиц: %+v", prefs)
+	}
+
+	if code := post(`{"home_city": "<script>"}`); code != http.StatusBadRequest {
+		t.Errorf("недопустимый город: код %d", code)
+	}
+	if code := post(`{"home_city": "` + strings.Repeat("а", webAppSettingsMaxBody) + `"}`); code != http.StatusBadRequest {
+		t.Errorf("слишком большое тело: код %d", code)
+	}
+	if home := store.Preferences(chatID).HomeCity; home != "Нижний Новгород" {
+		t.Errorf("домашний город после ошибок %q", home)
+	}
+
+	if code := post(`{"home_city": ""}`); code != http.StatusOK {
+		t.Fatalf("код ответа %d", code)
+	}
+	if home := store.Preferences(chatID).HomeCity; home != "" {
+		t.Errorf("домашний город не убран: %q", home)
+	}
+}
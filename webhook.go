@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Исходящие вебхуки: при срабатывании оповещения чат получает не только
+// сообщение, но и POST с JSON на свои адреса (IFTTT, Home Assistant, Zapier)
+
+// Сколько вебхуков можно завести в одном чате
+const maxWebhooks = 3
+
+// Типы событий в теле запроса
+const (
+	webhookEventAlert = "alert"
+	webhookEventTest  = "test"
+)
+
+// Вебхук чата. Secret подписывает тело запроса (заголовок X-Webhook-Signature)
+type Webhook struct {
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret"`
+	Created time.Time `json:"created"`
+}
+
+// Тело запроса вебхука
+type webhookPayload struct {
+	Event  string  `json:"event"`
+	Kind   string  `json:"kind,omitempty"`
+	Title  string  `json:"title,omitempty"`
+	ChatID int64   `json:"chat_id"`
+	City   string  `json:"city,omitempty"`
+	Lat    float64 `json:"lat,omitempty"`
+	Lon    float64 `json:"lon,omitempty"`
+	Text   string  `json:"text"`
+	Time   string  `json:"time"`
+}
+
+// Адреса задают пользователи, поэтому соединения во внутреннюю сеть бота
+// запрещены. Проверяется уже разрешенный IP, так что DNS-имя, указывающее
+// на 127.0.0.1, тоже не пройдет
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("адрес %s во внутренней сети", host)
+	}
+	return nil
+}
+
+// Запросы на вебхуки идут в фоне, чтобы медленный адрес пользователя
+// не задерживал оповещения и обработку сообщений. Пробный прогон и тесты
+// ждут их через webhookPosts.Wait()
+var webhookPosts sync.WaitGroup
+
+// Клиент для вебхуков пользователей: короткий таймаут, без перенаправлений
+var webhookClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}).DialContext,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Проверка адреса при добавлении: только https и не внутренние адреса
+func parseWebhookURL(value string) (string, error) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return "", fmt.Errorf("нужен адрес вида https://example.com/hook")
+	}
+	host := parsed.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return "", fmt.Errorf("адрес %s во внутренней сети", host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if err := webhookDialControl("tcp", net.JoinHostPort(host, "443"), nil); err != nil {
+			return "", err
+		}
+	}
+	return parsed.String(), nil
+}
+
+// Секрет для подписи запросов
+func newWebhookSecret() string {
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
+// Подпись тела запроса: HMAC-SHA256 секретом вебхука
+func webhookSignature(secret string, body []byte) string {
+	return "sha256=" + hex.EncodeToString(hmacSHA256([]byte(secret), string(body)))
+}
+
+// Добавление вебхука. Один и тот же адрес дважды не добавляется
+func (s *Store) AddWebhook(chatID int64, hook Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hooks := s.data.Webhooks[chatID]
+	for _, existing := range hooks {
+		if existing.URL == hook.URL {
+			return fmt.Errorf("этот адрес уже добавлен")
+		}
+	}
+	if len(hooks) >= maxWebhooks {
+		return fmt.Errorf("в чате может быть не больше %d вебхуков", maxWebhooks)
+	}
+	s.data.Webhooks[chatID] = append(hooks, &hook)
+	return s.save()
+}
+
+// Копии вебхуков чата в порядке добавления
+func (s *Store) Webhooks(chatID int64) []Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hooks := make([]Webhook, 0, len(s.data.Webhooks[chatID]))
+	for _, hook := range s.data.Webhooks[chatID] {
+		hooks = append(hooks, *hook)
+	}
+	return hooks
+}
+
+// Удаление вебхука по номеру из списка (с 1). Возвращает false, если такого нет
+func (s *Store) DeleteWebhook(chatID int64, n int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hooks := s.data.Webhooks[chatID]
+	if n < 1 || n > len(hooks) {
+		return false, nil
+	}
+	hooks = append(hooks[:n-1:n-1], hooks[n:]...)
+	if len(hooks) == 0 {
+		delete(s.data.Webhooks, chatID)
+	} else {
+		s.data.Webhooks[chatID] = hooks
+	}
+	return true, s.save()
+}
+
+// Отправка события на один вебхук
+func postWebhook(hook Webhook, payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка формирования запроса: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка формирования запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "donedron_bot-webhook")
+	req.Header.Set("X-Webhook-Event", payload.Event)
+	req.Header.Set("X-Webhook-Signature", webhookSignature(hook.Secret, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		// В адресе бывает ключ (у IFTTT он в пути), поэтому в лог его не пишем
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("ошибка запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("вебхук ответил статусом %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Оповещение на все вебхуки чата подписки в фоне. Ошибки только пишутся
+// в лог: сообщение в Telegram уже отправлено
+func sendAlertWebhooks(store *Store, sub AlertSubscription, text string) {
+	hooks := store.Webhooks(sub.ChatID)
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload := webhookPayload{
+		Event:  webhookEventAlert,
+		Kind:   sub.Kind,
+		Title:  alertKinds[sub.Kind].title,
+		ChatID: sub.ChatID,
+		City:   sub.City,
+		Lat:    sub.Lat,
+		Lon:    sub.Lon,
+		Text:   text,
+		Time:   clockNow().Format(time.RFC3339),
+	}
+	webhookPosts.Add(1)
+	go func() {
+		defer webhookPosts.Done()
+		for _, hook := range hooks {
+			if err := postWebhook(hook, payload); err != nil {
+				log.Printf("Ошибка отправки вебхука чата %d: %v", sub.ChatID, err)
+			}
+		}
+	}()
+}
+
+// Список вебхуков чата для ответа на /webhook
+func webhookListText(hooks []Webhook) string {
+	if len(hooks) == 0 {
+		return "Вебхуков нет. Добавьте адрес, и при каждом оповещении на него придет POST с JSON:\n" +
+			"/webhook add https://example.com/hook"
+	}
+
+	var b strings.Builder
+	b.WriteString("🔗 Вебхуки оповещений:\n")
+	for i, hook := range hooks {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, hook.URL)
+	}
+	b.WriteString("\nПроверить: /webhook test, удалить: /webhook del N")
+	return b.String()
+}
+
+// /webhook [add <url>|del N|test] — вебхуки для оповещений
+func handleWebhookCommand(c *commandContext) {
+	chatID := c.message.Chat.ID
+	args := strings.Fields(c.args)
+	if len(args) == 0 {
+		c.msg.Text = webhookListText(c.bot.store.Webhooks(chatID))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) != 2 {
+			c.msg.Text = "Укажите адрес, например: /webhook add https://example.com/hook"
+			return
+		}
+		hookURL, err := parseWebhookURL(args[1])
+		if err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		hook := Webhook{URL: hookURL, Secret: newWebhookSecret(), Created: time.Now()}
+		if err := c.bot.store.AddWebhook(chatID, hook); err != nil {
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+			return
+		}
+		c.msg.Text = fmt.Sprintf("✅ Вебхук добавлен: %s\n\n"+
+			"Каждый запрос подписан: X-Webhook-Signature = sha256=HMAC-SHA256 тела с секретом\n%s\n\n"+
+			"Проверить: /webhook test", hook.URL, hook.Secret)
+
+	case "del", "delete", "remove":
+		n := 0
+		if len(args) == 2 {
+			n, _ = strconv.Atoi(args[1])
+		}
+		removed, err := c.bot.store.DeleteWebhook(chatID, n)
+		switch {
+		case err != nil:
+			c.msg.Text = "❌ Ошибка: " + err.Error()
+		case removed:
+			c.msg.Text = "Вебхук удален."
+		default:
+			c.msg.Text = "Нет вебхука с таким номером. Список: /webhook"
+		}
+
+	case "test":
+		hooks := c.bot.store.Webhooks(chatID)
+		if len(hooks) == 0 {
+			c.msg.Text = webhookListText(hooks)
+			return
+		}
+		payload := webhookPayload{
+			Event:  webhookEventTest,
+			ChatID: chatID,
+			Text:   "Проверка вебхука",
+			Time:   time.Now().Format(time.RFC3339),
+		}
+		// Каждый адрес может отвечать до таймаута, поэтому проверяем в фоне,
+		// а результат присылаем отдельным сообщением
+		c.msg.Text = "⏳ Отправляю проверочный запрос, результат пришлю следом."
+		bot := c.bot
+		webhookPosts.Add(1)
+		go func() {
+			defer webhookPosts.Done()
+			var b strings.Builder
+			for i, hook := range hooks {
+				if err := postWebhook(hook, payload); err != nil {
+					fmt.Fprintf(&b, "%d. ❌ %v\n", i+1, err)
+				} else {
+					fmt.Fprintf(&b, "%d. ✅ доставлено\n", i+1)
+				}
+			}
+			if _, err := sendSplit(bot, tgbotapi.NewMessage(chatID, strings.TrimSpace(b.String()))); err != nil {
+				log.Printf("Ошибка отправки результата проверки вебхуков чату %d: %v", chatID, err)
+			}
+		}()
+
+	default:
+		c.msg.Text = "Команды: /webhook, /webhook add <адрес>, /webhook del N, /webhook test"
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseWebhookURL(t *testing.T) {
+	if got, err := parseWebhookURL("https://maker.ifttt.com/trigger/rain/with/key/abc"); err != nil || got != "https://maker.ifttt.com/trigger/rain/with/key/abc" {
+		t.Errorf("parseWebhookURL = %q, %v", got, err)
+	}
+	for _, value := range []string{
+		"http://example.com/hook",
+		"example.com",
+		"https://localhost/hook",
+		"https://127.0.0.1/hook",
+		"https://10.0.0.5/hook",
+		"https://192.168.1.10:8123/api/webhook/x",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https://homeassistant.local/hook",
+	} {
+		if _, err := parseWebhookURL(value); err == nil {
+			t.Errorf("parseWebhookURL(%q) без ошибки", value)
+		}
+	}
+}
+
+func TestWebhookClientRejectsInternalAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("запрос дошел до внутреннего адреса")
+	}))
+	defer server.Close()
+
+	err := postWebhook(Webhook{URL: server.URL + "/hook", Secret: "s"}, webhookPayload{Event: webhookEventTest})
+	if err == nil || !strings.Contains(err.Error(), "во внутренней сети") {
+		t.Errorf("ошибка %v", err)
+	}
+	if strings.Contains(err.Error(), "/hook") {
+		t.Errorf("адрес вебхука попал в ошибку: %v", err)
+	}
+}
+
+func TestSendAlertWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	previousStore := store
+	var err error
+	store, err = openStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	t.Cleanup(func() { store = previousStore })
+
+	type request struct {
+		event, signature string
+		body             []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Header.Get("X-Webhook-Event"), r.Header.Get("X-Webhook-Signature"), body}
+	}))
+	defer server.Close()
+
+	// Тестовый сервер слушает 127.0.0.1, поэтому проверку адресов обходим
+	previousClient := webhookClient
+	webhookClient = server.Client()
+	t.Cleanup(func() { webhookClient = previousClient })
+
+	hook := Webhook{URL: server.URL + "/hook", Secret: "secret"}
+	if err := store.AddWebhook(42, hook); err != nil {
+		t.Fatalf("AddWebhook: %v", err)
+	}
+	if err := store.AddWebhook(42, hook); err == nil {
+		t.Error("повторный адрес добавлен")
+	}
+
+	sub := AlertSubscription{ChatID: 42, Kind: "rain", City: "Москва", Lat: 55.75, Lon: 37.62}
+	sendAlertWebhooks(store, sub, "☔ Скоро дождь")
+
+	got := <-requests
+	if got.event != webhookEventAlert {
+		t.Errorf("X-Webhook-Event = %q", got.event)
+	}
+	if want := webhookSignature("secret", got.body); got.signature != want {
+		t.Errorf("X-Webhook-Signature = %q, ожидалось %q", got.signature, want)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("тело запроса: %v", err)
+	}
+	if payload.Event != webhookEventAlert || payload.Kind != "rain" || payload.ChatID != 42 ||
+		payload.City != "Москва" || payload.Text != "☔ Скоро дождь" || payload.Time == "" {
+		t.Errorf("тело запроса %+v", payload)
+	}
+
+	if removed, err := store.DeleteWebhook(42, 1); !removed || err != nil {
+		t.Fatalf("DeleteWebhook = %v, %v", removed, err)
+	}
+	sendAlertWebhooks(store, sub, "☔ Скоро дождь")
+	webhookPosts.Wait()
+	select {
+	case <-requests:
+		t.Error("запрос на удаленный вебхук")
+	default:
+	}
+}
+
+func TestWebhookTestCommand(t *testing.T) {
+	f := newFakeTelegram(t)
+	const chatID = 4071
+
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	previousClient := webhookClient
+	webhookClient = server.Client()
+	t.Cleanup(func() { webhookClient = previousClient })
+
+	if err := store.AddWebhook(chatID, Webhook{URL: server.URL + "/hook", Secret: "secret"}); err != nil {
+		t.Fatalf("AddWebhook: %v", err)
+	}
+
+	// Вебхук еще не ответил, а обработка команды уже закончилась
+	f.send(textUpdate(chatID, "/webhook test"))
+	if reply := f.reply(t, chatID); !strings.Contains(reply, "результат пришлю следом") {
+		t.Errorf("ответ на /webhook test: %q", reply)
+	}
+
+	f.reset()
+	close(release)
+	webhookPosts.Wait()
+	if reply := f.reply(t, chatID); reply != "1. ❌ вебхук ответил статусом 500" {
+		t.Errorf("результат проверки: %q", reply)
+	}
+}